@@ -73,6 +73,14 @@ func newApp() *cli.Command {
 				Name:  "dry-run",
 				Usage: "Plan tasks without executing workers (shows planned task graph)",
 			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "Record every Queen LLM call to a cassette file",
+			},
+			&cli.StringFlag{
+				Name:  "replay",
+				Usage: "Answer Queen LLM calls from a recorded cassette file instead of the provider",
+			},
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 			// Validate mutual exclusivity of output format flags
@@ -94,6 +102,9 @@ func newApp() *cli.Command {
 			if flagCount > 1 {
 				return ctx, fmt.Errorf("flags --quiet, --json, and --plain are mutually exclusive")
 			}
+			if cmd.String("record") != "" && cmd.String("replay") != "" {
+				return ctx, fmt.Errorf("flags --record and --replay are mutually exclusive")
+			}
 
 			return ctx, nil
 		},
@@ -139,6 +150,21 @@ func newApp() *cli.Command {
 				ArgsUsage: "<session-id>",
 				Action:    cmdKill,
 			},
+			{
+				Name:      "say",
+				Usage:     "Send a message to a session's Queen",
+				ArgsUsage: "<session-id> <message>",
+				Action:    cmdSay,
+			},
+			{
+				Name:      "answer",
+				Usage:     "Answer a question a --json session is waiting on",
+				ArgsUsage: "<question-id> [answer...]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "reject", Usage: "Reject an approval (the answer text is the reason)"},
+				},
+				Action: cmdAnswer,
+			},
 			{
 				Name:  "sessions",
 				Usage: "List past sessions",
@@ -154,7 +180,9 @@ func newApp() *cli.Command {
 				Usage:     "Show task dependency graph",
 				ArgsUsage: "[session-id]",
 				Flags: []cli.Flag{
-					&cli.BoolFlag{Name: "ascii", Usage: "ASCII output (default is DOT/Graphviz)"},
+					&cli.StringFlag{Name: "format", Aliases: []string{"f"}, Value: "dot", Usage: "Output format: dot, ascii, mermaid, json, svg, html"},
+					&cli.BoolFlag{Name: "ascii", Usage: "Shorthand for --format ascii"},
+					&cli.BoolFlag{Name: "critical", Usage: "List remaining tasks with estimates, slack and ETA"},
 					&cli.StringFlag{Name: "session", Aliases: []string{"s"}, Usage: "Session ID"},
 				},
 				Action: cmdDAG,
@@ -169,6 +197,24 @@ func newApp() *cli.Command {
 				},
 				Action: cmdList,
 			},
+			{
+				Name:      "attempts",
+				Usage:     "Show every worker attempt of a task",
+				ArgsUsage: "<task-id>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "session", Aliases: []string{"s"}, Usage: "Session ID (default: latest session)"},
+					&cli.IntFlag{Name: "attempt", Aliases: []string{"n"}, Usage: "Show one attempt in full, including output"},
+				},
+				Action: cmdAttempts,
+			},
+			{
+				Name:   "reviewers",
+				Usage:  "Show how often each review panel reviewer agreed with the outcome",
+				Action: cmdReviewers,
+			},
+			taskCommand(),
+			cacheCommand(),
+			devCommand(),
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Default action: treat remaining args as objective (implicit run)
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/HexSleeves/waggle/internal/queen"
+	"github.com/HexSleeves/waggle/internal/tui"
+	"github.com/urfave/cli/v3"
+)
+
+// answersDir is where `waggle answer` leaves answers for a --json session.
+const answersDir = "answers"
+
+// tuiAsker shows Queen questions as TUI prompts.
+type tuiAsker struct {
+	prog *tui.Program
+}
+
+func (a tuiAsker) Ask(ctx context.Context, qu queen.Question) (queen.Answer, error) {
+	reply, err := a.prog.Ask(ctx, tui.PromptMsg{
+		ID:       qu.ID,
+		Title:    qu.Prompt,
+		Details:  qu.Details,
+		Options:  qu.Options,
+		Approval: qu.IsApproval(),
+	})
+	if err != nil {
+		return queen.Answer{}, err
+	}
+	if !qu.IsApproval() {
+		return queen.ParseAnswer(qu, reply.Text), nil
+	}
+	return queen.Answer{Approved: reply.Approved, Text: reply.Text}, nil
+}
+
+// stdinAsker asks Queen questions on the terminal in plain mode. A single
+// goroutine reads lines so an unanswered question can time out without
+// leaving a reader behind to swallow the next answer.
+type stdinAsker struct {
+	mu    sync.Mutex // one question at a time
+	out   io.Writer
+	lines chan string
+}
+
+func newStdinAsker(in io.Reader, out io.Writer) *stdinAsker {
+	a := &stdinAsker{out: out, lines: make(chan string)}
+	go func() {
+		sc := bufio.NewScanner(in)
+		for sc.Scan() {
+			a.lines <- sc.Text()
+		}
+		close(a.lines)
+	}()
+	return a
+}
+
+func (a *stdinAsker) Ask(ctx context.Context, qu queen.Question) (queen.Answer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	fmt.Fprintf(a.out, "\n🙋 %s\n", qu.Prompt)
+	if qu.Details != "" {
+		fmt.Fprintln(a.out, strings.TrimRight(qu.Details, "\n"))
+	}
+	for i, o := range qu.Options {
+		fmt.Fprintf(a.out, "  %d. %s\n", i+1, o)
+	}
+	if qu.IsApproval() {
+		fmt.Fprint(a.out, "Approve? [y/n, optionally followed by a reason] ")
+	} else {
+		fmt.Fprint(a.out, "> ")
+	}
+
+	select {
+	case line, ok := <-a.lines:
+		if !ok {
+			return queen.Answer{}, io.EOF
+		}
+		return queen.ParseAnswer(qu, line), nil
+	case <-ctx.Done():
+		fmt.Fprintln(a.out)
+		return queen.Answer{}, ctx.Err()
+	}
+}
+
+// jsonAsker announces questions as JSON events and waits for `waggle answer`.
+func jsonAsker(jw *output.JSONWriter, hiveDir string) *queen.FileAsker {
+	dir := filepath.Join(hiveDir, answersDir)
+	return &queen.FileAsker{
+		Dir: dir,
+		Announce: func(qu queen.Question) {
+			_ = jw.WriteQuestion(qu.ID, qu.Kind, qu.Prompt, qu.Details, qu.TaskID, qu.Options, queen.AnswerPath(dir, qu.ID))
+		},
+	}
+}
+
+// cmdAnswer answers a question a --json session is waiting on.
+func cmdAnswer(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: waggle answer <question-id> [answer...] [--reject]")
+	}
+	answer := queen.Answer{
+		Approved: !cmd.Bool("reject"),
+		Text:     strings.Join(args[1:], " "),
+	}
+	dir := filepath.Join(cmd.String("project"), ".hive", answersDir)
+	if err := queen.WriteAnswer(dir, args[0], answer); err != nil {
+		return fmt.Errorf("write answer: %w", err)
+	}
+	output.NewPrinter(output.ModePlain, false).Success("Answered %s", args[0])
+	return nil
+}
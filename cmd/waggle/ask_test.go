@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/queen"
+	"github.com/urfave/cli/v3"
+)
+
+func TestStdinAsker(t *testing.T) {
+	var out bytes.Buffer
+	asker := newStdinAsker(strings.NewReader("n split the migration\n2\n"), &out)
+	ctx := context.Background()
+
+	a, err := asker.Ask(ctx, queen.Question{ID: "s-1", Kind: queen.QuestionPlan, Prompt: "Approve the plan (2 tasks)?", Details: "- [code] A (a)\n"})
+	if err != nil || a.Approved || a.Text != "split the migration" {
+		t.Fatalf("expected rejection with reason, got %+v, %v", a, err)
+	}
+	if !strings.Contains(out.String(), "Approve the plan (2 tasks)?") || !strings.Contains(out.String(), "[y/n") {
+		t.Errorf("unexpected prompt output: %q", out.String())
+	}
+
+	a, err = asker.Ask(ctx, queen.Question{ID: "s-2", Kind: queen.QuestionAsk, Prompt: "Which database?", Options: []string{"SQLite", "PostgreSQL"}})
+	if err != nil || a.Text != "PostgreSQL" {
+		t.Fatalf("expected option 2 picked, got %+v, %v", a, err)
+	}
+
+	// Input is exhausted: the question fails so the Queen uses its default.
+	short, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := asker.Ask(short, queen.Question{ID: "s-3", Kind: queen.QuestionAsk}); err == nil {
+		t.Error("expected error once stdin is closed")
+	}
+}
+
+func TestCmdAnswer(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := &cli.Command{
+		Name:  "waggle",
+		Flags: []cli.Flag{&cli.StringFlag{Name: "project", Value: tmpDir}},
+		Commands: []*cli.Command{{
+			Name:   "answer",
+			Flags:  []cli.Flag{&cli.BoolFlag{Name: "reject"}},
+			Action: cmdAnswer,
+		}},
+	}
+	if err := root.Run(context.Background(), []string{"waggle", "answer", "--reject", "s-1", "too", "many", "tasks"}); err != nil {
+		t.Fatalf("answer failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".hive", answersDir, "s-1.json"))
+	if err != nil {
+		t.Fatalf("read answer: %v", err)
+	}
+	var a queen.Answer
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatalf("decode answer: %v", err)
+	}
+	if a.Approved || a.Text != "too many tasks" {
+		t.Errorf("unexpected answer %+v", a)
+	}
+
+	if err := root.Run(context.Background(), []string{"waggle", "answer"}); err == nil {
+		t.Error("expected usage error without a question id")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func cmdAttempts(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) != 1 {
+		return fmt.Errorf("usage: waggle attempts <task-id>")
+	}
+	taskID := args[0]
+	projectDir := cmd.String("project")
+
+	hiveDir := filepath.Join(projectDir, ".hive")
+	if _, err := os.Stat(hiveDir); os.IsNotExist(err) {
+		return fmt.Errorf("no hive found at %s. Run 'waggle init' first", hiveDir)
+	}
+
+	db, err := state.OpenDB(hiveDir)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	var session *state.SessionInfo
+	if id := cmd.String("session"); id != "" {
+		session, err = db.GetSession(ctx, id)
+	} else {
+		session, err = db.LatestSession(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+
+	attempts, err := db.ListAttempts(ctx, session.ID, taskID)
+	if err != nil {
+		return fmt.Errorf("list attempts: %w", err)
+	}
+
+	if n := cmd.Int("attempt"); n != 0 {
+		if n < 1 || int(n) > len(attempts) {
+			return fmt.Errorf("task %q has %d recorded attempt(s)", taskID, len(attempts))
+		}
+		attempts = attempts[n-1 : n]
+	}
+
+	if cmd.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(attempts)
+	}
+
+	p := output.NewPrinter(output.ModePlain, false)
+	if len(attempts) == 0 {
+		p.Info("No attempts recorded for task %s in session %s.", taskID, session.ID)
+		return nil
+	}
+
+	if cmd.IsSet("attempt") {
+		printAttemptDetail(p, projectDir, attempts[0])
+		return nil
+	}
+
+	p.Header(fmt.Sprintf("Attempts for %s", taskID))
+	var rows [][]string
+	for _, a := range attempts {
+		note := a.Error
+		if a.Feedback != "" {
+			note = "rejected: " + a.Feedback
+		}
+		note = strings.ReplaceAll(note, "\n", " ")
+		if len(note) > 60 {
+			note = note[:57] + "..."
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", a.Attempt),
+			a.Status,
+			orDash(a.WorkerID),
+			orDash(a.Adapter),
+			attemptDuration(a),
+			orDash(a.ErrorType),
+			note,
+		})
+	}
+	p.Table([]string{"#", "Status", "Worker", "Adapter", "Duration", "Error Type", "Notes"}, rows)
+	p.Printf("\n%d attempt(s) in session %s. Use --attempt N to show one in full.\n", len(attempts), session.ID)
+	return nil
+}
+
+func printAttemptDetail(p *output.Printer, projectDir string, a state.AttemptRow) {
+	p.Header(fmt.Sprintf("%s attempt %d", a.TaskID, a.Attempt))
+	p.KeyValue([][]string{
+		{"Status", a.Status},
+		{"Worker", orDash(a.WorkerID)},
+		{"Adapter", orDash(a.Adapter)},
+		{"Started", a.StartedAt},
+		{"Ended", orDash(a.EndedAt)},
+		{"Error type", orDash(a.ErrorType)},
+		{"Metrics", orDash(a.Metrics)},
+	})
+	if a.Error != "" {
+		p.Section("Error")
+		p.Printf("%s\n", a.Error)
+	}
+	if a.Feedback != "" {
+		p.Section("Review feedback")
+		p.Printf("%s\n", a.Feedback)
+	}
+	if a.Review != "" {
+		p.Section("Review verdict")
+		var b bytes.Buffer
+		if err := json.Indent(&b, []byte(a.Review), "", "  "); err != nil {
+			b.Reset()
+			b.WriteString(a.Review)
+		}
+		p.Printf("%s\n", b.String())
+	}
+	out := a.Output
+	if a.OutputPath != "" {
+		if data, err := os.ReadFile(filepath.Join(projectDir, a.OutputPath)); err == nil {
+			out = string(data)
+		}
+	}
+	if out != "" {
+		p.Section("Output")
+		p.Printf("%s\n", strings.TrimRight(out, "\n"))
+	}
+}
+
+// attemptDuration formats the wall time of an attempt, or "-" if it never finished.
+func attemptDuration(a state.AttemptRow) string {
+	start, err1 := time.Parse(time.RFC3339Nano, a.StartedAt)
+	end, err2 := time.Parse(time.RFC3339Nano, a.EndedAt)
+	if err1 != nil || err2 != nil {
+		return "-"
+	}
+	return end.Sub(start).Round(time.Millisecond).String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func runAttemptsCmd(t *testing.T, projectDir string, args ...string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := &cli.Command{
+		Name: "attempts",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "project", Value: projectDir},
+			&cli.BoolFlag{Name: "json"},
+			&cli.StringFlag{Name: "session", Aliases: []string{"s"}},
+			&cli.IntFlag{Name: "attempt", Aliases: []string{"n"}},
+		},
+		Action: cmdAttempts,
+	}
+	err := cmd.Run(context.Background(), append([]string{"attempts"}, args...))
+
+	w.Close()
+	os.Stdout = oldStdout
+	buf.ReadFrom(r)
+	return buf.String(), err
+}
+
+func seedAttempts(t *testing.T, db *state.DB) {
+	t.Helper()
+	ctx := context.Background()
+	createTestSession(t, db, "s1", "objective")
+	createTestTask(t, db, "s1", state.TaskRow{ID: "t1", Type: "code", Status: "complete", Title: "T1"})
+
+	if _, err := db.StartAttempt(ctx, "s1", "t1", "w-1", "exec"); err != nil {
+		t.Fatalf("StartAttempt failed: %v", err)
+	}
+	if err := db.FinishAttempt(ctx, state.AttemptRow{
+		SessionID: "s1", TaskID: "t1", Attempt: 1,
+		Status: "failed", ErrorType: "retryable", Error: "timeout talking to API",
+	}); err != nil {
+		t.Fatalf("FinishAttempt failed: %v", err)
+	}
+	if _, err := db.StartAttempt(ctx, "s1", "t1", "w-2", "claude-code"); err != nil {
+		t.Fatalf("StartAttempt failed: %v", err)
+	}
+	if err := db.FinishAttempt(ctx, state.AttemptRow{
+		SessionID: "s1", TaskID: "t1", Attempt: 2, Status: "succeeded", Output: "all good",
+	}); err != nil {
+		t.Fatalf("FinishAttempt failed: %v", err)
+	}
+}
+
+func TestCmdAttempts_Table(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	seedAttempts(t, db)
+
+	out, err := runAttemptsCmd(t, tmpDir, "t1")
+	if err != nil {
+		t.Fatalf("attempts failed: %v", err)
+	}
+	for _, want := range []string{"w-1", "w-2", "retryable", "timeout talking to API", "claude-code", "2 attempt(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCmdAttempts_DetailAndJSON(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	seedAttempts(t, db)
+
+	out, err := runAttemptsCmd(t, tmpDir, "--attempt", "2", "t1")
+	if err != nil {
+		t.Fatalf("attempts --attempt failed: %v", err)
+	}
+	if !strings.Contains(out, "all good") || strings.Contains(out, "timeout talking to API") {
+		t.Errorf("expected only attempt 2 in detail view:\n%s", out)
+	}
+
+	out, err = runAttemptsCmd(t, tmpDir, "--json", "t1")
+	if err != nil {
+		t.Fatalf("attempts --json failed: %v", err)
+	}
+	var rows []state.AttemptRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(rows) != 2 || rows[0].WorkerID != "w-1" {
+		t.Errorf("unexpected JSON rows: %+v", rows)
+	}
+
+	if _, err := runAttemptsCmd(t, tmpDir, "--attempt", "5", "t1"); err == nil {
+		t.Error("expected error for missing attempt")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+// cacheCommand builds the `waggle cache` command group for inspecting and
+// clearing the cross-session task result cache.
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Inspect or clear the task result cache",
+		Commands: []*cli.Command{
+			{
+				Name:    "ls",
+				Aliases: []string{"list"},
+				Usage:   "List cached task results",
+				Action:  cmdCacheList,
+			},
+			{
+				Name:      "clear",
+				Usage:     "Remove cached results (all, by key prefix, or by age)",
+				ArgsUsage: "[key-prefix]",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{Name: "older-than", Usage: "Only remove entries older than this (e.g. 168h)"},
+				},
+				Action: cmdCacheClear,
+			},
+		},
+	}
+}
+
+func openHiveDB(cmd *cli.Command) (*state.DB, error) {
+	hiveDir := filepath.Join(cmd.String("project"), ".hive")
+	if _, err := os.Stat(hiveDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no hive found at %s. Run 'waggle init' first", hiveDir)
+	}
+	db, err := state.OpenDB(hiveDir)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	return db, nil
+}
+
+func cmdCacheList(ctx context.Context, cmd *cli.Command) error {
+	db, err := openHiveDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	entries, err := db.ListCache(ctx)
+	if err != nil {
+		return fmt.Errorf("list cache: %w", err)
+	}
+
+	if cmd.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	p := output.NewPrinter(output.ModePlain, false)
+	if len(entries) == 0 {
+		p.Info("Result cache is empty.")
+		return nil
+	}
+
+	p.Header("Result Cache")
+	var rows [][]string
+	for _, e := range entries {
+		title := e.Title
+		if len(title) > 40 {
+			title = title[:37] + "..."
+		}
+		rows = append(rows, []string{
+			e.Key[:min(12, len(e.Key))],
+			e.TaskType,
+			orDash(e.Adapter),
+			e.SessionID + "/" + e.TaskID,
+			fmt.Sprintf("%d", e.Hits),
+			e.CreatedAt,
+			title,
+		})
+	}
+	p.Table([]string{"Key", "Type", "Adapter", "Source", "Hits", "Created", "Title"}, rows)
+	p.Printf("\n%d cached result(s)\n", len(entries))
+	return nil
+}
+
+func cmdCacheClear(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) > 1 {
+		return fmt.Errorf("usage: waggle cache clear [key-prefix]")
+	}
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	db, err := openHiveDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	n, err := db.ClearCache(ctx, prefix, cmd.Duration("older-than"))
+	if err != nil {
+		return fmt.Errorf("clear cache: %w", err)
+	}
+	output.NewPrinter(output.ModePlain, false).Success("Removed %d cached result(s)", n)
+	return nil
+}
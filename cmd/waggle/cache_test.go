@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func runCacheCmd(t *testing.T, projectDir string, args ...string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	root := &cli.Command{
+		Name: "waggle",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "project", Value: projectDir},
+			&cli.BoolFlag{Name: "json"},
+		},
+		Commands: []*cli.Command{cacheCommand()},
+	}
+	err := root.Run(context.Background(), append([]string{"waggle", "cache"}, args...))
+
+	w.Close()
+	os.Stdout = oldStdout
+	buf.ReadFrom(r)
+	return buf.String(), err
+}
+
+func TestCmdCache_ListAndClear(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	out, err := runCacheCmd(t, tmpDir, "ls")
+	if err != nil {
+		t.Fatalf("cache ls failed: %v", err)
+	}
+	if !strings.Contains(out, "Result cache is empty") {
+		t.Errorf("expected empty message, got: %s", out)
+	}
+
+	for _, key := range []string{"abc123def456", "fff000"} {
+		if err := db.PutCache(ctx, state.CacheRow{
+			Key: key, TaskType: "research", Title: "Survey deps", Adapter: "exec",
+			Result: `{"success":true}`, SessionID: "s1", TaskID: "t-" + key,
+		}); err != nil {
+			t.Fatalf("PutCache failed: %v", err)
+		}
+	}
+
+	out, err = runCacheCmd(t, tmpDir, "ls")
+	if err != nil {
+		t.Fatalf("cache ls failed: %v", err)
+	}
+	for _, want := range []string{"abc123def456", "Survey deps", "s1/t-fff000", "2 cached result(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ls output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = runCacheCmd(t, tmpDir, "clear", "abc")
+	if err != nil {
+		t.Fatalf("cache clear failed: %v", err)
+	}
+	if !strings.Contains(out, "Removed 1 cached result(s)") {
+		t.Errorf("unexpected clear output: %s", out)
+	}
+
+	if _, err := runCacheCmd(t, tmpDir, "clear"); err != nil {
+		t.Fatalf("cache clear failed: %v", err)
+	}
+	entries, _ := db.ListCache(ctx)
+	if len(entries) != 0 {
+		t.Errorf("expected empty cache, got %d entries", len(entries))
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/HexSleeves/waggle/internal/bus"
 	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
 	"github.com/HexSleeves/waggle/internal/output"
 	"github.com/HexSleeves/waggle/internal/queen"
 	"github.com/HexSleeves/waggle/internal/state"
@@ -51,9 +52,26 @@ func loadConfigFromCtx(ctx context.Context, cmd *cli.Command) (*config.Config, e
 	// Propagate dry-run flag
 	cfg.Queen.DryRun = cmd.Bool("dry-run")
 
+	// Cassette flags name files from the working directory; they replace
+	// any cassette in the config file.
+	if path := cmd.String("record"); path != "" {
+		cfg.Cassette.Record, cfg.Cassette.Replay = absPath(path), ""
+	}
+	if path := cmd.String("replay"); path != "" {
+		cfg.Cassette.Replay, cfg.Cassette.Record = absPath(path), ""
+	}
+
 	return cfg, nil
 }
 
+// absPath returns path made absolute, or as it is if that fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
 func cmdInit(ctx context.Context, cmd *cli.Command) error {
 	projectDir := cmd.String("project")
 	configPath := cmd.String("config")
@@ -206,7 +224,11 @@ func newQueenForTUI(cfg *config.Config, tuiProg *tui.Program) (*queen.Queen, err
 	}
 	q.SetLogger(logger)
 	q.SuppressReport()
+	q.SetAsker(tuiAsker{prog: tuiProg})
 	subscribeBusEvents(q, tuiProg)
+	if history, err := q.DurationHistory(context.Background()); err == nil {
+		tuiProg.Send(tui.DurationHistoryMsg{History: history, Parallel: cfg.Workers.MaxParallel})
+	}
 	return q, nil
 }
 
@@ -222,9 +244,32 @@ func subscribeBusEvents(q *queen.Queen, tuiProg *tui.Program) {
 			tuiProg.SendTaskUpdate(msg.TaskID, "", "", string(payload["new"]), "")
 		}
 	})
+	q.Bus().Subscribe(bus.MsgTaskRemoved, func(msg bus.Message) {
+		tuiProg.Send(tui.TaskRemovedMsg{ID: msg.TaskID})
+	})
+	q.Bus().Subscribe(bus.MsgTaskHeld, func(msg bus.Message) {
+		if h, ok := msg.Payload.(queen.TaskHold); ok {
+			tuiProg.Send(tui.TaskHeldMsg{ID: h.TaskID, BlockedBy: h.BlockedBy, Path: h.Path})
+		}
+	})
+	q.Bus().Subscribe(bus.MsgSubSession, func(msg bus.Message) {
+		if sub, ok := msg.Payload.(queen.SubSession); ok {
+			tuiProg.Send(tui.SubSessionMsg{ID: sub.TaskID, SessionID: sub.SessionID, Done: sub.Done, Total: sub.Total})
+		}
+	})
 	q.Bus().Subscribe(bus.MsgTaskAssigned, func(msg bus.Message) {
 		tuiProg.SendTaskUpdate(msg.TaskID, "", "", "running", msg.WorkerID)
 	})
+	q.Bus().Subscribe(bus.MsgQueenStream, func(msg bus.Message) {
+		if e, ok := msg.Payload.(llm.StreamEvent); ok {
+			tuiProg.Send(tui.QueenStreamMsg{Kind: e.Type, Text: e.Text, ToolName: e.ToolName})
+		}
+	})
+	q.Bus().Subscribe(bus.MsgCostUpdate, func(msg bus.Message) {
+		if s, ok := msg.Payload.(queen.Spend); ok {
+			tuiProg.Send(tui.CostMsg{USD: s.SessionUSD, Tokens: s.Tokens, BudgetUSD: s.BudgetUSD})
+		}
+	})
 	q.Bus().Subscribe(bus.MsgWorkerSpawned, func(msg bus.Message) {
 		tuiProg.Send(tui.WorkerUpdateMsg{
 			ID: msg.WorkerID, TaskID: msg.TaskID, Status: "running",
@@ -273,6 +318,34 @@ func pollWorkerOutputs(ctx context.Context, q *queen.Queen, tuiProg *tui.Program
 	}
 }
 
+// forwardTaskActions queues task edits and messages made in the TUI as control
+// requests for the Queen.
+func forwardTaskActions(ctx context.Context, q *queen.Queen, tuiProg *tui.Program) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-tuiProg.TaskActions():
+			if a.Kind == queen.ControlSay {
+				if err := q.EnqueueControl(ctx, a.Kind, map[string]string{"text": a.Text}); err != nil {
+					tuiProg.Send(tui.LogMsg{Text: fmt.Sprintf("⚠ message not delivered: %v", err)})
+				}
+				continue
+			}
+			payload := map[string]interface{}{"task_id": a.TaskID}
+			if a.Cascade {
+				payload["cascade"] = true
+			}
+			if a.PriorityDelta != 0 {
+				payload["priority_delta"] = a.PriorityDelta
+			}
+			if err := q.EnqueueControl(ctx, a.Kind, payload); err != nil {
+				tuiProg.Send(tui.LogMsg{Text: fmt.Sprintf("⚠ %s %s: %v", a.Kind, a.TaskID, err)})
+			}
+		}
+	}
+}
+
 // startQueen runs the Queen in a goroutine and sends Done when finished.
 func startQueen(ctx context.Context, q *queen.Queen, tuiProg *tui.Program, objective string, forceLegacy bool) (context.CancelFunc, <-chan error) {
 	return startQueenWithFunc(ctx, q, tuiProg, func(runCtx context.Context) error {
@@ -299,6 +372,7 @@ func startQueenWithFunc(ctx context.Context, q *queen.Queen, tuiProg *tui.Progra
 	errCh := make(chan error, 1)
 
 	go pollWorkerOutputs(runCtx, q, tuiProg)
+	go forwardTaskActions(runCtx, q, tuiProg)
 
 	go func() {
 		defer cancel()
@@ -387,6 +461,7 @@ func runPlain(ctx context.Context, cmd *cli.Command, cfg *config.Config, objecti
 	// Wire printer and quiet mode
 	q.SetPrinter(p)
 	q.SetQuiet(quiet)
+	q.SetAsker(newStdinAsker(os.Stdin, os.Stdout))
 
 	if tasksFile != "" {
 		tasks, err := loadTasksFile(tasksFile, cfg)
@@ -469,6 +544,7 @@ func runJSON(ctx context.Context, cmd *cli.Command, cfg *config.Config, objectiv
 
 	// Suppress report output in JSON mode (we emit our own JSON summary)
 	q.SuppressReport()
+	q.SetAsker(jsonAsker(jsonWriter, cfg.HivePath()))
 
 	// Update session ID in JSON writer once available
 	// Note: session ID is created during Run/RunAgent
@@ -494,9 +570,17 @@ func runJSON(ctx context.Context, cmd *cli.Command, cfg *config.Config, objectiv
 			_ = jsonWriter.WriteTaskUpdated(msg.TaskID, string(payload["new"]), "")
 		}
 	})
+	q.Bus().Subscribe(bus.MsgTaskRemoved, func(msg bus.Message) {
+		_ = jsonWriter.WriteTaskUpdated(msg.TaskID, "removed", "")
+	})
 	q.Bus().Subscribe(bus.MsgTaskAssigned, func(msg bus.Message) {
 		_ = jsonWriter.WriteTaskUpdated(msg.TaskID, "running", msg.WorkerID)
 	})
+	q.Bus().Subscribe(bus.MsgQueenStream, func(msg bus.Message) {
+		if e, ok := msg.Payload.(llm.StreamEvent); ok {
+			_ = jsonWriter.WriteQueenDelta(e.Type, e.Text, e.ToolCallID, e.ToolName)
+		}
+	})
 	q.Bus().Subscribe(bus.MsgWorkerSpawned, func(msg bus.Message) {
 		_ = jsonWriter.WriteWorkerSpawned(msg.WorkerID, msg.TaskID)
 	})
@@ -569,6 +653,7 @@ func runJSON(ctx context.Context, cmd *cli.Command, cfg *config.Config, objectiv
 			Type:        string(tr.Type),
 			Status:      string(tr.Status),
 			WorkerID:    tr.WorkerID,
+			Attempts:    tr.Attempts,
 			CompletedAt: tr.CompletedAt,
 		}
 		if tr.Result != nil {
@@ -606,6 +691,10 @@ func runJSON(ctx context.Context, cmd *cli.Command, cfg *config.Config, objectiv
 		Iterations:     iterations,
 		Tasks:          taskEvents,
 	}
+	if len(allTasks) > 0 {
+		graph := q.DAG()
+		summary.Graph = &graph
+	}
 
 	// Emit final session summary
 	if err := jsonWriter.WriteSessionEnd(summary); err != nil {
@@ -724,6 +813,7 @@ func runResumePlain(ctx context.Context, cmd *cli.Command, cfg *config.Config, s
 	defer q.Close()
 
 	q.SetPrinter(p)
+	q.SetAsker(newStdinAsker(os.Stdin, os.Stdout))
 
 	// Resume the session
 	resumedObjective, err := q.ResumeSession(ctx, sessionID)
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/HexSleeves/waggle/internal/queen"
 	"github.com/HexSleeves/waggle/internal/state"
 	"github.com/HexSleeves/waggle/internal/task"
 	"github.com/urfave/cli/v3"
@@ -41,37 +46,108 @@ func cmdDAG(ctx context.Context, cmd *cli.Command) error {
 		sessionID = session.ID
 	}
 
-	// Load tasks
-	taskRows, err := db.GetTasks(ctx, sessionID)
+	graph, nodes, err := queen.LoadSessionGraph(ctx, db, sessionID)
 	if err != nil {
-		return fmt.Errorf("load tasks: %w", err)
+		return err
 	}
-	if len(taskRows) == 0 {
+	if len(graph.All()) == 0 {
 		fmt.Println("No tasks in session", sessionID)
 		return nil
 	}
 
-	// Build task graph
-	graph := task.NewTaskGraph(nil)
-	for _, tr := range taskRows {
-		var deps []string
-		if tr.DependsOn != "" {
-			deps = strings.Split(tr.DependsOn, ",")
+	cfg, err := loadConfigFromCtx(ctx, cmd)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	history, err := queen.LoadDurationHistory(ctx, db)
+	if err != nil {
+		return fmt.Errorf("load task durations: %w", err)
+	}
+	cp := graph.CriticalPath(func(t *task.Task) time.Duration {
+		adapterName, ok := cfg.Workers.AdapterMap[string(t.Type)]
+		if !ok {
+			adapterName = cfg.Workers.DefaultAdapter
 		}
-		graph.Add(&task.Task{
-			ID:        tr.ID,
-			Title:     tr.Title,
-			Type:      task.Type(tr.Type),
-			Status:    task.Status(tr.Status),
-			DependsOn: deps,
-		})
+		return history.Estimate(t.Type, adapterName)
+	})
+
+	if cmd.Bool("critical") {
+		printCriticalPath(graph, cp, history, cfg.Workers.MaxParallel)
+		return nil
 	}
 
+	format := strings.ToLower(cmd.String("format"))
 	if cmd.Bool("ascii") {
-		fmt.Print(graph.RenderASCII(80))
-	} else {
-		fmt.Print(graph.RenderDOT())
+		format = "ascii"
+	}
+	opts := task.RenderOptions{Critical: cp, Nodes: nodes}
+	if session, err := db.GetSession(ctx, sessionID); err == nil {
+		opts.Title = session.Objective
+	}
+
+	switch format {
+	case "", "dot":
+		fmt.Print(graph.RenderDOTCritical(cp))
+	case "ascii":
+		fmt.Print(graph.RenderASCIICritical(80, cp))
+	case "mermaid":
+		fmt.Print(graph.RenderMermaid(opts))
+	case "json":
+		data, err := graph.RenderJSON(opts)
+		if err != nil {
+			return fmt.Errorf("encode graph: %w", err)
+		}
+		fmt.Println(string(data))
+	case "svg":
+		fmt.Print(graph.RenderSVG(opts))
+	case "html":
+		fmt.Print(graph.RenderHTML(opts))
+	default:
+		return fmt.Errorf("unknown format %q (want dot, ascii, mermaid, json, svg or html)", format)
 	}
 
 	return nil
 }
+
+// printCriticalPath lists every unfinished task with its estimate and slack,
+// critical-path tasks first, followed by the ETA.
+func printCriticalPath(graph *task.TaskGraph, cp *task.CriticalPath, history *task.DurationHistory, parallel int) {
+	p := output.NewPrinter(output.ModePlain, false)
+	if len(cp.Remaining) == 0 {
+		p.Info("All tasks are finished.")
+		return
+	}
+
+	ids := make([]string, 0, len(cp.Remaining))
+	for id := range cp.Remaining {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if cp.Slack[ids[i]] != cp.Slack[ids[j]] {
+			return cp.Slack[ids[i]] < cp.Slack[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	p.Header("Critical path")
+	p.Printf("%s\n\n", strings.Join(cp.Path, " -> "))
+
+	var rows [][]string
+	for _, id := range ids {
+		t, _ := graph.Get(id)
+		mark, slack := "", "-"
+		if cp.IsCritical(id) {
+			mark = "\u2605"
+		} else {
+			slack = task.FormatEstimate(cp.Slack[id])
+		}
+		rows = append(rows, []string{mark, id, t.Title, string(t.Status), task.FormatEstimate(cp.Remaining[id]), slack})
+	}
+	p.Table([]string{"", "Task", "Title", "Status", "Remaining", "Slack"}, rows)
+
+	p.Printf("\nCritical path length: %s\n", task.FormatEstimate(cp.Length))
+	p.Printf("ETA with %d workers:  ~%s\n", parallel, task.FormatEstimate(cp.ETA(parallel)))
+	if history.Empty() {
+		p.Printf("(no recorded attempts yet; assuming %s per task)\n", task.FormatEstimate(task.DefaultEstimate))
+	}
+}
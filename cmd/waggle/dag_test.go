@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func runDAGCmd(t *testing.T, projectDir string, args ...string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := &cli.Command{
+		Name: "dag",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "project", Value: projectDir},
+			&cli.StringFlag{Name: "format", Aliases: []string{"f"}, Value: "dot"},
+			&cli.BoolFlag{Name: "ascii"},
+			&cli.BoolFlag{Name: "critical"},
+			&cli.StringFlag{Name: "session", Aliases: []string{"s"}},
+		},
+		Action: cmdDAG,
+	}
+	err := cmd.Run(context.Background(), append([]string{"dag"}, args...))
+
+	w.Close()
+	os.Stdout = oldStdout
+	buf.ReadFrom(r)
+	return buf.String(), err
+}
+
+func TestCmdDAG_Critical(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+
+	createTestSession(t, db, "s1", "objective")
+	createTestTask(t, db, "s1", state.TaskRow{ID: "setup", Type: "code", Status: "pending", Title: "Setup"})
+	createTestTask(t, db, "s1", state.TaskRow{ID: "build", Type: "code", Status: "pending", Title: "Build", DependsOn: "setup"})
+	createTestTask(t, db, "s1", state.TaskRow{ID: "docs", Type: "research", Status: "pending", Title: "Docs"})
+	createTestTask(t, db, "s1", state.TaskRow{ID: "old", Type: "code", Status: "complete", Title: "Old"})
+
+	out, err := runDAGCmd(t, tmpDir, "--critical")
+	if err != nil {
+		t.Fatalf("dag --critical failed: %v", err)
+	}
+	for _, want := range []string{"setup -> build", "Critical path length: 4m", "no recorded attempts yet"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Old") {
+		t.Errorf("finished tasks should not be listed:\n%s", out)
+	}
+
+	dot, err := runDAGCmd(t, tmpDir)
+	if err != nil {
+		t.Fatalf("dag failed: %v", err)
+	}
+	if !strings.Contains(dot, `"setup" -> "build" [color="red", penwidth=2]`) {
+		t.Errorf("expected critical edge highlighted in DOT:\n%s", dot)
+	}
+}
+
+func TestCmdDAG_Formats(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+
+	createTestSession(t, db, "s1", "ship the api")
+	createTestTask(t, db, "s1", state.TaskRow{ID: "setup", Type: "code", Status: "complete", Title: "Setup"})
+	createTestTask(t, db, "s1", state.TaskRow{ID: "build", Type: "code", Status: "failed", Title: "Build", DependsOn: "setup"})
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"mermaid", []string{"flowchart LR", "n1 --> n0", `title: "ship the api"`}},
+		{"json", []string{`"nodes"`, `"from": "setup"`, `"to": "build"`}},
+		{"svg", []string{"<svg", "<title>Build (build, failed)"}},
+		{"html", []string{"<!DOCTYPE html>", "<h1>ship the api</h1>", "<svg"}},
+		{"ascii", []string{"Layer 0 (roots):"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := runDAGCmd(t, tmpDir, "--format", tt.format)
+			if err != nil {
+				t.Fatalf("dag --format %s failed: %v", tt.format, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+
+	if _, err := runDAGCmd(t, tmpDir, "--format", "png"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/HexSleeves/waggle/internal/fakellm"
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/urfave/cli/v3"
+)
+
+// devCommand builds the `waggle dev` command group of tools for developing
+// and testing waggle itself.
+func devCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "dev",
+		Usage: "Tools for developing and testing waggle",
+		Commands: []*cli.Command{
+			{
+				Name:      "fake-llm",
+				Usage:     "Serve scripted Anthropic, OpenAI and Gemini API responses locally",
+				ArgsUsage: "<script.json>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "addr", Value: "127.0.0.1:8089", Usage: "Address to listen on"},
+				},
+				Action: cmdFakeLLM,
+			},
+		},
+	}
+}
+
+func cmdFakeLLM(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("usage: waggle dev fake-llm [--addr host:port] <script.json>")
+	}
+	script, err := fakellm.LoadScript(cmd.Args().First())
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", cmd.String("addr"))
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	fake := fakellm.New(*script)
+	fake.Logger = log.New(os.Stderr, "[fake-llm] ", log.LstdFlags)
+	root := "http://" + ln.Addr().String()
+	urls := fakellm.BaseURLs(root)
+
+	p := output.NewPrinter(output.ModePlain, false)
+	p.Success("Fake LLM listening on %s (%d scripted responses)", root, len(script.Responses))
+	p.KeyValue([][]string{
+		{"anthropic", urls["anthropic"]},
+		{"openai", urls["openai"]},
+		{"gemini-api", urls["gemini-api"]},
+	})
+	p.Info("Set queen.base_url to the provider's URL. Press Ctrl+C to stop.")
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Handler: fake}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	p.Info("Served %d requests, %d scripted responses left", len(fake.Requests()), fake.Remaining())
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/urfave/cli/v3"
+)
+
+// cmdReviewers shows how often each panel reviewer agreed with the outcome
+// of the reviews it voted in, across all sessions.
+func cmdReviewers(ctx context.Context, cmd *cli.Command) error {
+	db, err := openHiveDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := db.ReviewerStats(ctx)
+	if err != nil {
+		return fmt.Errorf("load reviewer stats: %w", err)
+	}
+
+	if cmd.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	p := output.NewPrinter(output.ModePlain, false)
+	if len(stats) == 0 {
+		p.Info("No review panel votes recorded. Configure review.reviewers to review with a panel.")
+		return nil
+	}
+
+	p.Header("Reviewer Agreement")
+	var rows [][]string
+	for _, s := range stats {
+		rows = append(rows, []string{
+			s.Reviewer,
+			fmt.Sprintf("%d", s.Votes),
+			fmt.Sprintf("%d", s.Approvals),
+			fmt.Sprintf("%.0f%%", 100*s.AgreementRate()),
+			fmt.Sprintf("%d", s.Disputed),
+			fmt.Sprintf("%.1f", s.MeanScore),
+		})
+	}
+	p.Table([]string{"Reviewer", "Votes", "Approved", "Agreed", "Disputed", "Mean Score"}, rows)
+	p.Printf("\nAgreed: votes that matched the outcome. Disputed: votes in reviews that were not unanimous.\n")
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func runReviewersCmd(t *testing.T, projectDir string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := &cli.Command{
+		Name: "reviewers",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "project", Value: projectDir},
+			&cli.BoolFlag{Name: "json"},
+		},
+		Action: cmdReviewers,
+	}
+	err := cmd.Run(context.Background(), []string{"reviewers"})
+
+	w.Close()
+	os.Stdout = oldStdout
+	buf.ReadFrom(r)
+	return buf.String(), err
+}
+
+func TestCmdReviewers(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+
+	out, err := runReviewersCmd(t, tmpDir)
+	if err != nil {
+		t.Fatalf("reviewers failed: %v", err)
+	}
+	if !strings.Contains(out, "No review panel votes recorded") {
+		t.Errorf("expected empty message, got: %s", out)
+	}
+
+	createTestSession(t, db, "s1", "objective")
+	if err := db.RecordReviewVotes(context.Background(), []state.ReviewVoteRow{
+		{SessionID: "s1", TaskID: "t1", Attempt: 1, Reviewer: "anthropic/claude-haiku-4-5", Approved: true, Score: 8, Outcome: true, Disputed: true},
+		{SessionID: "s1", TaskID: "t1", Attempt: 1, Reviewer: "openai/gpt-4o-mini", Approved: false, Score: 4, Outcome: true, Disputed: true},
+	}); err != nil {
+		t.Fatalf("RecordReviewVotes failed: %v", err)
+	}
+
+	out, err = runReviewersCmd(t, tmpDir)
+	if err != nil {
+		t.Fatalf("reviewers failed: %v", err)
+	}
+	for _, want := range []string{"anthropic/claude-haiku-4-5", "openai/gpt-4o-mini", "100%", "0%", "4.0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
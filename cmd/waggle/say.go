@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/HexSleeves/waggle/internal/queen"
+	"github.com/urfave/cli/v3"
+)
+
+// cmdSay queues a message for a session's Queen. A running Queen receives it
+// as a user message before its next turn (or straight away if it is waiting
+// for workers); a stopped session receives it on resume.
+func cmdSay(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) < 2 {
+		return fmt.Errorf("usage: waggle say <session-id> <message>")
+	}
+	text := strings.TrimSpace(strings.Join(args[1:], " "))
+	if text == "" {
+		return fmt.Errorf("message must not be empty")
+	}
+
+	db, session, err := openControlSession(ctx, cmd.String("project"), args[0])
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	id, err := queueControl(ctx, db, session.ID, queen.ControlSay, map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	p := output.NewPrinter(output.ModePlain, false)
+	p.Success("Queued message for session %s (request #%d)", session.ID, id)
+	printDelivery(p, session)
+	return nil
+}
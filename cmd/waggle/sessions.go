@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/HexSleeves/waggle/internal/output"
 	"github.com/HexSleeves/waggle/internal/state"
@@ -49,33 +50,77 @@ func cmdSessions(ctx context.Context, cmd *cli.Command) error {
 
 	p.Header("Sessions")
 
+	sessions, depths := nestSessions(sessions)
 	var rows [][]string
-	for _, s := range sessions {
+	for i, s := range sessions {
 		obj := s.Objective
 		if len(obj) > 40 {
 			obj = obj[:37] + "..."
 		}
+		id := s.ID
+		if depths[i] > 0 {
+			// Nested Queen session, listed under its parent
+			id = strings.Repeat("  ", depths[i]-1) + "└ " + s.ID
+			obj = "[" + s.ParentTaskID + "] " + obj
+		}
 		status := s.Status
 		if s.Status != "done" && s.Status != "cancelled" {
 			status = output.StatusIcon(s.Status) + " " + s.Status
 		}
 		rows = append(rows, []string{
-			s.ID,
+			id,
 			status,
 			fmt.Sprintf("%d", s.CompletedTasks),
 			fmt.Sprintf("%d", s.FailedTasks),
 			fmt.Sprintf("%d", s.PendingTasks),
+			fmt.Sprintf("$%.2f", s.CostUSD),
 			obj,
 		})
 	}
 	p.Table(
-		[]string{"Session", "Status", "Done", "Fail", "Pend", "Objective"},
+		[]string{"Session", "Status", "Done", "Fail", "Pend", "Cost", "Objective"},
 		rows,
 	)
 	p.Printf("\n%d session(s)\n", len(sessions))
 	return nil
 }
 
+// nestSessions orders sessions so that each nested Queen's session follows its
+// parent, and returns the nesting depth of each. Sessions whose parent is not
+// in the list stay at the top level.
+func nestSessions(sessions []state.SessionSummary) ([]state.SessionSummary, []int) {
+	present := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		present[s.ID] = true
+	}
+	children := make(map[string][]state.SessionSummary)
+	var roots []state.SessionSummary
+	for _, s := range sessions {
+		if s.ParentID != "" && present[s.ParentID] {
+			children[s.ParentID] = append(children[s.ParentID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	ordered := make([]state.SessionSummary, 0, len(sessions))
+	depths := make([]int, 0, len(sessions))
+	var walk func(s state.SessionSummary, depth int)
+	walk = func(s state.SessionSummary, depth int) {
+		ordered = append(ordered, s)
+		depths = append(depths, depth)
+		kids := children[s.ID]
+		// Sessions arrive newest first; list children in the order they ran
+		for i := len(kids) - 1; i >= 0; i-- {
+			walk(kids[i], depth+1)
+		}
+	}
+	for _, s := range roots {
+		walk(s, 0)
+	}
+	return ordered, depths
+}
+
 func cmdRemoveSession(ctx context.Context, cmd *cli.Command, _ bool) error {
 	projectDir := cmd.String("project")
 	sessionID := cmd.Args().First()
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func TestCmdSessions_Nested(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	createTestSession(t, db, "parent1", "migrate the service")
+	time.Sleep(5 * time.Millisecond)
+	createTestSession(t, db, "child1", "migrate the billing module")
+	if err := db.SetSessionParent(ctx, "child1", "parent1", "billing"); err != nil {
+		t.Fatalf("SetSessionParent: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	createTestSession(t, db, "other1", "unrelated")
+	if err := db.RecordCost(ctx, state.CostRow{SessionID: "parent1", Source: state.CostSourceQueen, CostUSD: 1.25}); err != nil {
+		t.Fatalf("RecordCost: %v", err)
+	}
+
+	var buf bytes.Buffer
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := &cli.Command{
+		Name: "sessions",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "project", Value: tmpDir},
+			&cli.IntFlag{Name: "limit", Value: 20},
+			&cli.BoolFlag{Name: "json"},
+			&cli.BoolFlag{Name: "running"},
+		},
+		Action: cmdSessions,
+	}
+	err := cmd.Run(ctx, []string{"sessions"})
+
+	w.Close()
+	os.Stdout = oldStdout
+	buf.ReadFrom(r)
+	out := buf.String()
+
+	if err != nil {
+		t.Fatalf("sessions failed: %v", err)
+	}
+	parentAt := strings.Index(out, "parent1")
+	childAt := strings.Index(out, "└ child1")
+	if parentAt < 0 || childAt < parentAt {
+		t.Fatalf("expected child listed under its parent:\n%s", out)
+	}
+	if !strings.Contains(out, "[billing]") {
+		t.Errorf("expected parent task shown for child session:\n%s", out)
+	}
+	if !strings.Contains(out, "$1.25") {
+		t.Errorf("expected session cost column:\n%s", out)
+	}
+	if otherAt := strings.Index(out, "other1"); otherAt > parentAt {
+		t.Errorf("expected newest root session first:\n%s", out)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HexSleeves/waggle/internal/output"
+	"github.com/HexSleeves/waggle/internal/queen"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+// taskCommand builds the `waggle task` command group for editing tasks of a
+// live (or resumable) session. Edits are queued and applied by the Queen at
+// its next turn, so they never race with in-flight tool calls.
+func taskCommand() *cli.Command {
+	sessionFlag := &cli.StringFlag{Name: "session", Aliases: []string{"s"}, Usage: "Session ID (default: latest session)"}
+	return &cli.Command{
+		Name:  "task",
+		Usage: "Cancel, edit or delete tasks in a running session",
+		Commands: []*cli.Command{
+			{
+				Name:      "cancel",
+				Usage:     "Cancel a task (kills its worker if running)",
+				ArgsUsage: "<task-id>",
+				Flags: []cli.Flag{
+					sessionFlag,
+					&cli.BoolFlag{Name: "cascade", Usage: "Also cancel tasks that depend on it"},
+					&cli.StringFlag{Name: "reason", Usage: "Reason recorded for the Queen"},
+				},
+				Action: cmdTaskCancel,
+			},
+			{
+				Name:      "update",
+				Usage:     "Edit a pending task",
+				ArgsUsage: "<task-id>",
+				Flags: []cli.Flag{
+					sessionFlag,
+					&cli.StringFlag{Name: "description", Usage: "Replacement description"},
+					&cli.StringSliceFlag{Name: "constraint", Usage: "Replacement constraint (repeatable)"},
+					&cli.StringSliceFlag{Name: "depends-on", Usage: "Replacement dependency IDs (repeatable or comma-separated)"},
+					&cli.BoolFlag{Name: "clear-deps", Usage: "Remove all dependencies"},
+					&cli.IntFlag{Name: "priority", Usage: "Priority 0 (low) to 3 (critical)"},
+					&cli.DurationFlag{Name: "timeout", Usage: "Worker timeout (e.g. 10m)"},
+				},
+				Action: cmdTaskUpdate,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a task that is not running",
+				ArgsUsage: "<task-id>",
+				Flags:     []cli.Flag{sessionFlag},
+				Action:    cmdTaskDelete,
+			},
+		},
+	}
+}
+
+func cmdTaskCancel(ctx context.Context, cmd *cli.Command) error {
+	payload := map[string]interface{}{}
+	if cmd.Bool("cascade") {
+		payload["cascade"] = true
+	}
+	if r := cmd.String("reason"); r != "" {
+		payload["reason"] = r
+	}
+	return enqueueTaskControl(ctx, cmd, queen.ControlCancelTask, payload)
+}
+
+func cmdTaskUpdate(ctx context.Context, cmd *cli.Command) error {
+	payload := map[string]interface{}{}
+	if cmd.IsSet("description") {
+		payload["description"] = cmd.String("description")
+	}
+	if cmd.IsSet("constraint") {
+		payload["constraints"] = cmd.StringSlice("constraint")
+	}
+	if cmd.Bool("clear-deps") {
+		payload["depends_on"] = []string{}
+	} else if cmd.IsSet("depends-on") {
+		payload["depends_on"] = cmd.StringSlice("depends-on")
+	}
+	if cmd.IsSet("priority") {
+		payload["priority"] = cmd.Int("priority")
+	}
+	if cmd.IsSet("timeout") {
+		payload["timeout_seconds"] = int(cmd.Duration("timeout").Seconds())
+	}
+	if len(payload) == 0 {
+		return fmt.Errorf("nothing to update: pass at least one of --description, --constraint, --depends-on, --clear-deps, --priority, --timeout")
+	}
+	return enqueueTaskControl(ctx, cmd, queen.ControlUpdateTask, payload)
+}
+
+func cmdTaskDelete(ctx context.Context, cmd *cli.Command) error {
+	return enqueueTaskControl(ctx, cmd, queen.ControlDeleteTask, map[string]interface{}{})
+}
+
+// enqueueTaskControl validates the target session and task, then queues the
+// control request for the Queen.
+func enqueueTaskControl(ctx context.Context, cmd *cli.Command, kind string, payload map[string]interface{}) error {
+	args := cmd.Args().Slice()
+	if len(args) != 1 {
+		return fmt.Errorf("usage: waggle task %s <task-id>", cmd.Name)
+	}
+	taskID := args[0]
+
+	db, session, err := openControlSession(ctx, cmd.String("project"), cmd.String("session"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.GetTask(ctx, session.ID, taskID); err != nil {
+		return fmt.Errorf("task %q not found in session %s", taskID, session.ID)
+	}
+
+	payload["task_id"] = taskID
+	id, err := queueControl(ctx, db, session.ID, kind, payload)
+	if err != nil {
+		return err
+	}
+
+	p := output.NewPrinter(output.ModePlain, false)
+	p.Success("Queued %s for task %s (request #%d)", kind, taskID, id)
+	printDelivery(p, session)
+	return nil
+}
+
+// openControlSession opens the hive database and finds the session a control
+// request targets: sessionID, or the latest session when empty. Finished
+// sessions are refused.
+func openControlSession(ctx context.Context, projectDir, sessionID string) (*state.DB, *state.SessionInfo, error) {
+	hiveDir := filepath.Join(projectDir, ".hive")
+	if _, err := os.Stat(hiveDir); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("no hive found at %s. Run 'waggle init' first", hiveDir)
+	}
+
+	db, err := state.OpenDB(hiveDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+
+	var session *state.SessionInfo
+	if sessionID != "" {
+		session, err = db.GetSession(ctx, sessionID)
+	} else {
+		session, err = db.LatestSession(ctx)
+	}
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("session not found")
+	}
+	if session.Status == "done" {
+		db.Close()
+		return nil, nil, fmt.Errorf("session %s is already done", session.ID)
+	}
+	return db, session, nil
+}
+
+// queueControl stores a control request for the session's Queen.
+func queueControl(ctx context.Context, db *state.DB, sessionID, kind string, payload interface{}) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("encode request: %w", err)
+	}
+	id, err := db.EnqueueControl(ctx, sessionID, kind, string(data))
+	if err != nil {
+		return 0, fmt.Errorf("queue request: %w", err)
+	}
+	return id, nil
+}
+
+// printDelivery tells the operator when a queued request takes effect.
+func printDelivery(p *output.Printer, session *state.SessionInfo) {
+	if session.Status == "running" {
+		p.Info("The Queen will apply it before its next turn.")
+	} else {
+		p.Info("Session is %s; the request will be applied on 'waggle resume'.", session.Status)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/urfave/cli/v3"
+)
+
+func runTaskCmd(t *testing.T, projectDir string, args ...string) error {
+	t.Helper()
+	root := &cli.Command{
+		Name: "waggle",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "project", Value: projectDir},
+		},
+		Commands: []*cli.Command{taskCommand()},
+	}
+	return root.Run(context.Background(), append([]string{"waggle", "task"}, args...))
+}
+
+func TestCmdTask_QueuesControlRequests(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	createTestSession(t, db, "s1", "objective")
+	createTestTask(t, db, "s1", state.TaskRow{ID: "t1", Type: "code", Status: "pending", Title: "T1"})
+
+	if err := runTaskCmd(t, tmpDir, "cancel", "--cascade", "t1"); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if err := runTaskCmd(t, tmpDir, "update", "--priority", "3", "--depends-on", "a,b", "t1"); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := runTaskCmd(t, tmpDir, "delete", "--session", "s1", "t1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	pending, err := db.PendingControls(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("PendingControls failed: %v", err)
+	}
+	if len(pending) != 3 {
+		t.Fatalf("expected 3 queued requests, got %d", len(pending))
+	}
+
+	kinds := []string{pending[0].Kind, pending[1].Kind, pending[2].Kind}
+	if strings.Join(kinds, ",") != "cancel_task,update_task,delete_task" {
+		t.Errorf("unexpected kinds: %v", kinds)
+	}
+
+	var update map[string]interface{}
+	if err := json.Unmarshal([]byte(pending[1].Payload), &update); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if update["task_id"] != "t1" || update["priority"] != float64(3) {
+		t.Errorf("unexpected update payload: %v", update)
+	}
+	if deps, ok := update["depends_on"].([]interface{}); !ok || len(deps) != 2 {
+		t.Errorf("expected two dependencies, got %v", update["depends_on"])
+	}
+}
+
+func TestCmdTask_UnknownTask(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	createTestSession(t, db, "s1", "objective")
+
+	err := runTaskCmd(t, tmpDir, "cancel", "ghost")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+}
+
+func TestCmdTask_UpdateRequiresFields(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	createTestSession(t, db, "s1", "objective")
+	createTestTask(t, db, "s1", state.TaskRow{ID: "t1", Type: "code", Status: "pending", Title: "T1"})
+
+	err := runTaskCmd(t, tmpDir, "update", "t1")
+	if err == nil || !strings.Contains(err.Error(), "nothing to update") {
+		t.Fatalf("expected nothing to update error, got: %v", err)
+	}
+}
+
+func TestCmdSay_QueuesMessage(t *testing.T) {
+	tmpDir, db := setupTestHive(t)
+	defer db.Close()
+	createTestSession(t, db, "s1", "objective")
+
+	root := &cli.Command{
+		Name:     "waggle",
+		Flags:    []cli.Flag{&cli.StringFlag{Name: "project", Value: tmpDir}},
+		Commands: []*cli.Command{{Name: "say", Action: cmdSay}},
+	}
+	if err := root.Run(context.Background(), []string{"waggle", "say", "s1", "skip", "the", "docs", "tasks"}); err != nil {
+		t.Fatalf("say failed: %v", err)
+	}
+
+	pending, err := db.PendingControls(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("PendingControls failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Kind != "say" || pending[0].Payload != `{"text":"skip the docs tasks"}` {
+		t.Fatalf("unexpected queued requests: %+v", pending)
+	}
+
+	if err := root.Run(context.Background(), []string{"waggle", "say", "s1"}); err == nil {
+		t.Error("expected usage error without a message")
+	}
+	if err := root.Run(context.Background(), []string{"waggle", "say", "missing", "hi"}); err == nil {
+		t.Error("expected error for an unknown session")
+	}
+}
@@ -24,6 +24,9 @@ func loadTasksFile(path string, cfg *config.Config) ([]*task.Task, error) {
 		Priority    int      `json:"priority"`
 		DependsOn   []string `json:"depends_on"`
 		MaxRetries  int      `json:"max_retries"`
+		Cache       *bool    `json:"cache"`
+
+		SkipDepResults bool `json:"skip_dependency_results"`
 	}
 
 	var rawTasks []rawTask
@@ -44,6 +47,9 @@ func loadTasksFile(path string, cfg *config.Config) ([]*task.Task, error) {
 			MaxRetries:  rt.MaxRetries,
 			CreatedAt:   time.Now(),
 			Timeout:     cfg.Workers.DefaultTimeout,
+			Cache:       rt.Cache,
+
+			SkipDepResults: rt.SkipDepResults,
 		}
 		if t.MaxRetries == 0 {
 			t.MaxRetries = cfg.Workers.MaxRetries
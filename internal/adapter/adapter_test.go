@@ -344,6 +344,8 @@ func TestBuildPrompt(t *testing.T) {
 		Description:  "This is a test description",
 		Context:      map[string]string{"env": "test", "debug": "true"},
 		AllowedPaths: []string{"/tmp", "/home/user"},
+		Notes:        []string{"conventions: use slog for logging"},
+		DepResults:   "### schema: Add schema (code)\nCreated users table",
 	}
 
 	prompt := buildPrompt(task)
@@ -355,6 +357,8 @@ func TestBuildPrompt(t *testing.T) {
 		"- env: test",
 		"- debug: true",
 		"Only modify files in: /tmp, /home/user",
+		"Shared notes (conventions and decisions for this project):\n- conventions: use slog for logging",
+		"Results from dependencies (completed tasks this one builds on):\n### schema: Add schema (code)\nCreated users table",
 	}
 
 	for _, part := range expectedParts {
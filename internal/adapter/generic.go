@@ -217,6 +217,13 @@ func (w *CLIWorker) Spawn(ctx context.Context, t *task.Task) error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
 
+		metrics := map[string]float64{"exit_code": float64(getExitCode(err))}
+		if w.adapter.mode != PromptAsScript {
+			for k, v := range usageMetrics(stdoutBuf.String() + "\n" + stderrBuf.String()) {
+				metrics[k] = v
+			}
+		}
+
 		if err != nil {
 			w.status = worker.StatusFailed
 			var errMsg string
@@ -233,12 +240,14 @@ func (w *CLIWorker) Spawn(ctx context.Context, t *task.Task) error {
 				Success: false,
 				Output:  stdoutBuf.String(),
 				Errors:  []string{errMsg, stderrBuf.String()},
+				Metrics: metrics,
 			}
 		} else {
 			w.status = worker.StatusComplete
 			w.result = &task.Result{
 				Success: true,
 				Output:  stdoutBuf.String(),
+				Metrics: metrics,
 			}
 		}
 	}()
@@ -0,0 +1,56 @@
+package adapter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Usage reports printed by worker CLIs. Claude Code's JSON output carries
+// total_cost_usd and a usage object; other CLIs print a summary line.
+var (
+	costPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`"total_cost_usd"\s*:\s*([0-9.]+)`),
+		regexp.MustCompile(`(?i)total cost:\s*\$([0-9.]+)`),
+	}
+	inputTokensPattern  = regexp.MustCompile(`"input_tokens"\s*:\s*(\d+)`)
+	outputTokensPattern = regexp.MustCompile(`"output_tokens"\s*:\s*(\d+)`)
+	tokensUsedPattern   = regexp.MustCompile(`(?i)tokens used[:\s]+([\d,]+)`)
+)
+
+// usageMetrics extracts the cost and token counts a worker CLI reported in
+// its output, as task result metrics (cost_usd, input_tokens, output_tokens,
+// tokens). When a report appears more than once, the last one wins.
+func usageMetrics(output string) map[string]float64 {
+	metrics := make(map[string]float64)
+	for _, re := range costPatterns {
+		if v, ok := lastNumber(re, output); ok {
+			metrics["cost_usd"] = v
+			break
+		}
+	}
+	in, hasIn := lastNumber(inputTokensPattern, output)
+	out, hasOut := lastNumber(outputTokensPattern, output)
+	if hasIn {
+		metrics["input_tokens"] = in
+	}
+	if hasOut {
+		metrics["output_tokens"] = out
+	}
+	if hasIn || hasOut {
+		metrics["tokens"] = in + out
+	} else if v, ok := lastNumber(tokensUsedPattern, output); ok {
+		metrics["tokens"] = v
+	}
+	return metrics
+}
+
+// lastNumber returns the number captured by the last match of re in s.
+func lastNumber(re *regexp.Regexp, s string) (float64, bool) {
+	matches := re.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(matches[len(matches)-1][1], ",", ""), 64)
+	return v, err == nil
+}
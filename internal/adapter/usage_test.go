@@ -0,0 +1,45 @@
+package adapter
+
+import "testing"
+
+func TestUsageMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]float64
+	}{
+		{
+			name:   "claude json",
+			output: `{"type":"result","total_cost_usd":0.0421,"usage":{"input_tokens":1200,"output_tokens":340}}`,
+			want:   map[string]float64{"cost_usd": 0.0421, "input_tokens": 1200, "output_tokens": 340, "tokens": 1540},
+		},
+		{
+			name:   "summary line",
+			output: "done\nTotal cost: $1.25\n",
+			want:   map[string]float64{"cost_usd": 1.25},
+		},
+		{
+			name:   "codex tokens used, last report wins",
+			output: "tokens used: 900\n...\ntokens used\n12,345\n",
+			want:   map[string]float64{"tokens": 12345},
+		},
+		{
+			name:   "nothing reported",
+			output: "hello world",
+			want:   map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := usageMetrics(tt.output)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s: expected %v, got %v", k, v, got[k])
+				}
+			}
+		})
+	}
+}
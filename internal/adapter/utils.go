@@ -79,6 +79,17 @@ func buildPrompt(t *task.Task) string {
 		}
 	}
 
+	if deps := t.GetDepResults(); deps != "" {
+		fmt.Fprintf(&b, "\nResults from dependencies (completed tasks this one builds on):\n%s\n", deps)
+	}
+
+	if notes := t.GetNotes(); len(notes) > 0 {
+		fmt.Fprintf(&b, "\nShared notes (conventions and decisions for this project):\n")
+		for _, n := range notes {
+			fmt.Fprintf(&b, "- %s\n", n)
+		}
+	}
+
 	if len(t.AllowedPaths) > 0 {
 		fmt.Fprintf(&b, "\nOnly modify files in: %s\n", strings.Join(t.AllowedPaths, ", "))
 	}
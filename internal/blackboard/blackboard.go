@@ -1,6 +1,7 @@
 package blackboard
 
 import (
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -111,6 +112,31 @@ func (bb *Blackboard) ReadByTask(taskID string) []*Entry {
 	return result
 }
 
+// Search returns entries whose key, tags or text value contain query
+// (case-insensitive), oldest first.
+func (bb *Blackboard) Search(query string) []*Entry {
+	bb.mu.RLock()
+	defer bb.mu.RUnlock()
+	query = strings.ToLower(query)
+	var result []*Entry
+	for _, e := range bb.entries {
+		text := e.Key + "\n" + strings.Join(e.Tags, "\n")
+		if s, ok := e.Value.(string); ok {
+			text += "\n" + s
+		}
+		if strings.Contains(strings.ToLower(text), query) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].Timestamp.Before(result[j].Timestamp)
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
 // Keys returns all current keys
 func (bb *Blackboard) Keys() []string {
 	bb.mu.RLock()
@@ -203,6 +203,24 @@ func TestBlackboardReadByTag(t *testing.T) {
 	}
 }
 
+func TestBlackboardSearch(t *testing.T) {
+	bb := New(nil)
+	bb.Post(&Entry{Key: "conventions", Value: "Use slog for Logging", PostedBy: "queen"})
+	bb.Post(&Entry{Key: "db-schema", Value: "users(id, email)", PostedBy: "queen", Tags: []string{"logging"}})
+	bb.Post(&Entry{Key: "count", Value: 42, PostedBy: "w1"})
+
+	found := bb.Search("LOGGING")
+	if len(found) != 2 || found[0].Key != "conventions" || found[1].Key != "db-schema" {
+		t.Errorf("expected value and tag matches oldest first, got %+v", found)
+	}
+	if found := bb.Search("schema"); len(found) != 1 {
+		t.Errorf("expected key match, got %d entries", len(found))
+	}
+	if found := bb.Search("42"); len(found) != 0 {
+		t.Errorf("expected non-string values not searched, got %d entries", len(found))
+	}
+}
+
 func TestBlackboardReadByWorker(t *testing.T) {
 	bb := New(nil)
 
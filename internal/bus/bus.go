@@ -13,6 +13,9 @@ const (
 	MsgTaskCreated       MsgType = "task.created"
 	MsgTaskStatusChanged MsgType = "task.status_changed"
 	MsgTaskAssigned      MsgType = "task.assigned"
+	MsgTaskRemoved       MsgType = "task.removed"
+	MsgTaskHeld          MsgType = "task.held"
+	MsgSubSession        MsgType = "task.sub_session"
 	MsgWorkerSpawned     MsgType = "worker.spawned"
 	MsgWorkerCompleted   MsgType = "worker.completed"
 	MsgWorkerFailed      MsgType = "worker.failed"
@@ -20,9 +23,22 @@ const (
 	MsgBlackboardUpdate  MsgType = "blackboard.update"
 	MsgQueenDecision     MsgType = "queen.decision"
 	MsgQueenPlan         MsgType = "queen.plan"
+	MsgQueenStream       MsgType = "queen.stream"
+	MsgCostUpdate        MsgType = "cost.update"
 	MsgSystemError       MsgType = "system.error"
 )
 
+// transient message types are high-volume and short-lived (e.g. streamed LLM
+// deltas). They are delivered to handlers but not kept in the history.
+var transient = map[MsgType]bool{
+	MsgQueenStream: true,
+}
+
+// IsTransient reports whether messages of type t are kept out of the history.
+func IsTransient(t MsgType) bool {
+	return transient[t]
+}
+
 type Message struct {
 	Type     MsgType     `json:"type"`
 	TaskID   string      `json:"task_id,omitempty"`
@@ -100,11 +116,13 @@ func (b *MessageBus) unsubscribe(msgType MsgType, id uint64) {
 
 func (b *MessageBus) Publish(msg Message) {
 	b.mu.Lock()
-	b.history = append(b.history, msg)
-	if len(b.history) > b.maxHist {
-		trimmed := make([]Message, b.maxHist)
-		copy(trimmed, b.history[len(b.history)-b.maxHist:])
-		b.history = trimmed
+	if !transient[msg.Type] {
+		b.history = append(b.history, msg)
+		if len(b.history) > b.maxHist {
+			trimmed := make([]Message, b.maxHist)
+			copy(trimmed, b.history[len(b.history)-b.maxHist:])
+			b.history = trimmed
+		}
 	}
 	// Copy handlers under lock
 	specific := make([]Handler, len(b.handlers[msg.Type]))
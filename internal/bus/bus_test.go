@@ -24,6 +24,7 @@ func TestMsgTypeConstants(t *testing.T) {
 		{"BlackboardUpdate", MsgBlackboardUpdate, "blackboard.update"},
 		{"QueenDecision", MsgQueenDecision, "queen.decision"},
 		{"QueenPlan", MsgQueenPlan, "queen.plan"},
+		{"QueenStream", MsgQueenStream, "queen.stream"},
 		{"SystemError", MsgSystemError, "system.error"},
 	}
 
@@ -222,6 +223,23 @@ func TestPublish(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestPublishTransient(t *testing.T) {
+	b := New(100)
+
+	delivered := 0
+	b.Subscribe(MsgQueenStream, func(msg Message) { delivered++ })
+
+	b.Publish(Message{Type: MsgTaskCreated, TaskID: "task-1"})
+	b.Publish(Message{Type: MsgQueenStream, Payload: "delta"})
+
+	if delivered != 1 {
+		t.Errorf("Expected transient message to be delivered once, got %d", delivered)
+	}
+	if h := b.History(0); len(h) != 1 || h[0].Type != MsgTaskCreated {
+		t.Errorf("Expected transient message to stay out of history, got %+v", h)
+	}
+}
+
 func TestPublishNoSubscribers(t *testing.T) {
 	b := New(100)
 
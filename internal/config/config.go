@@ -24,6 +24,25 @@ type Config struct {
 	// Safety settings
 	Safety SafetyConfig `json:"safety"`
 
+	// Cross-session result cache
+	Cache CacheConfig `json:"cache"`
+
+	// Human checkpoints
+	Approval ApprovalConfig `json:"approval"`
+
+	// LLM review of worker output: rubrics and reviewer panels
+	Review ReviewConfig `json:"review"`
+
+	// Dependency results passed to downstream workers
+	Handoff HandoffConfig `json:"handoff"`
+
+	// Spending limits and model prices
+	Budget  BudgetConfig          `json:"budget"`
+	Pricing map[string]ModelPrice `json:"pricing,omitempty"` // "provider/model" or model (prefix) → price
+
+	// Recording and replay of the Queen's LLM calls
+	Cassette CassetteConfig `json:"cassette"`
+
 	// Output mode settings (set via CLI flags, not persisted to config file)
 	Output OutputConfig `json:"-"`
 }
@@ -67,7 +86,51 @@ type QueenConfig struct {
 	PlanTimeout   time.Duration `json:"plan_timeout"`
 	ReviewTimeout time.Duration `json:"review_timeout"`
 	CompactAfter  int           `json:"compact_after_messages"`
-	DryRun        bool          `json:"-"` // Runtime-only: plan without executing workers
+
+	// Compaction: the conversation is compacted once the prompt reaches
+	// CompactAt of the model's context window.
+	ContextWindow int     `json:"context_window,omitempty"` // tokens (0 = known size of the model)
+	CompactAt     float64 `json:"compact_at"`               // fraction of the context window
+
+	// Failover: when the provider keeps failing, calls move to the first
+	// working fallback and return to the provider after FailbackAfter.
+	Fallbacks     []LLMBackend  `json:"fallbacks,omitempty"`
+	FailbackAfter time.Duration `json:"failback_after"`
+
+	// Models routes each purpose of the Queen's LLM calls (agent, review,
+	// replan, summarize, compaction) to its own model and generation
+	// settings; unset fields fall back to the Queen's provider and model.
+	Models map[string]ModelConfig `json:"models,omitempty"`
+
+	// Nested Queens: "objective" tasks run a child Queen on a sub-objective.
+	MaxDepth         int `json:"max_depth"`          // nesting levels allowed (0 = no objective tasks)
+	SubMaxIterations int `json:"sub_max_iterations"` // default turn budget of a child Queen
+
+	DryRun bool `json:"-"` // Runtime-only: plan without executing workers
+}
+
+// LLMBackend is a provider/model the Queen can fall back to.
+type LLMBackend struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	BaseURL  string `json:"base_url,omitempty"`
+}
+
+// ModelConfig is the model and generation settings of one purpose.
+type ModelConfig struct {
+	Provider    string   `json:"provider,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	APIKey      string   `json:"api_key,omitempty"`
+	BaseURL     string   `json:"base_url,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // output tokens per call (0 = client default)
+	Temperature *float64 `json:"temperature,omitempty"` // nil = provider default
+
+	// Reasoning before answering: a token budget (Anthropic extended
+	// thinking, Gemini) or an effort level, low | medium | high (OpenAI).
+	// Either one enables it on every provider; neither keeps the default.
+	ThinkingBudget  int    `json:"thinking_budget,omitempty"`
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
 }
 
 type WorkerConfig struct {
@@ -77,8 +140,19 @@ type WorkerConfig struct {
 	DefaultAdapter string            `json:"default_adapter"`
 	MaxOutputSize  int               `json:"max_output_size"`
 	AdapterMap     map[string]string `json:"adapter_map,omitempty"` // task type → adapter name
+
+	// Path claims: tasks claim their AllowedPaths; overlapping claims are
+	// serialized unless every overlapping task is of a read-only type.
+	ReadOnlyTypes []string `json:"read_only_types,omitempty"` // task types that only read their paths
+	UnscopedClaim string   `json:"unscoped_claim,omitempty"`  // tasks without paths: "project" (default) or "none"
 }
 
+// Values for WorkerConfig.UnscopedClaim.
+const (
+	UnscopedClaimProject = "project"
+	UnscopedClaimNone    = "none"
+)
+
 type AdapterConfig struct {
 	Command string            `json:"command"`
 	Args    []string          `json:"args,omitempty"`
@@ -87,6 +161,96 @@ type AdapterConfig struct {
 	Timeout time.Duration     `json:"timeout,omitempty"`
 }
 
+// CacheConfig controls memoization of successful task results across sessions.
+// Tasks can override the default with their own cache flag.
+type CacheConfig struct {
+	Enabled bool          `json:"enabled"`
+	Types   []string      `json:"types,omitempty"`   // task types cached by default
+	MaxAge  time.Duration `json:"max_age,omitempty"` // 0 = entries never expire
+}
+
+// ApprovalConfig selects the Queen actions that wait for an operator's
+// approval. Gates apply to the top-level Queen, except assignment gates, which
+// also apply to nested Queens.
+type ApprovalConfig struct {
+	Plan           bool          `json:"plan,omitempty"`            // approve the first create_tasks
+	AssignTypes    []string      `json:"assign_types,omitempty"`    // approve assign_task for these task types
+	AssignAdapters []string      `json:"assign_adapters,omitempty"` // approve assign_task for these adapters
+	Complete       bool          `json:"complete,omitempty"`        // approve complete
+	Timeout        time.Duration `json:"timeout,omitempty"`         // 0 = wait indefinitely
+	OnTimeout      string        `json:"on_timeout,omitempty"`      // approve | reject (default)
+	DefaultAnswer  string        `json:"default_answer,omitempty"`  // ask_user answer when nobody replies
+}
+
+// Values for ApprovalConfig.OnTimeout.
+const (
+	ApprovalApprove = "approve"
+	ApprovalReject  = "reject"
+)
+
+// ReviewConfig sets the criteria worker output is reviewed against and who
+// reviews it. A rubric in .hive/rubrics/<type>.md replaces the configured one
+// for that task type. With Reviewers, each reviewer votes and Quorum
+// approvals accept the output; otherwise the review model decides alone.
+type ReviewConfig struct {
+	Rubrics        map[string][]string `json:"rubrics,omitempty"`         // task type (or "default") → criteria
+	Reviewers      []ModelConfig       `json:"reviewers,omitempty"`       // models on the panel; unset fields come from the review model
+	Quorum         int                 `json:"quorum,omitempty"`          // approvals needed (0 = a majority)
+	OnDisagreement string              `json:"on_disagreement,omitempty"` // queen (default) | human
+}
+
+// Values for ReviewConfig.OnDisagreement.
+const (
+	DisagreementQueen = "queen" // the quorum decides; the split is reported
+	DisagreementHuman = "human" // the operator decides split votes
+)
+
+// HandoffConfig controls the "Results from dependencies" section of a worker
+// prompt, built from the outputs, blackboard entries and artifacts of the
+// task's dependencies. Tasks can opt out with skip_dependency_results.
+type HandoffConfig struct {
+	Disabled      bool `json:"disabled,omitempty"`
+	MaxChars      int  `json:"max_chars"`           // per dependency
+	MaxTotalChars int  `json:"max_total_chars"`     // whole section
+	Summarize     bool `json:"summarize,omitempty"` // LLM-summarize outputs longer than max_chars instead of truncating
+}
+
+// BudgetConfig caps what a session may spend. Zero values mean no limit.
+// When a limit is crossed the Queen is told to wrap up; if it keeps going on
+// the next turn, the session is stopped.
+type BudgetConfig struct {
+	MaxSessionUSD float64 `json:"max_session_usd,omitempty"` // Queen + workers, per session
+	MaxTaskUSD    float64 `json:"max_task_usd,omitempty"`    // per task, across attempts
+	MaxTokens     int     `json:"max_tokens,omitempty"`      // Queen + workers, per session
+	WarnAt        float64 `json:"warn_at,omitempty"`         // fraction of a limit that triggers a warning
+}
+
+// CassetteConfig records every Queen LLM call to a cassette file, or answers
+// the calls from one instead of a provider. Relative paths are under the hive
+// directory.
+type CassetteConfig struct {
+	Record string `json:"record,omitempty"` // cassette to write
+	Replay string `json:"replay,omitempty"` // cassette to answer from
+	Match  string `json:"match,omitempty"`  // replay matching: sequence (default) | hash
+}
+
+// CassettePath resolves a cassette path from the config.
+func (c *Config) CassettePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return c.HivePath(path)
+}
+
+// ModelPrice is a model's price in USD per million tokens. It overrides the
+// built-in price table.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
 type SafetyConfig struct {
 	AllowedPaths       []string `json:"allowed_paths"`
 	BlockedCommands    []string `json:"blocked_commands"`
@@ -110,6 +274,11 @@ func DefaultConfig() *Config {
 			PlanTimeout:   5 * time.Minute,
 			ReviewTimeout: 2 * time.Minute,
 			CompactAfter:  100,
+			CompactAt:     0.7,
+			FailbackAfter: 5 * time.Minute,
+
+			MaxDepth:         2,
+			SubMaxIterations: 25,
 		},
 		Workers: WorkerConfig{
 			MaxParallel:    4,
@@ -117,6 +286,8 @@ func DefaultConfig() *Config {
 			MaxRetries:     2,
 			DefaultAdapter: "claude-code",
 			MaxOutputSize:  1024 * 1024, // 1MB
+			ReadOnlyTypes:  []string{"research", "review"},
+			UnscopedClaim:  UnscopedClaimProject,
 		},
 		Adapters: map[string]AdapterConfig{
 			"claude-code": {
@@ -149,6 +320,19 @@ func DefaultConfig() *Config {
 			Mode:              SafetyModeStrict,
 			EnforceOnAdapters: []string{"exec"},
 		},
+		Cache: CacheConfig{
+			Types: []string{"research", "review"},
+		},
+		Approval: ApprovalConfig{
+			OnTimeout: ApprovalReject,
+		},
+		Handoff: HandoffConfig{
+			MaxChars:      4000,
+			MaxTotalChars: 12000,
+		},
+		Budget: BudgetConfig{
+			WarnAt: 0.8,
+		},
 	}
 }
 
@@ -28,6 +28,9 @@ func TestDefaultConfig_QueenDefaults(t *testing.T) {
 	if cfg.Queen.CompactAfter != 100 {
 		t.Errorf("Queen.CompactAfter = %d, want %d", cfg.Queen.CompactAfter, 100)
 	}
+	if cfg.Queen.CompactAt != 0.7 {
+		t.Errorf("Queen.CompactAt = %v, want %v", cfg.Queen.CompactAt, 0.7)
+	}
 }
 
 func TestDefaultConfig_WorkerDefaults(t *testing.T) {
@@ -487,3 +490,135 @@ func TestConfigAdapterMapOmittedWhenEmpty(t *testing.T) {
 		t.Errorf("AdapterMap should be nil when not set, got %v", loaded.Workers.AdapterMap)
 	}
 }
+
+func TestLoad_BudgetAndPricing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "waggle.json")
+	data := `{
+		"budget": {"max_session_usd": 5, "max_task_usd": 1.5, "max_tokens": 2000000},
+		"pricing": {"ollama/llama3": {"input": 0.1, "output": 0.2}}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Budget.MaxSessionUSD != 5 || cfg.Budget.MaxTaskUSD != 1.5 || cfg.Budget.MaxTokens != 2000000 {
+		t.Errorf("Budget = %+v", cfg.Budget)
+	}
+	if cfg.Budget.WarnAt != 0.8 {
+		t.Errorf("Budget.WarnAt = %v, want default 0.8", cfg.Budget.WarnAt)
+	}
+	if p := cfg.Pricing["ollama/llama3"]; p.Input != 0.1 || p.Output != 0.2 {
+		t.Errorf("Pricing[ollama/llama3] = %+v", p)
+	}
+}
+
+func TestLoad_Approval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "waggle.json")
+	data := `{
+		"approval": {"plan": true, "assign_types": ["code"], "assign_adapters": ["exec"], "complete": true, "timeout": 600000000000, "default_answer": "use defaults"}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	a := cfg.Approval
+	if !a.Plan || !a.Complete || len(a.AssignTypes) != 1 || a.AssignAdapters[0] != "exec" {
+		t.Errorf("Approval = %+v", a)
+	}
+	if a.Timeout != 10*time.Minute || a.DefaultAnswer != "use defaults" {
+		t.Errorf("Approval timeout/default = %v, %q", a.Timeout, a.DefaultAnswer)
+	}
+	if a.OnTimeout != ApprovalReject {
+		t.Errorf("Approval.OnTimeout = %q, want default %q", a.OnTimeout, ApprovalReject)
+	}
+}
+
+func TestLoad_Handoff(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "waggle.json")
+	data := `{
+		"handoff": {"max_chars": 2000, "summarize": true}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	h := cfg.Handoff
+	if h.Disabled || !h.Summarize || h.MaxChars != 2000 {
+		t.Errorf("Handoff = %+v", h)
+	}
+	if h.MaxTotalChars != 12000 {
+		t.Errorf("Handoff.MaxTotalChars = %d, want default 12000", h.MaxTotalChars)
+	}
+}
+
+func TestLoad_Fallbacks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "waggle.json")
+	data := `{
+		"queen": {
+			"provider": "anthropic",
+			"fallbacks": [
+				{"provider": "openai", "model": "gpt-4.1"},
+				{"provider": "gemini-api", "model": "gemini-2.5-pro", "api_key": "k"}
+			]
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	fb := cfg.Queen.Fallbacks
+	if len(fb) != 2 || fb[0].Provider != "openai" || fb[1].Model != "gemini-2.5-pro" || fb[1].APIKey != "k" {
+		t.Errorf("Queen.Fallbacks = %+v", fb)
+	}
+	if cfg.Queen.FailbackAfter != 5*time.Minute {
+		t.Errorf("Queen.FailbackAfter = %v, want default 5m", cfg.Queen.FailbackAfter)
+	}
+}
+
+func TestLoad_Models(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "waggle.json")
+	data := `{
+		"queen": {
+			"models": {
+				"review": {"model": "claude-haiku-4-5", "max_tokens": 1024, "temperature": 0},
+				"summarize": {"provider": "openai", "model": "gpt-4o-mini"}
+			}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	review := cfg.Queen.Models["review"]
+	if review.Model != "claude-haiku-4-5" || review.MaxTokens != 1024 || review.Temperature == nil || *review.Temperature != 0 {
+		t.Errorf("review model = %+v", review)
+	}
+	if s := cfg.Queen.Models["summarize"]; s.Provider != "openai" || s.Temperature != nil {
+		t.Errorf("summarize model = %+v", s)
+	}
+}
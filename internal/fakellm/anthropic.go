@@ -0,0 +1,131 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// anthropic serves POST /v1/messages.
+func (s *Server) anthropic(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	body, err := readRequest(r, &req)
+	if err != nil {
+		anthropicError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rep, err := s.take("anthropic", req.Model, req.Stream, body, "toolu_fake_")
+	if err != nil {
+		anthropicError(w, http.StatusGone, err.Error())
+		return
+	}
+	if rep.Error != 0 {
+		anthropicError(w, rep.Error, rep.errorMessage())
+		return
+	}
+	id := fmt.Sprintf("msg_fake_%d", rep.n)
+	if !req.Stream {
+		writeJSON(w, http.StatusOK, anthropicMessage(id, rep, rep.stop, rep.usage.OutputTokens, anthropicContent(rep)))
+		return
+	}
+
+	// message_start carries the input usage, message_delta the output.
+	sse := newSSEWriter(w)
+	sse.event("message_start", map[string]any{"type": "message_start", "message": anthropicMessage(id, rep, nil, 1, []any{})})
+	index := 0
+	if rep.Thinking != "" {
+		sse.event("content_block_start", map[string]any{"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "thinking", "thinking": "", "signature": ""}})
+		for _, c := range chunks(rep.Thinking) {
+			sse.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "thinking_delta", "thinking": c}})
+		}
+		sse.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": index,
+			"delta": map[string]any{"type": "signature_delta", "signature": rep.signature()}})
+		sse.event("content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+	if rep.Text != "" {
+		sse.event("content_block_start", map[string]any{"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "text", "text": ""}})
+		for _, c := range chunks(rep.Text) {
+			sse.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "text_delta", "text": c}})
+		}
+		sse.event("content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+	for _, tc := range rep.ToolCalls {
+		sse.event("content_block_start", map[string]any{"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "tool_use", "id": tc.ID, "name": tc.Name, "input": map[string]any{}}})
+		for _, c := range chunks(string(tc.Input)) {
+			sse.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "input_json_delta", "partial_json": c}})
+		}
+		sse.event("content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+	sse.event("message_delta", map[string]any{"type": "message_delta",
+		"delta": map[string]any{"stop_reason": rep.stop, "stop_sequence": nil},
+		"usage": map[string]any{"output_tokens": rep.usage.OutputTokens}})
+	sse.event("message_stop", map[string]any{"type": "message_stop"})
+}
+
+// anthropicMessage is a Messages API message with the reply's input usage.
+// A stream starts with one whose stop reason is not known yet.
+func anthropicMessage(id string, rep *reply, stop any, outputTokens int, content []any) map[string]any {
+	return map[string]any{
+		"id":            id,
+		"type":          "message",
+		"role":          "assistant",
+		"model":         rep.model,
+		"content":       content,
+		"stop_reason":   stop,
+		"stop_sequence": nil,
+		"usage": map[string]any{
+			"input_tokens":                rep.usage.InputTokens,
+			"output_tokens":               outputTokens,
+			"cache_read_input_tokens":     rep.usage.CacheReadTokens,
+			"cache_creation_input_tokens": rep.usage.CacheCreationTokens,
+		},
+	}
+}
+
+func anthropicContent(rep *reply) []any {
+	content := []any{}
+	if rep.Thinking != "" {
+		content = append(content, map[string]any{"type": "thinking", "thinking": rep.Thinking, "signature": rep.signature()})
+	}
+	if rep.Text != "" {
+		content = append(content, map[string]any{"type": "text", "text": rep.Text})
+	}
+	for _, tc := range rep.ToolCalls {
+		content = append(content, map[string]any{"type": "tool_use", "id": tc.ID, "name": tc.Name, "input": json.RawMessage(tc.Input)})
+	}
+	return content
+}
+
+// anthropicError writes an API error. x-should-retry stops the SDK from
+// retrying on its own, so each attempt the caller makes takes one step.
+func anthropicError(w http.ResponseWriter, status int, message string) {
+	typ := "api_error"
+	switch status {
+	case http.StatusBadRequest:
+		typ = "invalid_request_error"
+	case http.StatusUnauthorized:
+		typ = "authentication_error"
+	case http.StatusForbidden:
+		typ = "permission_error"
+	case http.StatusNotFound:
+		typ = "not_found_error"
+	case http.StatusTooManyRequests:
+		typ = "rate_limit_error"
+	case 529:
+		typ = "overloaded_error"
+	}
+	w.Header().Set("x-should-retry", "false")
+	writeJSON(w, status, map[string]any{"type": "error", "error": map[string]any{"type": typ, "message": message}})
+}
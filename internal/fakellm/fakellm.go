@@ -0,0 +1,322 @@
+// Package fakellm is a local stand-in for the Anthropic Messages, OpenAI Chat
+// Completions and Gemini generateContent APIs. It answers from a script of
+// responses, including tool calls, streaming, usage and injected errors, so
+// the real HTTP clients can be run end to end without network access.
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Script is the responses a Server gives, one per request in order,
+// whichever API the request is for.
+type Script struct {
+	Model     string `json:"model,omitempty"` // model reported in responses (default: the requested one)
+	Responses []Step `json:"responses"`
+	Loop      bool   `json:"loop,omitempty"` // start over once every response is used
+}
+
+// Step is one scripted response: a reply, or an error.
+type Step struct {
+	Thinking   string     `json:"thinking,omitempty"` // reasoning given before the reply, signed
+	Text       string     `json:"text,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	StopReason string     `json:"stop_reason,omitempty"` // end_turn, tool_use or max_tokens (default: from the tool calls)
+	Usage      *Usage     `json:"usage,omitempty"`       // default: estimated from the request and reply sizes
+
+	// Error fails the request with this HTTP status (429, 500, 503, 529...)
+	// and the provider's error body for it.
+	Error   int    `json:"error,omitempty"`
+	Message string `json:"message,omitempty"` // error message (default: the status text)
+}
+
+// ToolCall is a tool call in a scripted reply.
+type ToolCall struct {
+	ID    string          `json:"id,omitempty"` // default: generated
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// Usage is the token usage reported with a reply, in Anthropic's terms:
+// input tokens exclude cached ones.
+type Usage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+	ThinkingTokens      int `json:"thinking_tokens,omitempty"` // part of the output tokens
+}
+
+// LoadScript reads and checks a script file.
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+	var s Script
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse script %s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("script %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Validate checks that every step is a usable reply or error.
+func (s *Script) Validate() error {
+	if len(s.Responses) == 0 {
+		return fmt.Errorf("no responses")
+	}
+	for i, step := range s.Responses {
+		if step.Error != 0 {
+			if step.Error < 400 || step.Error > 599 {
+				return fmt.Errorf("responses[%d]: error %d is not an HTTP error status", i, step.Error)
+			}
+			continue
+		}
+		switch step.StopReason {
+		case "", "end_turn", "tool_use", "max_tokens":
+		default:
+			return fmt.Errorf("responses[%d]: unknown stop_reason %q", i, step.StopReason)
+		}
+		for j, tc := range step.ToolCalls {
+			if tc.Name == "" {
+				return fmt.Errorf("responses[%d].tool_calls[%d]: missing name", i, j)
+			}
+			if len(tc.Input) > 0 {
+				var input map[string]any
+				if err := json.Unmarshal(tc.Input, &input); err != nil {
+					return fmt.Errorf("responses[%d].tool_calls[%d]: input must be a JSON object: %w", i, j, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Request is a request a Server has answered.
+type Request struct {
+	API    string          // "anthropic", "openai" or "gemini"
+	Model  string          // requested model
+	Stream bool            // whether the reply was streamed
+	Body   json.RawMessage // request body as sent
+}
+
+// Server serves a Script over the three APIs. Point a client at it with
+// the base URLs from BaseURLs.
+type Server struct {
+	Logger *log.Logger // optional: one line per request
+
+	mu       sync.Mutex
+	script   Script
+	next     int // index of the next step
+	calls    int // tool calls given out, for IDs
+	requests []Request
+	mux      *http.ServeMux
+}
+
+// New returns a Server answering from script.
+func New(script Script) *Server {
+	s := &Server{script: script, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /v1/messages", s.anthropic)
+	s.mux.HandleFunc("POST /v1/chat/completions", s.openai)
+	s.mux.HandleFunc("POST /v1beta/models/{call}", s.gemini)
+	return s
+}
+
+// BaseURLs returns the base_url of each provider for a server reachable at
+// root (e.g. "http://127.0.0.1:8089").
+func BaseURLs(root string) map[string]string {
+	root = strings.TrimRight(root, "/")
+	return map[string]string{
+		"anthropic":  root,
+		"openai":     root + "/v1",
+		"gemini-api": root + "/v1beta",
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Requests returns the requests answered so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Remaining reports how many scripted responses are left before the script
+// runs out, or starts over if it loops.
+func (s *Server) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.script.Responses) - s.next
+}
+
+// reply is a step taken for a request, with its IDs, usage and stop reason
+// filled in.
+type reply struct {
+	Step
+	n     int // request number, from 1
+	model string
+	stop  string
+	usage Usage
+}
+
+// take hands out the next step for a request and records the request.
+// Missing tool call IDs are generated with idPrefix.
+func (s *Server) take(api, model string, stream bool, body []byte, idPrefix string) (*reply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == len(s.script.Responses) && s.script.Loop {
+		s.next = 0
+	}
+	if s.next >= len(s.script.Responses) {
+		s.logf("%s %s: script exhausted", api, model)
+		return nil, fmt.Errorf("fake-llm: script exhausted after %d responses", len(s.script.Responses))
+	}
+	step := s.script.Responses[s.next]
+	s.next++
+	s.requests = append(s.requests, Request{API: api, Model: model, Stream: stream, Body: body})
+
+	r := &reply{Step: step, n: len(s.requests), model: model}
+	if s.script.Model != "" {
+		r.model = s.script.Model
+	}
+	if step.Error != 0 {
+		s.logf("#%d %s %s: error %d", r.n, api, model, step.Error)
+		return r, nil
+	}
+
+	r.ToolCalls = make([]ToolCall, len(step.ToolCalls))
+	for i, tc := range step.ToolCalls {
+		if tc.ID == "" {
+			s.calls++
+			tc.ID = fmt.Sprintf("%s%d", idPrefix, s.calls)
+		}
+		if len(tc.Input) == 0 {
+			tc.Input = json.RawMessage("{}")
+		}
+		r.ToolCalls[i] = tc
+	}
+	r.stop = step.StopReason
+	if r.stop == "" {
+		r.stop = "end_turn"
+		if len(step.ToolCalls) > 0 {
+			r.stop = "tool_use"
+		}
+	}
+	if step.Usage != nil {
+		r.usage = *step.Usage
+	} else {
+		r.usage = estimateUsage(body, r.Step)
+	}
+
+	names := make([]string, len(r.ToolCalls))
+	for i, tc := range r.ToolCalls {
+		names[i] = tc.Name
+	}
+	s.logf("#%d %s %s (stream=%t): %s %s", r.n, api, model, stream, r.stop, strings.Join(names, ", "))
+	return r, nil
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, args...)
+	}
+}
+
+// estimateUsage guesses token counts at four bytes a token.
+func estimateUsage(body []byte, step Step) Usage {
+	thinking := len(step.Thinking) / 4
+	out := len(step.Text)
+	for _, tc := range step.ToolCalls {
+		out += len(tc.Name) + len(tc.Input)
+	}
+	return Usage{InputTokens: max(1, len(body)/4), OutputTokens: max(1, thinking+out/4), ThinkingTokens: thinking}
+}
+
+// signature is the signature of the reply's thinking.
+func (r *reply) signature() string {
+	return fmt.Sprintf("sig_fake_%d", r.n)
+}
+
+// errorMessage is the message of a scripted error.
+func (r *reply) errorMessage() string {
+	if r.Message != "" {
+		return r.Message
+	}
+	if r.Error == 529 {
+		return "Overloaded"
+	}
+	return http.StatusText(r.Error)
+}
+
+// readRequest reads a request body and decodes it into v.
+func readRequest(r *http.Request, v any) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	return body, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// sseWriter writes server-sent events, flushing each one.
+type sseWriter struct {
+	w http.ResponseWriter
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	return &sseWriter{w: w}
+}
+
+// event writes v as the data of an event; name may be empty.
+func (e *sseWriter) event(name string, v any) {
+	if name != "" {
+		fmt.Fprintf(e.w, "event: %s\n", name)
+	}
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(e.w, "data: %s\n\n", data)
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// chunkSize is how many characters of text or tool input go in one streamed
+// delta.
+const chunkSize = 16
+
+// chunks splits s into pieces of at most chunkSize runes.
+func chunks(s string) []string {
+	var out []string
+	runes := []rune(s)
+	for len(runes) > 0 {
+		n := min(chunkSize, len(runes))
+		out = append(out, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return out
+}
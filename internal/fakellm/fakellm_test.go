@@ -0,0 +1,275 @@
+package fakellm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+var testMessages = []llm.ToolMessage{{Role: "user", Content: []llm.ContentBlock{{Type: "text", Text: "Objective: build it"}}}}
+
+var testTools = []llm.ToolDef{{
+	Name:        "create_tasks",
+	Description: "Create tasks",
+	InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+}}
+
+// testScript plans with reasoning and a tool call, fails with each injectable error and
+// then finishes, twice: once non-streaming and once streaming.
+func testScript() Script {
+	plan := Step{
+		Thinking:  "One handler, so one task.",
+		Text:      "Planning the work in two tasks.",
+		ToolCalls: []ToolCall{{Name: "create_tasks", Input: json.RawMessage(`{"tasks":[{"id":"t1","title":"Write the handler"}]}`)}},
+		Usage:     &Usage{InputTokens: 1200, OutputTokens: 80, CacheReadTokens: 300},
+	}
+	done := Step{Text: "All done.", Usage: &Usage{InputTokens: 1500, OutputTokens: 5}}
+	steps := []Step{plan, {Error: 429}, {Error: 529}, {Error: 503}, done}
+	return Script{Model: "fake-model", Responses: append(steps, steps...)}
+}
+
+// newClient starts a server for script and returns a provider's client
+// pointed at it.
+func newClient(t *testing.T, provider string, script Script) (llm.StreamingToolClient, *Server) {
+	t.Helper()
+	fake := New(script)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	c, err := llm.NewFromConfig(llm.ProviderConfig{
+		Provider: provider,
+		Model:    "test-model",
+		APIKey:   "test-key",
+		BaseURL:  BaseURLs(server.URL)[provider],
+	})
+	if err != nil {
+		t.Fatalf("NewFromConfig(%s): %v", provider, err)
+	}
+	return c.(llm.StreamingToolClient), fake
+}
+
+func TestServer_Providers(t *testing.T) {
+	for _, provider := range []string{"anthropic", "openai", "gemini-api"} {
+		t.Run(provider, func(t *testing.T) {
+			c, fake := newClient(t, provider, testScript())
+			ctx := context.Background()
+
+			// The same script played non-streaming and streaming.
+			var results [2][]*llm.Response
+			var events []llm.StreamEvent
+			for round := range 2 {
+				for i := range 5 {
+					var resp *llm.Response
+					var err error
+					if round == 0 {
+						resp, err = c.ChatWithTools(ctx, "sys", testMessages, testTools)
+					} else {
+						resp, err = c.ChatWithToolsStream(ctx, "sys", testMessages, testTools, func(e llm.StreamEvent) { events = append(events, e) })
+					}
+					if i >= 1 && i <= 3 {
+						if err == nil || !llm.IsRetryableError(err) {
+							t.Fatalf("round %d call %d: expected a retryable error, got %v", round, i, err)
+						}
+						continue
+					}
+					if err != nil {
+						t.Fatalf("round %d call %d: %v", round, i, err)
+					}
+					results[round] = append(results[round], resp)
+				}
+			}
+			if fake.Remaining() != 0 {
+				t.Errorf("expected the whole script used, %d left", fake.Remaining())
+			}
+
+			plan := results[0][0]
+			if plan.StopReason != "tool_use" || plan.Text() != "Planning the work in two tasks." || plan.Model != "fake-model" {
+				t.Errorf("unexpected plan response: %+v", plan)
+			}
+			if call := plan.Content[len(plan.Content)-1].ToolCall; call == nil || call.Name != "create_tasks" ||
+				!strings.Contains(string(call.Input), `"title":"Write the handler"`) {
+				t.Errorf("unexpected tool call: %+v", call)
+			}
+			if plan.Usage.OutputTokens != 80 || plan.Usage.InputTokens == 0 {
+				t.Errorf("unexpected usage: %+v", plan.Usage)
+			}
+			if done := results[0][1]; done.StopReason != "end_turn" || done.Text() != "All done." || done.Usage.OutputTokens != 5 {
+				t.Errorf("unexpected final response: %+v", done)
+			}
+
+			// Generated tool call IDs and signatures differ between calls;
+			// otherwise streaming must not change the response.
+			for i := range results[0] {
+				a, b := results[0][i], results[1][i]
+				for _, resp := range []*llm.Response{a, b} {
+					for j := range resp.Content {
+						if resp.Content[j].ToolCall != nil {
+							resp.Content[j].ToolCall.ID = ""
+						}
+						resp.Content[j].Signature = ""
+					}
+				}
+				if !reflect.DeepEqual(a, b) {
+					t.Errorf("call %d: streamed response differs:\n got %+v\nwant %+v", i, b, a)
+				}
+			}
+			var text strings.Builder
+			for _, e := range events {
+				if e.Type == llm.StreamText {
+					text.WriteString(e.Text)
+				}
+			}
+			if text.String() != "Planning the work in two tasks.All done." {
+				t.Errorf("streamed text = %q", text.String())
+			}
+
+			reqs := fake.Requests()
+			if len(reqs) != 10 || reqs[0].Model != "test-model" || reqs[0].Stream || !reqs[5].Stream {
+				t.Errorf("unexpected requests: %d, first %+v", len(reqs), reqs[0])
+			}
+		})
+	}
+}
+
+func TestServer_ChatAndExhaustion(t *testing.T) {
+	c, _ := newClient(t, "anthropic", Script{Responses: []Step{{Text: "hello"}}})
+	ctx := context.Background()
+	resp, err := c.(llm.UsageClient).ChatWithUsage(ctx, "sys", "hi")
+	if err != nil || resp.Text() != "hello" {
+		t.Fatalf("ChatWithUsage = %+v, %v", resp, err)
+	}
+	if resp.Usage.InputTokens == 0 || resp.Usage.OutputTokens == 0 {
+		t.Errorf("expected usage estimated from the sizes, got %+v", resp.Usage)
+	}
+	_, err = c.Chat(ctx, "sys", "hi")
+	if err == nil || !strings.Contains(err.Error(), "script exhausted") || llm.IsRetryableError(err) {
+		t.Errorf("expected a final script exhausted error, got %v", err)
+	}
+
+	c, _ = newClient(t, "openai", Script{Responses: []Step{{Text: "again"}}, Loop: true})
+	for range 3 {
+		if out, err := c.Chat(ctx, "sys", "hi"); err != nil || out != "again" {
+			t.Fatalf("looping Chat = %q, %v", out, err)
+		}
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	s, err := LoadScript(write("ok.json", `{"responses": [{"tool_calls": [{"name": "complete", "input": {"summary": "ok"}}]}, {"error": 529}]}`))
+	if err != nil || len(s.Responses) != 2 || s.Responses[1].Error != 529 {
+		t.Fatalf("LoadScript = %+v, %v", s, err)
+	}
+
+	for name, content := range map[string]string{
+		"empty.json":  `{"responses": []}`,
+		"status.json": `{"responses": [{"error": 200}]}`,
+		"stop.json":   `{"responses": [{"text": "x", "stop_reason": "done"}]}`,
+		"name.json":   `{"responses": [{"tool_calls": [{"input": {}}]}]}`,
+		"input.json":  `{"responses": [{"tool_calls": [{"name": "complete", "input": "ok"}]}]}`,
+	} {
+		if _, err := LoadScript(write(name, content)); err == nil {
+			t.Errorf("%s: expected an invalid script to be rejected", name)
+		}
+	}
+}
+
+func TestServer_Thinking(t *testing.T) {
+	script := Script{Responses: []Step{
+		{
+			Thinking:  "The objective needs one task.",
+			ToolCalls: []ToolCall{{Name: "create_tasks", Input: json.RawMessage(`{"tasks":[]}`)}},
+			Usage:     &Usage{InputTokens: 100, OutputTokens: 50, ThinkingTokens: 30},
+		},
+		{Text: "Done.", Usage: &Usage{InputTokens: 150, OutputTokens: 5}},
+	}}
+	// What each API's requests carry: the reasoning setting, and the
+	// reasoning replayed with the first reply in the second request.
+	tests := []struct {
+		provider       string
+		setting        string
+		replayed       string
+		signed         bool
+		thinkingTokens int
+	}{
+		{"anthropic", `"thinking":{"budget_tokens":4096,"type":"enabled"}`, `"signature":"sig_fake_1"`, true, 0},
+		{"openai", `"reasoning_effort":"medium"`, "", false, 30},
+		{"gemini-api", `"thinkingConfig":{"thinkingBudget":4096,"includeThoughts":true}`, `"thoughtSignature":"sig_fake_1"`, true, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			fake := New(script)
+			server := httptest.NewServer(fake)
+			defer server.Close()
+			c, err := llm.NewFromConfig(llm.ProviderConfig{
+				Provider: tt.provider,
+				Model:    "test-model",
+				APIKey:   "test-key",
+				BaseURL:  BaseURLs(server.URL)[tt.provider],
+				Options:  llm.Options{ThinkingBudget: 4096},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			var events []llm.StreamEvent
+			resp, err := c.(llm.StreamingToolClient).ChatWithToolsStream(ctx, "sys", testMessages, testTools, func(e llm.StreamEvent) { events = append(events, e) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Thinking() != "The objective needs one task." || resp.Content[0].Type != "thinking" {
+				t.Errorf("unexpected content: %+v", resp.Content)
+			}
+			if got := resp.Content[0].Signature != "" || resp.Content[len(resp.Content)-1].Signature != ""; got != tt.signed {
+				t.Errorf("signed = %t, want %t: %+v", got, tt.signed, resp.Content)
+			}
+			if resp.Usage.OutputTokens != 50 || resp.Usage.ThinkingTokens != tt.thinkingTokens {
+				t.Errorf("unexpected usage: %+v", resp.Usage)
+			}
+			var thinking strings.Builder
+			for _, e := range events {
+				if e.Type == llm.StreamThinking {
+					thinking.WriteString(e.Text)
+				}
+			}
+			if thinking.String() != resp.Thinking() {
+				t.Errorf("streamed thinking = %q", thinking.String())
+			}
+
+			history := append(append([]llm.ToolMessage(nil), testMessages...),
+				llm.ToolMessage{Role: "assistant", Content: resp.Content},
+				llm.ToolMessage{Role: "tool_result", ToolResults: []llm.ToolResult{{ToolCallID: resp.Content[len(resp.Content)-1].ToolCall.ID, Content: "created"}}})
+			if _, err := c.(llm.ToolClient).ChatWithTools(ctx, "sys", history, testTools); err != nil {
+				t.Fatal(err)
+			}
+			reqs := fake.Requests()
+			if !strings.Contains(string(reqs[0].Body), tt.setting) {
+				t.Errorf("request lacks %s: %s", tt.setting, reqs[0].Body)
+			}
+			second := string(reqs[1].Body)
+			if tt.replayed != "" && !strings.Contains(second, tt.replayed) {
+				t.Errorf("reasoning not replayed (%s): %s", tt.replayed, second)
+			}
+			if !strings.Contains(second, tt.setting) {
+				t.Errorf("thinking turned off after a signed reply: %s", second)
+			}
+			if tt.provider == "openai" && strings.Contains(second, "reasoning_content") {
+				t.Errorf("reasoning sent back: %s", second)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// gemini serves POST /v1beta/models/{model}:generateContent and
+// :streamGenerateContent (server-sent events with alt=sse).
+func (s *Server) gemini(w http.ResponseWriter, r *http.Request) {
+	model, method, ok := strings.Cut(r.PathValue("call"), ":")
+	stream := method == "streamGenerateContent"
+	if !ok || (method != "generateContent" && !stream) {
+		geminiError(w, http.StatusNotFound, "unknown method "+r.PathValue("call"))
+		return
+	}
+	if stream && r.URL.Query().Get("alt") != "sse" {
+		geminiError(w, http.StatusBadRequest, "only alt=sse streaming is supported")
+		return
+	}
+	var req map[string]any
+	body, err := readRequest(r, &req)
+	if err != nil {
+		geminiError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Gemini does not give function calls IDs.
+	rep, err := s.take("gemini", model, stream, body, "")
+	if err != nil {
+		geminiError(w, http.StatusGone, err.Error())
+		return
+	}
+	if rep.Error != 0 {
+		geminiError(w, rep.Error, rep.errorMessage())
+		return
+	}
+	finish := "STOP"
+	if rep.stop == "max_tokens" {
+		finish = "MAX_TOKENS"
+	}
+	if !stream {
+		parts := geminiThoughts(rep, []string{rep.Thinking})
+		if rep.Text != "" {
+			parts = append(parts, map[string]any{"text": rep.Text})
+		}
+		parts = append(parts, geminiCalls(rep)...)
+		writeJSON(w, http.StatusOK, geminiResponse(rep, parts, finish, true))
+		return
+	}
+
+	// Thoughts and text arrive in pieces, function calls whole; the last
+	// chunk has the finish reason and usage.
+	sse := newSSEWriter(w)
+	for _, t := range geminiThoughts(rep, chunks(rep.Thinking)) {
+		sse.event("", geminiResponse(rep, []any{t}, "", false))
+	}
+	for _, c := range chunks(rep.Text) {
+		sse.event("", geminiResponse(rep, []any{map[string]any{"text": c}}, "", false))
+	}
+	for _, call := range geminiCalls(rep) {
+		sse.event("", geminiResponse(rep, []any{call}, "", false))
+	}
+	sse.event("", geminiResponse(rep, []any{map[string]any{"text": ""}}, finish, true))
+}
+
+// geminiThoughts is the thought summary parts of a reply, the last one
+// carrying the thought signature.
+func geminiThoughts(rep *reply, pieces []string) []any {
+	if rep.Thinking == "" {
+		return []any{}
+	}
+	parts := make([]any, len(pieces))
+	for i, p := range pieces {
+		part := map[string]any{"text": p, "thought": true}
+		if i == len(pieces)-1 {
+			part["thoughtSignature"] = rep.signature()
+		}
+		parts[i] = part
+	}
+	return parts
+}
+
+func geminiCalls(rep *reply) []any {
+	var parts []any
+	for _, tc := range rep.ToolCalls {
+		var args map[string]any
+		_ = json.Unmarshal(tc.Input, &args)
+		parts = append(parts, map[string]any{"functionCall": map[string]any{"name": tc.Name, "args": args}})
+	}
+	return parts
+}
+
+// geminiResponse is a response (or stream chunk) with one candidate.
+func geminiResponse(rep *reply, parts []any, finish string, withUsage bool) map[string]any {
+	candidate := map[string]any{"content": map[string]any{"role": "model", "parts": parts}, "index": 0}
+	if finish != "" {
+		candidate["finishReason"] = finish
+	}
+	resp := map[string]any{"candidates": []any{candidate}, "modelVersion": rep.model}
+	if withUsage {
+		// Prompt tokens include cached ones.
+		prompt := rep.usage.InputTokens + rep.usage.CacheReadTokens + rep.usage.CacheCreationTokens
+		// Thought tokens are counted apart from the candidates'.
+		resp["usageMetadata"] = map[string]any{
+			"promptTokenCount":        prompt,
+			"candidatesTokenCount":    rep.usage.OutputTokens - rep.usage.ThinkingTokens,
+			"thoughtsTokenCount":      rep.usage.ThinkingTokens,
+			"totalTokenCount":         prompt + rep.usage.OutputTokens,
+			"cachedContentTokenCount": rep.usage.CacheReadTokens,
+		}
+	}
+	return resp
+}
+
+func geminiError(w http.ResponseWriter, status int, message string) {
+	st := "INTERNAL"
+	switch status {
+	case http.StatusBadRequest:
+		st = "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		st = "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		st = "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		st = "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		st = "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable, 529:
+		st = "UNAVAILABLE"
+	}
+	writeJSON(w, status, map[string]any{"error": map[string]any{"code": status, "message": message, "status": st}})
+}
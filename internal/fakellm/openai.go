@@ -0,0 +1,120 @@
+package fakellm
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// openai serves POST /v1/chat/completions.
+func (s *Server) openai(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model         string `json:"model"`
+		Stream        bool   `json:"stream"`
+		StreamOptions *struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options"`
+	}
+	body, err := readRequest(r, &req)
+	if err != nil {
+		openaiError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rep, err := s.take("openai", req.Model, req.Stream, body, "call_fake_")
+	if err != nil {
+		openaiError(w, http.StatusGone, err.Error())
+		return
+	}
+	if rep.Error != 0 {
+		openaiError(w, rep.Error, rep.errorMessage())
+		return
+	}
+	id := fmt.Sprintf("chatcmpl-fake-%d", rep.n)
+	finish := openaiFinishReason(rep.stop)
+	if !req.Stream {
+		msg := map[string]any{"role": "assistant", "content": nil}
+		if rep.Thinking != "" {
+			msg["reasoning_content"] = rep.Thinking
+		}
+		if rep.Text != "" {
+			msg["content"] = rep.Text
+		}
+		if len(rep.ToolCalls) > 0 {
+			calls := make([]any, len(rep.ToolCalls))
+			for i, tc := range rep.ToolCalls {
+				calls[i] = map[string]any{"id": tc.ID, "type": "function",
+					"function": map[string]any{"name": tc.Name, "arguments": string(tc.Input)}}
+			}
+			msg["tool_calls"] = calls
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id":      id,
+			"object":  "chat.completion",
+			"model":   rep.model,
+			"choices": []any{map[string]any{"index": 0, "message": msg, "finish_reason": finish}},
+			"usage":   openaiUsage(rep.usage),
+		})
+		return
+	}
+
+	sse := newSSEWriter(w)
+	chunk := func(delta map[string]any, finish any) {
+		sse.event("", map[string]any{"id": id, "object": "chat.completion.chunk", "model": rep.model,
+			"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finish}}})
+	}
+	chunk(map[string]any{"role": "assistant", "content": ""}, nil)
+	for _, c := range chunks(rep.Thinking) {
+		chunk(map[string]any{"reasoning_content": c}, nil)
+	}
+	for _, c := range chunks(rep.Text) {
+		chunk(map[string]any{"content": c}, nil)
+	}
+	for i, tc := range rep.ToolCalls {
+		chunk(map[string]any{"tool_calls": []any{map[string]any{"index": i, "id": tc.ID, "type": "function",
+			"function": map[string]any{"name": tc.Name, "arguments": ""}}}}, nil)
+		for _, c := range chunks(string(tc.Input)) {
+			chunk(map[string]any{"tool_calls": []any{map[string]any{"index": i,
+				"function": map[string]any{"arguments": c}}}}, nil)
+		}
+	}
+	chunk(map[string]any{}, finish)
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		sse.event("", map[string]any{"id": id, "object": "chat.completion.chunk", "model": rep.model,
+			"choices": []any{}, "usage": openaiUsage(rep.usage)})
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func openaiFinishReason(stop string) string {
+	switch stop {
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	default:
+		return "stop"
+	}
+}
+
+// openaiUsage reports usage the OpenAI way: prompt tokens include cached
+// ones.
+func openaiUsage(u Usage) map[string]any {
+	prompt := u.InputTokens + u.CacheReadTokens + u.CacheCreationTokens
+	return map[string]any{
+		"prompt_tokens":             prompt,
+		"completion_tokens":         u.OutputTokens,
+		"total_tokens":              prompt + u.OutputTokens,
+		"prompt_tokens_details":     map[string]any{"cached_tokens": u.CacheReadTokens},
+		"completion_tokens_details": map[string]any{"reasoning_tokens": u.ThinkingTokens},
+	}
+}
+
+func openaiError(w http.ResponseWriter, status int, message string) {
+	typ, code := "invalid_request_error", any(nil)
+	switch {
+	case status == http.StatusTooManyRequests:
+		typ, code = "requests", "rate_limit_exceeded"
+	case status >= 500:
+		typ = "server_error"
+	}
+	writeJSON(w, status, map[string]any{"error": map[string]any{"message": message, "type": typ, "code": code}})
+}
@@ -3,7 +3,7 @@ package llm
 import (
 	"context"
 	"encoding/json"
-	"strings"
+	"slices"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -14,13 +14,20 @@ import (
 type AnthropicClient struct {
 	client *anthropic.Client
 	model  string
+	opts   Options
 }
 
-func NewAnthropicClient(apiKey, model string) *AnthropicClient {
+// NewAnthropicClient creates a client for the Anthropic Messages API.
+// If apiKey is empty, the SDK reads ANTHROPIC_API_KEY from the environment.
+// If baseURL is empty, the SDK's default (or ANTHROPIC_BASE_URL) is used.
+func NewAnthropicClient(apiKey, model, baseURL string) *AnthropicClient {
 	opts := []option.RequestOption{}
 	if apiKey != "" {
 		opts = append(opts, option.WithAPIKey(apiKey))
 	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
 	if model == "" {
 		model = "claude-sonnet-4-20250514"
 	}
@@ -35,28 +42,39 @@ func (c *AnthropicClient) Chat(ctx context.Context, systemPrompt, userMessage st
 	return c.ChatWithHistory(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
 }
 
+// SetOptions sets the generation options of every later call.
+func (c *AnthropicClient) SetOptions(o Options) {
+	c.opts = o
+}
+
 func (c *AnthropicClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	resp, err := c.chat(ctx, systemPrompt, messages)
+	if err != nil {
+		return "", err
+	}
+	return resp.Text(), nil
+}
+
+// ChatWithUsage implements UsageClient.
+func (c *AnthropicClient) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error) {
+	return c.chat(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
+}
+
+func (c *AnthropicClient) chat(ctx context.Context, systemPrompt string, messages []Message) (*Response, error) {
 	params := anthropic.MessageNewParams{
-		Model:     anthropic.Model(c.model),
-		MaxTokens: 4096,
-		Messages:  toAnthropicMessages(messages),
+		Model:    anthropic.Model(c.model),
+		Messages: toAnthropicMessages(messages),
 	}
 	if systemPrompt != "" {
 		params.System = []anthropic.TextBlockParam{{Text: systemPrompt}}
 	}
+	c.setGeneration(&params, 4096, true)
 
 	resp, err := c.client.Messages.New(ctx, params)
 	if err != nil {
-		return "", err
-	}
-
-	var out strings.Builder
-	for _, block := range resp.Content {
-		if block.Type == "text" {
-			out.WriteString(block.Text)
-		}
+		return nil, err
 	}
-	return out.String(), nil
+	return anthropicResponse(resp), nil
 }
 
 func toAnthropicMessages(msgs []Message) []anthropic.MessageParam {
@@ -75,7 +93,54 @@ func toAnthropicMessages(msgs []Message) []anthropic.MessageParam {
 // which may include tool-use requests.
 func (c *AnthropicClient) ChatWithTools(ctx context.Context, systemPrompt string,
 	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	resp, err := c.client.Messages.New(ctx, c.toolParams(ctx, systemPrompt, messages, tools))
+	if err != nil {
+		return nil, err
+	}
+	return anthropicResponse(resp), nil
+}
+
+// ChatWithToolsStream is ChatWithTools with text, thinking and tool input
+// deltas passed to onEvent as they arrive.
+func (c *AnthropicClient) ChatWithToolsStream(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef, onEvent func(StreamEvent)) (*Response, error) {
+	stream := c.client.Messages.NewStreaming(ctx, c.toolParams(ctx, systemPrompt, messages, tools))
+	defer stream.Close()
 
+	var msg anthropic.Message
+	toolIDs := make(map[int64]string)
+	for stream.Next() {
+		event := stream.Current()
+		if err := msg.Accumulate(event); err != nil {
+			return nil, err
+		}
+		switch ev := event.AsAny().(type) {
+		case anthropic.ContentBlockStartEvent:
+			if ev.ContentBlock.Type == "tool_use" {
+				toolIDs[ev.Index] = ev.ContentBlock.ID
+				onEvent(StreamEvent{Type: StreamToolCall, ToolCallID: ev.ContentBlock.ID, ToolName: ev.ContentBlock.Name})
+			}
+		case anthropic.ContentBlockDeltaEvent:
+			switch delta := ev.Delta.AsAny().(type) {
+			case anthropic.TextDelta:
+				onEvent(StreamEvent{Type: StreamText, Text: delta.Text})
+			case anthropic.ThinkingDelta:
+				onEvent(StreamEvent{Type: StreamThinking, Text: delta.Thinking})
+			case anthropic.InputJSONDelta:
+				if delta.PartialJSON != "" {
+					onEvent(StreamEvent{Type: StreamToolInput, Text: delta.PartialJSON, ToolCallID: toolIDs[ev.Index]})
+				}
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return anthropicResponse(&msg), nil
+}
+
+// toolParams builds the request for a tool-use call.
+func (c *AnthropicClient) toolParams(ctx context.Context, systemPrompt string, messages []ToolMessage, tools []ToolDef) anthropic.MessageNewParams {
 	// Convert tool definitions to Anthropic SDK params.
 	apiTools := make([]anthropic.ToolUnionParam, len(tools))
 	for i, td := range tools {
@@ -117,6 +182,10 @@ func (c *AnthropicClient) ChatWithTools(ctx context.Context, systemPrompt string
 			blocks := make([]anthropic.ContentBlockParamUnion, 0, len(msg.Content))
 			for _, b := range msg.Content {
 				switch b.Type {
+				case "thinking":
+					blocks = append(blocks, anthropic.NewThinkingBlock(b.Signature, b.Text))
+				case "redacted_thinking":
+					blocks = append(blocks, anthropic.NewRedactedThinkingBlock(b.Data))
 				case "text":
 					blocks = append(blocks, anthropic.NewTextBlock(b.Text))
 				case "tool_use":
@@ -139,23 +208,62 @@ func (c *AnthropicClient) ChatWithTools(ctx context.Context, systemPrompt string
 	}
 
 	params := anthropic.MessageNewParams{
-		Model:     anthropic.Model(c.model),
-		MaxTokens: 8192,
-		Messages:  apiMessages,
-		Tools:     apiTools,
+		Model:    anthropic.Model(c.model),
+		Messages: apiMessages,
+		Tools:    apiTools,
+	}
+	c.setGeneration(&params, 8192, canThinkAfter(messages))
+	if tool := toolChoice(ctx); tool != "" && params.Thinking.OfEnabled == nil {
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(tool)
 	}
 	if systemPrompt != "" {
 		sysBlocks := []anthropic.TextBlockParam{{Text: systemPrompt}}
 		sysBlocks[len(sysBlocks)-1].CacheControl = anthropic.NewCacheControlEphemeralParam()
 		params.System = sysBlocks
 	}
+	return params
+}
 
-	resp, err := c.client.Messages.New(ctx, params)
-	if err != nil {
-		return nil, err
+// minThinkingBudget is the smallest budget_tokens the API accepts.
+const minThinkingBudget = 1024
+
+// setGeneration sets a request's output limit, temperature and extended
+// thinking. Thinking needs max_tokens above its budget, and the API rejects
+// a temperature with it.
+func (c *AnthropicClient) setGeneration(params *anthropic.MessageNewParams, defMaxTokens int, thinking bool) {
+	params.MaxTokens = int64(c.opts.maxTokens(defMaxTokens))
+	budget := c.opts.thinkingBudget()
+	if !thinking || budget == 0 {
+		if c.opts.Temperature != nil {
+			params.Temperature = param.NewOpt(*c.opts.Temperature)
+		}
+		return
+	}
+	budget = max(budget, minThinkingBudget)
+	params.Thinking = anthropic.ThinkingConfigParamOfEnabled(int64(budget))
+	if params.MaxTokens <= int64(budget) {
+		params.MaxTokens = int64(budget + defMaxTokens)
+	}
+}
+
+// canThinkAfter reports whether a call continuing messages may use extended
+// thinking. With thinking on, the API requires an assistant turn that ends
+// in tool calls to start with its thinking, which history written without
+// thinking (or by another provider) lacks.
+func canThinkAfter(messages []ToolMessage) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "assistant" {
+			continue
+		}
+		content := messages[i].Content
+		calls := slices.ContainsFunc(content, func(b ContentBlock) bool { return b.Type == "tool_use" })
+		return !calls || len(content) > 0 && (content[0].Type == "thinking" || content[0].Type == "redacted_thinking")
 	}
+	return true
+}
 
-	// Parse response into our Response type.
+// anthropicResponse converts an API message into our Response type.
+func anthropicResponse(resp *anthropic.Message) *Response {
 	result := &Response{
 		StopReason: string(resp.StopReason),
 		Model:      string(resp.Model),
@@ -168,23 +276,34 @@ func (c *AnthropicClient) ChatWithTools(ctx context.Context, systemPrompt string
 	}
 	for _, block := range resp.Content {
 		switch block.Type {
+		case "thinking":
+			result.Content = append(result.Content, ContentBlock{
+				Type:      "thinking",
+				Text:      block.Thinking,
+				Signature: block.Signature,
+			})
+		case "redacted_thinking":
+			result.Content = append(result.Content, ContentBlock{
+				Type: "redacted_thinking",
+				Data: block.Data,
+			})
 		case "text":
 			result.Content = append(result.Content, ContentBlock{
 				Type: "text",
 				Text: block.Text,
 			})
 		case "tool_use":
-			toolUse := block.AsToolUse()
+			// Read the union's fields rather than AsToolUse, which decodes the
+			// raw JSON that an accumulated stream message may not carry.
 			result.Content = append(result.Content, ContentBlock{
 				Type: "tool_use",
 				ToolCall: &ToolCall{
-					ID:    toolUse.ID,
-					Name:  toolUse.Name,
-					Input: toolUse.Input,
+					ID:    block.ID,
+					Name:  block.Name,
+					Input: block.Input,
 				},
 			})
 		}
 	}
-
-	return result, nil
+	return result
 }
@@ -0,0 +1,404 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Kinds of recorded calls.
+const (
+	CallChat  = "chat"  // Chat or ChatWithHistory
+	CallTools = "tools" // ChatWithTools or ChatWithToolsStream
+)
+
+// Replay matching modes.
+const (
+	MatchSequence = "sequence" // serve interactions in recorded order
+	MatchHash     = "hash"     // serve the interaction recorded for the same request
+)
+
+// CassetteClient is a client that records to or replays from a cassette.
+// ForStream returns a client for the calls of one of several concurrent
+// callers, such as a child Queen: each stream keeps its own recorded order,
+// so replay does not depend on how the callers' calls interleave.
+type CassetteClient interface {
+	Client
+	ForStream(stream string) Client
+}
+
+// CassetteRequest is what was sent in a recorded call.
+type CassetteRequest struct {
+	System   string        `json:"system,omitempty"`
+	Messages []ToolMessage `json:"messages,omitempty"` // tool calls
+	History  []Message     `json:"history,omitempty"`  // plain chat calls
+	Tools    []string      `json:"tools,omitempty"`    // names of the tools offered
+}
+
+// Interaction is one recorded call: a line of a cassette file.
+type Interaction struct {
+	Seq      int             `json:"seq"`
+	Stream   string          `json:"stream,omitempty"` // caller the call was made for ("" = top-level Queen)
+	Kind     string          `json:"kind"`
+	Hash     string          `json:"hash"`
+	Request  CassetteRequest `json:"request"`
+	Response *Response       `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// hashRequest returns a digest of what a call sends. The usage kept with
+// assistant messages is not sent, so it is left out.
+func hashRequest(kind string, req CassetteRequest) string {
+	msgs := make([]ToolMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		m.Turn = nil
+		msgs[i] = m
+	}
+	req.Messages = msgs
+	data, _ := json.Marshal(struct {
+		Kind    string          `json:"kind"`
+		Request CassetteRequest `json:"request"`
+	}{kind, req})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func toolNames(tools []ToolDef) []string {
+	names := make([]string, len(tools))
+	for i, td := range tools {
+		names[i] = td.Name
+	}
+	return names
+}
+
+// --- Recording ---
+
+// RecordingClient passes every call to a ToolClient and appends the request
+// and response, or error, to a cassette file as a line of JSON.
+type RecordingClient struct {
+	inner  ToolClient
+	out    *cassetteFile
+	stream string
+}
+
+// cassetteFile is a cassette being written, shared by the clients recording
+// to it.
+type cassetteFile struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int
+}
+
+// NewRecordingClient records the calls made through inner to the cassette at
+// path, replacing any earlier recording there.
+func NewRecordingClient(inner ToolClient, path string) (*RecordingClient, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create cassette dir: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create cassette: %w", err)
+	}
+	return &RecordingClient{inner: inner, out: &cassetteFile{file: f}}, nil
+}
+
+// Wrap returns a client that records the calls made through inner to the
+// same cassette, in the order they are made.
+func (c *RecordingClient) Wrap(inner ToolClient) *RecordingClient {
+	return &RecordingClient{inner: inner, out: c.out, stream: c.stream}
+}
+
+// ForStream implements CassetteClient.
+func (c *RecordingClient) ForStream(stream string) Client {
+	return &RecordingClient{inner: c.inner, out: c.out, stream: stream}
+}
+
+// RetriesInternally implements Retrier.
+func (c *RecordingClient) RetriesInternally() bool {
+	return RetriesInternally(c.inner)
+}
+
+// Close closes the cassette file.
+func (c *RecordingClient) Close() error {
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+	return c.out.file.Close()
+}
+
+// record appends one interaction. A cassette that cannot be written fails
+// the call: a recording with gaps could not be replayed.
+func (c *RecordingClient) record(kind string, req CassetteRequest, resp *Response, callErr error) error {
+	in := Interaction{Stream: c.stream, Kind: kind, Hash: hashRequest(kind, req), Request: req, Response: resp}
+	if callErr != nil {
+		in.Error = callErr.Error()
+	}
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+	c.out.seq++
+	in.Seq = c.out.seq
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("record cassette: %w", err)
+	}
+	if _, err := c.out.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("record cassette: %w", err)
+	}
+	return callErr
+}
+
+// Chat implements Client.
+func (c *RecordingClient) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	return c.ChatWithHistory(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
+}
+
+// ChatWithHistory implements Client.
+func (c *RecordingClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	out, err := c.inner.ChatWithHistory(ctx, systemPrompt, messages)
+	resp := &Response{Content: []ContentBlock{{Type: "text", Text: out}}, StopReason: "end_turn"}
+	if err != nil {
+		resp = nil
+	}
+	return out, c.record(CallChat, CassetteRequest{System: systemPrompt, History: messages}, resp, err)
+}
+
+// ChatWithUsage implements UsageClient.
+func (c *RecordingClient) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error) {
+	uc, ok := c.inner.(UsageClient)
+	if !ok {
+		out, err := c.Chat(ctx, systemPrompt, userMessage)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Content: []ContentBlock{{Type: "text", Text: out}}}, nil
+	}
+	history := []Message{{Role: "user", Content: userMessage}}
+	resp, err := uc.ChatWithUsage(ctx, systemPrompt, userMessage)
+	return resp, c.record(CallChat, CassetteRequest{System: systemPrompt, History: history}, resp, err)
+}
+
+// ChatWithTools implements ToolClient.
+func (c *RecordingClient) ChatWithTools(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	resp, err := c.inner.ChatWithTools(ctx, systemPrompt, messages, tools)
+	req := CassetteRequest{System: systemPrompt, Messages: messages, Tools: toolNames(tools)}
+	return resp, c.record(CallTools, req, resp, err)
+}
+
+// ChatWithToolsStream implements StreamingToolClient. A client that cannot
+// stream answers in one piece.
+func (c *RecordingClient) ChatWithToolsStream(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef, onEvent func(StreamEvent)) (*Response, error) {
+	sc, ok := c.inner.(StreamingToolClient)
+	if !ok {
+		return c.ChatWithTools(ctx, systemPrompt, messages, tools)
+	}
+	resp, err := sc.ChatWithToolsStream(ctx, systemPrompt, messages, tools, onEvent)
+	req := CassetteRequest{System: systemPrompt, Messages: messages, Tools: toolNames(tools)}
+	return resp, c.record(CallTools, req, resp, err)
+}
+
+// --- Replay ---
+
+// ReplayClient answers calls from a cassette instead of a provider. With
+// MatchSequence each call gets the next interaction recorded for its stream,
+// which must be of the same kind with the same tools; with MatchHash it gets
+// the next unused interaction recorded for exactly the same request. Any
+// other call fails with an error describing where it diverged from the
+// recording.
+type ReplayClient struct {
+	match  string
+	stream string
+	tape   *replayTape
+}
+
+// replayTape is a loaded cassette, shared by the clients replaying it.
+type replayTape struct {
+	mu       sync.Mutex
+	all      []Interaction
+	byStream map[string][]int // MatchSequence: unused interactions per stream, in order
+	byHash   map[string][]int // MatchHash: unused interactions per request hash
+}
+
+// ErrCassetteDiverged is returned when a call does not match the recording.
+var ErrCassetteDiverged = errors.New("cassette diverged")
+
+// NewReplayClient loads the cassette at path.
+func NewReplayClient(path, match string) (*ReplayClient, error) {
+	if match == "" {
+		match = MatchSequence
+	}
+	if match != MatchSequence && match != MatchHash {
+		return nil, fmt.Errorf("unknown cassette match %q (want %s or %s)", match, MatchSequence, MatchHash)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open cassette: %w", err)
+	}
+	defer f.Close()
+
+	tape := &replayTape{byStream: make(map[string][]int), byHash: make(map[string][]int)}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 256<<20) // a request carries the whole conversation
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var in Interaction
+		if err := json.Unmarshal(sc.Bytes(), &in); err != nil {
+			return nil, fmt.Errorf("cassette %s line %d: %w", path, line, err)
+		}
+		tape.byStream[in.Stream] = append(tape.byStream[in.Stream], len(tape.all))
+		tape.byHash[in.Hash] = append(tape.byHash[in.Hash], len(tape.all))
+		tape.all = append(tape.all, in)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	return &ReplayClient{match: match, tape: tape}, nil
+}
+
+// ForStream implements CassetteClient.
+func (c *ReplayClient) ForStream(stream string) Client {
+	return &ReplayClient{match: c.match, stream: stream, tape: c.tape}
+}
+
+// Remaining returns how many recorded interactions have not been served.
+func (c *ReplayClient) Remaining() int {
+	c.tape.mu.Lock()
+	defer c.tape.mu.Unlock()
+	unused := c.tape.byHash
+	if c.match == MatchSequence {
+		unused = c.tape.byStream
+	}
+	n := 0
+	for _, idx := range unused {
+		n += len(idx)
+	}
+	return n
+}
+
+// serve returns the recorded response for a call.
+func (c *ReplayClient) serve(kind string, req CassetteRequest) (*Response, error) {
+	hash := hashRequest(kind, req)
+	t := c.tape
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var in Interaction
+	switch c.match {
+	case MatchHash:
+		idx := t.byHash[hash]
+		if len(idx) == 0 {
+			return nil, fmt.Errorf("%w: no recorded %s call matches this request%s", ErrCassetteDiverged, kind, t.nearest(kind, req))
+		}
+		in = t.all[idx[0]]
+		t.byHash[hash] = idx[1:]
+	default:
+		idx := t.byStream[c.stream]
+		if len(idx) == 0 {
+			if c.stream != "" {
+				return nil, fmt.Errorf("%w: the recording of stream %q has no more calls", ErrCassetteDiverged, c.stream)
+			}
+			return nil, fmt.Errorf("%w: the recording ended after %d calls", ErrCassetteDiverged, len(t.all))
+		}
+		in = t.all[idx[0]]
+		if in.Kind != kind || !slices.Equal(in.Request.Tools, req.Tools) {
+			return nil, fmt.Errorf("%w at call %d: recorded a %s call with tools %v, got a %s call with tools %v",
+				ErrCassetteDiverged, in.Seq, in.Kind, in.Request.Tools, kind, req.Tools)
+		}
+		t.byStream[c.stream] = idx[1:]
+	}
+	if in.Error != "" {
+		return nil, errors.New(in.Error)
+	}
+	if in.Response == nil {
+		return nil, fmt.Errorf("cassette call %d has no response", in.Seq)
+	}
+	resp := *in.Response
+	return &resp, nil
+}
+
+// nearest describes how a request differs from the unused recording of the
+// same kind that shares the longest prefix of messages with it.
+func (c *replayTape) nearest(kind string, req CassetteRequest) string {
+	best, bestLen := -1, -1
+	for _, idx := range c.byHash {
+		for _, i := range idx {
+			in := c.all[i]
+			if in.Kind != kind {
+				continue
+			}
+			if n := commonPrefix(in.Request, req); n > bestLen {
+				best, bestLen = i, n
+			}
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	rec := c.all[best].Request
+	switch {
+	case rec.System != req.System:
+		return fmt.Sprintf(" (closest, call %d, has a different system prompt)", c.all[best].Seq)
+	case !slices.Equal(rec.Tools, req.Tools):
+		return fmt.Sprintf(" (closest, call %d, offered tools %v)", c.all[best].Seq, rec.Tools)
+	default:
+		return fmt.Sprintf(" (closest, call %d, differs from message %d)", c.all[best].Seq, bestLen)
+	}
+}
+
+// commonPrefix returns how many leading messages two requests share.
+func commonPrefix(a, b CassetteRequest) int {
+	if len(a.History) > 0 || len(b.History) > 0 {
+		n := 0
+		for n < len(a.History) && n < len(b.History) && a.History[n] == b.History[n] {
+			n++
+		}
+		return n
+	}
+	n := 0
+	for n < len(a.Messages) && n < len(b.Messages) {
+		x, y := a.Messages[n], b.Messages[n]
+		x.Turn, y.Turn = nil, nil
+		xj, _ := json.Marshal(x)
+		yj, _ := json.Marshal(y)
+		if string(xj) != string(yj) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// Chat implements Client.
+func (c *ReplayClient) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	return c.ChatWithHistory(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
+}
+
+// ChatWithHistory implements Client.
+func (c *ReplayClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	resp, err := c.serve(CallChat, CassetteRequest{System: systemPrompt, History: messages})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text(), nil
+}
+
+// ChatWithUsage implements UsageClient.
+func (c *ReplayClient) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error) {
+	return c.serve(CallChat, CassetteRequest{System: systemPrompt, History: []Message{{Role: "user", Content: userMessage}}})
+}
+
+// ChatWithTools implements ToolClient.
+func (c *ReplayClient) ChatWithTools(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	return c.serve(CallTools, CassetteRequest{System: systemPrompt, Messages: messages, Tools: toolNames(tools)})
+}
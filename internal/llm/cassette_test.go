@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cannedClient answers tool calls with "reply N" and plain chats with
+// "chat N", failing the calls listed in fail.
+type cannedClient struct {
+	calls int
+	fail  map[int]bool
+}
+
+func (c *cannedClient) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	c.calls++
+	return "chat " + string(rune('0'+c.calls)), nil
+}
+
+func (c *cannedClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	return c.Chat(ctx, systemPrompt, "")
+}
+
+func (c *cannedClient) ChatWithTools(ctx context.Context, systemPrompt string, messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	c.calls++
+	if c.fail[c.calls] {
+		return nil, errors.New("status 529: overloaded_error")
+	}
+	return &Response{
+		Content:    []ContentBlock{{Type: "text", Text: "reply " + string(rune('0'+c.calls))}},
+		StopReason: "end_turn",
+		Usage:      Usage{InputTokens: 10 * c.calls},
+	}, nil
+}
+
+func userText(s string) ToolMessage {
+	return ToolMessage{Role: "user", Content: []ContentBlock{{Type: "text", Text: s}}}
+}
+
+// recordSession records a tool call, a failed tool call, its retry and a
+// plain chat.
+func recordSession(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cassettes", "run.jsonl")
+	rec, err := NewRecordingClient(&cannedClient{fail: map[int]bool{2: true}}, path)
+	if err != nil {
+		t.Fatalf("NewRecordingClient: %v", err)
+	}
+	ctx := context.Background()
+	tools := []ToolDef{{Name: "get_status"}}
+	history := []ToolMessage{userText("Objective: one")}
+	if _, err := rec.ChatWithTools(ctx, "sys", history, tools); err != nil {
+		t.Fatalf("call 1: %v", err)
+	}
+	history = append(history, ToolMessage{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "reply 1"}}, Turn: &TurnUsage{Model: "m"}}, userText("go on"))
+	if _, err := rec.ChatWithTools(ctx, "sys", history, tools); err == nil {
+		t.Fatal("call 2: expected the recorded failure")
+	}
+	if _, err := rec.ChatWithTools(ctx, "sys", history, tools); err != nil {
+		t.Fatalf("call 3: %v", err)
+	}
+	if _, err := rec.Wrap(&cannedClient{}).Chat(ctx, "review", "output"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return path
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	path := recordSession(t)
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 4 {
+		t.Fatalf("expected 4 recorded calls, got %d:\n%s", n, data)
+	}
+
+	for _, match := range []string{MatchSequence, MatchHash} {
+		rc, err := NewReplayClient(path, match)
+		if err != nil {
+			t.Fatalf("NewReplayClient(%s): %v", match, err)
+		}
+		ctx := context.Background()
+		tools := []ToolDef{{Name: "get_status"}}
+		history := []ToolMessage{userText("Objective: one")}
+		resp, err := rc.ChatWithTools(ctx, "sys", history, tools)
+		if err != nil || resp.Text() != "reply 1" || resp.Usage.InputTokens != 10 {
+			t.Fatalf("%s: call 1 = %+v, %v", match, resp, err)
+		}
+		// The usage kept with the assistant message does not change the request.
+		history = append(history, ToolMessage{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "reply 1"}}}, userText("go on"))
+		if _, err := rc.ChatWithTools(ctx, "sys", history, tools); err == nil || !IsRetryableError(err) {
+			t.Fatalf("%s: expected the recorded overload error, got %v", match, err)
+		}
+		if resp, err := rc.ChatWithTools(ctx, "sys", history, tools); err != nil || resp.Text() != "reply 3" {
+			t.Fatalf("%s: call 3 = %+v, %v", match, resp, err)
+		}
+		if out, err := rc.Chat(ctx, "review", "output"); err != nil || out != "chat 1" {
+			t.Fatalf("%s: chat = %q, %v", match, out, err)
+		}
+		if rc.Remaining() != 0 {
+			t.Errorf("%s: expected the whole cassette served, %d left", match, rc.Remaining())
+		}
+		if _, err := rc.Chat(ctx, "review", "output"); !errors.Is(err, ErrCassetteDiverged) {
+			t.Errorf("%s: expected divergence once the cassette is used up, got %v", match, err)
+		}
+	}
+}
+
+func TestCassetteStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	rec, err := NewRecordingClient(&cannedClient{}, path)
+	if err != nil {
+		t.Fatalf("NewRecordingClient: %v", err)
+	}
+	ctx := context.Background()
+	a, b := rec.ForStream("t1"), rec.ForStream("t2")
+	for _, c := range []Client{a, b, a} {
+		if _, err := c.Chat(ctx, "sys", "go"); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	rec.Close()
+
+	// The children ask in another order than they did when recording.
+	rc, _ := NewReplayClient(path, MatchSequence)
+	a, b = rc.ForStream("t1"), rc.ForStream("t2")
+	for i, want := range []struct {
+		c   Client
+		out string
+	}{{b, "chat 2"}, {a, "chat 1"}, {a, "chat 3"}} {
+		if out, err := want.c.Chat(ctx, "sys", "go"); err != nil || out != want.out {
+			t.Errorf("call %d = %q, %v, want %q", i+1, out, err, want.out)
+		}
+	}
+	if _, err := b.Chat(ctx, "sys", "go"); !errors.Is(err, ErrCassetteDiverged) || !strings.Contains(err.Error(), `"t2"`) {
+		t.Errorf("expected stream t2 to be used up, got %v", err)
+	}
+	if _, err := rc.Chat(ctx, "sys", "go"); !errors.Is(err, ErrCassetteDiverged) {
+		t.Errorf("expected the top-level stream to be empty, got %v", err)
+	}
+}
+
+func TestCassetteDivergence(t *testing.T) {
+	path := recordSession(t)
+	ctx := context.Background()
+	tools := []ToolDef{{Name: "get_status"}}
+
+	// Hash matching points at the closest recording.
+	rc, _ := NewReplayClient(path, MatchHash)
+	history := []ToolMessage{userText("Objective: one"), {Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "reply 1"}}}, userText("stop")}
+	_, err := rc.ChatWithTools(ctx, "sys", history, tools)
+	if !errors.Is(err, ErrCassetteDiverged) || !strings.Contains(err.Error(), "differs from message 2") {
+		t.Errorf("expected divergence at message 2, got %v", err)
+	}
+
+	// Sequence matching tolerates different content but not a different call.
+	rc, _ = NewReplayClient(path, MatchSequence)
+	if resp, err := rc.ChatWithTools(ctx, "sys", []ToolMessage{userText("Objective: two")}, tools); err != nil || resp.Text() != "reply 1" {
+		t.Errorf("expected the first recording for a changed request, got %+v, %v", resp, err)
+	}
+	_, err = rc.ChatWithTools(ctx, "sys", nil, []ToolDef{{Name: "get_status"}, {Name: "complete"}})
+	if !errors.Is(err, ErrCassetteDiverged) || !strings.Contains(err.Error(), "at call 2") {
+		t.Errorf("expected divergence at call 2, got %v", err)
+	}
+
+	if _, err := NewReplayClient(path, "fuzzy"); err == nil {
+		t.Error("expected an unknown match mode to be rejected")
+	}
+}
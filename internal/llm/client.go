@@ -23,3 +23,102 @@ type ToolClient interface {
 	ChatWithTools(ctx context.Context, systemPrompt string,
 		messages []ToolMessage, tools []ToolDef) (*Response, error)
 }
+
+// StreamingToolClient is a ToolClient that can stream its response.
+// onEvent receives text and tool-call deltas as they arrive; the returned
+// Response is the same one ChatWithTools would return for the same call.
+type StreamingToolClient interface {
+	ToolClient
+	ChatWithToolsStream(ctx context.Context, systemPrompt string,
+		messages []ToolMessage, tools []ToolDef, onEvent func(StreamEvent)) (*Response, error)
+}
+
+// UsageClient is a Client whose plain chat calls can also report the model
+// and token usage of the call.
+type UsageClient interface {
+	Client
+	ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error)
+}
+
+// Retrier is a client that may retry failed calls itself, such as one
+// failing over between backends. Clients that wrap another forward it.
+type Retrier interface {
+	RetriesInternally() bool
+}
+
+// RetriesInternally reports whether c retries failed calls itself, so its
+// callers should not retry them again.
+func RetriesInternally(c Client) bool {
+	r, ok := c.(Retrier)
+	return ok && r.RetriesInternally()
+}
+
+type toolChoiceKey struct{}
+
+// WithToolChoice returns a context whose tool-use calls must call the named
+// tool, such as one that submits a structured answer. Providers with a tool
+// choice parameter enforce it; the text protocol rejects replies without it.
+// Anthropic cannot force a tool while extended thinking is on, so there the
+// choice is left to the model and callers must handle a reply without it.
+func WithToolChoice(ctx context.Context, tool string) context.Context {
+	return context.WithValue(ctx, toolChoiceKey{}, tool)
+}
+
+// toolChoice returns the tool a call must use, or "".
+func toolChoice(ctx context.Context) string {
+	tool, _ := ctx.Value(toolChoiceKey{}).(string)
+	return tool
+}
+
+// Options tune generation. Zero values keep the client's defaults.
+type Options struct {
+	MaxTokens   int      // output tokens per call
+	Temperature *float64 // nil = the provider's default
+
+	// ThinkingBudget is the tokens a call may spend reasoning before it
+	// answers (Anthropic extended thinking, Gemini thinking budget).
+	ThinkingBudget int
+	// ReasoningEffort is "low", "medium" or "high" (OpenAI reasoning
+	// models). Each provider derives the setting it lacks from the other.
+	ReasoningEffort string
+}
+
+// Reasoning effort levels and the thinking budget each stands for.
+var effortBudgets = map[string]int{
+	"low":    1024,
+	"medium": 4096,
+	"high":   16384,
+}
+
+// maxTokens returns the configured output limit, or def.
+func (o Options) maxTokens(def int) int {
+	if o.MaxTokens > 0 {
+		return o.MaxTokens
+	}
+	return def
+}
+
+// thinkingBudget returns the configured thinking budget, or the one for
+// the reasoning effort, or 0 when reasoning is not configured.
+func (o Options) thinkingBudget() int {
+	if o.ThinkingBudget > 0 {
+		return o.ThinkingBudget
+	}
+	return effortBudgets[o.ReasoningEffort]
+}
+
+// reasoningEffort returns the configured reasoning effort, or the lowest
+// level whose budget covers the thinking budget, or "".
+func (o Options) reasoningEffort() string {
+	if o.ReasoningEffort != "" || o.ThinkingBudget <= 0 {
+		return o.ReasoningEffort
+	}
+	switch {
+	case o.ThinkingBudget <= effortBudgets["low"]:
+		return "low"
+	case o.ThinkingBudget <= effortBudgets["medium"]:
+		return "medium"
+	default:
+		return "high"
+	}
+}
@@ -3,7 +3,7 @@ package llm
 import "testing"
 
 func TestAnthropicClient(t *testing.T) {
-	c := NewAnthropicClient("test-key", "")
+	c := NewAnthropicClient("test-key", "", "")
 	if c == nil {
 		t.Fatal("NewAnthropicClient returned nil")
 	}
@@ -13,7 +13,7 @@ func TestAnthropicClient(t *testing.T) {
 }
 
 func TestAnthropicClientCustomModel(t *testing.T) {
-	c := NewAnthropicClient("test-key", "claude-haiku-3-20240307")
+	c := NewAnthropicClient("test-key", "claude-haiku-3-20240307", "")
 	if c.model != "claude-haiku-3-20240307" {
 		t.Fatalf("expected custom model, got %q", c.model)
 	}
@@ -87,6 +87,11 @@ func TestInterfaceCompliance(t *testing.T) {
 
 	// Verify AnthropicClient satisfies ToolClient
 	var _ ToolClient = (*AnthropicClient)(nil)
+
+	// API clients report usage of plain chat calls
+	var _ UsageClient = (*AnthropicClient)(nil)
+	var _ UsageClient = (*GeminiClient)(nil)
+	var _ UsageClient = (*FailoverClient)(nil)
 }
 
 func TestUsageInResponse(t *testing.T) {
@@ -0,0 +1,40 @@
+package llm
+
+import "strings"
+
+// DefaultContextWindow is assumed for models missing from the table.
+const DefaultContextWindow = 128_000
+
+// contextWindows are input context sizes in tokens, keyed by model name or
+// model-name prefix.
+var contextWindows = map[string]int{
+	// Anthropic
+	"claude": 200_000,
+
+	// OpenAI
+	"gpt-4o":            128_000,
+	"gpt-4.1":           1_047_576,
+	"o3":                200_000,
+	"o4-mini":           200_000,
+	"codex-mini-latest": 200_000,
+	"gpt-5":             400_000,
+
+	// Gemini
+	"gemini-2.5": 1_048_576,
+	"gemini-2.0": 1_048_576,
+}
+
+// ContextWindow returns the context size of model in tokens, matching the
+// longest known prefix of its name. Unknown models get DefaultContextWindow.
+func ContextWindow(model string) int {
+	best := ""
+	for k := range contextWindows {
+		if len(k) > len(best) && strings.HasPrefix(model, k) {
+			best = k
+		}
+	}
+	if best == "" {
+		return DefaultContextWindow
+	}
+	return contextWindows[best]
+}
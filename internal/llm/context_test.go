@@ -0,0 +1,22 @@
+package llm
+
+import "testing"
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"claude-sonnet-4-20250514", 200_000},
+		{"gpt-4o-mini", 128_000},
+		{"gpt-4.1-mini", 1_047_576},
+		{"gpt-5-mini", 400_000},
+		{"gemini-2.5-flash", 1_048_576},
+		{"llama3", DefaultContextWindow},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
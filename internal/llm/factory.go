@@ -7,23 +7,29 @@ type ProviderConfig struct {
 	Provider string // "anthropic", "openai", "codex", "gemini", "kimi", "claude-cli", etc.
 	Model    string
 	APIKey   string
-	BaseURL  string // optional: override API base URL (for OpenAI-compatible endpoints)
-	WorkDir  string // for CLI-based providers
+	BaseURL  string  // optional: override the API base URL (API-based providers)
+	WorkDir  string  // for CLI-based providers
+	Options  Options // generation options (API-based providers only)
 }
 
 // NewFromConfig creates the appropriate Client based on provider name.
 // API-based providers (anthropic, openai, codex, gemini-api) support tool use (ToolClient).
-// CLI-based providers (kimi, claude-cli, gemini, opencode) only support basic chat (Client).
+// CLI-based providers (kimi, claude-cli, gemini, opencode) call tools through a
+// JSON envelope in their text replies (TextToolClient).
 func NewFromConfig(cfg ProviderConfig) (Client, error) {
 	switch cfg.Provider {
 
 	// === API-based providers (support tool use → agent mode) ===
 
 	case "anthropic":
-		return NewAnthropicClient(cfg.APIKey, cfg.Model), nil
+		c := NewAnthropicClient(cfg.APIKey, cfg.Model, cfg.BaseURL)
+		c.SetOptions(cfg.Options)
+		return c, nil
 
 	case "openai":
-		return NewOpenAIClient(cfg.APIKey, cfg.Model, cfg.BaseURL), nil
+		c := NewOpenAIClient(cfg.APIKey, cfg.Model, cfg.BaseURL)
+		c.SetOptions(cfg.Options)
+		return c, nil
 
 	case "codex":
 		// Codex uses the OpenAI API
@@ -31,25 +37,29 @@ func NewFromConfig(cfg ProviderConfig) (Client, error) {
 		if model == "" {
 			model = "codex-mini-latest"
 		}
-		return NewOpenAIClient(cfg.APIKey, model, cfg.BaseURL), nil
+		c := NewOpenAIClient(cfg.APIKey, model, cfg.BaseURL)
+		c.SetOptions(cfg.Options)
+		return c, nil
 
 	case "gemini-api", "google":
-		return NewGeminiClient(cfg.APIKey, cfg.Model), nil
+		c := NewGeminiClient(cfg.APIKey, cfg.Model, cfg.BaseURL)
+		c.SetOptions(cfg.Options)
+		return c, nil
 
-	// === CLI-based providers (basic chat only → legacy mode) ===
+	// === CLI-based providers (tool use through text replies) ===
 
 	case "kimi":
-		return NewCLIClient("kimi", []string{"--print", "--final-message-only", "-p"}, cfg.WorkDir, false), nil
+		return NewTextToolClient(NewCLIClient("kimi", []string{"--print", "--final-message-only", "-p"}, cfg.WorkDir, false)), nil
 
 	case "claude-cli", "claude-code":
-		return NewCLIClient("claude", []string{"-p"}, cfg.WorkDir, false), nil
+		return NewTextToolClient(NewCLIClient("claude", []string{"-p"}, cfg.WorkDir, false)), nil
 
 	case "gemini":
 		// CLI-based gemini (piped). For API-based, use "gemini-api".
-		return NewCLIClient("gemini", nil, cfg.WorkDir, true), nil
+		return NewTextToolClient(NewCLIClient("gemini", nil, cfg.WorkDir, true)), nil
 
 	case "opencode":
-		return NewCLIClient("opencode", []string{"run"}, cfg.WorkDir, false), nil
+		return NewTextToolClient(NewCLIClient("opencode", []string{"run"}, cfg.WorkDir, false)), nil
 
 	case "":
 		return nil, fmt.Errorf("no LLM provider configured (set queen.provider in waggle.json)")
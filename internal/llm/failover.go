@@ -0,0 +1,320 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend is one provider/model a FailoverClient can use.
+type Backend struct {
+	Provider string
+	Model    string
+	Client   Client
+}
+
+func (b Backend) String() string {
+	if b.Model == "" {
+		return b.Provider
+	}
+	return b.Provider + "/" + b.Model
+}
+
+// FailoverClient sends each call to the first healthy backend in order. A
+// backend whose call fails, after its own retries for transient errors, is
+// set aside for a cool-down and the call moves on to the next one; once the
+// cool-down has passed, calls fail back to it. Backends without tool support
+// are skipped for tool calls.
+type FailoverClient struct {
+	backends []Backend
+	coolDown time.Duration
+	retries  int // retries of transient errors per backend
+	logger   *log.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	active   int               // backend that served the last call
+	failedAt map[int]time.Time // backend index -> time it was set aside
+}
+
+// NewFailoverClient returns a client over backends, most preferred first.
+func NewFailoverClient(backends []Backend, coolDown time.Duration, logger *log.Logger) *FailoverClient {
+	return &FailoverClient{
+		backends: backends,
+		coolDown: coolDown,
+		retries:  3,
+		logger:   logger,
+		now:      time.Now,
+		failedAt: make(map[int]time.Time),
+	}
+}
+
+// RetriesInternally implements Retrier: each backend is retried before the
+// next one is tried.
+func (c *FailoverClient) RetriesInternally() bool {
+	return true
+}
+
+// Active returns the backend that served the last call.
+func (c *FailoverClient) Active() Backend {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.backends[c.active]
+}
+
+// candidates returns the backends to try, in order: those not cooling down,
+// or all of them when every one is.
+func (c *FailoverClient) candidates(tools bool) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var ready, all []int
+	for i, b := range c.backends {
+		if _, ok := b.Client.(ToolClient); tools && !ok {
+			continue
+		}
+		all = append(all, i)
+		if at, failed := c.failedAt[i]; !failed || now.Sub(at) >= c.coolDown {
+			ready = append(ready, i)
+		}
+	}
+	if len(ready) == 0 {
+		return all
+	}
+	return ready
+}
+
+// call runs fn on the candidate backends until one succeeds.
+func (c *FailoverClient) call(ctx context.Context, tools bool, fn func(b Backend) (*Response, error)) (*Response, error) {
+	candidates := c.candidates(tools)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no LLM backend supports tool use")
+	}
+	var errs []error
+	for n, i := range candidates {
+		b := c.backends[i]
+		resp, err := RetryLLMCall(ctx, c.retries, c.logger, func() (*Response, error) { return fn(b) })
+		if err == nil {
+			c.mu.Lock()
+			if c.active != i && c.logger != nil {
+				c.logger.Printf("✓ Queen LLM now using %s", b)
+			}
+			c.active = i
+			delete(c.failedAt, i)
+			c.mu.Unlock()
+			if resp != nil {
+				if resp.Provider == "" {
+					resp.Provider = b.Provider
+				}
+				if resp.Model == "" {
+					resp.Model = b.Model
+				}
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b, err))
+		c.mu.Lock()
+		c.failedAt[i] = c.now()
+		c.mu.Unlock()
+		if c.logger != nil && n+1 < len(candidates) {
+			c.logger.Printf("⚠ Queen LLM %s failed: %v; switching to %s", b, err, c.backends[candidates[n+1]])
+		}
+	}
+	return nil, fmt.Errorf("all LLM backends failed: %w", errors.Join(errs...))
+}
+
+// Chat implements Client.
+func (c *FailoverClient) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	var out string
+	_, err := c.call(ctx, false, func(b Backend) (*Response, error) {
+		s, err := b.Client.Chat(ctx, systemPrompt, userMessage)
+		out = s
+		return nil, err
+	})
+	return out, err
+}
+
+// ChatWithHistory implements Client.
+func (c *FailoverClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	var out string
+	_, err := c.call(ctx, false, func(b Backend) (*Response, error) {
+		s, err := b.Client.ChatWithHistory(ctx, systemPrompt, messages)
+		out = s
+		return nil, err
+	})
+	return out, err
+}
+
+// ChatWithUsage implements UsageClient. Backends that do not report usage
+// answer with the text alone.
+func (c *FailoverClient) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error) {
+	return c.call(ctx, false, func(b Backend) (*Response, error) {
+		if uc, ok := b.Client.(UsageClient); ok {
+			return uc.ChatWithUsage(ctx, systemPrompt, userMessage)
+		}
+		s, err := b.Client.Chat(ctx, systemPrompt, userMessage)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Content: []ContentBlock{{Type: "text", Text: s}}}, nil
+	})
+}
+
+// ChatWithTools implements ToolClient.
+func (c *FailoverClient) ChatWithTools(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	history := PortableToolHistory(messages)
+	return c.call(ctx, true, func(b Backend) (*Response, error) {
+		return b.Client.(ToolClient).ChatWithTools(ctx, systemPrompt, ownReasoning(history, b.Provider), tools)
+	})
+}
+
+// ChatWithToolsStream implements StreamingToolClient. Backends that cannot
+// stream answer in one piece. Every attempt after the first starts with a
+// StreamStart event so consumers drop the output of the failed one.
+func (c *FailoverClient) ChatWithToolsStream(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef, onEvent func(StreamEvent)) (*Response, error) {
+	history := PortableToolHistory(messages)
+	attempt := 0
+	return c.call(ctx, true, func(b Backend) (*Response, error) {
+		if attempt++; attempt > 1 {
+			onEvent(StreamEvent{Type: StreamStart})
+		}
+		history := ownReasoning(history, b.Provider)
+		if sc, ok := b.Client.(StreamingToolClient); ok {
+			return sc.ChatWithToolsStream(ctx, systemPrompt, history, tools, onEvent)
+		}
+		return b.Client.(ToolClient).ChatWithTools(ctx, systemPrompt, history, tools)
+	})
+}
+
+// maxToolCallIDLen is the longest tool call ID every provider accepts.
+const maxToolCallIDLen = 40
+
+// PortableToolHistory rewrites tool call IDs that another provider would
+// reject so a history can be sent to any of them: Anthropic only accepts
+// [A-Za-z0-9_-], OpenAI caps IDs at 40 characters, and both require IDs to
+// be unique, which Gemini's generated IDs are not across turns. Each tool
+// result follows its call's new ID. Messages are copied only when changed.
+func PortableToolHistory(messages []ToolMessage) []ToolMessage {
+	seen := make(map[string]bool)
+	renamed := make(map[string]string) // IDs of the latest assistant message
+	var out []ToolMessage
+	for i, msg := range messages {
+		changed := false
+		if msg.Role == "assistant" {
+			clear(renamed)
+			content := msg.Content
+			for j, block := range msg.Content {
+				if block.Type != "tool_use" || block.ToolCall == nil {
+					continue
+				}
+				id := portableID(block.ToolCall.ID, seen)
+				seen[id] = true
+				if id == block.ToolCall.ID {
+					continue
+				}
+				renamed[block.ToolCall.ID] = id
+				if !changed {
+					content = append([]ContentBlock(nil), msg.Content...)
+					changed = true
+				}
+				tc := *block.ToolCall
+				tc.ID = id
+				content[j].ToolCall = &tc
+			}
+			msg.Content = content
+		}
+		if len(msg.ToolResults) > 0 && len(renamed) > 0 {
+			results := append([]ToolResult(nil), msg.ToolResults...)
+			for j, r := range results {
+				if id, ok := renamed[r.ToolCallID]; ok {
+					results[j].ToolCallID = id
+					changed = true
+				}
+			}
+			msg.ToolResults = results
+		}
+		if changed && out == nil {
+			out = append(make([]ToolMessage, 0, len(messages)), messages[:i]...)
+		}
+		if out != nil {
+			out = append(out, msg)
+		}
+	}
+	if out == nil {
+		return messages
+	}
+	return out
+}
+
+// ownReasoning drops the thinking blocks and signatures of assistant turns
+// another provider produced, which provider would reject as invalid.
+// Messages are copied only when changed.
+func ownReasoning(messages []ToolMessage, provider string) []ToolMessage {
+	var out []ToolMessage
+	for i, msg := range messages {
+		if msg.Role != "assistant" || msg.Turn == nil || msg.Turn.Provider == "" || msg.Turn.Provider == provider {
+			if out != nil {
+				out = append(out, msg)
+			}
+			continue
+		}
+		var content []ContentBlock
+		changed := false
+		for _, block := range msg.Content {
+			switch {
+			case block.Type == "thinking" || block.Type == "redacted_thinking":
+				changed = true
+				continue
+			case block.Signature != "":
+				block.Signature = ""
+				changed = true
+			}
+			content = append(content, block)
+		}
+		if changed && out == nil {
+			out = append(make([]ToolMessage, 0, len(messages)), messages[:i]...)
+		}
+		if out != nil {
+			if changed {
+				msg.Content = content
+			}
+			out = append(out, msg)
+		}
+	}
+	if out == nil {
+		return messages
+	}
+	return out
+}
+
+// portableID returns id, or a replacement made of allowed characters, short
+// enough, and not in seen.
+func portableID(id string, seen map[string]bool) string {
+	clean := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, id)
+	if clean == "" {
+		clean = "call"
+	}
+	if len(clean) > maxToolCallIDLen {
+		clean = clean[:maxToolCallIDLen]
+	}
+	unique := clean
+	for n := 2; seen[unique]; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		unique = clean[:min(len(clean), maxToolCallIDLen-len(suffix))] + suffix
+	}
+	return unique
+}
@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedClient fails while err is set and records the histories it gets.
+type scriptedClient struct {
+	name  string
+	err   error
+	calls int
+	seen  [][]ToolMessage
+}
+
+func (c *scriptedClient) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	c.calls++
+	return c.name, c.err
+}
+
+func (c *scriptedClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	return c.Chat(ctx, systemPrompt, "")
+}
+
+func (c *scriptedClient) ChatWithTools(ctx context.Context, systemPrompt string, messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	c.calls++
+	c.seen = append(c.seen, messages)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Response{Content: []ContentBlock{{Type: "text", Text: c.name}}, StopReason: "end_turn"}, nil
+}
+
+func TestFailoverClient(t *testing.T) {
+	ctx := context.Background()
+	primary := &scriptedClient{name: "primary", err: errors.New("status 529: overloaded_error")}
+	fallback := &scriptedClient{name: "fallback"}
+	c := NewFailoverClient([]Backend{
+		{Provider: "anthropic", Model: "claude-sonnet-4", Client: primary},
+		{Provider: "openai", Model: "gpt-4.1", Client: fallback},
+	}, time.Minute, nil)
+	c.retries = 0
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	resp, err := c.ChatWithTools(ctx, "", nil, nil)
+	if err != nil || resp.Content[0].Text != "fallback" || resp.Provider != "openai" || resp.Model != "gpt-4.1" {
+		t.Fatalf("expected the fallback to answer, got %+v, %v", resp, err)
+	}
+	if c.Active().Provider != "openai" {
+		t.Errorf("expected fallback active, got %s", c.Active())
+	}
+
+	// The failed primary is not tried again during its cool-down.
+	primary.calls = 0
+	if _, err := c.ChatWithTools(ctx, "", nil, nil); err != nil || primary.calls != 0 {
+		t.Errorf("expected primary skipped while cooling down, got %d calls, %v", primary.calls, err)
+	}
+
+	// After the cool-down, calls fail back to the recovered primary.
+	primary.err = nil
+	now = now.Add(time.Minute)
+	if resp, _ := c.ChatWithTools(ctx, "", nil, nil); resp.Content[0].Text != "primary" || resp.Provider != "anthropic" {
+		t.Errorf("expected failback to primary, got %+v", resp)
+	}
+
+	// Non-transient errors switch too; when every backend fails, all errors are reported.
+	primary.err = errors.New("invalid x-api-key")
+	fallback.err = errors.New("status 401")
+	_, err = c.ChatWithTools(ctx, "", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "anthropic/claude-sonnet-4: invalid x-api-key") || !strings.Contains(err.Error(), "openai/gpt-4.1: status 401") {
+		t.Errorf("expected both failures reported, got %v", err)
+	}
+	// ...and the next call still tries them rather than giving up.
+	fallback.err = nil
+	if _, err := c.ChatWithTools(ctx, "", nil, nil); err != nil {
+		t.Errorf("expected a backend to be tried when all are cooling down, got %v", err)
+	}
+
+	if s, err := c.Chat(ctx, "", "hi"); err != nil || s != "fallback" {
+		t.Errorf("expected Chat to fail over too, got %q, %v", s, err)
+	}
+}
+
+func TestFailoverClient_SkipsBackendsWithoutTools(t *testing.T) {
+	cli := &scriptedClient{name: "cli"}
+	api := &scriptedClient{name: "api"}
+	c := NewFailoverClient([]Backend{
+		{Provider: "claude-cli", Client: struct{ Client }{cli}}, // hides ChatWithTools
+		{Provider: "anthropic", Client: api},
+	}, time.Minute, nil)
+
+	if resp, err := c.ChatWithTools(context.Background(), "", nil, nil); err != nil || resp.Content[0].Text != "api" || cli.calls != 0 {
+		t.Errorf("expected tool call served by the API backend, got %+v, %v", resp, err)
+	}
+	if s, _ := c.Chat(context.Background(), "", "hi"); s != "cli" {
+		t.Errorf("expected plain chat served by the first backend, got %q", s)
+	}
+}
+
+func TestPortableToolHistory(t *testing.T) {
+	call := func(id string) ToolMessage {
+		return ToolMessage{Role: "assistant", Content: []ContentBlock{
+			{Type: "text", Text: "checking"},
+			{Type: "tool_use", ToolCall: &ToolCall{ID: id, Name: "get_status"}},
+		}}
+	}
+	result := func(id string) ToolMessage {
+		return ToolMessage{Role: "tool_result", ToolResults: []ToolResult{{ToolCallID: id, Content: "ok"}}}
+	}
+
+	// Gemini reuses IDs across turns; other IDs may hold characters or
+	// lengths another provider rejects.
+	long := "call." + strings.Repeat("x", 60)
+	in := []ToolMessage{
+		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Objective"}}},
+		call("gemini-call-0"), result("gemini-call-0"),
+		call("gemini-call-0"), result("gemini-call-0"),
+		call(long), result(long),
+	}
+	out := PortableToolHistory(in)
+
+	ids := []string{
+		out[1].Content[1].ToolCall.ID, out[3].Content[1].ToolCall.ID, out[5].Content[1].ToolCall.ID,
+	}
+	if ids[0] != "gemini-call-0" || ids[1] != "gemini-call-0-2" || len(ids[2]) != maxToolCallIDLen || strings.Contains(ids[2], ".") {
+		t.Errorf("unexpected IDs %q", ids)
+	}
+	for i, id := range ids {
+		if got := out[2*i+2].ToolResults[0].ToolCallID; got != id {
+			t.Errorf("result %d refers to %q, want %q", i, got, id)
+		}
+	}
+	if in[3].Content[1].ToolCall.ID != "gemini-call-0" || in[4].ToolResults[0].ToolCallID != "gemini-call-0" {
+		t.Error("expected the input history left unchanged")
+	}
+
+	// Portable histories are returned as they are.
+	clean := in[:3]
+	if got := PortableToolHistory(clean); &got[0] != &clean[0] {
+		t.Error("expected no copy for a portable history")
+	}
+}
+
+func TestFailoverClient_DropsForeignReasoning(t *testing.T) {
+	primary := &scriptedClient{name: "primary", err: errors.New("status 529: overloaded_error")}
+	fallback := &scriptedClient{name: "fallback"}
+	c := NewFailoverClient([]Backend{
+		{Provider: "anthropic", Client: primary},
+		{Provider: "gemini-api", Client: fallback},
+	}, time.Minute, nil)
+	c.retries = 0
+
+	history := []ToolMessage{
+		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Objective"}}},
+		{Role: "assistant", Turn: &TurnUsage{Provider: "anthropic"}, Content: []ContentBlock{
+			{Type: "thinking", Text: "Plan first.", Signature: "sig_a"},
+			{Type: "tool_use", ToolCall: &ToolCall{ID: "tu_1", Name: "get_status"}},
+		}},
+		{Role: "tool_result", ToolResults: []ToolResult{{ToolCallID: "tu_1", Content: "ok"}}},
+	}
+	if _, err := c.ChatWithTools(context.Background(), "", history, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := primary.seen[0][1].Content; len(got) != 2 {
+		t.Errorf("expected the provider's own reasoning kept, got %+v", got)
+	}
+	if got := fallback.seen[0][1].Content; len(got) != 1 || got[0].Type != "tool_use" {
+		t.Errorf("expected another provider's reasoning dropped, got %+v", got)
+	}
+	if len(history[1].Content) != 2 {
+		t.Error("expected the input history left unchanged")
+	}
+}
+
+func TestRetriesInternally(t *testing.T) {
+	fc := NewFailoverClient([]Backend{{Provider: "anthropic", Client: &scriptedClient{name: "primary"}}}, time.Minute, nil)
+	rec, err := NewRecordingClient(fc, filepath.Join(t.TempDir(), "run.jsonl"))
+	if err != nil {
+		t.Fatalf("NewRecordingClient: %v", err)
+	}
+	defer rec.Close()
+
+	for name, c := range map[string]Client{
+		"failover":  fc,
+		"recorded":  rec,
+		"text tool": NewTextToolClient(fc),
+	} {
+		if !RetriesInternally(c) {
+			t.Errorf("%s: expected the failover's own retries to be reported", name)
+		}
+	}
+	if RetriesInternally(&scriptedClient{}) || RetriesInternally(rec.Wrap(&scriptedClient{})) {
+		t.Error("expected a plain client to leave retries to its caller")
+	}
+}
@@ -18,15 +18,17 @@ type GeminiClient struct {
 	model   string
 	baseURL string
 	client  *http.Client
+	opts    Options
 }
 
 // Gemini API types
 
 type geminiRequest struct {
-	Contents          []geminiContent  `json:"contents"`
-	Tools             []geminiTool     `json:"tools,omitempty"`
-	SystemInstruction *geminiContent   `json:"systemInstruction,omitempty"`
-	GenerationConfig  *geminiGenConfig `json:"generationConfig,omitempty"`
+	Contents          []geminiContent   `json:"contents"`
+	Tools             []geminiTool      `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig `json:"toolConfig,omitempty"`
+	SystemInstruction *geminiContent    `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenConfig  `json:"generationConfig,omitempty"`
 }
 
 type geminiContent struct {
@@ -36,6 +38,8 @@ type geminiContent struct {
 
 type geminiPart struct {
 	Text             string              `json:"text,omitempty"`
+	Thought          bool                `json:"thought,omitempty"` // Text is a thought summary
+	ThoughtSignature string              `json:"thoughtSignature,omitempty"`
 	FunctionCall     *geminiFunctionCall `json:"functionCall,omitempty"`
 	FunctionResponse *geminiFunctionResp `json:"functionResponse,omitempty"`
 }
@@ -54,6 +58,14 @@ type geminiTool struct {
 	FunctionDeclarations []geminiFuncDecl `json:"functionDeclarations"`
 }
 
+// geminiToolConfig restricts which functions the model may call.
+type geminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"` // "AUTO", "ANY" or "NONE"
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
+}
+
 type geminiFuncDecl struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
@@ -61,8 +73,14 @@ type geminiFuncDecl struct {
 }
 
 type geminiGenConfig struct {
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
-	Temperature     float64 `json:"temperature,omitempty"`
+	MaxOutputTokens int                   `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64              `json:"temperature,omitempty"`
+	ThinkingConfig  *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type geminiThinkingConfig struct {
+	ThinkingBudget  int  `json:"thinkingBudget"`
+	IncludeThoughts bool `json:"includeThoughts"`
 }
 
 type geminiResponse struct {
@@ -75,6 +93,7 @@ type geminiResponse struct {
 type geminiUsage struct {
 	PromptTokenCount     int `json:"promptTokenCount"`
 	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount,omitempty"` // not included in CandidatesTokenCount
 	TotalTokenCount      int `json:"totalTokenCount"`
 }
 
@@ -91,7 +110,8 @@ type geminiError struct {
 
 // NewGeminiClient creates a client for Google's Gemini API.
 // If apiKey is empty, it reads GEMINI_API_KEY (or GOOGLE_API_KEY) from the environment.
-func NewGeminiClient(apiKey, model string) *GeminiClient {
+// If baseURL is empty, it defaults to https://generativelanguage.googleapis.com/v1beta.
+func NewGeminiClient(apiKey, model, baseURL string) *GeminiClient {
 	if apiKey == "" {
 		apiKey = os.Getenv("GEMINI_API_KEY")
 	}
@@ -101,11 +121,15 @@ func NewGeminiClient(apiKey, model string) *GeminiClient {
 	if model == "" {
 		model = "gemini-2.5-flash"
 	}
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
 
 	return &GeminiClient{
 		apiKey:  apiKey,
 		model:   model,
-		baseURL: "https://generativelanguage.googleapis.com/v1beta",
+		baseURL: baseURL,
 		client:  &http.Client{Timeout: 120 * time.Second},
 	}
 }
@@ -114,10 +138,26 @@ func (c *GeminiClient) Chat(ctx context.Context, systemPrompt, userMessage strin
 	return c.ChatWithHistory(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
 }
 
+// SetOptions sets the generation options of every later call.
+func (c *GeminiClient) SetOptions(o Options) {
+	c.opts = o
+}
+
 func (c *GeminiClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
-	req := geminiRequest{
-		GenerationConfig: &geminiGenConfig{MaxOutputTokens: 4096},
+	resp, err := c.chat(ctx, systemPrompt, messages)
+	if err != nil {
+		return "", err
 	}
+	return resp.Text(), nil
+}
+
+// ChatWithUsage implements UsageClient.
+func (c *GeminiClient) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error) {
+	return c.chat(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
+}
+
+func (c *GeminiClient) chat(ctx context.Context, systemPrompt string, messages []Message) (*Response, error) {
+	req := geminiRequest{GenerationConfig: c.genConfig(4096)}
 
 	if systemPrompt != "" {
 		req.SystemInstruction = &geminiContent{
@@ -138,27 +178,122 @@ func (c *GeminiClient) ChatWithHistory(ctx context.Context, systemPrompt string,
 
 	resp, err := c.doRequest(ctx, req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return geminiToolsResponse(resp)
+}
 
-	if len(resp.Candidates) == 0 {
-		return "", fmt.Errorf("gemini: no candidates in response")
+// genConfig returns the generation config with the client's options. With
+// a thinking budget the response includes thought summaries.
+func (c *GeminiClient) genConfig(defMaxTokens int) *geminiGenConfig {
+	gc := &geminiGenConfig{
+		MaxOutputTokens: c.opts.maxTokens(defMaxTokens),
+		Temperature:     c.opts.Temperature,
 	}
-
-	var out strings.Builder
-	for _, p := range resp.Candidates[0].Content.Parts {
-		if p.Text != "" {
-			out.WriteString(p.Text)
-		}
+	if budget := c.opts.thinkingBudget(); budget > 0 {
+		gc.ThinkingConfig = &geminiThinkingConfig{ThinkingBudget: budget, IncludeThoughts: true}
 	}
-	return out.String(), nil
+	return gc
 }
 
 func (c *GeminiClient) ChatWithTools(ctx context.Context, systemPrompt string,
 	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	resp, err := c.doRequest(ctx, c.toolsRequest(ctx, systemPrompt, messages, tools))
+	if err != nil {
+		return nil, err
+	}
+	return geminiToolsResponse(resp)
+}
+
+// ChatWithToolsStream is ChatWithTools with text, thought and function-call
+// deltas passed to onEvent as they arrive.
+func (c *GeminiClient) ChatWithToolsStream(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef, onEvent func(StreamEvent)) (*Response, error) {
+	body, err := c.post(ctx, "streamGenerateContent?alt=sse", c.toolsRequest(ctx, systemPrompt, messages, tools))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// Reassemble the chunks into the response a non-streaming call returns:
+	// consecutive text (or thought) parts are merged, function calls arrive
+	// whole, and a thought signature on an empty part goes with the part
+	// before it.
+	var resp geminiResponse
+	var candidate geminiCandidate
+	tcID := 0
+	err = readSSE(body, func(data []byte) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("gemini: unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("gemini: %s (code %d)", chunk.Error.Message, chunk.Error.Code)
+		}
+		if chunk.ModelVersion != "" {
+			resp.ModelVersion = chunk.ModelVersion
+		}
+		if chunk.UsageMetadata != nil {
+			resp.UsageMetadata = chunk.UsageMetadata
+		}
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+		cand := chunk.Candidates[0]
+		if cand.Content.Role != "" {
+			candidate.Content.Role = cand.Content.Role
+		}
+		if cand.FinishReason != "" {
+			candidate.FinishReason = cand.FinishReason
+		}
+		for _, p := range cand.Content.Parts {
+			parts := candidate.Content.Parts
+			switch {
+			case p.FunctionCall != nil:
+				candidate.Content.Parts = append(parts, p)
+				args, _ := json.Marshal(p.FunctionCall.Args)
+				id := fmt.Sprintf("gemini-call-%d", tcID)
+				tcID++
+				onEvent(StreamEvent{Type: StreamToolCall, ToolCallID: id, ToolName: p.FunctionCall.Name})
+				onEvent(StreamEvent{Type: StreamToolInput, Text: string(args), ToolCallID: id})
+			case p.Text != "":
+				if n := len(parts); n > 0 && parts[n-1].FunctionCall == nil && parts[n-1].Text != "" &&
+					parts[n-1].Thought == p.Thought && parts[n-1].ThoughtSignature == "" {
+					parts[n-1].Text += p.Text
+					parts[n-1].ThoughtSignature = p.ThoughtSignature
+				} else {
+					candidate.Content.Parts = append(parts, p)
+				}
+				if p.Thought {
+					onEvent(StreamEvent{Type: StreamThinking, Text: p.Text})
+				} else {
+					onEvent(StreamEvent{Type: StreamText, Text: p.Text})
+				}
+			case p.ThoughtSignature != "":
+				if n := len(parts); n > 0 && parts[n-1].ThoughtSignature == "" {
+					parts[n-1].ThoughtSignature = p.ThoughtSignature
+				} else {
+					candidate.Content.Parts = append(parts, p)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gemini: read stream: %w", err)
+	}
+
+	resp.Candidates = []geminiCandidate{candidate}
+	return geminiToolsResponse(&resp)
+}
 
-	req := geminiRequest{
-		GenerationConfig: &geminiGenConfig{MaxOutputTokens: 8192},
+// toolsRequest builds the request for a tool-use call.
+func (c *GeminiClient) toolsRequest(ctx context.Context, systemPrompt string, messages []ToolMessage, tools []ToolDef) geminiRequest {
+	req := geminiRequest{GenerationConfig: c.genConfig(8192)}
+	if tool := toolChoice(ctx); tool != "" {
+		req.ToolConfig = &geminiToolConfig{}
+		req.ToolConfig.FunctionCallingConfig.Mode = "ANY"
+		req.ToolConfig.FunctionCallingConfig.AllowedFunctionNames = []string{tool}
 	}
 
 	if systemPrompt != "" {
@@ -204,9 +339,14 @@ func (c *GeminiClient) ChatWithTools(ctx context.Context, systemPrompt string,
 
 		case "assistant":
 			var parts []geminiPart
+			// Thought summaries are not sent back, but thought signatures
+			// must return on the parts that carried them.
 			for _, b := range msg.Content {
-				if b.Type == "text" && b.Text != "" {
-					parts = append(parts, geminiPart{Text: b.Text})
+				if b.Type == "thinking" && b.Signature != "" {
+					parts = append(parts, geminiPart{Text: b.Text, Thought: true, ThoughtSignature: b.Signature})
+				}
+				if b.Type == "text" && (b.Text != "" || b.Signature != "") {
+					parts = append(parts, geminiPart{Text: b.Text, ThoughtSignature: b.Signature})
 				}
 				if b.Type == "tool_use" && b.ToolCall != nil {
 					var args map[string]interface{}
@@ -216,6 +356,7 @@ func (c *GeminiClient) ChatWithTools(ctx context.Context, systemPrompt string,
 							Name: b.ToolCall.Name,
 							Args: args,
 						},
+						ThoughtSignature: b.Signature,
 					})
 				}
 			}
@@ -246,11 +387,11 @@ func (c *GeminiClient) ChatWithTools(ctx context.Context, systemPrompt string,
 		}
 	}
 
-	resp, err := c.doRequest(ctx, req)
-	if err != nil {
-		return nil, err
-	}
+	return req
+}
 
+// geminiToolsResponse converts a generateContent response into our Response type.
+func geminiToolsResponse(resp *geminiResponse) (*Response, error) {
 	if len(resp.Candidates) == 0 {
 		return nil, fmt.Errorf("gemini: no candidates in response")
 	}
@@ -277,18 +418,17 @@ func (c *GeminiClient) ChatWithTools(ctx context.Context, systemPrompt string,
 	result.Model = resp.ModelVersion
 	if resp.UsageMetadata != nil {
 		result.Usage = Usage{
-			InputTokens:  resp.UsageMetadata.PromptTokenCount,
-			OutputTokens: resp.UsageMetadata.CandidatesTokenCount,
+			InputTokens:    resp.UsageMetadata.PromptTokenCount,
+			OutputTokens:   resp.UsageMetadata.CandidatesTokenCount + resp.UsageMetadata.ThoughtsTokenCount,
+			ThinkingTokens: resp.UsageMetadata.ThoughtsTokenCount,
 		}
 	}
 
 	// toolCallCounter generates stable IDs since Gemini doesn't provide them
 	tcID := 0
 	for _, p := range candidate.Content.Parts {
-		if p.Text != "" {
-			result.Content = append(result.Content, ContentBlock{Type: "text", Text: p.Text})
-		}
-		if p.FunctionCall != nil {
+		switch {
+		case p.FunctionCall != nil:
 			argsJSON, _ := json.Marshal(p.FunctionCall.Args)
 			result.Content = append(result.Content, ContentBlock{
 				Type: "tool_use",
@@ -297,8 +437,13 @@ func (c *GeminiClient) ChatWithTools(ctx context.Context, systemPrompt string,
 					Name:  p.FunctionCall.Name,
 					Input: argsJSON,
 				},
+				Signature: p.ThoughtSignature,
 			})
 			tcID++
+		case p.Thought:
+			result.Content = append(result.Content, ContentBlock{Type: "thinking", Text: p.Text, Signature: p.ThoughtSignature})
+		case p.Text != "" || p.ThoughtSignature != "":
+			result.Content = append(result.Content, ContentBlock{Type: "text", Text: p.Text, Signature: p.ThoughtSignature})
 		}
 	}
 
@@ -321,12 +466,38 @@ func (c *GeminiClient) findFunctionName(messages []ToolMessage, toolCallID strin
 }
 
 func (c *GeminiClient) doRequest(ctx context.Context, body geminiRequest) (*geminiResponse, error) {
+	httpBody, err := c.post(ctx, "generateContent", body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpBody.Close()
+
+	respBody, err := io.ReadAll(httpBody)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: read response: %w", err)
+	}
+
+	var resp geminiResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("gemini: unmarshal response: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("gemini: %s (code %d)", resp.Error.Message, resp.Error.Code)
+	}
+
+	return &resp, nil
+}
+
+// post sends a request to the model's method (e.g. "generateContent") and
+// returns the response body of a successful call. The caller must close it.
+func (c *GeminiClient) post(ctx context.Context, method string, body geminiRequest) (io.ReadCloser, error) {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("gemini: marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, c.model)
+	url := fmt.Sprintf("%s/models/%s:%s", c.baseURL, c.model, method)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
@@ -339,25 +510,10 @@ func (c *GeminiClient) doRequest(ctx context.Context, body geminiRequest) (*gemi
 	if err != nil {
 		return nil, fmt.Errorf("gemini: request failed: %w", err)
 	}
-	defer httpResp.Body.Close()
-
-	respBody, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("gemini: read response: %w", err)
-	}
-
 	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
 		return nil, fmt.Errorf("gemini: API error %d: %s", httpResp.StatusCode, string(respBody))
 	}
-
-	var resp geminiResponse
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("gemini: unmarshal response: %w", err)
-	}
-
-	if resp.Error != nil {
-		return nil, fmt.Errorf("gemini: %s (code %d)", resp.Error.Message, resp.Error.Code)
-	}
-
-	return &resp, nil
+	return httpResp.Body, nil
 }
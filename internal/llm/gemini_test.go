@@ -40,8 +40,7 @@ func TestGeminiClient_ChatWithHistory(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("test-key", "gemini-2.5-flash")
-	client.baseURL = server.URL
+	client := NewGeminiClient("test-key", "gemini-2.5-flash", server.URL)
 
 	result, err := client.ChatWithHistory(context.Background(), "Be helpful.", []Message{
 		{Role: "user", Content: "Hi"},
@@ -86,8 +85,7 @@ func TestGeminiClient_Chat(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 	result, err := client.Chat(context.Background(), "sys", "hello")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -119,8 +117,7 @@ func TestGeminiClient_ChatWithTools_Serialization(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "gemini-2.5-flash")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "gemini-2.5-flash", server.URL)
 
 	messages := []ToolMessage{
 		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Do something"}}},
@@ -184,8 +181,7 @@ func TestGeminiClient_FunctionCallParsing(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 
 	resp, err := client.ChatWithTools(context.Background(), "", []ToolMessage{
 		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Go"}}},
@@ -230,8 +226,7 @@ func TestGeminiClient_ErrorHandling(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 	_, err := client.Chat(context.Background(), "", "hello")
 	if err == nil {
 		t.Fatal("expected error")
@@ -248,8 +243,7 @@ func TestGeminiClient_RateLimitError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 	_, err := client.Chat(context.Background(), "", "hello")
 	if err == nil {
 		t.Fatal("expected error")
@@ -266,8 +260,7 @@ func TestGeminiClient_NoCandidates(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 	_, err := client.Chat(context.Background(), "", "hello")
 	if err == nil {
 		t.Fatal("expected error")
@@ -284,8 +277,7 @@ func TestGeminiClient_NoCandidatesToolCall(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 	_, err := client.ChatWithTools(context.Background(), "", []ToolMessage{
 		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Go"}}},
 	}, nil)
@@ -306,8 +298,7 @@ func TestGeminiClient_MaxTokensStopReason(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 	resp, err := client.ChatWithTools(context.Background(), "", []ToolMessage{
 		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Go"}}},
 	}, nil)
@@ -320,7 +311,7 @@ func TestGeminiClient_MaxTokensStopReason(t *testing.T) {
 }
 
 func TestGeminiClient_FindFunctionName(t *testing.T) {
-	client := NewGeminiClient("", "")
+	client := NewGeminiClient("", "", "")
 
 	messages := []ToolMessage{
 		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Go"}}},
@@ -363,8 +354,7 @@ func TestGeminiClient_ToolResultConversion(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 
 	messages := []ToolMessage{
 		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Go"}}},
@@ -415,8 +405,7 @@ func TestGeminiClient_ToolNilSchema(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewGeminiClient("key", "model")
-	client.baseURL = server.URL
+	client := NewGeminiClient("key", "model", server.URL)
 
 	tools := []ToolDef{{Name: "get_status", Description: "Get status", InputSchema: nil}}
 	_, err := client.ChatWithTools(context.Background(), "", []ToolMessage{
@@ -437,7 +426,7 @@ func TestGeminiClient_ToolNilSchema(t *testing.T) {
 }
 
 func TestNewGeminiClient_Defaults(t *testing.T) {
-	c := NewGeminiClient("", "")
+	c := NewGeminiClient("", "", "")
 	if c.model != "gemini-2.5-flash" {
 		t.Errorf("expected default model, got %s", c.model)
 	}
@@ -19,16 +19,25 @@ type OpenAIClient struct {
 	model   string
 	baseURL string
 	client  *http.Client
+	opts    Options
 }
 
 // OpenAI API request/response types
 
 type openaiRequest struct {
-	Model               string          `json:"model"`
-	Messages            []openaiMessage `json:"messages"`
-	Tools               []openaiTool    `json:"tools,omitempty"`
-	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
-	Temperature         *float64        `json:"temperature,omitempty"`
+	Model               string               `json:"model"`
+	Messages            []openaiMessage      `json:"messages"`
+	Tools               []openaiTool         `json:"tools,omitempty"`
+	ToolChoice          any                  `json:"tool_choice,omitempty"`
+	MaxCompletionTokens int                  `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64             `json:"temperature,omitempty"`
+	ReasoningEffort     string               `json:"reasoning_effort,omitempty"`
+	Stream              bool                 `json:"stream,omitempty"`
+	StreamOptions       *openaiStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openaiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openaiMessage struct {
@@ -36,6 +45,10 @@ type openaiMessage struct {
 	Content    any              `json:"content,omitempty"` // string or []openaiContentPart
 	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string           `json:"tool_call_id,omitempty"`
+
+	// ReasoningContent is the reasoning some compatible servers (DeepSeek,
+	// vLLM) return with a reply. It is never sent back.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 type openaiTool struct {
@@ -68,9 +81,12 @@ type openaiResponse struct {
 }
 
 type openaiUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int `json:"prompt_tokens"`
+	CompletionTokens        int `json:"completion_tokens"`
+	TotalTokens             int `json:"total_tokens"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details,omitempty"`
 }
 
 type openaiChoice struct {
@@ -78,6 +94,29 @@ type openaiChoice struct {
 	FinishReason string        `json:"finish_reason"` // "stop", "tool_calls", "length"
 }
 
+// openaiStreamChunk is one server-sent event of a streamed chat completion.
+type openaiStreamChunk struct {
+	Choices []struct {
+		Delta        openaiStreamDelta `json:"delta"`
+		FinishReason string            `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openaiUsage `json:"usage,omitempty"`
+	Model string       `json:"model,omitempty"`
+	Error *openaiError `json:"error,omitempty"`
+}
+
+type openaiStreamDelta struct {
+	Content          string                `json:"content"`
+	ReasoningContent string                `json:"reasoning_content"`
+	ToolCalls        []openaiToolCallDelta `json:"tool_calls"`
+}
+
+type openaiToolCallDelta struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id"`
+	Function openaiCallFunction `json:"function"`
+}
+
 type openaiError struct {
 	Message string `json:"message"`
 	Type    string `json:"type"`
@@ -111,7 +150,25 @@ func (c *OpenAIClient) Chat(ctx context.Context, systemPrompt, userMessage strin
 	return c.ChatWithHistory(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
 }
 
+// SetOptions sets the generation options of every later call.
+func (c *OpenAIClient) SetOptions(o Options) {
+	c.opts = o
+}
+
 func (c *OpenAIClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	resp, err := c.chat(ctx, systemPrompt, messages)
+	if err != nil {
+		return "", err
+	}
+	return resp.Text(), nil
+}
+
+// ChatWithUsage implements UsageClient.
+func (c *OpenAIClient) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*Response, error) {
+	return c.chat(ctx, systemPrompt, []Message{{Role: "user", Content: userMessage}})
+}
+
+func (c *OpenAIClient) chat(ctx context.Context, systemPrompt string, messages []Message) (*Response, error) {
 	var apiMessages []openaiMessage
 	if systemPrompt != "" {
 		apiMessages = append(apiMessages, openaiMessage{Role: "system", Content: systemPrompt})
@@ -123,32 +180,108 @@ func (c *OpenAIClient) ChatWithHistory(ctx context.Context, systemPrompt string,
 	reqBody := openaiRequest{
 		Model:               c.model,
 		Messages:            apiMessages,
-		MaxCompletionTokens: 4096,
+		MaxCompletionTokens: c.opts.maxTokens(4096),
 	}
+	c.setReasoning(&reqBody)
 
 	resp, err := c.doRequest(ctx, reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return openaiToolsResponse(resp)
+}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("openai: no choices in response")
+func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	resp, err := c.doRequest(ctx, c.toolsRequest(ctx, systemPrompt, messages, tools))
+	if err != nil {
+		return nil, err
 	}
+	return openaiToolsResponse(resp)
+}
 
-	// Extract text content
-	content := resp.Choices[0].Message.Content
-	switch v := content.(type) {
-	case string:
-		return v, nil
-	default:
-		raw, _ := json.Marshal(v)
-		return string(raw), nil
+// ChatWithToolsStream is ChatWithTools with text, reasoning and tool-call
+// deltas passed to onEvent as they arrive.
+func (c *OpenAIClient) ChatWithToolsStream(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef, onEvent func(StreamEvent)) (*Response, error) {
+	reqBody := c.toolsRequest(ctx, systemPrompt, messages, tools)
+	reqBody.Stream = true
+	reqBody.StreamOptions = &openaiStreamOptions{IncludeUsage: true}
+
+	body, err := c.post(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// Reassemble the chunks into the response a non-streaming call returns.
+	var (
+		resp      openaiResponse
+		text      strings.Builder
+		reasoning strings.Builder
+		toolCalls []openaiToolCall
+		finish    string
+	)
+	err = readSSE(body, func(data []byte) error {
+		var chunk openaiStreamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("openai: unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("openai: %s: %s", chunk.Error.Type, chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			resp.Usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				finish = choice.FinishReason
+			}
+			if choice.Delta.ReasoningContent != "" {
+				reasoning.WriteString(choice.Delta.ReasoningContent)
+				onEvent(StreamEvent{Type: StreamThinking, Text: choice.Delta.ReasoningContent})
+			}
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onEvent(StreamEvent{Type: StreamText, Text: choice.Delta.Content})
+			}
+			for _, d := range choice.Delta.ToolCalls {
+				for len(toolCalls) <= d.Index {
+					toolCalls = append(toolCalls, openaiToolCall{Type: "function"})
+				}
+				tc := &toolCalls[d.Index]
+				if d.ID != "" {
+					tc.ID = d.ID
+				}
+				if d.Function.Name != "" {
+					tc.Function.Name += d.Function.Name
+					onEvent(StreamEvent{Type: StreamToolCall, ToolCallID: tc.ID, ToolName: tc.Function.Name})
+				}
+				if d.Function.Arguments != "" {
+					tc.Function.Arguments += d.Function.Arguments
+					onEvent(StreamEvent{Type: StreamToolInput, Text: d.Function.Arguments, ToolCallID: tc.ID})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai: read stream: %w", err)
 	}
-}
 
-func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemPrompt string,
-	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	msg := openaiMessage{Role: "assistant", ToolCalls: toolCalls, ReasoningContent: reasoning.String()}
+	if text.Len() > 0 {
+		msg.Content = text.String()
+	}
+	resp.Choices = []openaiChoice{{Message: msg, FinishReason: finish}}
+	return openaiToolsResponse(&resp)
+}
 
+// toolsRequest builds the request for a tool-use call.
+func (c *OpenAIClient) toolsRequest(ctx context.Context, systemPrompt string, messages []ToolMessage, tools []ToolDef) openaiRequest {
 	// Build API messages
 	var apiMessages []openaiMessage
 	if systemPrompt != "" {
@@ -214,18 +347,30 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemPrompt string,
 		})
 	}
 
-	reqBody := openaiRequest{
+	req := openaiRequest{
 		Model:               c.model,
 		Messages:            apiMessages,
 		Tools:               apiTools,
-		MaxCompletionTokens: 8192,
+		MaxCompletionTokens: c.opts.maxTokens(8192),
 	}
+	c.setReasoning(&req)
+	if tool := toolChoice(ctx); tool != "" {
+		req.ToolChoice = map[string]any{"type": "function", "function": map[string]string{"name": tool}}
+	}
+	return req
+}
 
-	resp, err := c.doRequest(ctx, reqBody)
-	if err != nil {
-		return nil, err
+// setReasoning sets a request's reasoning effort, or its temperature when
+// reasoning is not configured: reasoning models reject a temperature.
+func (c *OpenAIClient) setReasoning(req *openaiRequest) {
+	req.ReasoningEffort = c.opts.reasoningEffort()
+	if req.ReasoningEffort == "" {
+		req.Temperature = c.opts.Temperature
 	}
+}
 
+// openaiToolsResponse converts a chat completion into our Response type.
+func openaiToolsResponse(resp *openaiResponse) (*Response, error) {
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("openai: no choices in response")
 	}
@@ -250,6 +395,13 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemPrompt string,
 			InputTokens:  resp.Usage.PromptTokens,
 			OutputTokens: resp.Usage.CompletionTokens,
 		}
+		if d := resp.Usage.CompletionTokensDetails; d != nil {
+			result.Usage.ThinkingTokens = d.ReasoningTokens
+		}
+	}
+
+	if r := choice.Message.ReasoningContent; r != "" {
+		result.Content = append(result.Content, ContentBlock{Type: "thinking", Text: r})
 	}
 
 	// Extract text content
@@ -278,6 +430,32 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemPrompt string,
 }
 
 func (c *OpenAIClient) doRequest(ctx context.Context, body openaiRequest) (*openaiResponse, error) {
+	httpBody, err := c.post(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpBody.Close()
+
+	respBody, err := io.ReadAll(httpBody)
+	if err != nil {
+		return nil, fmt.Errorf("openai: read response: %w", err)
+	}
+
+	var resp openaiResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("openai: unmarshal response: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("openai: %s: %s", resp.Error.Type, resp.Error.Message)
+	}
+
+	return &resp, nil
+}
+
+// post sends a chat completion request and returns the response body of a
+// successful call. The caller must close it.
+func (c *OpenAIClient) post(ctx context.Context, body openaiRequest) (io.ReadCloser, error) {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("openai: marshal request: %w", err)
@@ -297,25 +475,10 @@ func (c *OpenAIClient) doRequest(ctx context.Context, body openaiRequest) (*open
 	if err != nil {
 		return nil, fmt.Errorf("openai: request failed: %w", err)
 	}
-	defer httpResp.Body.Close()
-
-	respBody, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("openai: read response: %w", err)
-	}
-
 	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
 		return nil, fmt.Errorf("openai: API error %d: %s", httpResp.StatusCode, string(respBody))
 	}
-
-	var resp openaiResponse
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("openai: unmarshal response: %w", err)
-	}
-
-	if resp.Error != nil {
-		return nil, fmt.Errorf("openai: %s: %s", resp.Error.Type, resp.Error.Message)
-	}
-
-	return &resp, nil
+	return httpResp.Body, nil
 }
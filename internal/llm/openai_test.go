@@ -395,7 +395,52 @@ func TestNewOpenAIClient_TrailingSlash(t *testing.T) {
 	}
 }
 
+func TestOpenAIClient_ChatWithUsageOptions(t *testing.T) {
+	var reqs []openaiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiRequest
+		mustUnmarshalJSON(t, mustReadAll(t, r.Body), &req)
+		reqs = append(reqs, req)
+		mustEncodeJSON(t, w, openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+			Usage:   &openaiUsage{PromptTokens: 12, CompletionTokens: 3},
+			Model:   "gpt-4o-mini-2024",
+		})
+	}))
+	defer server.Close()
+
+	temp := 0.2
+	client := NewOpenAIClient("key", "gpt-4o-mini", server.URL)
+	client.SetOptions(Options{MaxTokens: 500, Temperature: &temp})
+
+	resp, err := client.ChatWithUsage(context.Background(), "system", "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Text() != "ok" || resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 3 || resp.Model != "gpt-4o-mini-2024" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if _, err := client.ChatWithTools(context.Background(), "system", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, req := range reqs {
+		if req.MaxCompletionTokens != 500 || req.Temperature == nil || *req.Temperature != 0.2 {
+			t.Errorf("request %d: max tokens %d, temperature %v", i, req.MaxCompletionTokens, req.Temperature)
+		}
+	}
+
+	// Without options the defaults are sent and the temperature is left out.
+	client.SetOptions(Options{})
+	if _, err := client.Chat(context.Background(), "system", "hello"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := reqs[len(reqs)-1]; last.MaxCompletionTokens != 4096 || last.Temperature != nil {
+		t.Errorf("default request: max tokens %d, temperature %v", last.MaxCompletionTokens, last.Temperature)
+	}
+}
+
 func TestOpenAIClient_InterfaceCompliance(t *testing.T) {
 	var _ Client = (*OpenAIClient)(nil)
 	var _ ToolClient = (*OpenAIClient)(nil)
+	var _ UsageClient = (*OpenAIClient)(nil)
 }
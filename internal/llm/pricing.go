@@ -0,0 +1,113 @@
+package llm
+
+import "strings"
+
+// Price is what a model charges, in USD per million tokens.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
+// Cost returns the dollar cost of u at this price. Cache reads and writes
+// fall back to the input rate when the model has no separate rate.
+func (p Price) Cost(u Usage) float64 {
+	read, write := p.CacheRead, p.CacheWrite
+	if read == 0 {
+		read = p.Input
+	}
+	if write == 0 {
+		write = p.Input
+	}
+	total := float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*read +
+		float64(u.CacheCreationTokens)*write
+	return total / 1e6
+}
+
+// defaultPrices are list prices keyed by model name or model-name prefix.
+// Smaller and larger siblings of a family ("o3-mini", "o3-pro") need their
+// own entries, or they are priced as the family by prefix.
+var defaultPrices = map[string]Price{
+	// Anthropic
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+
+	// OpenAI
+	"gpt-4o":            {Input: 2.5, Output: 10, CacheRead: 1.25},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.6, CacheRead: 0.075},
+	"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.5},
+	"gpt-4.1-mini":      {Input: 0.4, Output: 1.6, CacheRead: 0.1},
+	"gpt-4.1-nano":      {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"o1":                {Input: 15, Output: 60, CacheRead: 7.5},
+	"o1-mini":           {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+	"o1-pro":            {Input: 150, Output: 600},
+	"o3":                {Input: 2, Output: 8, CacheRead: 0.5},
+	"o3-mini":           {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+	"o3-pro":            {Input: 20, Output: 80},
+	"o4-mini":           {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+	"codex-mini-latest": {Input: 1.5, Output: 6, CacheRead: 0.375},
+	"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.4, CacheRead: 0.005},
+
+	// Gemini
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10, CacheRead: 0.31},
+	"gemini-2.5-flash":      {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+	"gemini-2.5-flash-lite": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"gemini-2.0-flash":      {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+}
+
+// Pricing resolves the price of a provider's model.
+type Pricing struct {
+	prices map[string]Price
+}
+
+// NewPricing returns the default price table with overrides applied on top.
+// Override keys are either "provider/model" or a model name (or prefix).
+func NewPricing(overrides map[string]Price) *Pricing {
+	prices := make(map[string]Price, len(defaultPrices)+len(overrides))
+	for k, v := range defaultPrices {
+		prices[k] = v
+	}
+	for k, v := range overrides {
+		prices[k] = v
+	}
+	return &Pricing{prices: prices}
+}
+
+// Lookup returns the price for model served by provider. It tries
+// "provider/model", then the model name, then the longest known prefix of the
+// model name (so dated snapshots match their family). ok is false for unknown
+// models, which are then treated as free.
+func (p *Pricing) Lookup(provider, model string) (Price, bool) {
+	if price, ok := p.prices[provider+"/"+model]; ok {
+		return price, true
+	}
+	if price, ok := p.prices[model]; ok {
+		return price, true
+	}
+	best := ""
+	for k := range p.prices {
+		if len(k) > len(best) && strings.HasPrefix(model, k) {
+			best = k
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return p.prices[best], true
+}
+
+// Cost returns the dollar cost of u on provider's model.
+func (p *Pricing) Cost(provider, model string, u Usage) float64 {
+	price, _ := p.Lookup(provider, model)
+	return price.Cost(u)
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceCost(t *testing.T) {
+	p := Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	u := Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 2_000_000, CacheCreationTokens: 400_000}
+	// 3 + 1.5 + 0.6 + 1.5
+	if got := p.Cost(u); math.Abs(got-6.6) > 1e-9 {
+		t.Errorf("expected $6.60, got %f", got)
+	}
+
+	// Without cache rates, cached tokens are billed as input.
+	p = Price{Input: 2, Output: 8}
+	u = Usage{CacheReadTokens: 500_000, CacheCreationTokens: 500_000}
+	if got := p.Cost(u); math.Abs(got-2) > 1e-9 {
+		t.Errorf("expected $2.00, got %f", got)
+	}
+}
+
+func TestPricingLookup(t *testing.T) {
+	p := NewPricing(map[string]Price{
+		"openai/gpt-4o":  {Input: 1, Output: 1},
+		"my-local-model": {Input: 0.5, Output: 0.5},
+	})
+
+	tests := []struct {
+		provider, model string
+		want            float64 // input price
+		ok              bool
+	}{
+		{"anthropic", "claude-sonnet-4-20250514", 3, true},
+		{"anthropic", "claude-opus-4-5-20251101", 5, true}, // longest prefix wins over claude-opus-4
+		{"anthropic", "claude-opus-4-20250514", 15, true},
+		{"openai", "gpt-4o", 1, true},         // provider-specific override
+		{"openai", "gpt-4o-mini", 0.15, true}, // not shadowed by the gpt-4o override
+		{"openai-compatible", "gpt-4o", 2.5, true},
+		{"openai", "o3-2025-04-16", 2, true},
+		{"openai", "o3-mini", 1.1, true}, // not priced as o3
+		{"openai", "o3-mini-2025-01-31", 1.1, true},
+		{"openai", "gpt-5-nano-2025-08-07", 0.05, true},
+		{"ollama", "my-local-model", 0.5, true},
+		{"ollama", "llama3", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := p.Lookup(tt.provider, tt.model)
+		if ok != tt.ok || price.Input != tt.want {
+			t.Errorf("Lookup(%q, %q) = %v, %v; want input %v, %v", tt.provider, tt.model, price, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// maxSSELine bounds a single server-sent event line.
+const maxSSELine = 4 * 1024 * 1024
+
+// readSSE reads a server-sent event stream and calls fn with the data of each
+// event. It stops at the OpenAI-style "[DONE]" sentinel or at end of stream.
+func readSSE(r io.Reader, fn func(data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSSELine)
+
+	var data []byte
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		d := data
+		data = nil
+		return fn(d)
+	}
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		payload, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue // event:, id:, retry: and comments
+		}
+		payload = bytes.TrimPrefix(payload, []byte(" "))
+		if string(payload) == "[DONE]" {
+			return nil
+		}
+		if len(data) > 0 {
+			data = append(data, '\n')
+		}
+		data = append(data, payload...)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
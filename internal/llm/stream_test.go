@@ -0,0 +1,347 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+var (
+	_ StreamingToolClient = (*AnthropicClient)(nil)
+	_ StreamingToolClient = (*OpenAIClient)(nil)
+	_ StreamingToolClient = (*GeminiClient)(nil)
+)
+
+// writeSSE writes each event as a server-sent event.
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\n\n", e)
+	}
+}
+
+// streamMatches runs the same call streaming and non-streaming and checks
+// that both return the same Response. It returns the streamed events.
+func streamMatches(t *testing.T, c StreamingToolClient) []StreamEvent {
+	t.Helper()
+	ctx := context.Background()
+	msgs := []ToolMessage{{Role: "user", Content: []ContentBlock{{Type: "text", Text: "hi"}}}}
+	tools := []ToolDef{{Name: "get_status", InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}}}
+
+	want, err := c.ChatWithTools(ctx, "sys", msgs, tools)
+	if err != nil {
+		t.Fatalf("ChatWithTools: %v", err)
+	}
+	var events []StreamEvent
+	got, err := c.ChatWithToolsStream(ctx, "sys", msgs, tools, func(e StreamEvent) { events = append(events, e) })
+	if err != nil {
+		t.Fatalf("ChatWithToolsStream: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		gj, _ := json.Marshal(got)
+		wj, _ := json.Marshal(want)
+		t.Errorf("streamed response differs:\n got %s\nwant %s", gj, wj)
+	}
+	return events
+}
+
+func joinText(events []StreamEvent, typ string) string {
+	var b strings.Builder
+	for _, e := range events {
+		if e.Type == typ {
+			b.WriteString(e.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestReadSSE(t *testing.T) {
+	in := ": comment\nevent: x\ndata: {\"a\":1}\n\ndata: line1\ndata: line2\n\ndata: [DONE]\n\ndata: ignored\n\n"
+	var got []string
+	if err := readSSE(strings.NewReader(in), func(d []byte) error {
+		got = append(got, string(d))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`{"a":1}`, "line1\nline2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAnthropicClient_ChatWithToolsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-test",
+				"content":[{"type":"text","text":"Checking status."},{"type":"tool_use","id":"tu_1","name":"get_status","input":{"verbose":true}}],
+				"stop_reason":"tool_use","usage":{"input_tokens":12,"output_tokens":7,"cache_read_input_tokens":3}}`)
+			return
+		}
+		writeSSE(w,
+			`event: message_start`+"\n"+`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-test","content":[],"stop_reason":null,"usage":{"input_tokens":12,"output_tokens":1,"cache_read_input_tokens":3}}}`,
+			`event: content_block_start`+"\n"+`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`event: content_block_delta`+"\n"+`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}`,
+			`event: content_block_delta`+"\n"+`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"status."}}`,
+			`event: content_block_stop`+"\n"+`data: {"type":"content_block_stop","index":0}`,
+			`event: content_block_start`+"\n"+`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"get_status","input":{}}}`,
+			`event: content_block_delta`+"\n"+`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"verbose\":"}}`,
+			`event: content_block_delta`+"\n"+`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"true}"}}`,
+			`event: content_block_stop`+"\n"+`data: {"type":"content_block_stop","index":1}`,
+			`event: message_delta`+"\n"+`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+			`event: message_stop`+"\n"+`data: {"type":"message_stop"}`,
+		)
+	}))
+	defer server.Close()
+
+	sdk := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	c := &AnthropicClient{client: &sdk, model: "claude-test"}
+
+	events := streamMatches(t, c)
+	if got := joinText(events, StreamText); got != "Checking status." {
+		t.Errorf("text deltas = %q", got)
+	}
+	if got := joinText(events, StreamToolInput); got != `{"verbose":true}` {
+		t.Errorf("tool input deltas = %q", got)
+	}
+	for _, e := range events {
+		if e.Type == StreamToolInput && e.ToolCallID != "tu_1" {
+			t.Errorf("tool input delta without call ID: %+v", e)
+		}
+	}
+}
+
+func TestOpenAIClient_ChatWithToolsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"model":"gpt-test","choices":[{"message":{"role":"assistant","content":"Checking status.",
+				"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_status","arguments":"{\"verbose\":true}"}}]},
+				"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":7,"total_tokens":19}}`)
+			return
+		}
+		if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Error("expected stream_options.include_usage")
+		}
+		writeSSE(w,
+			`data: {"model":"gpt-test","choices":[{"delta":{"role":"assistant","content":"Checking "}}]}`,
+			`data: {"model":"gpt-test","choices":[{"delta":{"content":"status."}}]}`,
+			`data: {"model":"gpt-test","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_status","arguments":""}}]}}]}`,
+			`data: {"model":"gpt-test","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"verbose\":"}}]}}]}`,
+			`data: {"model":"gpt-test","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"true}"}}]}}]}`,
+			`data: {"model":"gpt-test","choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+			`data: {"model":"gpt-test","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":7,"total_tokens":19}}`,
+			`data: [DONE]`,
+		)
+	}))
+	defer server.Close()
+
+	events := streamMatches(t, NewOpenAIClient("k", "gpt-test", server.URL))
+	if got := joinText(events, StreamText); got != "Checking status." {
+		t.Errorf("text deltas = %q", got)
+	}
+	if got := joinText(events, StreamToolInput); got != `{"verbose":true}` {
+		t.Errorf("tool input deltas = %q", got)
+	}
+}
+
+func TestGeminiClient_ChatWithToolsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "streamGenerateContent") {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"modelVersion":"gemini-test","candidates":[{"content":{"role":"model","parts":[
+				{"text":"Checking status."},{"functionCall":{"name":"get_status","args":{"verbose":true}}}]},
+				"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":7,"totalTokenCount":19}}`)
+			return
+		}
+		if r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("expected alt=sse, got %q", r.URL.RawQuery)
+		}
+		writeSSE(w,
+			`data: {"modelVersion":"gemini-test","candidates":[{"content":{"role":"model","parts":[{"text":"Checking "}]}}]}`,
+			`data: {"modelVersion":"gemini-test","candidates":[{"content":{"role":"model","parts":[{"text":"status."}]}}]}`,
+			`data: {"modelVersion":"gemini-test","candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_status","args":{"verbose":true}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":7,"totalTokenCount":19}}`,
+		)
+	}))
+	defer server.Close()
+
+	c := NewGeminiClient("k", "gemini-test", server.URL)
+	events := streamMatches(t, c)
+	if got := joinText(events, StreamText); got != "Checking status." {
+		t.Errorf("text deltas = %q", got)
+	}
+	if len(events) < 2 || events[len(events)-2].Type != StreamToolCall || events[len(events)-2].ToolName != "get_status" {
+		t.Errorf("expected a tool call event, got %+v", events)
+	}
+}
+
+func TestOpenAIClient_ChatWithToolsStream_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"slow down","type":"rate_limit"}}`)
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIClient("k", "gpt-test", server.URL).ChatWithToolsStream(context.Background(), "", nil, nil, func(StreamEvent) {})
+	if err == nil || !IsRetryableError(err) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+}
+
+func TestToolChoice(t *testing.T) {
+	// Each API client sends the forced tool in its own request field.
+	tests := []struct {
+		name   string
+		reply  string
+		client func(url string) ToolClient
+		want   string
+	}{
+		{
+			name:   "anthropic",
+			reply:  `{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`,
+			client: func(url string) ToolClient { return NewAnthropicClient("k", "m", url) },
+			want:   `"tool_choice":{"name":"submit_verdict","type":"tool"}`,
+		},
+		{
+			name:   "openai",
+			reply:  `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`,
+			client: func(url string) ToolClient { return NewOpenAIClient("k", "m", url) },
+			want:   `"tool_choice":{"function":{"name":"submit_verdict"},"type":"function"}`,
+		},
+		{
+			name:   "gemini",
+			reply:  `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`,
+			client: func(url string) ToolClient { return NewGeminiClient("k", "m", url) },
+			want:   `"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["submit_verdict"]}}`,
+		},
+	}
+	for _, tt := range tests {
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, tt.reply)
+		}))
+		c := tt.client(server.URL)
+		tools := []ToolDef{{Name: "submit_verdict", InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}}}
+		if _, err := c.ChatWithTools(context.Background(), "", nil, tools); err != nil {
+			t.Fatalf("%s: ChatWithTools: %v", tt.name, err)
+		}
+		if _, err := c.ChatWithTools(WithToolChoice(context.Background(), "submit_verdict"), "", nil, tools); err != nil {
+			t.Fatalf("%s: forced ChatWithTools: %v", tt.name, err)
+		}
+		server.Close()
+		if strings.Contains(bodies[0], "submit_verdict\"]") || strings.Contains(bodies[0], "tool_choice") {
+			t.Errorf("%s: unforced request chose a tool: %s", tt.name, bodies[0])
+		}
+		if !strings.Contains(bodies[1], tt.want) {
+			t.Errorf("%s: forced request lacks %s: %s", tt.name, tt.want, bodies[1])
+		}
+	}
+}
+
+func TestAnthropicThinking(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"m",
+			"content":[{"type":"thinking","thinking":"Plan first.","signature":"sig_1"},{"type":"redacted_thinking","data":"enc"},
+				{"type":"tool_use","id":"tu_1","name":"get_status","input":{}}],
+			"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	temp := 0.5
+	c := NewAnthropicClient("k", "m", server.URL)
+	c.SetOptions(Options{ReasoningEffort: "medium", MaxTokens: 2000, Temperature: &temp})
+	ctx := WithToolChoice(context.Background(), "get_status")
+	msgs := []ToolMessage{{Role: "user", Content: []ContentBlock{{Type: "text", Text: "hi"}}}}
+	tools := []ToolDef{{Name: "get_status", InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}}}
+
+	resp, err := c.ChatWithTools(ctx, "", msgs, tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ContentBlock{
+		{Type: "thinking", Text: "Plan first.", Signature: "sig_1"},
+		{Type: "redacted_thinking", Data: "enc"},
+	}
+	if !reflect.DeepEqual(resp.Content[:2], want) || resp.Thinking() != "Plan first." {
+		t.Errorf("unexpected content: %+v", resp.Content)
+	}
+	// Thinking takes the effort's budget and raises max_tokens above it; it
+	// rules out a temperature and a forced tool.
+	first := bodies[0]
+	if thinking, _ := first["thinking"].(map[string]any); thinking["budget_tokens"] != 4096.0 || thinking["type"] != "enabled" {
+		t.Errorf("thinking = %v", first["thinking"])
+	}
+	if first["max_tokens"] != 4096.0+8192 || first["temperature"] != nil || first["tool_choice"] != nil {
+		t.Errorf("max_tokens = %v, temperature = %v, tool_choice = %v", first["max_tokens"], first["temperature"], first["tool_choice"])
+	}
+
+	// The signed blocks go back with the tool call they came with.
+	history := append(msgs,
+		ToolMessage{Role: "assistant", Content: resp.Content},
+		ToolMessage{Role: "tool_result", ToolResults: []ToolResult{{ToolCallID: "tu_1", Content: "ok"}}})
+	if _, err := c.ChatWithTools(ctx, "", history, tools); err != nil {
+		t.Fatal(err)
+	}
+	replayed, _ := json.Marshal(bodies[1]["messages"].([]any)[1])
+	if !strings.Contains(string(replayed), `{"signature":"sig_1","thinking":"Plan first.","type":"thinking"}`) ||
+		!strings.Contains(string(replayed), `{"data":"enc","type":"redacted_thinking"}`) {
+		t.Errorf("thinking not replayed: %s", replayed)
+	}
+	if bodies[1]["thinking"] == nil {
+		t.Error("expected thinking to stay on after a signed tool call")
+	}
+
+	// A tool call made without thinking cannot be continued with it.
+	history[1].Content = resp.Content[2:]
+	if _, err := c.ChatWithTools(ctx, "", history, tools); err != nil {
+		t.Fatal(err)
+	}
+	if bodies[2]["thinking"] != nil || bodies[2]["temperature"] != 0.5 || bodies[2]["tool_choice"] == nil || bodies[2]["max_tokens"] != 2000.0 {
+		t.Errorf("expected a plain request, got thinking %v, temperature %v, tool_choice %v, max_tokens %v",
+			bodies[2]["thinking"], bodies[2]["temperature"], bodies[2]["tool_choice"], bodies[2]["max_tokens"])
+	}
+}
+
+func TestReasoningOptions(t *testing.T) {
+	tests := []struct {
+		opts   Options
+		budget int
+		effort string
+	}{
+		{Options{}, 0, ""},
+		{Options{ThinkingBudget: 1000}, 1000, "low"},
+		{Options{ThinkingBudget: 4096}, 4096, "medium"},
+		{Options{ThinkingBudget: 20000}, 20000, "high"},
+		{Options{ReasoningEffort: "high"}, 16384, "high"},
+		{Options{ThinkingBudget: 2000, ReasoningEffort: "low"}, 2000, "low"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.thinkingBudget(); got != tt.budget {
+			t.Errorf("%+v: thinkingBudget = %d, want %d", tt.opts, got, tt.budget)
+		}
+		if got := tt.opts.reasoningEffort(); got != tt.effort {
+			t.Errorf("%+v: reasoningEffort = %q, want %q", tt.opts, got, tt.effort)
+		}
+	}
+}
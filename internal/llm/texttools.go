@@ -0,0 +1,314 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxToolReplyRepairs is how many times a malformed tool-call reply is sent
+// back for another try.
+const maxToolReplyRepairs = 2
+
+// TextToolClient gives a Client without native tool use, such as a CLI, a
+// ChatWithTools. The tools are described in the prompt and the model has to
+// reply with a JSON envelope of tool calls, which is parsed and checked
+// against the tools' input schemas. A reply that does not pass is sent back
+// with what was wrong with it.
+type TextToolClient struct {
+	Client
+}
+
+// NewTextToolClient returns a ToolClient that calls tools through c's text
+// replies.
+func NewTextToolClient(c Client) *TextToolClient {
+	return &TextToolClient{Client: c}
+}
+
+// RetriesInternally implements Retrier.
+func (c *TextToolClient) RetriesInternally() bool {
+	return RetriesInternally(c.Client)
+}
+
+// textEnvelope is the reply a model must give: what it has to say, and the
+// tools it calls. No tool calls ends its turn.
+type textEnvelope struct {
+	Text      string         `json:"text,omitempty"`
+	ToolCalls []textToolCall `json:"tool_calls"`
+}
+
+type textToolCall struct {
+	ID    string          `json:"id,omitempty"` // set on calls shown in the transcript
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// ChatWithTools implements ToolClient.
+func (c *TextToolClient) ChatWithTools(ctx context.Context, systemPrompt string,
+	messages []ToolMessage, tools []ToolDef) (*Response, error) {
+	system := toolProtocolPrompt(systemPrompt, tools)
+	forced := toolChoice(ctx)
+	if forced != "" {
+		system += fmt.Sprintf("\nYour reply must call the %s tool.\n", forced)
+	}
+	prompt := textTranscript(messages)
+
+	for attempt := 0; ; attempt++ {
+		reply, err := c.Client.Chat(ctx, system, prompt)
+		if err != nil {
+			return nil, err
+		}
+		env, err := parseToolEnvelope(reply, tools)
+		if err == nil && forced != "" && !slices.ContainsFunc(env.ToolCalls, func(tc textToolCall) bool { return tc.Name == forced }) {
+			err = fmt.Errorf("the reply must call the %s tool", forced)
+		}
+		if err == nil {
+			return env.response()
+		}
+		if attempt == maxToolReplyRepairs {
+			return nil, fmt.Errorf("no valid tool-call reply after %d attempts: %w", attempt+1, err)
+		}
+		prompt += fmt.Sprintf("[assistant]:\n%s\n\n[system]:\nYour reply could not be used: %v. "+
+			"Reply again with only the JSON object the tool protocol describes.\n\n", reply, err)
+	}
+}
+
+// response converts a checked envelope into our Response type, giving each
+// tool call a new ID.
+func (e *textEnvelope) response() (*Response, error) {
+	resp := &Response{StopReason: "end_turn"}
+	if e.Text != "" {
+		resp.Content = append(resp.Content, ContentBlock{Type: "text", Text: e.Text})
+	}
+	for _, tc := range e.ToolCalls {
+		id, err := newToolCallID()
+		if err != nil {
+			return nil, err
+		}
+		resp.Content = append(resp.Content, ContentBlock{
+			Type:     "tool_use",
+			ToolCall: &ToolCall{ID: id, Name: tc.Name, Input: tc.Input},
+		})
+		resp.StopReason = "tool_use"
+	}
+	return resp, nil
+}
+
+func newToolCallID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("tool call ID: %w", err)
+	}
+	return "call_" + hex.EncodeToString(b[:]), nil
+}
+
+// toolProtocolPrompt appends the tool protocol and the tools to the system
+// prompt.
+func toolProtocolPrompt(systemPrompt string, tools []ToolDef) string {
+	var b strings.Builder
+	if systemPrompt != "" {
+		b.WriteString(systemPrompt)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(`## Tool protocol
+
+You act by calling tools. Reply with exactly one JSON object and nothing else, no prose and no code fences:
+
+{"text": "<optional note on what you are doing>", "tool_calls": [{"name": "<tool name>", "input": {<arguments matching the tool's input schema>}}]}
+
+List several tool calls to make independent calls at once. To end your turn without calling a tool, reply with an empty "tool_calls" list. The results of your calls are given to you in the next message as [tool_result] entries.
+
+## Tools
+`)
+	for _, td := range tools {
+		schema, _ := json.Marshal(td.InputSchema)
+		fmt.Fprintf(&b, "\n### %s\n%s\nInput schema: %s\n", td.Name, td.Description, schema)
+	}
+	return b.String()
+}
+
+// textTranscript renders a tool-use history as one prompt. Assistant turns
+// are shown as the envelopes they stand for, with their call IDs, so results
+// can be matched to calls.
+func textTranscript(messages []ToolMessage) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		switch msg.Role {
+		case "assistant":
+			var env textEnvelope
+			var text []string
+			for _, block := range msg.Content {
+				switch {
+				case block.Type == "text" && block.Text != "":
+					text = append(text, block.Text)
+				case block.Type == "tool_use" && block.ToolCall != nil:
+					env.ToolCalls = append(env.ToolCalls, textToolCall{
+						ID: block.ToolCall.ID, Name: block.ToolCall.Name, Input: block.ToolCall.Input,
+					})
+				}
+			}
+			env.Text = strings.Join(text, "\n")
+			if env.ToolCalls == nil {
+				env.ToolCalls = []textToolCall{}
+			}
+			out, _ := json.Marshal(env)
+			fmt.Fprintf(&b, "[assistant]:\n%s\n\n", out)
+		default:
+			for _, block := range msg.Content {
+				if block.Type == "text" && block.Text != "" {
+					fmt.Fprintf(&b, "[%s]:\n%s\n\n", msg.Role, block.Text)
+				}
+			}
+		}
+		for _, r := range msg.ToolResults {
+			status := ""
+			if r.IsError {
+				status = " error"
+			}
+			fmt.Fprintf(&b, "[tool_result %s%s]:\n%s\n\n", r.ToolCallID, status, r.Content)
+		}
+	}
+	return b.String()
+}
+
+// parseToolEnvelope parses a reply as a tool-call envelope and checks each
+// call against its tool's input schema. A code fence around the object is
+// tolerated; anything else around it is not.
+func parseToolEnvelope(reply string, tools []ToolDef) (*textEnvelope, error) {
+	s := strings.TrimSpace(reply)
+	if rest, ok := strings.CutPrefix(s, "```"); ok {
+		rest = strings.TrimPrefix(rest, "json")
+		if body, ok := strings.CutSuffix(strings.TrimSpace(rest), "```"); ok {
+			s = strings.TrimSpace(body)
+		}
+	}
+	if !strings.HasPrefix(s, "{") {
+		return nil, fmt.Errorf("reply is not a JSON object")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	var env textEnvelope
+	if err := dec.Decode(&env); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("text after the JSON object")
+	}
+
+	for i, tc := range env.ToolCalls {
+		idx := slices.IndexFunc(tools, func(td ToolDef) bool { return td.Name == tc.Name })
+		if idx < 0 {
+			return nil, fmt.Errorf("tool_calls[%d]: unknown tool %q", i, tc.Name)
+		}
+		input := bytes.TrimSpace(tc.Input)
+		if len(input) == 0 || string(input) == "null" {
+			input = []byte("{}")
+		}
+		var v any
+		if err := json.Unmarshal(input, &v); err != nil {
+			return nil, fmt.Errorf("tool_calls[%d] (%s): invalid input: %w", i, tc.Name, err)
+		}
+		if err := checkSchema(tools[idx].InputSchema, v, "input"); err != nil {
+			return nil, fmt.Errorf("tool_calls[%d] (%s): %w", i, tc.Name, err)
+		}
+		env.ToolCalls[i].Input = json.RawMessage(input)
+	}
+	return &env, nil
+}
+
+// checkSchema checks v against the parts of a JSON schema tools use: type,
+// enum, required and properties of objects, and items of arrays.
+func checkSchema(schema map[string]any, v any, path string) error {
+	if schema == nil {
+		return nil
+	}
+	if typ, _ := schema["type"].(string); typ != "" && !hasJSONType(v, typ) {
+		return fmt.Errorf("%s: want %s, got %s", path, typ, jsonType(v))
+	}
+	if enum := stringList(schema["enum"]); len(enum) > 0 {
+		if s, ok := v.(string); ok && !slices.Contains(enum, s) {
+			return fmt.Errorf("%s: %q is not one of %s", path, s, strings.Join(enum, ", "))
+		}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range stringList(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, val := range v {
+			if sub, ok := props[name].(map[string]any); ok {
+				if err := checkSchema(sub, val, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, val := range v {
+				if err := checkSchema(items, val, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasJSONType reports whether a decoded JSON value is of a schema type.
+func hasJSONType(v any, typ string) bool {
+	switch typ {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	default:
+		return jsonType(v) == typ
+	}
+}
+
+// jsonType names the schema type of a decoded JSON value.
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// stringList returns a schema's list of strings, as written in Go or decoded
+// from JSON.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
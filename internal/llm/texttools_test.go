@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// replyClient answers chat calls with its replies in turn and records the
+// prompts it gets.
+type replyClient struct {
+	replies []string
+	prompts []string
+	systems []string
+}
+
+func (c *replyClient) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	c.systems = append(c.systems, systemPrompt)
+	c.prompts = append(c.prompts, userMessage)
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+func (c *replyClient) ChatWithHistory(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	return c.Chat(ctx, systemPrompt, "")
+}
+
+var textTestTools = []ToolDef{
+	{
+		Name:        "assign_task",
+		Description: "Assign a task to a worker",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task_id":  map[string]interface{}{"type": "string"},
+				"priority": map[string]interface{}{"type": "integer"},
+				"kind":     map[string]interface{}{"type": "string", "enum": []string{"code", "test"}},
+				"paths":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+			"required": []string{"task_id"},
+		},
+	},
+	{Name: "get_status", Description: "Show status", InputSchema: map[string]interface{}{"type": "object"}},
+}
+
+func TestTextToolClient(t *testing.T) {
+	inner := &replyClient{replies: []string{
+		"```json\n" + `{"text": "Assigning.", "tool_calls": [{"name": "assign_task", "input": {"task_id": "t1", "paths": ["a.go"]}}, {"name": "get_status"}]}` + "\n```",
+	}}
+	c := NewTextToolClient(inner)
+	var _ ToolClient = c
+
+	history := []ToolMessage{
+		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Objective: build it"}}},
+		{Role: "assistant", Content: []ContentBlock{{Type: "tool_use", ToolCall: &ToolCall{ID: "call_1", Name: "get_status", Input: json.RawMessage(`{}`)}}}},
+		{Role: "user", ToolResults: []ToolResult{{ToolCallID: "call_1", Content: "0 tasks", IsError: true}}},
+	}
+	resp, err := c.ChatWithTools(context.Background(), "You are the Queen.", history, textTestTools)
+	if err != nil {
+		t.Fatalf("ChatWithTools: %v", err)
+	}
+	if resp.StopReason != "tool_use" || len(resp.Content) != 3 || resp.Text() != "Assigning." {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	first, second := resp.Content[1].ToolCall, resp.Content[2].ToolCall
+	if first.Name != "assign_task" || string(first.Input) != `{"task_id": "t1", "paths": ["a.go"]}` {
+		t.Errorf("unexpected first call: %+v", first)
+	}
+	if second.Name != "get_status" || string(second.Input) != "{}" {
+		t.Errorf("expected a missing input to become {}, got %+v", second)
+	}
+	if first.ID == "" || first.ID == second.ID {
+		t.Errorf("expected distinct call IDs, got %q and %q", first.ID, second.ID)
+	}
+
+	system, prompt := inner.systems[0], inner.prompts[0]
+	for _, want := range []string{"You are the Queen.", "## Tool protocol", "### assign_task", `"required":["task_id"]`} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system prompt lacks %q", want)
+		}
+	}
+	for _, want := range []string{"[user]:\nObjective: build it", `{"tool_calls":[{"id":"call_1","name":"get_status","input":{}}]}`, "[tool_result call_1 error]:\n0 tasks"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("transcript lacks %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestTextToolClient_Repairs(t *testing.T) {
+	inner := &replyClient{replies: []string{
+		"I'll assign t1 now.",
+		`{"tool_calls": [{"name": "assign_task", "input": {"task_id": 7}}]}`,
+		`{"tool_calls": [], "text": "All done."}`,
+	}}
+	resp, err := NewTextToolClient(inner).ChatWithTools(context.Background(), "", nil, textTestTools)
+	if err != nil {
+		t.Fatalf("ChatWithTools: %v", err)
+	}
+	if resp.StopReason != "end_turn" || resp.Text() != "All done." {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(inner.prompts) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(inner.prompts))
+	}
+	if last := inner.prompts[2]; !strings.Contains(last, "not a JSON object") || !strings.Contains(last, "input.task_id: want string, got number") {
+		t.Errorf("expected both problems reported back, got:\n%s", last)
+	}
+
+	inner = &replyClient{replies: []string{"no", "still no", "nope"}}
+	if _, err := NewTextToolClient(inner).ChatWithTools(context.Background(), "", nil, textTestTools); err == nil ||
+		!strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("expected to give up after 3 attempts, got %v", err)
+	}
+}
+
+func TestParseToolEnvelope(t *testing.T) {
+	tests := []struct {
+		reply, err string
+	}{
+		{`{"tool_calls": [{"name": "assign_task", "input": {"task_id": "t1", "priority": 2, "kind": "code"}}]}`, ""},
+		{`{"tool_calls": [{"name": "rm_rf", "input": {}}]}`, `unknown tool "rm_rf"`},
+		{`{"tool_calls": [{"name": "assign_task", "input": {}}]}`, `missing required field "task_id"`},
+		{`{"tool_calls": [{"name": "assign_task", "input": {"task_id": "t1", "priority": 1.5}}]}`, "input.priority: want integer"},
+		{`{"tool_calls": [{"name": "assign_task", "input": {"task_id": "t1", "kind": "docs"}}]}`, `"docs" is not one of code, test`},
+		{`{"tool_calls": [{"name": "assign_task", "input": {"task_id": "t1", "paths": [1]}}]}`, "input.paths[0]: want string"},
+		{`{"tool_calls": [{"name": "assign_task", "input": "t1"}]}`, "input: want object, got string"},
+		{`{"calls": []}`, "invalid JSON"},
+		{`{"tool_calls": []} and more`, "text after the JSON object"},
+	}
+	for _, tt := range tests {
+		_, err := parseToolEnvelope(tt.reply, textTestTools)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.reply, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.reply, tt.err, err)
+		}
+	}
+}
+
+func TestTextToolClient_ToolChoice(t *testing.T) {
+	inner := &replyClient{replies: []string{
+		`{"tool_calls": [], "text": "Looks fine."}`,
+		`{"tool_calls": [{"name": "get_status"}]}`,
+	}}
+	ctx := WithToolChoice(context.Background(), "get_status")
+	resp, err := NewTextToolClient(inner).ChatWithTools(ctx, "", nil, textTestTools)
+	if err != nil {
+		t.Fatalf("ChatWithTools: %v", err)
+	}
+	if resp.StopReason != "tool_use" || resp.Content[0].ToolCall.Name != "get_status" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if !strings.Contains(inner.systems[0], "must call the get_status tool") {
+		t.Errorf("expected the forced tool in the system prompt:\n%s", inner.systems[0])
+	}
+	if !strings.Contains(inner.prompts[1], "must call the get_status tool") {
+		t.Errorf("expected a reply without the tool to be sent back:\n%s", inner.prompts[1])
+	}
+}
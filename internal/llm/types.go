@@ -1,6 +1,9 @@
 package llm
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // ToolDef defines a tool the LLM can call.
 type ToolDef struct {
@@ -24,12 +27,20 @@ type ToolResult struct {
 	IsError    bool   `json:"is_error,omitempty"`
 }
 
-// ContentBlock is a single block in a message (text or tool_use).
+// ContentBlock is a single block in a message (text, tool_use, or the
+// model's reasoning: thinking or redacted_thinking).
 type ContentBlock struct {
-	Type     string    `json:"type"` // "text" or "tool_use"
+	Type     string    `json:"type"` // "text", "tool_use", "thinking" or "redacted_thinking"
 	Text     string    `json:"text,omitempty"`
 	ToolCall *ToolCall `json:"tool_call,omitempty"`
 	Cache    bool      `json:"cache,omitempty"`
+
+	// Signature is the provider's signature over a thinking block (Anthropic)
+	// or the thought signature of a part (Gemini). It must be sent back
+	// unchanged with the block in later turns.
+	Signature string `json:"signature,omitempty"`
+	// Data is the encrypted content of a redacted_thinking block.
+	Data string `json:"data,omitempty"`
 }
 
 // Usage tracks token consumption for an LLM call.
@@ -38,6 +49,9 @@ type Usage struct {
 	OutputTokens        int `json:"output_tokens"`
 	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
 	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`
+	// ThinkingTokens is the part of OutputTokens spent reasoning, when the
+	// provider reports it.
+	ThinkingTokens int `json:"thinking_tokens,omitempty"`
 }
 
 // Response is the LLM's response, possibly containing tool calls.
@@ -46,6 +60,30 @@ type Response struct {
 	StopReason string         `json:"stop_reason"` // "end_turn", "tool_use", "max_tokens"
 	Usage      Usage          `json:"usage"`
 	Model      string         `json:"model,omitempty"`
+	Provider   string         `json:"provider,omitempty"` // set when a FailoverClient served the call
+}
+
+// Text returns the response's text blocks joined together.
+func (r *Response) Text() string {
+	var out strings.Builder
+	for _, b := range r.Content {
+		if b.Type == "text" {
+			out.WriteString(b.Text)
+		}
+	}
+	return out.String()
+}
+
+// Thinking returns the response's readable reasoning, the text of its
+// thinking blocks joined together.
+func (r *Response) Thinking() string {
+	var out strings.Builder
+	for _, b := range r.Content {
+		if b.Type == "thinking" {
+			out.WriteString(b.Text)
+		}
+	}
+	return out.String()
 }
 
 // ToolMessage is a rich message that can contain text, tool calls, or tool results.
@@ -53,4 +91,38 @@ type ToolMessage struct {
 	Role        string         `json:"role"` // "user", "assistant", "tool_result"
 	Content     []ContentBlock `json:"content,omitempty"`
 	ToolResults []ToolResult   `json:"tool_results,omitempty"`
+
+	// Turn records which model produced an assistant message and what it
+	// cost. It is kept with the history but never sent to a provider.
+	Turn *TurnUsage `json:"turn,omitempty"`
+}
+
+// TurnUsage is the provider, model and token usage of one LLM call.
+type TurnUsage struct {
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Usage    Usage  `json:"usage"`
+}
+
+// Stream event types.
+const (
+	// StreamStart begins a (re)try of a streamed call. Consumers should drop
+	// any partial output received before it.
+	StreamStart = "start"
+	// StreamText carries a text delta.
+	StreamText = "text"
+	// StreamThinking carries a delta of the model's reasoning.
+	StreamThinking = "thinking"
+	// StreamToolCall announces a tool call (ToolCallID, ToolName).
+	StreamToolCall = "tool_call"
+	// StreamToolInput carries a fragment of a tool call's JSON input.
+	StreamToolInput = "tool_input"
+)
+
+// StreamEvent is an incremental piece of a streamed response.
+type StreamEvent struct {
+	Type       string `json:"type"`
+	Text       string `json:"text,omitempty"` // text or thinking delta, or partial tool input
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
 }
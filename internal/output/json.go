@@ -30,6 +30,10 @@ const (
 	EventWorkerOutput EventType = "worker_output"
 	// EventQueenDecision is emitted when the queen makes a decision.
 	EventQueenDecision EventType = "queen_decision"
+	// EventQueenDelta is emitted for each fragment of a streamed queen response.
+	EventQueenDelta EventType = "queen_delta"
+	// EventQuestion is emitted when the queen waits for an operator answer.
+	EventQuestion EventType = "question"
 	// EventError is emitted when an error occurs.
 	EventError EventType = "error"
 )
@@ -47,6 +51,7 @@ type TaskEvent struct {
 	DependsOn   []string    `json:"depends_on,omitempty"`
 	MaxRetries  int         `json:"max_retries,omitempty"`
 	RetryCount  int         `json:"retry_count,omitempty"`
+	Attempts    int         `json:"attempts,omitempty"`
 	LastError   string      `json:"last_error,omitempty"`
 	CreatedAt   *time.Time  `json:"created_at,omitempty"`
 	StartedAt   *time.Time  `json:"started_at,omitempty"`
@@ -74,6 +79,7 @@ type SessionSummary struct {
 	Iterations     int           `json:"iterations"`
 	Duration       time.Duration `json:"duration_ms"`
 	Tasks          []TaskEvent   `json:"tasks,omitempty"`
+	Graph          *task.DAG     `json:"graph,omitempty"`
 }
 
 // JSONEvent is the wrapper for all JSON output events.
@@ -276,6 +282,52 @@ func (jw *JSONWriter) WriteQueenDecision(phase, message string, data map[string]
 	return jw.writeEvent(event)
 }
 
+// WriteQueenDelta emits a fragment of the queen's streamed LLM response.
+// kind is "start" (a new attempt; discard earlier fragments), "thinking",
+// "text", "tool_call" or "tool_input".
+func (jw *JSONWriter) WriteQueenDelta(kind, text, toolCallID, toolName string) error {
+	data := map[string]interface{}{"kind": kind}
+	if toolCallID != "" {
+		data["tool_call_id"] = toolCallID
+	}
+	if toolName != "" {
+		data["tool_name"] = toolName
+	}
+	event := JSONEvent{
+		Type:    EventQueenDelta,
+		Message: text,
+		Data:    data,
+	}
+	return jw.writeEvent(event)
+}
+
+// WriteQuestion emits a question the session is waiting on: an approval gate
+// (kind "plan", "assign" or "complete") or an ask_user question (kind "ask").
+// The session resumes once an answer is written to answerFile, e.g. with
+// `waggle answer`.
+func (jw *JSONWriter) WriteQuestion(id, kind, prompt, details, taskID string, options []string, answerFile string) error {
+	data := map[string]interface{}{
+		"id":          id,
+		"kind":        kind,
+		"answer_file": answerFile,
+	}
+	if details != "" {
+		data["details"] = details
+	}
+	if taskID != "" {
+		data["task_id"] = taskID
+	}
+	if len(options) > 0 {
+		data["options"] = options
+	}
+	event := JSONEvent{
+		Type:    EventQuestion,
+		Message: prompt,
+		Data:    data,
+	}
+	return jw.writeEvent(event)
+}
+
 // GetTaskEvents returns all tracked task events.
 func (jw *JSONWriter) GetTaskEvents() []TaskEvent {
 	jw.mu.Lock()
@@ -178,9 +178,12 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 	q.Printer().Header("Waggle Agent Mode")
 	q.Printer().Info("Objective: %s", objective)
 
-	// Preflight: verify and configure adapters
-	if err := q.setupAdapters(ctx); err != nil {
-		return err
+	// Preflight: verify and configure adapters (a child Queen shares its
+	// parent's, already configured)
+	if q.parent == nil {
+		if err := q.setupAdapters(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Create DB session
@@ -188,6 +191,7 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 	if err := q.db.CreateSession(ctx, q.sessionID, objective); err != nil {
 		q.logger.Printf("⚠ DB: failed to create session: %v", err)
 	}
+	q.linkParent(ctx)
 
 	// Build initial conversation
 	messages := []llm.ToolMessage{{
@@ -221,14 +225,26 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 
 		q.Printer().Section(fmt.Sprintf("Agent Turn %d/%d", turn+1, maxTurns))
 
+		// Apply operator edits (CLI/TUI) queued since the last turn
+		messages = q.injectControlNotes(ctx, messages)
+
+		// Enforce spending limits: warn, then ask to wrap up, then stop
+		var budgetErr error
+		if messages, budgetErr = q.checkBudget(messages); budgetErr != nil {
+			if err := q.db.UpdateSessionStatus(ctx, q.sessionID, "failed"); err != nil {
+				q.logger.Printf("⚠ Warning: failed to update session status: %v", err)
+			}
+			q.logRunSummary(totalUsage, toolTimings)
+			return budgetErr
+		}
+
 		// Repair history before sending to LLM
 		messages = repairToolHistory(messages)
+		sent := len(messages)
 
 		// Call LLM with tools (with retry)
 		cachedTools, cachedMsgs := applyCacheHints(tools, messages)
-		resp, err := llm.RetryLLMCall(ctx, 3, q.logger, func() (*llm.Response, error) {
-			return toolClient.ChatWithTools(ctx, systemPrompt, cachedMsgs, cachedTools)
-		})
+		resp, err := q.chatWithTools(ctx, toolClient, systemPrompt, cachedMsgs, cachedTools)
 		if err != nil {
 			return fmt.Errorf("queen LLM call failed: %w", err)
 		}
@@ -238,6 +254,8 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 		totalUsage.OutputTokens += resp.Usage.OutputTokens
 		totalUsage.CacheCreationTokens += resp.Usage.CacheCreationTokens
 		totalUsage.CacheReadTokens += resp.Usage.CacheReadTokens
+		totalUsage.ThinkingTokens += resp.Usage.ThinkingTokens
+		q.recordTurnCost(ctx, turn, resp)
 		q.Printer().Debug("Tokens: %d in / %d out", resp.Usage.InputTokens, resp.Usage.OutputTokens)
 
 		// Handle max_tokens truncation: do NOT append the truncated response
@@ -257,9 +275,11 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 		messages = append(messages, llm.ToolMessage{
 			Role:    "assistant",
 			Content: resp.Content,
+			Turn:    q.turnUsage(purposeAgent, resp),
 		})
 
-		// Log any text output from the Queen
+		// Log the Queen's reasoning and any text output
+		q.logReasoning(resp)
 		for _, block := range resp.Content {
 			if block.Type == "text" && block.Text != "" {
 				if !q.quiet {
@@ -290,27 +310,13 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 		var completed bool
 		var failReason string
 
-		for _, block := range resp.Content {
-			if block.Type != "tool_use" || block.ToolCall == nil {
-				continue
-			}
-
-			tc := block.ToolCall
-
-			toolStart := time.Now()
-			result, toolErr := q.executeTool(ctx, tc)
-			toolDuration := time.Since(toolStart)
+		for _, run := range q.runToolCalls(ctx, toolCalls(resp.Content)) {
+			tc, result, toolErr := run.call, run.output, run.err
 
 			if !q.quiet {
-				q.Printer().Debug("Tool: %s (%s)", tc.Name, toolDuration.Round(time.Millisecond))
-			}
-
-			if tt, ok := toolTimings[tc.Name]; ok {
-				tt.calls++
-				tt.totalDur += toolDuration
-			} else {
-				toolTimings[tc.Name] = &toolTiming{calls: 1, totalDur: toolDuration}
+				q.Printer().Debug("Tool: %s (%s)", tc.Name, run.dur.Round(time.Millisecond))
 			}
+			recordToolTiming(toolTimings, tc.Name, run.dur)
 
 			isError := toolErr != nil
 			var content string
@@ -381,10 +387,8 @@ func (q *Queen) RunAgent(ctx context.Context, objective string) error {
 			return fmt.Errorf("queen declared failure: %s", failReason)
 		}
 
-		// Context window management: compact if conversation is getting long
-		if len(messages) > 80 {
-			messages = q.compactMessages(messages)
-		}
+		// Context window management: compact as the prompt nears the model's limit
+		messages = q.maybeCompact(ctx, messages, turn, resp.Usage, sent)
 	}
 
 	q.logRunSummary(totalUsage, toolTimings)
@@ -416,6 +420,11 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 		return q.Run(ctx, objective)
 	}
 
+	// Compacted-away messages stay in the table; the live ones are rewritten after them
+	if q.msgSeq, err = q.db.ResetLiveMessages(ctx, sessionID); err != nil {
+		q.logger.Printf("⚠ Warning: failed to reset live messages: %v", err)
+	}
+
 	if !q.quiet {
 		q.Printer().Info("Resuming agent session %s with %d messages", sessionID, len(messages))
 	}
@@ -426,8 +435,8 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 		Role: "user",
 		Content: []llm.ContentBlock{{
 			Type: "text",
-			Text: fmt.Sprintf("Session resumed. Current task status:\n%s\n\nContinue working on the objective: %s",
-				statusOutput.LLMContent, objective),
+			Text: fmt.Sprintf("Session resumed. Current task status:\n%s%s\n\nContinue working on the objective: %s",
+				statusOutput.LLMContent, q.resumeAttemptNote(ctx), objective),
 		}},
 	})
 
@@ -446,13 +455,25 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 		default:
 		}
 
+		// Apply operator edits (CLI/TUI) queued since the last turn
+		messages = q.injectControlNotes(ctx, messages)
+
+		// Enforce spending limits: warn, then ask to wrap up, then stop
+		var budgetErr error
+		if messages, budgetErr = q.checkBudget(messages); budgetErr != nil {
+			if err := q.db.UpdateSessionStatus(ctx, q.sessionID, "failed"); err != nil {
+				q.logger.Printf("⚠ Warning: failed to update session status: %v", err)
+			}
+			q.logRunSummary(totalUsage, toolTimings)
+			return budgetErr
+		}
+
 		// Repair history before sending to LLM
 		messages = repairToolHistory(messages)
+		sent := len(messages)
 
 		cachedTools, cachedMsgs := applyCacheHints(tools, messages)
-		resp, err := llm.RetryLLMCall(ctx, 3, q.logger, func() (*llm.Response, error) {
-			return toolClient.ChatWithTools(ctx, systemPrompt, cachedMsgs, cachedTools)
-		})
+		resp, err := q.chatWithTools(ctx, toolClient, systemPrompt, cachedMsgs, cachedTools)
 		if err != nil {
 			return fmt.Errorf("queen LLM call failed: %w", err)
 		}
@@ -462,6 +483,8 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 		totalUsage.OutputTokens += resp.Usage.OutputTokens
 		totalUsage.CacheCreationTokens += resp.Usage.CacheCreationTokens
 		totalUsage.CacheReadTokens += resp.Usage.CacheReadTokens
+		totalUsage.ThinkingTokens += resp.Usage.ThinkingTokens
+		q.recordTurnCost(ctx, turn, resp)
 		if !q.quiet {
 			q.Printer().Debug("Tokens: %d in / %d out", resp.Usage.InputTokens, resp.Usage.OutputTokens)
 		}
@@ -484,8 +507,10 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 		messages = append(messages, llm.ToolMessage{
 			Role:    "assistant",
 			Content: resp.Content,
+			Turn:    q.turnUsage(purposeAgent, resp),
 		})
 
+		q.logReasoning(resp)
 		for _, block := range resp.Content {
 			if block.Type == "text" && block.Text != "" {
 				if !q.quiet {
@@ -508,25 +533,13 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 		var toolResults []llm.ToolResult
 		var completed bool
 
-		for _, block := range resp.Content {
-			if block.Type != "tool_use" || block.ToolCall == nil {
-				continue
-			}
-
-			toolStart := time.Now()
-			result, toolErr := q.executeTool(ctx, block.ToolCall)
-			toolDuration := time.Since(toolStart)
+		for _, run := range q.runToolCalls(ctx, toolCalls(resp.Content)) {
+			tc, result, toolErr := run.call, run.output, run.err
 
 			if !q.quiet {
-				q.Printer().Debug("Tool: %s (%s)", block.ToolCall.Name, toolDuration.Round(time.Millisecond))
-			}
-
-			if tt, ok := toolTimings[block.ToolCall.Name]; ok {
-				tt.calls++
-				tt.totalDur += toolDuration
-			} else {
-				toolTimings[block.ToolCall.Name] = &toolTiming{calls: 1, totalDur: toolDuration}
+				q.Printer().Debug("Tool: %s (%s)", tc.Name, run.dur.Round(time.Millisecond))
 			}
+			recordToolTiming(toolTimings, tc.Name, run.dur)
 
 			isError := toolErr != nil
 			var content string
@@ -537,12 +550,12 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 			}
 
 			toolResults = append(toolResults, llm.ToolResult{
-				ToolCallID: block.ToolCall.ID,
+				ToolCallID: tc.ID,
 				Content:    content,
 				IsError:    isError,
 			})
 
-			if block.ToolCall.Name == "complete" && !isError {
+			if tc.Name == "complete" && !isError {
 				completed = true
 			}
 		}
@@ -566,9 +579,7 @@ func (q *Queen) RunAgentResume(ctx context.Context, sessionID string) error {
 			return nil
 		}
 
-		if len(messages) > 80 {
-			messages = q.compactMessages(messages)
-		}
+		messages = q.maybeCompact(ctx, messages, turn, resp.Usage, sent)
 	}
 
 	q.logRunSummary(totalUsage, toolTimings)
@@ -587,12 +598,18 @@ func (q *Queen) SupportsAgentMode() bool {
 	return ok
 }
 
-// persistMessages saves individual messages to the messages table.
+// persistMessages saves individual messages to the messages table, with the
+// provider, model and usage of each assistant turn as its usage data.
 func (q *Queen) persistMessages(ctx context.Context, messages []llm.ToolMessage) {
 	for i, msg := range messages {
 		content, _ := json.Marshal(msg)
-		if err := q.db.AppendMessage(ctx, q.sessionID, i, msg.Role, string(content), ""); err != nil {
-			q.logger.Printf("⚠ Warning: failed to persist message %d: %v", i, err)
+		usage := ""
+		if msg.Turn != nil {
+			b, _ := json.Marshal(msg.Turn)
+			usage = string(b)
+		}
+		if err := q.db.AppendMessage(ctx, q.sessionID, q.msgSeq+i, msg.Role, string(content), usage); err != nil {
+			q.logger.Printf("⚠ Warning: failed to persist message %d: %v", q.msgSeq+i, err)
 		}
 	}
 }
@@ -662,116 +679,22 @@ func (q *Queen) loadConversationLegacy(ctx context.Context, sessionID string) ([
 	return messages, nil
 }
 
-// compactMessages reduces the conversation size by summarizing older turns.
-// Keeps the first message (objective) and the last N messages intact.
-// Never splits tool_use/tool_result pairs.
-func (q *Queen) compactMessages(messages []llm.ToolMessage) []llm.ToolMessage {
-	keepLast := 20
-
-	if len(messages) <= keepLast+2 {
-		return messages
-	}
-
-	// Find a safe cut point that doesn't split tool_use/tool_result pairs.
-	idealCut := len(messages) - keepLast
-	cutPoint := idealCut
-	for cutPoint > 1 {
-		msg := messages[cutPoint]
-		// Don't start the kept section with a tool_result
-		if msg.Role == "tool_result" || len(msg.ToolResults) > 0 {
-			cutPoint--
-			continue
-		}
-		// Don't cut right after an assistant message with tool_use
-		if cutPoint > 0 {
-			prev := messages[cutPoint-1]
-			if prev.Role == "assistant" && hasToolUse(prev) {
-				cutPoint--
-				continue
-			}
-		}
-		break
-	}
-	if cutPoint <= 1 {
-		// Scan forward from idealCut to find a safe boundary
-		cutPoint = idealCut
-		for cutPoint < len(messages)-1 {
-			msg := messages[cutPoint]
-			if msg.Role == "tool_result" || len(msg.ToolResults) > 0 {
-				cutPoint++
-				continue
-			}
-			if cutPoint > 0 {
-				prev := messages[cutPoint-1]
-				if prev.Role == "assistant" && hasToolUse(prev) {
-					cutPoint++
-					continue
-				}
-			}
-			break
-		}
-	}
-
-	// Build summary of compacted section
-	var summary strings.Builder
-	summary.WriteString("[Conversation history compacted. Summary of earlier turns:]\n")
-
-	toolCallCount := 0
-	for _, msg := range messages[1:cutPoint] {
-		for _, block := range msg.Content {
-			if block.Type == "tool_use" && block.ToolCall != nil {
-				toolCallCount++
-				summary.WriteString(fmt.Sprintf("- Called %s\n", block.ToolCall.Name))
-			}
-			if block.Type == "text" && block.Text != "" && msg.Role == "assistant" {
-				text := block.Text
-				if len(text) > 150 {
-					text = text[:150] + "..."
-				}
-				summary.WriteString(fmt.Sprintf("- Queen said: %s\n", text))
-			}
-		}
-	}
-	summary.WriteString(fmt.Sprintf("\n[%d tool calls in compacted section]\n", toolCallCount))
-
-	compacted := make([]llm.ToolMessage, 0, len(messages)-cutPoint+2)
-	compacted = append(compacted, messages[0]) // objective
-	compacted = append(compacted, llm.ToolMessage{
-		Role: "user",
-		Content: []llm.ContentBlock{{
-			Type: "text",
-			Text: summary.String(),
-		}},
-	})
-	compacted = append(compacted, messages[cutPoint:]...)
-
-	// Verify: the first kept message after the summary must not be tool_result
-	if len(compacted) > 2 {
-		if compacted[2].Role == "tool_result" || len(compacted[2].ToolResults) > 0 {
-			q.logger.Printf("⚠ compactMessages: first kept message is tool_result, adjusting")
-			// Skip tool_result messages at the start of the kept section
-			idx := 2
-			for idx < len(compacted) && (compacted[idx].Role == "tool_result" || len(compacted[idx].ToolResults) > 0) {
-				idx++
-			}
-			compacted = append(compacted[:2], compacted[idx:]...)
-		}
+// logReasoning shows the reasoning of a Queen response, one "💭" log line
+// per line for the TUI's Queen panel, or as debug output with a printer.
+func (q *Queen) logReasoning(resp *llm.Response) {
+	thinking := strings.TrimSpace(resp.Thinking())
+	if thinking == "" || q.quiet {
+		return
 	}
-
-	if !q.quiet {
-		q.Printer().Info("Compacted conversation: %d → %d messages", len(messages), len(compacted))
+	if q.printer != nil {
+		q.Printer().Debug("Queen reasoning: %s", thinking)
+		return
 	}
-	return compacted
-}
-
-// hasToolUse returns true if the message contains any tool_use content blocks.
-func hasToolUse(msg llm.ToolMessage) bool {
-	for _, block := range msg.Content {
-		if block.Type == "tool_use" {
-			return true
+	for line := range strings.Lines(thinking) {
+		if line = strings.TrimSpace(line); line != "" {
+			q.logger.Printf("💭 %s", line)
 		}
 	}
-	return false
 }
 
 // logRunSummary logs total usage and tool timing at the end of a run.
@@ -784,6 +707,16 @@ func (q *Queen) logRunSummary(totalUsage llm.Usage, toolTimings map[string]*tool
 		if totalUsage.CacheReadTokens > 0 {
 			q.Printer().Debug("Cache: %dk created / %dk read", totalUsage.CacheCreationTokens/1000, totalUsage.CacheReadTokens/1000)
 		}
+		if totalUsage.ThinkingTokens > 0 {
+			q.Printer().Debug("Thinking: %dk of the output tokens", totalUsage.ThinkingTokens/1000)
+		}
+	}
+	if s := q.Spent(); s.SessionUSD > 0 {
+		if s.BudgetUSD > 0 {
+			q.Printer().Info("Total cost: %s of %s budget", formatUSD(s.SessionUSD), formatUSD(s.BudgetUSD))
+		} else {
+			q.Printer().Info("Total cost: %s", formatUSD(s.SessionUSD))
+		}
 	}
 	if len(toolTimings) > 0 {
 		q.Printer().Section("Tool Timing Summary")
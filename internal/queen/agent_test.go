@@ -176,6 +176,46 @@ func TestRunAgentCreateAndComplete(t *testing.T) {
 	}
 }
 
+// textReplies is a chat-only client, like a CLI provider, that answers with
+// its replies in turn.
+type textReplies struct {
+	replies []string
+}
+
+func (c *textReplies) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	if len(c.replies) == 0 {
+		return "", fmt.Errorf("no more replies")
+	}
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+func (c *textReplies) ChatWithHistory(ctx context.Context, systemPrompt string, messages []llm.Message) (string, error) {
+	return c.Chat(ctx, systemPrompt, "")
+}
+
+func TestRunAgentTextToolProtocol(t *testing.T) {
+	// A chat-only provider runs agent mode through JSON tool-call replies,
+	// including one that has to be repaired.
+	q := setupTestQueen(t)
+	q.llm = llm.NewTextToolClient(&textReplies{replies: []string{
+		`{"tool_calls": [{"name": "create_tasks", "input": {"tasks": [{"id": "task-1", "title": "Test task", "description": "echo hello", "type": "test"}]}}]}`,
+		`{"tool_calls": [{"name": "complete", "input": {}}]}`,
+		`{"tool_calls": [{"name": "complete", "input": {"summary": "All done"}}]}`,
+	}})
+	if !q.SupportsAgentMode() {
+		t.Fatal("expected agent mode with the text tool protocol")
+	}
+
+	if err := q.RunAgent(context.Background(), "test objective"); err != nil {
+		t.Fatalf("RunAgent failed: %v", err)
+	}
+	if tasks := q.tasks.All(); len(tasks) != 1 || tasks[0].ID != "task-1" {
+		t.Fatalf("expected task-1 to be created, got %+v", tasks)
+	}
+}
+
 func TestRunAgentFailTool(t *testing.T) {
 	q := setupTestQueen(t)
 
@@ -277,7 +317,7 @@ func TestCompactMessages(t *testing.T) {
 	}
 
 	// Total: 1 + 160 = 161 messages
-	compacted := q.compactMessages(messages)
+	compacted := q.compactMessages(context.Background(), messages)
 
 	// Should be much smaller: objective + summary + last 20
 	if len(compacted) >= len(messages) {
@@ -668,7 +708,7 @@ func TestCompactMessages_NeverSplitsPairs(t *testing.T) {
 	// Message at index 21 is a tool_result (odd indices are tool_results for i>=1)
 	// So idealCut would land on a tool_result — the function should adjust.
 
-	compacted := q.compactMessages(messages)
+	compacted := q.compactMessages(context.Background(), messages)
 
 	// Verify no tool_result appears right after the summary (index 2)
 	for i := 2; i < len(compacted); i++ {
@@ -725,7 +765,7 @@ func TestCompactMessages_SafeFallback(t *testing.T) {
 	}
 
 	// Total: 1 + 60 = 61 messages, idealCut = 61-20 = 41
-	compacted := q.compactMessages(messages)
+	compacted := q.compactMessages(context.Background(), messages)
 
 	// The result should have been compacted
 	if len(compacted) >= len(messages) {
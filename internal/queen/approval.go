@@ -0,0 +1,286 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// Question kinds. Every kind but QuestionAsk is a yes/no approval gate.
+const (
+	QuestionPlan     = "plan"     // approve the initial task graph
+	QuestionAssign   = "assign"   // approve starting a task
+	QuestionComplete = "complete" // approve declaring the objective done
+	QuestionReview   = "review"   // decide a task the reviewers disagree on
+	QuestionAsk      = "ask"      // free-form question from ask_user
+)
+
+// Question is put to the operator by an approval gate or the ask_user tool.
+type Question struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Prompt  string   `json:"prompt"`
+	Details string   `json:"details,omitempty"`
+	Options []string `json:"options,omitempty"` // suggested answers (ask_user)
+	TaskID  string   `json:"task_id,omitempty"`
+}
+
+// IsApproval reports whether the question expects a yes/no answer.
+func (qu Question) IsApproval() bool {
+	return qu.Kind != QuestionAsk
+}
+
+// Answer is the operator's reply. For approvals Text is an optional reason or
+// comment; for ask_user it is the answer itself.
+type Answer struct {
+	Approved bool   `json:"approved"`
+	Text     string `json:"text,omitempty"`
+}
+
+// Asker puts a question to the operator and blocks until it is answered or
+// ctx is done.
+type Asker interface {
+	Ask(ctx context.Context, qu Question) (Answer, error)
+}
+
+// AskerFunc adapts a function to the Asker interface.
+type AskerFunc func(ctx context.Context, qu Question) (Answer, error)
+
+// Ask calls f.
+func (f AskerFunc) Ask(ctx context.Context, qu Question) (Answer, error) {
+	return f(ctx, qu)
+}
+
+// SetAsker sets how questions reach the operator (TUI prompt, stdin, answer
+// files). Without one, every question gets its configured default answer.
+func (q *Queen) SetAsker(a Asker) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.asker = a
+}
+
+// ask puts a question to the operator, waiting at most approval.timeout.
+// When nobody answers, it returns the configured default.
+func (q *Queen) ask(ctx context.Context, qu Question) Answer {
+	q.mu.Lock()
+	q.questions++
+	qu.ID = fmt.Sprintf("%s-%d", q.sessionID, q.questions)
+	asker := q.asker
+	q.mu.Unlock()
+
+	if asker != nil {
+		askCtx := ctx
+		if timeout := q.cfg.Approval.Timeout; timeout > 0 {
+			var cancel context.CancelFunc
+			askCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		q.logger.Printf("🙋 Waiting for operator: %s", qu.Prompt)
+		answer, err := asker.Ask(askCtx, qu)
+		if err == nil {
+			q.logger.Printf("🙋 Operator answered: %s", describeAnswer(qu, answer))
+			return answer
+		}
+		if ctx.Err() != nil {
+			return Answer{Text: "session cancelled"}
+		}
+		q.logger.Printf("⚠ No operator answer (%v), using the default", err)
+	}
+	answer := q.defaultAnswer(qu)
+	q.logger.Printf("🙋 Default answer: %s", describeAnswer(qu, answer))
+	return answer
+}
+
+// defaultAnswer is the answer used when no operator replies.
+func (q *Queen) defaultAnswer(qu Question) Answer {
+	cfg := q.cfg.Approval
+	if !qu.IsApproval() {
+		text := cfg.DefaultAnswer
+		if text == "" {
+			text = "No answer from the user. Proceed with your best judgement and state the assumption you made."
+		}
+		return Answer{Approved: true, Text: text}
+	}
+	if cfg.OnTimeout == config.ApprovalApprove {
+		return Answer{Approved: true, Text: "approved by default, no operator answer"}
+	}
+	return Answer{Text: "no operator answer"}
+}
+
+func describeAnswer(qu Question, a Answer) string {
+	if !qu.IsApproval() {
+		return a.Text
+	}
+	verdict := "rejected"
+	if a.Approved {
+		verdict = "approved"
+	}
+	if a.Text != "" {
+		return verdict + " (" + a.Text + ")"
+	}
+	return verdict
+}
+
+// reason formats an operator comment as a sentence suffix.
+func reason(a Answer) string {
+	if a.Text == "" {
+		return ""
+	}
+	return ": " + a.Text
+}
+
+// --- Gates ---
+
+// approvePlan asks the operator to approve the first tasks created in a
+// top-level session.
+func (q *Queen) approvePlan(ctx context.Context, created []*task.Task) error {
+	if !q.cfg.Approval.Plan || q.parent != nil || len(q.tasks.All()) != len(created) {
+		return nil
+	}
+	var b strings.Builder
+	for _, t := range created {
+		fmt.Fprintf(&b, "- [%s] %s (%s)", t.Type, t.Title, t.ID)
+		if len(t.DependsOn) > 0 {
+			fmt.Fprintf(&b, " after %s", strings.Join(t.DependsOn, ", "))
+		}
+		b.WriteString("\n")
+	}
+	answer := q.ask(ctx, Question{
+		Kind:    QuestionPlan,
+		Prompt:  fmt.Sprintf("Approve the plan (%d tasks)?", len(created)),
+		Details: b.String(),
+	})
+	if !answer.Approved {
+		return fmt.Errorf("the operator rejected the plan%s. Revise it and call create_tasks again", reason(answer))
+	}
+	return nil
+}
+
+// approveAssign asks the operator before starting a task whose type or
+// adapter is gated.
+func (q *Queen) approveAssign(ctx context.Context, t *task.Task, adapterName string) error {
+	cfg := q.cfg.Approval
+	if !slices.Contains(cfg.AssignTypes, string(t.Type)) && !slices.Contains(cfg.AssignAdapters, adapterName) {
+		return nil
+	}
+	answer := q.ask(ctx, Question{
+		Kind:    QuestionAssign,
+		Prompt:  fmt.Sprintf("Start %s task %q on %s?", t.Type, t.Title, adapterName),
+		Details: t.GetDescription(),
+		TaskID:  t.ID,
+	})
+	if !answer.Approved {
+		return fmt.Errorf("the operator did not approve starting task %q%s. Revise it with update_task, cancel it, or work around it", t.ID, reason(answer))
+	}
+	return nil
+}
+
+// approveComplete asks the operator to accept a top-level session's summary.
+func (q *Queen) approveComplete(ctx context.Context, summary string) error {
+	if !q.cfg.Approval.Complete || q.parent != nil || q.cfg.Queen.DryRun {
+		return nil
+	}
+	answer := q.ask(ctx, Question{
+		Kind:    QuestionComplete,
+		Prompt:  "Accept the result and finish the session?",
+		Details: summary,
+	})
+	if !answer.Approved {
+		return fmt.Errorf("the operator did not accept completion%s. Address the feedback, then call complete again", reason(answer))
+	}
+	return nil
+}
+
+// --- Answering ---
+
+// ParseAnswer reads an operator's typed reply. For approvals a reply
+// starting with y/yes/ok/approve approves and anything else rejects; the
+// remaining words are the comment or reason. For questions with options, a
+// number picks that option.
+func ParseAnswer(qu Question, reply string) Answer {
+	reply = strings.TrimSpace(reply)
+	if !qu.IsApproval() {
+		if n, err := strconv.Atoi(reply); err == nil && n >= 1 && n <= len(qu.Options) {
+			reply = qu.Options[n-1]
+		}
+		return Answer{Approved: true, Text: reply}
+	}
+	word, rest, _ := strings.Cut(reply, " ")
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(word) {
+	case "y", "yes", "ok", "approve":
+		return Answer{Approved: true, Text: rest}
+	case "n", "no", "reject":
+		return Answer{Text: rest}
+	}
+	return Answer{Text: reply}
+}
+
+// FileAsker waits for answers written to Dir as <question-id>.json (see
+// WriteAnswer). It lets a supervising process answer a headless session,
+// e.g. in --json mode.
+type FileAsker struct {
+	Dir      string
+	Announce func(Question) // called once per question, e.g. to print it
+	Interval time.Duration  // polling interval (default 250ms)
+}
+
+// Ask announces the question and polls for its answer file.
+func (a *FileAsker) Ask(ctx context.Context, qu Question) (Answer, error) {
+	if a.Announce != nil {
+		a.Announce(qu)
+	}
+	interval := a.Interval
+	if interval <= 0 {
+		interval = 250 * time.Millisecond
+	}
+	path := AnswerPath(a.Dir, qu.ID)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if data, err := os.ReadFile(path); err == nil {
+			var answer Answer
+			if err := json.Unmarshal(data, &answer); err == nil {
+				_ = os.Remove(path)
+				return answer, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return Answer{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// AnswerPath is the file a FileAsker polling dir reads the answer to
+// question id from.
+func AnswerPath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// WriteAnswer answers question id for a FileAsker polling dir. The file is
+// written atomically so a poller never reads a partial answer.
+func WriteAnswer(dir, id string, answer Answer) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return err
+	}
+	tmp := AnswerPath(dir, id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, AnswerPath(dir, id))
+}
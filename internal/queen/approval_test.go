@@ -0,0 +1,264 @@
+package queen
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/task"
+	"github.com/HexSleeves/waggle/internal/worker"
+)
+
+// scriptedAsker answers questions in order and records them.
+type scriptedAsker struct {
+	mu        sync.Mutex
+	answers   []Answer
+	questions []Question
+}
+
+func (a *scriptedAsker) Ask(ctx context.Context, qu Question) (Answer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.questions = append(a.questions, qu)
+	if len(a.answers) == 0 {
+		<-ctx.Done()
+		return Answer{}, ctx.Err()
+	}
+	next := a.answers[0]
+	a.answers = a.answers[1:]
+	return next, nil
+}
+
+func planInput() []byte {
+	return toJSON(map[string]interface{}{
+		"tasks": []map[string]interface{}{
+			{"id": "a", "title": "A", "description": "do a", "type": "code"},
+			{"id": "b", "title": "B", "description": "do b", "type": "test", "depends_on": []string{"a"}},
+		},
+	})
+}
+
+func TestPlanApproval(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Approval.Plan = true
+	asker := &scriptedAsker{answers: []Answer{{Text: "split a into two"}, {Approved: true}}}
+	q.SetAsker(asker)
+	ctx := context.Background()
+
+	_, err := handleCreateTasks(ctx, q, planInput())
+	if err == nil || !strings.Contains(err.Error(), "rejected the plan: split a into two") {
+		t.Fatalf("expected plan rejection with reason, got %v", err)
+	}
+	if n := len(q.tasks.All()); n != 0 {
+		t.Fatalf("expected rejected tasks rolled back, got %d", n)
+	}
+	if qu := asker.questions[0]; qu.Kind != QuestionPlan || !strings.Contains(qu.Details, "[test] B (b) after a") {
+		t.Errorf("unexpected plan question %+v", qu)
+	}
+
+	if _, err := handleCreateTasks(ctx, q, planInput()); err != nil {
+		t.Fatalf("expected approved plan, got %v", err)
+	}
+	if n := len(q.tasks.All()); n != 2 {
+		t.Fatalf("expected 2 tasks, got %d", n)
+	}
+
+	// Only the first plan needs approval.
+	more := toJSON(map[string]interface{}{"tasks": []map[string]interface{}{
+		{"id": "c", "title": "C", "description": "do c", "type": "code"},
+	}})
+	if _, err := handleCreateTasks(ctx, q, more); err != nil {
+		t.Fatalf("expected later tasks without approval, got %v", err)
+	}
+	if len(asker.questions) != 2 {
+		t.Errorf("expected 2 questions, got %d", len(asker.questions))
+	}
+	if asker.questions[0].ID == asker.questions[1].ID {
+		t.Errorf("expected unique question IDs, got %q twice", asker.questions[0].ID)
+	}
+}
+
+func TestAssignApproval(t *testing.T) {
+	q, dir := testQueen(t)
+	useExecRouter(q, dir)
+	q.cfg.Approval.AssignTypes = []string{"code"}
+	asker := &scriptedAsker{answers: []Answer{{Text: "not yet"}}}
+	q.SetAsker(asker)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "Migrate", Type: task.TypeCode, Status: task.StatusPending})
+
+	_, err := handleAssignTask(ctx, q, toJSON(map[string]string{"task_id": "t1"}))
+	if err == nil || !strings.Contains(err.Error(), `did not approve starting task "t1": not yet`) {
+		t.Fatalf("expected assignment refused, got %v", err)
+	}
+	if tk, _ := q.tasks.Get("t1"); tk.GetStatus() != task.StatusPending {
+		t.Errorf("expected t1 still pending, got %s", tk.GetStatus())
+	}
+	if qu := asker.questions[0]; qu.Kind != QuestionAssign || qu.TaskID != "t1" || !strings.Contains(qu.Prompt, "on exec") {
+		t.Errorf("unexpected assign question %+v", qu)
+	}
+
+	// Other types and adapters are not gated.
+	if err := q.approveAssign(ctx, &task.Task{ID: "r1", Type: task.TypeResearch}, "exec"); err != nil {
+		t.Errorf("expected research task ungated, got %v", err)
+	}
+	q.cfg.Approval.AssignAdapters = []string{"exec"}
+	q.cfg.Approval.Timeout = 20 * time.Millisecond
+	if err := q.approveAssign(ctx, &task.Task{ID: "r1", Type: task.TypeResearch}, "exec"); err == nil {
+		t.Error("expected exec adapter gated")
+	}
+}
+
+func TestDelegate_AssignApproval(t *testing.T) {
+	q, dir := testQueen(t)
+	useExecRouter(q, dir)
+	q.pool = worker.NewPool(4, func(id, adapterName string) (worker.Bee, error) {
+		return NewEnhancedMockBee(id, adapterName), nil
+	}, q.bus)
+	q.cfg.Approval.AssignTypes = []string{"code"}
+	asker := &scriptedAsker{answers: []Answer{{Text: "not yet"}, {Approved: true}}}
+	q.SetAsker(asker)
+	ctx := context.Background()
+	tk := &task.Task{ID: "t1", Title: "Migrate", Type: task.TypeCode, Status: task.StatusPending, Description: "echo hi"}
+	q.tasks.Add(tk)
+
+	if err := q.delegate(ctx); err != nil {
+		t.Fatalf("delegate: %v", err)
+	}
+	if tk.GetStatus() != task.StatusPending || q.pool.ActiveCount() != 0 {
+		t.Fatalf("expected the refused task left pending, got %s with %d workers", tk.GetStatus(), q.pool.ActiveCount())
+	}
+
+	// It is asked about again on the next pass.
+	if err := q.delegate(ctx); err != nil {
+		t.Fatalf("delegate: %v", err)
+	}
+	if tk.GetStatus() != task.StatusRunning || len(asker.questions) != 2 {
+		t.Errorf("expected the approved task started after 2 questions, got %s after %d", tk.GetStatus(), len(asker.questions))
+	}
+}
+
+func TestCompleteApproval(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Approval.Complete = true
+	q.SetAsker(&scriptedAsker{answers: []Answer{{Text: "tests are missing"}, {Approved: true}}})
+	ctx := context.Background()
+	input := toJSON(map[string]string{"summary": "All done"})
+
+	_, err := handleComplete(ctx, q, input)
+	if err == nil || !strings.Contains(err.Error(), "tests are missing") {
+		t.Fatalf("expected completion refused with feedback, got %v", err)
+	}
+	if q.phase == PhaseDone {
+		t.Error("expected session not done after rejection")
+	}
+	if _, err := handleComplete(ctx, q, input); err != nil || q.phase != PhaseDone {
+		t.Fatalf("expected completion accepted, got %v (phase %s)", err, q.phase)
+	}
+}
+
+func TestAskUser(t *testing.T) {
+	q, _ := testQueen(t)
+	asker := &scriptedAsker{answers: []Answer{{Approved: true, Text: "PostgreSQL"}}}
+	q.SetAsker(asker)
+
+	out, err := handleAskUser(context.Background(), q, toJSON(map[string]interface{}{
+		"question": "Which database?", "options": []string{"SQLite", "PostgreSQL"},
+	}))
+	if err != nil {
+		t.Fatalf("ask_user: %v", err)
+	}
+	if out.LLMContent != "The user answered: PostgreSQL" {
+		t.Errorf("unexpected output %q", out.LLMContent)
+	}
+	if qu := asker.questions[0]; qu.Kind != QuestionAsk || len(qu.Options) != 2 {
+		t.Errorf("unexpected question %+v", qu)
+	}
+
+	if _, err := handleAskUser(context.Background(), q, toJSON(map[string]string{})); err == nil {
+		t.Error("expected error without a question")
+	}
+}
+
+func TestAskDefaults(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Approval.Timeout = 20 * time.Millisecond
+	q.SetAsker(&scriptedAsker{}) // never answers
+	ctx := context.Background()
+
+	if a := q.ask(ctx, Question{Kind: QuestionPlan}); a.Approved {
+		t.Errorf("expected approvals rejected by default, got %+v", a)
+	}
+	q.cfg.Approval.OnTimeout = config.ApprovalApprove
+	if a := q.ask(ctx, Question{Kind: QuestionComplete}); !a.Approved {
+		t.Errorf("expected on_timeout=approve to approve, got %+v", a)
+	}
+	q.cfg.Approval.DefaultAnswer = "use SQLite"
+	if a := q.ask(ctx, Question{Kind: QuestionAsk}); a.Text != "use SQLite" {
+		t.Errorf("expected default answer, got %+v", a)
+	}
+
+	// Without an asker nobody is waited for.
+	q.SetAsker(nil)
+	if a := q.ask(ctx, Question{Kind: QuestionAsk}); a.Text != "use SQLite" {
+		t.Errorf("expected default answer without an asker, got %+v", a)
+	}
+}
+
+func TestParseAnswer(t *testing.T) {
+	approval := Question{Kind: QuestionPlan}
+	question := Question{Kind: QuestionAsk, Options: []string{"SQLite", "PostgreSQL"}}
+	tests := []struct {
+		qu    Question
+		reply string
+		want  Answer
+	}{
+		{approval, "y", Answer{Approved: true}},
+		{approval, "Yes looks good", Answer{Approved: true, Text: "looks good"}},
+		{approval, "n too many tasks", Answer{Text: "too many tasks"}},
+		{approval, "merge tasks a and b", Answer{Text: "merge tasks a and b"}},
+		{question, "2", Answer{Approved: true, Text: "PostgreSQL"}},
+		{question, " MySQL ", Answer{Approved: true, Text: "MySQL"}},
+	}
+	for _, tt := range tests {
+		if got := ParseAnswer(tt.qu, tt.reply); got != tt.want {
+			t.Errorf("ParseAnswer(%q) = %+v, want %+v", tt.reply, got, tt.want)
+		}
+	}
+}
+
+func TestFileAsker(t *testing.T) {
+	dir := t.TempDir()
+	var announced []Question
+	asker := &FileAsker{Dir: dir, Interval: 5 * time.Millisecond, Announce: func(qu Question) {
+		announced = append(announced, qu)
+	}}
+	qu := Question{ID: "s-1", Kind: QuestionPlan}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = WriteAnswer(dir, "s-1", Answer{Text: "no"})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	a, err := asker.Ask(ctx, qu)
+	if err != nil || a.Approved || a.Text != "no" {
+		t.Fatalf("expected rejection read from file, got %+v, %v", a, err)
+	}
+	if len(announced) != 1 || announced[0].ID != "s-1" {
+		t.Errorf("expected question announced once, got %+v", announced)
+	}
+	if _, err := os.Stat(AnswerPath(dir, "s-1")); !os.IsNotExist(err) {
+		t.Errorf("expected answer file removed, got %v", err)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if _, err := asker.Ask(short, Question{ID: "s-2"}); err == nil {
+		t.Error("expected timeout without an answer file")
+	}
+}
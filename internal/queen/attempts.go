@@ -0,0 +1,183 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// Attempt statuses recorded in the task_attempts table.
+const (
+	AttemptRunning     = "running"
+	AttemptSucceeded   = "succeeded"
+	AttemptFailed      = "failed"
+	AttemptCancelled   = "cancelled"
+	AttemptInterrupted = "interrupted"
+	AttemptCached      = "cached" // result reused from the cross-session cache
+)
+
+// openAttempt tracks an attempt whose worker has not reported back yet.
+type openAttempt struct {
+	taskID  string
+	number  int
+	started time.Time
+}
+
+// startAttempt records a new attempt for a task that was just handed to workerID.
+func (q *Queen) startAttempt(ctx context.Context, t *task.Task, workerID, adapterName string) {
+	n, err := q.db.StartAttempt(ctx, q.sessionID, t.ID, workerID, adapterName)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to record task attempt: %v", err)
+		return
+	}
+	q.mu.Lock()
+	if q.attempts == nil {
+		q.attempts = make(map[string]openAttempt)
+	}
+	q.attempts[workerID] = openAttempt{taskID: t.ID, number: n, started: time.Now()}
+	q.mu.Unlock()
+}
+
+// finishAttempt closes the attempt run by workerID with the given status.
+// Output larger than maxOutputLen is written to .hive/outputs and only a
+// pointer plus the head of the output is kept in the database.
+func (q *Queen) finishAttempt(ctx context.Context, workerID, status, errType string, result *task.Result) {
+	q.mu.Lock()
+	open, ok := q.attempts[workerID]
+	delete(q.attempts, workerID)
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	row := state.AttemptRow{
+		SessionID: q.sessionID,
+		TaskID:    open.taskID,
+		Attempt:   open.number,
+		Status:    status,
+		ErrorType: errType,
+	}
+
+	metrics := map[string]float64{
+		"duration_seconds": time.Since(open.started).Seconds(),
+	}
+	if result != nil {
+		row.Error = strings.Join(nonEmpty(result.Errors), "; ")
+		row.Output = result.Output
+		metrics["output_bytes"] = float64(len(result.Output))
+		for k, v := range result.Metrics {
+			metrics[k] = v
+		}
+	}
+	if len(row.Output) > maxOutputLen {
+		name := fmt.Sprintf("%s-attempt-%d.log", open.taskID, open.number)
+		dir := filepath.Join(q.cfg.HivePath(), "outputs")
+		if err := os.MkdirAll(dir, 0o755); err == nil {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(row.Output), 0o644); err == nil {
+				row.OutputPath = filepath.Join(".hive", "outputs", name)
+				row.Output = row.Output[:maxOutputLen]
+			}
+		}
+	}
+	if b, err := json.Marshal(metrics); err == nil {
+		row.Metrics = string(b)
+	}
+	q.recordTaskCost(ctx, open.taskID, metrics)
+
+	if err := q.db.FinishAttempt(ctx, row); err != nil {
+		q.logger.Printf("⚠ Warning: failed to record attempt result: %v", err)
+	}
+}
+
+// recordAttemptFeedback stores review feedback on the task's latest attempt.
+func (q *Queen) recordAttemptFeedback(ctx context.Context, taskID, feedback string) {
+	if err := q.db.SetAttemptFeedback(ctx, q.sessionID, taskID, feedback); err != nil {
+		q.logger.Printf("⚠ Warning: failed to record attempt feedback: %v", err)
+	}
+}
+
+// recordAttemptReview stores a verdict on the task's latest attempt.
+func (q *Queen) recordAttemptReview(ctx context.Context, taskID string, v *ReviewVerdict) {
+	b, err := json.Marshal(v)
+	if err == nil {
+		err = q.db.SetAttemptReview(ctx, q.sessionID, taskID, string(b))
+	}
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to record review verdict: %v", err)
+	}
+}
+
+// formatAttemptHistory renders a compact one-line-per-attempt summary.
+func formatAttemptHistory(attempts []state.AttemptRow) string {
+	var b strings.Builder
+	for _, a := range attempts {
+		fmt.Fprintf(&b, "  #%d %s", a.Attempt, a.Status)
+		if a.WorkerID != "" {
+			fmt.Fprintf(&b, " worker=%s", a.WorkerID)
+		}
+		if a.Adapter != "" {
+			fmt.Fprintf(&b, " adapter=%s", a.Adapter)
+		}
+		if a.ErrorType != "" {
+			fmt.Fprintf(&b, " error_type=%s", a.ErrorType)
+		}
+		if a.Error != "" {
+			fmt.Fprintf(&b, " error=%q", truncate(a.Error, 120))
+		}
+		if a.Feedback != "" {
+			fmt.Fprintf(&b, " feedback=%q", truncate(a.Feedback, 120))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// resumeAttemptNote summarises attempt history for tasks that were retried or
+// interrupted, so a resumed Queen knows what was already tried.
+func (q *Queen) resumeAttemptNote(ctx context.Context) string {
+	rows, err := q.db.ListSessionAttempts(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load task attempts: %v", err)
+		return ""
+	}
+
+	byTask := map[string][]state.AttemptRow{}
+	var order []string
+	for _, a := range rows {
+		if _, seen := byTask[a.TaskID]; !seen {
+			order = append(order, a.TaskID)
+		}
+		byTask[a.TaskID] = append(byTask[a.TaskID], a)
+	}
+
+	var b strings.Builder
+	for _, id := range order {
+		attempts := byTask[id]
+		last := attempts[len(attempts)-1]
+		if len(attempts) < 2 && last.Status != AttemptInterrupted {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n%s", id, formatAttemptHistory(attempts))
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n\nAttempt history (interrupted attempts were reset to pending; use get_task_output with attempt=N for details):\n" + b.String()
+}
+
+func nonEmpty(ss []string) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if strings.TrimSpace(s) != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
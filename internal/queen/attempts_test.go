@@ -0,0 +1,136 @@
+package queen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func TestAttemptHistory_FailureThenSuccess(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	tk := &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusRunning, MaxRetries: 2}
+	addPersistedTask(t, q, tk)
+
+	q.startAttempt(ctx, tk, "w-1", "exec")
+	q.handleTaskFailure(ctx, "t1", "w-1", &task.Result{Errors: []string{"connection reset", ""}})
+
+	q.startAttempt(ctx, tk, "w-2", "exec")
+	q.finishAttempt(ctx, "w-2", AttemptSucceeded, "", &task.Result{
+		Success: true, Output: "done", Metrics: map[string]float64{"exit_code": 0},
+	})
+
+	attempts, err := q.db.ListAttempts(ctx, q.sessionID, "t1")
+	if err != nil {
+		t.Fatalf("ListAttempts failed: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(attempts))
+	}
+	if attempts[0].Status != AttemptFailed || attempts[0].Error != "connection reset" || attempts[0].ErrorType == "" {
+		t.Errorf("unexpected first attempt: %+v", attempts[0])
+	}
+	if attempts[1].Status != AttemptSucceeded || attempts[1].Output != "done" || attempts[1].WorkerID != "w-2" {
+		t.Errorf("unexpected second attempt: %+v", attempts[1])
+	}
+	if !strings.Contains(attempts[1].Metrics, `"exit_code":0`) || !strings.Contains(attempts[1].Metrics, "duration_seconds") {
+		t.Errorf("expected merged metrics, got %s", attempts[1].Metrics)
+	}
+}
+
+func TestAttemptHistory_LargeOutputSpillsToFile(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	tk := &task.Task{ID: "big", Title: "Big", Type: task.TypeCode, Status: task.StatusRunning}
+	addPersistedTask(t, q, tk)
+
+	large := strings.Repeat("x", maxOutputLen*2)
+	q.startAttempt(ctx, tk, "w-1", "exec")
+	q.finishAttempt(ctx, "w-1", AttemptSucceeded, "", &task.Result{Success: true, Output: large})
+
+	a, err := q.db.GetAttempt(ctx, q.sessionID, "big", 1)
+	if err != nil {
+		t.Fatalf("GetAttempt failed: %v", err)
+	}
+	if a.OutputPath != filepath.Join(".hive", "outputs", "big-attempt-1.log") {
+		t.Errorf("unexpected output path %q", a.OutputPath)
+	}
+	if len(a.Output) != maxOutputLen {
+		t.Errorf("stored output should be capped at %d bytes, got %d", maxOutputLen, len(a.Output))
+	}
+	data, err := os.ReadFile(filepath.Join(q.cfg.ProjectDir, a.OutputPath))
+	if err != nil {
+		t.Fatalf("read spilled output: %v", err)
+	}
+	if string(data) != large {
+		t.Error("spilled output does not match the full worker output")
+	}
+}
+
+func TestHandleGetTaskOutput_Attempt(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	tk := &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusRunning, MaxRetries: 2}
+	addPersistedTask(t, q, tk)
+
+	q.startAttempt(ctx, tk, "w-1", "exec")
+	q.finishAttempt(ctx, "w-1", AttemptSucceeded, "", &task.Result{Success: true, Output: "first try"})
+	if _, err := handleRejectTask(ctx, q, toJSON(map[string]interface{}{"task_id": "t1", "feedback": "needs tests"})); err != nil {
+		t.Fatalf("reject failed: %v", err)
+	}
+	q.startAttempt(ctx, tk, "w-2", "exec")
+	q.finishAttempt(ctx, "w-2", AttemptSucceeded, "", &task.Result{Success: true, Output: "second try"})
+	tk.SetResult(&task.Result{Success: true, Output: "second try"})
+	_ = q.tasks.UpdateStatus("t1", task.StatusComplete)
+
+	out, err := handleGetTaskOutput(ctx, q, toJSON(map[string]interface{}{"task_id": "t1", "attempt": 1}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Attempt: 1/2", "first try", "Review feedback: needs tests", "Worker: w-1"} {
+		if !strings.Contains(out.LLMContent, want) {
+			t.Errorf("attempt output missing %q:\n%s", want, out.LLMContent)
+		}
+	}
+
+	latest, err := handleGetTaskOutput(ctx, q, toJSON(map[string]interface{}{"task_id": "t1"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(latest.LLMContent, "second try") || !strings.Contains(latest.LLMContent, "#1 succeeded") {
+		t.Errorf("latest output should include attempt history:\n%s", latest.LLMContent)
+	}
+
+	if _, err := handleGetTaskOutput(ctx, q, toJSON(map[string]interface{}{"task_id": "t1", "attempt": 3})); err == nil {
+		t.Error("expected error for missing attempt")
+	}
+}
+
+func TestResumeAttemptNote(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	one := &task.Task{ID: "once", Title: "Once", Type: task.TypeCode, Status: task.StatusComplete}
+	cut := &task.Task{ID: "cut", Title: "Cut", Type: task.TypeCode, Status: task.StatusRunning}
+	addPersistedTask(t, q, one)
+	addPersistedTask(t, q, cut)
+
+	q.startAttempt(ctx, one, "w-1", "exec")
+	q.finishAttempt(ctx, "w-1", AttemptSucceeded, "", &task.Result{Success: true})
+	q.startAttempt(ctx, cut, "w-2", "exec")
+
+	if err := q.db.InterruptRunningAttempts(ctx, q.sessionID); err != nil {
+		t.Fatalf("InterruptRunningAttempts failed: %v", err)
+	}
+
+	note := q.resumeAttemptNote(ctx)
+	if !strings.Contains(note, "cut:") || !strings.Contains(note, "#1 interrupted") {
+		t.Errorf("expected interrupted attempt in note:\n%s", note)
+	}
+	if strings.Contains(note, "once:") {
+		t.Errorf("single successful attempts should be omitted:\n%s", note)
+	}
+}
@@ -0,0 +1,269 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// Blackboard tags with a meaning for workers. An entry tagged tagWorkers is
+// included in worker prompts; "type:<task type>" and "task:<task id>" tags
+// narrow it to matching tasks.
+const (
+	tagWorkers      = "workers"
+	tagTypePrefix   = "type:"
+	tagTaskPrefix   = "task:"
+	maxEntryChars   = 4000 // per entry returned by blackboard_read
+	maxBoardResults = 20
+	maxNoteChars    = 1000 // per note included in a worker prompt
+)
+
+// reservedBoardPrefixes are keys the Queen posts herself; blackboard_write may
+// not overwrite them.
+var reservedBoardPrefixes = []string{"result-", "approval-", "rejection-", summaryKeyPrefix}
+
+// postBoard posts an entry to the blackboard and persists it.
+func (q *Queen) postBoard(ctx context.Context, e *blackboard.Entry) {
+	q.board.Post(e)
+	if err := q.db.PostBlackboard(ctx, q.sessionID, e.Key, entryText(e), e.PostedBy, e.TaskID, strings.Join(e.Tags, ",")); err != nil {
+		q.logger.Printf("⚠ Warning: failed to post blackboard entry %s: %v", e.Key, err)
+	}
+}
+
+func entryText(e *blackboard.Entry) string {
+	if s, ok := e.Value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(e.Value)
+}
+
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + fmt.Sprintf("… (%d more chars)", len(s)-n)
+}
+
+func sortEntries(entries []*blackboard.Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Timestamp.Equal(entries[j].Timestamp) {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+}
+
+// formatEntry renders an entry header and its value clipped to n characters.
+func formatEntry(e *blackboard.Entry, n int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] by %s", e.Key, e.PostedBy)
+	if e.TaskID != "" {
+		fmt.Fprintf(&b, " (task: %s)", e.TaskID)
+	}
+	if len(e.Tags) > 0 {
+		fmt.Fprintf(&b, " tags: %s", strings.Join(e.Tags, ", "))
+	}
+	b.WriteString("\n")
+	b.WriteString(clip(entryText(e), n))
+	return b.String()
+}
+
+// workerNotes returns the blackboard entries shared with t's worker, as
+// "key: value" lines.
+func (q *Queen) workerNotes(t *task.Task) []string {
+	entries := q.board.ReadByTag(tagWorkers)
+	sortEntries(entries)
+	var notes []string
+	for _, e := range entries {
+		if sharedWith(e, t) {
+			notes = append(notes, e.Key+": "+clip(entryText(e), maxNoteChars))
+		}
+	}
+	return notes
+}
+
+// sharedWith reports whether an entry tagged for workers applies to t.
+func sharedWith(e *blackboard.Entry, t *task.Task) bool {
+	scoped := false
+	for _, tag := range e.Tags {
+		switch {
+		case strings.HasPrefix(tag, tagTypePrefix):
+			scoped = true
+			if tag == tagTypePrefix+string(t.Type) {
+				return true
+			}
+		case strings.HasPrefix(tag, tagTaskPrefix):
+			scoped = true
+			if tag == tagTaskPrefix+t.ID {
+				return true
+			}
+		}
+	}
+	return !scoped
+}
+
+// ---------- blackboard_read ----------
+
+type blackboardReadInput struct {
+	Key    string `json:"key"`
+	Tag    string `json:"tag"`
+	TaskID string `json:"task_id"`
+}
+
+func handleBlackboardRead(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in blackboardReadInput
+	_ = json.Unmarshal(input, &in) // all fields optional
+
+	if in.Key != "" {
+		e, ok := q.board.Read(in.Key)
+		if !ok {
+			return ToolOutput{}, fmt.Errorf("no blackboard entry %q", in.Key)
+		}
+		return ToolOutput{LLMContent: formatEntry(e, maxEntryChars)}, nil
+	}
+
+	var entries []*blackboard.Entry
+	switch {
+	case in.Tag != "":
+		entries = q.board.ReadByTag(in.Tag)
+		if in.TaskID != "" {
+			entries = slices.DeleteFunc(entries, func(e *blackboard.Entry) bool { return e.TaskID != in.TaskID })
+		}
+	case in.TaskID != "":
+		entries = q.board.ReadByTask(in.TaskID)
+	default:
+		// No filter: list the keys so the Queen can pick what to read.
+		all := q.board.All()
+		if len(all) == 0 {
+			return ToolOutput{LLMContent: "Blackboard is empty."}, nil
+		}
+		for _, e := range all {
+			entries = append(entries, e)
+		}
+		sortEntries(entries)
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d entries (read one with key):\n", len(entries))
+		for _, e := range entries {
+			fmt.Fprintf(&b, "- %s", e.Key)
+			if len(e.Tags) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(e.Tags, ", "))
+			}
+			b.WriteString("\n")
+		}
+		return ToolOutput{LLMContent: strings.TrimRight(b.String(), "\n")}, nil
+	}
+
+	if len(entries) == 0 {
+		return ToolOutput{LLMContent: "No matching blackboard entries."}, nil
+	}
+	sortEntries(entries)
+	return boardEntriesOutput(entries), nil
+}
+
+// boardEntriesOutput renders up to maxBoardResults entries, each clipped.
+func boardEntriesOutput(entries []*blackboard.Entry) ToolOutput {
+	var parts, keys []string
+	for i, e := range entries {
+		if i == maxBoardResults {
+			parts = append(parts, fmt.Sprintf("(%d more entries not shown; filter by key, tag or task_id)", len(entries)-i))
+			break
+		}
+		parts = append(parts, formatEntry(e, maxEntryChars))
+		keys = append(keys, e.Key)
+	}
+	return ToolOutput{
+		LLMContent: strings.Join(parts, "\n\n"),
+		Display:    fmt.Sprintf("📋 %d blackboard entries: %s", len(entries), strings.Join(keys, ", ")),
+	}
+}
+
+// ---------- blackboard_write ----------
+
+type blackboardWriteInput struct {
+	Key              string   `json:"key"`
+	Value            string   `json:"value"`
+	Tags             []string `json:"tags"`
+	TaskID           string   `json:"task_id"`
+	ShareWithWorkers bool     `json:"share_with_workers"`
+	TaskTypes        []string `json:"task_types"`
+}
+
+func handleBlackboardWrite(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in blackboardWriteInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(in.Key) == "" || strings.TrimSpace(in.Value) == "" {
+		return ToolOutput{}, fmt.Errorf("key and value are required")
+	}
+	for _, p := range reservedBoardPrefixes {
+		if strings.HasPrefix(in.Key, p) {
+			return ToolOutput{}, fmt.Errorf("keys starting with %q are reserved for task results and reviews; choose another key", p)
+		}
+	}
+	if in.TaskID != "" {
+		if _, ok := q.tasks.Get(in.TaskID); !ok {
+			return ToolOutput{}, fmt.Errorf("task %q not found", in.TaskID)
+		}
+	}
+	// Tags are stored comma-joined
+	for _, tag := range slices.Concat(in.Tags, in.TaskTypes) {
+		if strings.Contains(tag, ",") {
+			return ToolOutput{}, fmt.Errorf("tag %q must not contain a comma; use one tag per entry", tag)
+		}
+	}
+
+	tags := slices.Clone(in.Tags)
+	if in.ShareWithWorkers {
+		tags = append(tags, tagWorkers)
+		for _, tt := range in.TaskTypes {
+			tags = append(tags, tagTypePrefix+tt)
+		}
+		if in.TaskID != "" {
+			tags = append(tags, tagTaskPrefix+in.TaskID)
+		}
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+
+	q.postBoard(ctx, &blackboard.Entry{
+		Key:      in.Key,
+		Value:    in.Value,
+		PostedBy: "queen",
+		TaskID:   in.TaskID,
+		Tags:     tags,
+	})
+
+	msg := fmt.Sprintf("Posted %q to the blackboard.", in.Key)
+	if in.ShareWithWorkers {
+		msg += " It will be included in the prompts of matching tasks assigned from now on."
+	}
+	return ToolOutput{LLMContent: msg}, nil
+}
+
+// ---------- blackboard_search ----------
+
+type blackboardSearchInput struct {
+	Query string `json:"query"`
+}
+
+func handleBlackboardSearch(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in blackboardSearchInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(in.Query) == "" {
+		return ToolOutput{}, fmt.Errorf("query is required")
+	}
+	entries := q.board.Search(in.Query)
+	if len(entries) == 0 {
+		return ToolOutput{LLMContent: fmt.Sprintf("No blackboard entries match %q.", in.Query)}, nil
+	}
+	return boardEntriesOutput(entries), nil
+}
@@ -0,0 +1,88 @@
+package queen
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func TestBlackboardWriteAndRead(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+
+	_, err := handleBlackboardWrite(ctx, q, toJSON(map[string]interface{}{
+		"key": "conventions", "value": "Use slog for logging", "tags": []string{"style"},
+	}))
+	if err != nil {
+		t.Fatalf("blackboard_write: %v", err)
+	}
+	if v, err := q.db.ReadBlackboard(ctx, q.sessionID, "conventions"); err != nil || v != "Use slog for logging" {
+		t.Errorf("expected entry persisted, got %q, %v", v, err)
+	}
+
+	out, err := handleBlackboardRead(ctx, q, toJSON(map[string]string{"key": "conventions"}))
+	if err != nil || out.LLMContent != "[conventions] by queen tags: style\nUse slog for logging" {
+		t.Fatalf("unexpected read %q, %v", out.LLMContent, err)
+	}
+	out, _ = handleBlackboardRead(ctx, q, toJSON(map[string]string{"tag": "style"}))
+	if !strings.Contains(out.LLMContent, "Use slog for logging") || !strings.Contains(out.Display, "1 blackboard entries") {
+		t.Errorf("unexpected read by tag %+v", out)
+	}
+	out, _ = handleBlackboardRead(ctx, q, toJSON(map[string]string{}))
+	if out.LLMContent != "1 entries (read one with key):\n- conventions [style]" {
+		t.Errorf("unexpected key listing %q", out.LLMContent)
+	}
+
+	out, _ = handleBlackboardSearch(ctx, q, toJSON(map[string]string{"query": "SLOG"}))
+	if !strings.Contains(out.LLMContent, "[conventions]") {
+		t.Errorf("expected search hit, got %q", out.LLMContent)
+	}
+
+	if _, err := handleBlackboardRead(ctx, q, toJSON(map[string]string{"key": "missing"})); err == nil {
+		t.Error("expected error for a missing key")
+	}
+	if _, err := handleBlackboardWrite(ctx, q, toJSON(map[string]string{"key": "result-t1", "value": "fake"})); err == nil {
+		t.Error("expected reserved key rejected")
+	}
+	if _, err := handleBlackboardWrite(ctx, q, toJSON(map[string]string{"key": "x"})); err == nil {
+		t.Error("expected error without a value")
+	}
+	// A comma would split the tag in two when a session is resumed.
+	_, err = handleBlackboardWrite(ctx, q, toJSON(map[string]interface{}{"key": "x", "value": "y", "tags": []string{"api,v2"}}))
+	if err == nil || !strings.Contains(err.Error(), "comma") {
+		t.Errorf("expected a tag with a comma rejected, got %v", err)
+	}
+}
+
+func TestWorkerNotes(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "API", Type: task.TypeCode, Status: task.StatusPending})
+	addPersistedTask(t, q, &task.Task{ID: "t2", Title: "Tests", Type: task.TypeTest, Status: task.StatusPending})
+
+	writes := []map[string]interface{}{
+		{"key": "conventions", "value": "Use slog", "share_with_workers": true},
+		{"key": "test-db", "value": "Use the in-memory store", "share_with_workers": true, "task_types": []string{"test"}},
+		{"key": "api-port", "value": "8080", "share_with_workers": true, "task_id": "t1"},
+		{"key": "private", "value": "Queen only"},
+	}
+	for _, w := range writes {
+		if _, err := handleBlackboardWrite(ctx, q, toJSON(w)); err != nil {
+			t.Fatalf("blackboard_write %v: %v", w["key"], err)
+		}
+	}
+	// Results are not tagged for workers.
+	q.board.Post(&blackboard.Entry{Key: "result-t0", Value: "done", Tags: []string{"result"}})
+
+	t1, _ := q.tasks.Get("t1")
+	if got := strings.Join(q.workerNotes(t1), "|"); got != "conventions: Use slog|api-port: 8080" {
+		t.Errorf("unexpected notes for t1: %q", got)
+	}
+	t2, _ := q.tasks.Get("t2")
+	if got := strings.Join(q.workerNotes(t2), "|"); got != "conventions: Use slog|test-db: Use the in-memory store" {
+		t.Errorf("unexpected notes for t2: %q", got)
+	}
+}
@@ -0,0 +1,339 @@
+package queen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// Result artifacts set on results served from the cache.
+const (
+	ArtifactCacheKey   = "cache_key"
+	ArtifactCachedFrom = "cached_from" // "<session>/<task>" that produced the result
+)
+
+// pendingCache remembers the cache key of a running task so its result can be
+// stored once the worker succeeds.
+type pendingCache struct {
+	key     string
+	adapter string
+}
+
+// cacheEnabled reports whether t may be served from or stored in the result cache.
+func (q *Queen) cacheEnabled(t *task.Task) bool {
+	if t.Type == task.TypeObjective {
+		return false // a child Queen's run is not reproducible
+	}
+	if t.Cache != nil {
+		return *t.Cache
+	}
+	if !q.cfg.Cache.Enabled {
+		return false
+	}
+	for _, typ := range q.cfg.Cache.Types {
+		if strings.EqualFold(typ, string(t.Type)) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskCacheKey hashes everything that determines a task's result: type,
+// description, constraints, context, adapter, the shared notes and results
+// of its dependencies in its prompt, and a fingerprint of its inputs (the
+// files under AllowedPaths, or the git working tree when none are set).
+func (q *Queen) taskCacheKey(t *task.Task, adapterName string) (string, error) {
+	var inputs string
+	var err error
+	if len(t.AllowedPaths) > 0 {
+		inputs, err = hashPaths(q.cfg.ProjectDir, t.AllowedPaths)
+	} else {
+		inputs, err = gitFingerprint(q.cfg.ProjectDir, q.cfg.HiveDir)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(t.Context))
+	for k := range t.Context {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var taskCtx strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&taskCtx, "%s=%s\n", k, t.Context[k])
+	}
+
+	h := sha256.New()
+	for _, part := range []string{
+		string(t.Type),
+		t.GetDescription(),
+		strings.Join(t.GetConstraints(), "\n"),
+		taskCtx.String(),
+		adapterName,
+		strings.Join(t.GetNotes(), "\n"),
+		t.GetDepResults(),
+		inputs,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkCache computes t's cache key and looks it up. It returns the key (empty
+// when caching does not apply) and the cached entry on a hit.
+func (q *Queen) checkCache(ctx context.Context, t *task.Task, adapterName string) (string, *state.CacheRow) {
+	if !q.cacheEnabled(t) {
+		return "", nil
+	}
+	key, err := q.taskCacheKey(t, adapterName)
+	if err != nil {
+		q.logVerbose("  Cache disabled for %s: %v", t.ID, err)
+		return "", nil
+	}
+
+	entry, err := q.db.GetCache(ctx, key)
+	if err != nil {
+		return key, nil
+	}
+	if q.cfg.Cache.MaxAge > 0 {
+		created, err := time.Parse(time.RFC3339Nano, entry.CreatedAt)
+		if err != nil || time.Since(created) > q.cfg.Cache.MaxAge {
+			return key, nil
+		}
+	}
+	return key, entry
+}
+
+// completeFromCache marks t complete using a cached result instead of
+// spawning a worker. It returns false if the entry could not be decoded.
+func (q *Queen) completeFromCache(ctx context.Context, t *task.Task, entry *state.CacheRow) bool {
+	var result task.Result
+	if err := json.Unmarshal([]byte(entry.Result), &result); err != nil {
+		q.logger.Printf("⚠ Warning: discarding unreadable cache entry %s: %v", shortKey(entry.Key), err)
+		return false
+	}
+	artifacts := make(map[string]string, len(result.Artifacts)+2)
+	for k, v := range result.Artifacts {
+		artifacts[k] = v
+	}
+	artifacts[ArtifactCacheKey] = entry.Key
+	artifacts[ArtifactCachedFrom] = entry.SessionID + "/" + entry.TaskID
+	result.Artifacts = artifacts
+
+	if err := q.tasks.UpdateStatus(t.ID, task.StatusComplete); err != nil {
+		q.logger.Printf("⚠ Warning: failed to update task status: %v", err)
+	}
+	t.SetResult(&result)
+	if err := q.db.UpdateTaskStatus(ctx, q.sessionID, t.ID, "complete"); err != nil {
+		q.logger.Printf("⚠ Warning: failed to update task status: %v", err)
+	}
+	if err := q.db.UpdateTaskResult(ctx, q.sessionID, t.ID, &result); err != nil {
+		q.logger.Printf("⚠ Warning: failed to update task result: %v", err)
+	}
+
+	if n, err := q.db.StartAttempt(ctx, q.sessionID, t.ID, "", "cache"); err == nil {
+		_ = q.db.FinishAttempt(ctx, state.AttemptRow{
+			SessionID: q.sessionID, TaskID: t.ID, Attempt: n,
+			Status: AttemptCached, Output: truncate(result.Output, maxOutputLen),
+		})
+	}
+
+	q.board.Post(&blackboard.Entry{
+		Key:      fmt.Sprintf("result-%s", t.ID),
+		Value:    result.Output,
+		PostedBy: "cache",
+		TaskID:   t.ID,
+		Tags:     []string{"result", "cached"},
+	})
+
+	q.logger.Printf("♻ Cache hit for task %s (from %s/%s)", t.ID, entry.SessionID, entry.TaskID)
+	return true
+}
+
+// rememberCacheKey records the cache key of a task just handed to workerID.
+func (q *Queen) rememberCacheKey(workerID, key, adapterName string) {
+	if key == "" {
+		return
+	}
+	q.mu.Lock()
+	if q.cacheKeys == nil {
+		q.cacheKeys = make(map[string]pendingCache)
+	}
+	q.cacheKeys[workerID] = pendingCache{key: key, adapter: adapterName}
+	q.mu.Unlock()
+}
+
+// storeCachedResult saves a successful worker result under the key recorded
+// when the worker was spawned. Workers without a key are ignored.
+func (q *Queen) storeCachedResult(ctx context.Context, workerID string, t *task.Task, result *task.Result) {
+	q.mu.Lock()
+	pc, ok := q.cacheKeys[workerID]
+	delete(q.cacheKeys, workerID)
+	q.mu.Unlock()
+	if !ok || t == nil || result == nil || !result.Success {
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := q.db.PutCache(ctx, state.CacheRow{
+		Key:       pc.key,
+		TaskType:  string(t.Type),
+		Title:     t.Title,
+		Adapter:   pc.adapter,
+		Result:    string(data),
+		SessionID: q.sessionID,
+		TaskID:    t.ID,
+	}); err != nil {
+		q.logger.Printf("⚠ Warning: failed to cache task result: %v", err)
+	}
+}
+
+// invalidateCache drops cached results produced by a task whose output was
+// rejected, so later sessions do not reuse it.
+func (q *Queen) invalidateCache(ctx context.Context, taskID string) {
+	if err := q.db.DeleteCacheForTask(ctx, q.sessionID, taskID); err != nil {
+		q.logger.Printf("⚠ Warning: failed to invalidate cached result: %v", err)
+	}
+}
+
+// hashPaths fingerprints the files under paths (relative to projectDir). A
+// glob is hashed as the files it matches under the directory before its
+// first wildcard.
+func hashPaths(projectDir string, paths []string) (string, error) {
+	var files []string
+	h := sha256.New()
+	for _, p := range paths {
+		root := p
+		var glob *regexp.Regexp
+		if i := strings.IndexAny(p, "*?["); i >= 0 {
+			re, err := globRegexp(filepath.ToSlash(p))
+			if err != nil {
+				return "", fmt.Errorf("hash %s: %w", p, err)
+			}
+			glob, root = re, filepath.Dir(p[:i]+"x")
+		}
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(projectDir, root)
+		}
+		if _, err := os.Stat(root); err != nil {
+			fmt.Fprintf(h, "missing:%s\x00", p)
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name := d.Name(); name == ".git" || name == ".hive" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if glob != nil && !glob.MatchString(globName(projectDir, p, path)) {
+				return nil
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", p, err)
+		}
+	}
+
+	sort.Strings(files)
+	for _, f := range files {
+		if err := hashFile(h, projectDir, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// globName returns what glob pattern p is matched against for path: the path
+// relative to projectDir (or absolute, for an absolute pattern), or the base
+// name for a pattern without "/".
+func globName(projectDir, p, path string) string {
+	if !strings.Contains(filepath.ToSlash(p), "/") {
+		return filepath.Base(path)
+	}
+	if !filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(projectDir, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// gitFingerprint identifies the working tree by HEAD's tree hash plus any
+// uncommitted changes and untracked files. The hive directory is excluded.
+func gitFingerprint(projectDir, hiveDir string) (string, error) {
+	tree, err := runGit(projectDir, "rev-parse", "HEAD^{tree}")
+	if err != nil {
+		return "", fmt.Errorf("no git tree to fingerprint and no allowed_paths set")
+	}
+	exclude := ":(exclude)" + hiveDir
+	diff, err := runGit(projectDir, "diff", "HEAD", "--", ".", exclude)
+	if err != nil {
+		return "", fmt.Errorf("git diff: %w", err)
+	}
+	untracked, err := runGit(projectDir, "ls-files", "--others", "--exclude-standard", "--", ".", exclude)
+	if err != nil {
+		return "", fmt.Errorf("git ls-files: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", strings.TrimSpace(tree), diff)
+	for _, f := range strings.Split(strings.TrimSpace(untracked), "\n") {
+		if f == "" {
+			continue
+		}
+		if err := hashFile(h, projectDir, filepath.Join(projectDir, f)); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, projectDir, path string) error {
+	rel, err := filepath.Rel(projectDir, path)
+	if err != nil {
+		rel = path
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("hash %s: %w", rel, err)
+	}
+	defer f.Close()
+	fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("hash %s: %w", rel, err)
+	}
+	h.Write([]byte{0})
+	return nil
+}
+
+func shortKey(key string) string {
+	if len(key) > 12 {
+		return key[:12]
+	}
+	return key
+}
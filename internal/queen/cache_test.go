@@ -0,0 +1,231 @@
+package queen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/adapter"
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+// useExecRouter routes every task type to the exec adapter.
+func useExecRouter(q *Queen, dir string) {
+	registry := adapter.NewRegistry()
+	registry.Register(adapter.NewExecAdapter(dir, q.guard))
+	q.router = adapter.NewTaskRouter(registry, "exec")
+}
+
+func cacheTestQueen(t *testing.T) (*Queen, string) {
+	t.Helper()
+	q, dir := testQueen(t)
+	q.cfg.Cache.Enabled = true
+	q.cfg.Cache.Types = []string{"research"}
+	useExecRouter(q, dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "api.md"), []byte("v1"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return q, dir
+}
+
+func TestCacheEnabled(t *testing.T) {
+	q, _ := cacheTestQueen(t)
+
+	if !q.cacheEnabled(&task.Task{Type: task.TypeResearch}) {
+		t.Error("research tasks should be cached by default")
+	}
+	if q.cacheEnabled(&task.Task{Type: task.TypeCode}) {
+		t.Error("code tasks should not be cached by default")
+	}
+	if !q.cacheEnabled(&task.Task{Type: task.TypeCode, Cache: boolPtr(true)}) {
+		t.Error("task opt-in should override type defaults")
+	}
+	if q.cacheEnabled(&task.Task{Type: task.TypeResearch, Cache: boolPtr(false)}) {
+		t.Error("task opt-out should override type defaults")
+	}
+
+	q.cfg.Cache.Enabled = false
+	if q.cacheEnabled(&task.Task{Type: task.TypeResearch}) {
+		t.Error("cache should be off when disabled in config")
+	}
+}
+
+func TestTaskCacheKey_ChangesWithInputs(t *testing.T) {
+	q, dir := cacheTestQueen(t)
+	tk := &task.Task{ID: "r1", Type: task.TypeResearch, Description: "Summarize the API", AllowedPaths: []string{"docs"}}
+
+	k1, err := q.taskCacheKey(tk, "exec")
+	if err != nil {
+		t.Fatalf("taskCacheKey: %v", err)
+	}
+	k2, _ := q.taskCacheKey(tk, "exec")
+	if k1 != k2 {
+		t.Error("key should be stable for unchanged inputs")
+	}
+	if k3, _ := q.taskCacheKey(tk, "claude-code"); k3 == k1 {
+		t.Error("key should depend on the adapter")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "docs", "api.md"), []byte("v2"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if k4, _ := q.taskCacheKey(tk, "exec"); k4 == k1 {
+		t.Error("key should change when a file under allowed_paths changes")
+	}
+
+	// No allowed_paths and no git repository: caching does not apply.
+	if _, err := q.taskCacheKey(&task.Task{Type: task.TypeResearch}, "exec"); err == nil {
+		t.Error("expected error without allowed_paths outside a git repository")
+	}
+}
+
+func TestTaskCacheKey_GlobPaths(t *testing.T) {
+	q, dir := cacheTestQueen(t)
+	write := func(name, data string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write("internal/api/client.go", "package api")
+	write("internal/api/README.md", "notes")
+	tk := &task.Task{ID: "r1", Type: task.TypeResearch, Description: "Review the client", AllowedPaths: []string{"internal/**/*.go"}}
+
+	k1, err := q.taskCacheKey(tk, "exec")
+	if err != nil {
+		t.Fatalf("taskCacheKey: %v", err)
+	}
+	write("internal/api/README.md", "more notes")
+	if k2, _ := q.taskCacheKey(tk, "exec"); k2 != k1 {
+		t.Error("key should not change when a file outside the glob changes")
+	}
+	write("internal/api/client.go", "package api // v2")
+	if k3, _ := q.taskCacheKey(tk, "exec"); k3 == k1 {
+		t.Error("key should change when a file matched by the glob changes")
+	}
+}
+
+func TestCheckCache_DependencyResults(t *testing.T) {
+	q, _ := cacheTestQueen(t)
+	ctx := context.Background()
+	addCompletedTask(t, q, "schema", "users table has an email column", nil)
+	tk := &task.Task{ID: "r1", Title: "Summarize", Type: task.TypeResearch, Status: task.StatusPending,
+		Description: "Summarize the API", AllowedPaths: []string{"docs"}, DependsOn: []string{"schema"}}
+	addPersistedTask(t, q, tk)
+
+	q.prepareWorker(ctx, tk)
+	key, _ := q.checkCache(ctx, tk, "exec")
+	q.rememberCacheKey("w-1", key, "exec")
+	q.storeCachedResult(ctx, "w-1", tk, &task.Result{Success: true, Output: "API keyed by email"})
+	if _, hit := q.checkCache(ctx, tk, "exec"); hit == nil {
+		t.Fatal("expected a hit for unchanged dependency results")
+	}
+
+	// The upstream output changes while the hashed files do not.
+	dep, _ := q.tasks.Get("schema")
+	dep.SetResult(&task.Result{Success: true, Output: "users table keyed by username"})
+	q.prepareWorker(ctx, tk)
+	if key2, hit := q.checkCache(ctx, tk, "exec"); hit != nil || key2 == key {
+		t.Errorf("expected a miss with a new key after the dependency's output changed, got key=%q hit=%v", key2, hit)
+	}
+}
+
+func TestCheckCache_NotesAndContext(t *testing.T) {
+	q, _ := cacheTestQueen(t)
+	ctx := context.Background()
+	tk := &task.Task{ID: "r1", Title: "Summarize", Type: task.TypeResearch, Status: task.StatusPending,
+		Description: "Summarize the API", AllowedPaths: []string{"docs"}, Context: map[string]string{"audience": "devs"}}
+	addPersistedTask(t, q, tk)
+
+	q.prepareWorker(ctx, tk)
+	key, _ := q.checkCache(ctx, tk, "exec")
+
+	// A note shared with workers now reaches the task's prompt.
+	q.board.Post(&blackboard.Entry{Key: "style", Value: "Use British spelling", Tags: []string{tagWorkers}})
+	q.prepareWorker(ctx, tk)
+	withNote, _ := q.checkCache(ctx, tk, "exec")
+	if withNote == key {
+		t.Error("key should change when a shared note is added")
+	}
+
+	tk.Context["audience"] = "managers"
+	if withContext, _ := q.checkCache(ctx, tk, "exec"); withContext == withNote {
+		t.Error("key should change with the task context")
+	}
+}
+
+func TestAssignTask_CacheHit(t *testing.T) {
+	q, _ := cacheTestQueen(t)
+	ctx := context.Background()
+
+	// A previous session produced a result for identical inputs.
+	prev := &task.Task{ID: "old", Title: "Old", Type: task.TypeResearch, Description: "Summarize the API", AllowedPaths: []string{"docs"}}
+	injectDefaultConstraints(prev)
+	key, hit := q.checkCache(ctx, prev, "exec")
+	if key == "" || hit != nil {
+		t.Fatalf("expected a cache miss with a key, got key=%q hit=%v", key, hit)
+	}
+	q.rememberCacheKey("w-old", key, "exec")
+	q.storeCachedResult(ctx, "w-old", prev, &task.Result{Success: true, Output: "API has 3 endpoints"})
+
+	tk := &task.Task{ID: "r1", Title: "Summarize", Type: task.TypeResearch, Status: task.StatusPending,
+		Description: "Summarize the API", AllowedPaths: []string{"docs"}}
+	addPersistedTask(t, q, tk)
+
+	out, err := handleAssignTask(ctx, q, toJSON(map[string]interface{}{"task_id": "r1"}))
+	if err != nil {
+		t.Fatalf("assign_task: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, "completed from cache") {
+		t.Errorf("expected cache hit message, got: %s", out.LLMContent)
+	}
+	if tk.GetStatus() != task.StatusComplete {
+		t.Errorf("status = %s, want complete", tk.GetStatus())
+	}
+
+	got, err := handleGetTaskOutput(ctx, q, toJSON(map[string]interface{}{"task_id": "r1"}))
+	if err != nil {
+		t.Fatalf("get_task_output: %v", err)
+	}
+	if !strings.Contains(got.LLMContent, "API has 3 endpoints") || !strings.Contains(got.LLMContent, "Source: result cache") {
+		t.Errorf("unexpected output: %s", got.LLMContent)
+	}
+
+	attempts, _ := q.db.ListAttempts(ctx, q.sessionID, "r1")
+	if len(attempts) != 1 || attempts[0].Status != AttemptCached {
+		t.Errorf("expected one cached attempt, got %+v", attempts)
+	}
+}
+
+func TestRejectTask_InvalidatesCache(t *testing.T) {
+	q, _ := cacheTestQueen(t)
+	ctx := context.Background()
+
+	tk := &task.Task{ID: "r1", Title: "R", Type: task.TypeResearch, Status: task.StatusComplete,
+		Description: "Summarize", AllowedPaths: []string{"docs"}, MaxRetries: 2}
+	addPersistedTask(t, q, tk)
+	key, _ := q.checkCache(ctx, tk, "exec")
+	q.rememberCacheKey("w-1", key, "exec")
+	q.storeCachedResult(ctx, "w-1", tk, &task.Result{Success: true, Output: "wrong"})
+
+	if _, err := handleRejectTask(ctx, q, toJSON(map[string]interface{}{"task_id": "r1", "feedback": "incomplete"})); err != nil {
+		t.Fatalf("reject_task: %v", err)
+	}
+	entries, _ := q.db.ListCache(ctx)
+	if len(entries) != 0 {
+		t.Errorf("rejected result should be evicted from cache, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,67 @@
+package queen
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+// replayLLM returns a client that answers every Queen LLM call from the
+// cassette.replay file, or nil when none is configured. No provider is
+// needed to replay.
+func replayLLM(cfg *config.Config, logger *log.Logger) (llm.Client, error) {
+	c := cfg.Cassette
+	if c.Replay == "" {
+		return nil, nil
+	}
+	if c.Record != "" {
+		return nil, fmt.Errorf("cassette: record and replay cannot be used together")
+	}
+	path := cfg.CassettePath(c.Replay)
+	client, err := llm.NewReplayClient(path, c.Match)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	logger.Printf("✓ Queen LLM replaying %s (%d calls)", path, client.Remaining())
+	return client, nil
+}
+
+// recordLLM wraps the Queen's client, and the clients of its other purposes,
+// so all their calls are recorded to the cassette.record file in the order
+// they are made. Clients without tool use cannot be recorded and are left
+// as they are.
+func recordLLM(cfg *config.Config, logger *log.Logger, client llm.Client, routes map[string]route) (llm.Client, *llm.RecordingClient, error) {
+	tc, ok := client.(llm.ToolClient)
+	if !ok {
+		return nil, nil, fmt.Errorf("record: provider %q does not support tool use", cfg.Queen.Provider)
+	}
+	path := cfg.CassettePath(cfg.Cassette.Record)
+	rec, err := llm.NewRecordingClient(tc, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("record: %w", err)
+	}
+	for purpose, r := range routes {
+		if r.client == nil {
+			continue
+		}
+		if rtc, ok := r.client.(llm.ToolClient); ok {
+			r.client = rec.Wrap(rtc)
+			routes[purpose] = r
+		} else {
+			logger.Printf("⚠ Warning: %s calls are not recorded: their provider does not support tool use", purpose)
+		}
+	}
+	logger.Printf("✓ Recording Queen LLM calls to %s", path)
+	return rec, rec, nil
+}
+
+// forStream returns the client a child Queen uses for the calls of stream.
+// Only cassette clients tell the streams apart.
+func forStream(client llm.Client, stream string) llm.Client {
+	if cc, ok := client.(llm.CassetteClient); ok {
+		return cc.ForStream(stream)
+	}
+	return client
+}
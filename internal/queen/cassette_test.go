@@ -0,0 +1,87 @@
+package queen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func TestCassetteRecordReplayAgent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+
+	q := setupTestQueen(t)
+	q.cfg.Cassette.Record = path
+	client, rec, err := recordLLM(q.cfg, q.logger, &mockToolClient{responses: []*llm.Response{
+		makeToolResponse("call-1", "create_tasks", toJSON(map[string]interface{}{
+			"tasks": []map[string]interface{}{{"id": "task-1", "title": "Test task", "description": "echo hello", "type": "test"}},
+		})),
+		makeToolResponse("call-2", "complete", toJSON(map[string]string{"summary": "All done"})),
+	}}, nil)
+	if err != nil {
+		t.Fatalf("recordLLM: %v", err)
+	}
+	q.llm = client
+	if err := q.RunAgent(context.Background(), "test objective"); err != nil {
+		t.Fatalf("recorded RunAgent: %v", err)
+	}
+	rec.Close()
+
+	// A fresh Queen with no provider replays the run from the cassette alone.
+	q2 := setupTestQueen(t)
+	q2.cfg.Cassette.Replay = path
+	replay, err := replayLLM(q2.cfg, q2.logger)
+	if err != nil {
+		t.Fatalf("replayLLM: %v", err)
+	}
+	q2.llm = replay
+	if err := q2.RunAgent(context.Background(), "test objective"); err != nil {
+		t.Fatalf("replayed RunAgent: %v", err)
+	}
+	if tasks := q2.tasks.All(); len(tasks) != 1 || tasks[0].ID != "task-1" {
+		t.Fatalf("expected the replay to create task-1, got %+v", tasks)
+	}
+	if n := replay.(*llm.ReplayClient).Remaining(); n != 0 {
+		t.Errorf("expected every recorded call replayed, %d left", n)
+	}
+
+	// Replaying a different objective diverges instead of inventing answers.
+	q3 := setupTestQueen(t)
+	q3.cfg.Cassette.Replay = path
+	q3.cfg.Cassette.Match = llm.MatchHash
+	q3.llm, _ = replayLLM(q3.cfg, q3.logger)
+	if err := q3.RunAgent(context.Background(), "another objective"); err == nil {
+		t.Error("expected a diverging replay to fail")
+	}
+
+	q3.cfg.Cassette.Record = path
+	if _, err := replayLLM(q3.cfg, q3.logger); err == nil {
+		t.Error("expected record and replay together to be rejected")
+	}
+}
+
+func TestNewChild_CassetteStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	q := setupTestQueen(t)
+	rec, err := llm.NewRecordingClient(&mockToolClient{}, path)
+	if err != nil {
+		t.Fatalf("NewRecordingClient: %v", err)
+	}
+	q.llm = rec
+
+	child := q.newChild(&task.Task{ID: "billing"})
+	grandchild := child.newChild(&task.Task{ID: "invoices"})
+	if _, err := grandchild.llm.Chat(context.Background(), "sys", "go"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	rec.Close()
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"stream":"billing/invoices"`) {
+		t.Errorf("expected the call recorded under the child's stream:\n%s", data)
+	}
+}
@@ -0,0 +1,344 @@
+package queen
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/compact"
+	"github.com/HexSleeves/waggle/internal/task"
+	"github.com/HexSleeves/waggle/internal/worker"
+)
+
+// childAdapter is recorded as the adapter of objective tasks, which run on a
+// nested Queen rather than a worker CLI.
+const childAdapter = "queen"
+
+// maxTurnsKey is the task context key holding an objective task's turn budget.
+const maxTurnsKey = "max_turns"
+
+// SubSession links a parent task to the session of the child Queen working on
+// it. It is published on the parent's bus (MsgSubSession) when the child
+// session starts and whenever one of its tasks changes status.
+type SubSession struct {
+	TaskID    string `json:"task_id"`
+	SessionID string `json:"session_id"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+}
+
+// routeTask picks the adapter for t. Objective tasks run on a child Queen.
+func (q *Queen) routeTask(t *task.Task) string {
+	if t.Type == task.TypeObjective {
+		return childAdapter
+	}
+	return q.router.Route(t)
+}
+
+// prepareWorker fills in the parts of a worker's prompt that come from other
+// tasks and the blackboard. It runs before the cache lookup, whose key covers
+// them.
+func (q *Queen) prepareWorker(ctx context.Context, t *task.Task) {
+	if t.Type == task.TypeObjective {
+		return
+	}
+	t.SetNotes(q.workerNotes(t))
+	q.setDepResults(ctx, t)
+}
+
+// spawnWorker starts a worker for t: a child Queen for objective tasks, an
+// adapter-backed bee otherwise.
+func (q *Queen) spawnWorker(ctx context.Context, t *task.Task, adapterName string) (worker.Bee, error) {
+	if t.Type != task.TypeObjective {
+		return q.pool.Spawn(ctx, t, adapterName)
+	}
+	if q.depth >= q.cfg.Queen.MaxDepth {
+		return nil, fmt.Errorf("nesting depth limit (%d) reached, split %q into regular tasks", q.cfg.Queen.MaxDepth, t.ID)
+	}
+	bee := newChildBee(q.newChild(t), t)
+	if err := q.pool.Adopt(ctx, t, bee); err != nil {
+		return nil, err
+	}
+	return bee, nil
+}
+
+// newChild builds a child Queen for an objective task. It shares the parent's
+// database, adapters, safety guard and LLM client, and draws workers from a
+// sub-pool of the parent's pool, but has its own bus, task graph, blackboard,
+// conversation and turn budget.
+func (q *Queen) newChild(t *task.Task) *Queen {
+	cfg := *q.cfg
+	cfg.Queen.MaxIterations = q.cfg.Queen.SubMaxIterations
+	if n, err := strconv.Atoi(t.Context[maxTurnsKey]); err == nil && n > 0 {
+		cfg.Queen.MaxIterations = n
+	}
+	// The child's whole session is one task of the parent's.
+	if cfg.Budget.MaxTaskUSD > 0 {
+		cfg.Budget.MaxSessionUSD = cfg.Budget.MaxTaskUSD
+	}
+
+	// A cassette keeps each child's calls apart, as children run concurrently.
+	stream := t.ID
+	if q.stream != "" {
+		stream = q.stream + "/" + t.ID
+	}
+	routes := make(map[string]route, len(q.routes))
+	for purpose, r := range q.routes {
+		r.client = forStream(r.client, stream)
+		routes[purpose] = r
+	}
+
+	msgBus := bus.New(1000)
+	child := &Queen{
+		cfg:            &cfg,
+		bus:            msgBus,
+		db:             q.db,
+		board:          blackboard.New(msgBus),
+		tasks:          task.NewTaskGraph(msgBus),
+		pool:           q.pool.Sub(msgBus),
+		router:         q.router,
+		registry:       q.registry,
+		ctx:            compact.NewContext(200000),
+		llm:            forStream(q.llm, stream),
+		routes:         routes,
+		guard:          q.guard,
+		asker:          q.asker,
+		phase:          PhasePlan,
+		logger:         q.logger,
+		suppressReport: true,
+		quiet:          true,
+		suppressBanner: true,
+		assignments:    make(map[string]string),
+		parent:         q,
+		parentTask:     t.ID,
+		depth:          q.depth + 1,
+		stream:         stream,
+	}
+	child.logEvents()
+	return child
+}
+
+// linkParent records a child Queen's session under its parent's session and
+// announces it on the parent's bus. It is a no-op for a top-level Queen.
+func (q *Queen) linkParent(ctx context.Context) {
+	if q.parent == nil {
+		return
+	}
+	if err := q.db.SetSessionParent(ctx, q.sessionID, q.parent.sessionID, q.parentTask); err != nil {
+		q.logger.Printf("⚠ Warning: failed to link session to parent: %v", err)
+	}
+	q.announceSubSession(0, 0)
+}
+
+// announceSubSession publishes the child session's progress on the parent's bus.
+func (q *Queen) announceSubSession(done, total int) {
+	if q.parent == nil || q.parent.bus == nil {
+		return
+	}
+	q.parent.bus.Publish(bus.Message{
+		Type:   bus.MsgSubSession,
+		TaskID: q.parentTask,
+		Payload: SubSession{
+			TaskID:    q.parentTask,
+			SessionID: q.sessionID,
+			Done:      done,
+			Total:     total,
+		},
+		Time: time.Now(),
+	})
+}
+
+// childObjective phrases an objective task as the objective of a child Queen.
+func childObjective(parentObjective string, t *task.Task) string {
+	var b strings.Builder
+	b.WriteString(t.Title)
+	if d := t.GetDescription(); d != "" {
+		b.WriteString("\n\n" + d)
+	}
+	if c := t.GetConstraints(); len(c) > 0 {
+		b.WriteString("\n\nConstraints:")
+		for _, line := range c {
+			b.WriteString("\n- " + line)
+		}
+	}
+	if len(t.AllowedPaths) > 0 {
+		fmt.Fprintf(&b, "\n\nOnly modify files under: %s", strings.Join(t.AllowedPaths, ", "))
+	}
+	if parentObjective != "" {
+		fmt.Fprintf(&b, "\n\nThis is part of a larger objective coordinated by another Queen: %s", parentObjective)
+	}
+	return b.String()
+}
+
+// subResult reports a finished child run to the parent as a task result: the
+// child's summary followed by the final state of its tasks.
+func (q *Queen) subResult(runErr error) *task.Result {
+	q.mu.RLock()
+	summary, phase := q.summary, q.phase
+	q.mu.RUnlock()
+
+	all := q.tasks.All()
+	done := 0
+	for _, t := range all {
+		if t.GetStatus() == task.StatusComplete {
+			done++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sub-session %s: %d/%d task(s) complete\n", q.sessionID, done, len(all))
+	if summary != "" {
+		fmt.Fprintf(&b, "\nSummary: %s\n", summary)
+	}
+	if len(all) > 0 {
+		b.WriteString("\nTasks:\n")
+		for _, t := range all {
+			fmt.Fprintf(&b, "  - [%s] %s: %s\n", t.GetStatus(), t.ID, t.Title)
+		}
+	}
+
+	// Report the child's spend so the parent charges it to the task.
+	spend := q.Spent()
+	result := &task.Result{
+		Success: runErr == nil && phase != PhaseFailed,
+		Output:  b.String(),
+		Metrics: map[string]float64{"cost_usd": spend.SessionUSD, "tokens": float64(spend.Tokens)},
+	}
+	if runErr != nil {
+		result.Errors = []string{runErr.Error()}
+	}
+	return result
+}
+
+// childBee runs a child Queen on an objective task. It lives in the parent's
+// worker pool, so wait_for_workers, kill and result handling treat it like any
+// other worker, but it does not take one of the parent's worker slots.
+type childBee struct {
+	id    string
+	queen *Queen
+
+	mu       sync.Mutex
+	status   worker.Status
+	result   *task.Result
+	progress []string
+	statuses map[string]task.Status
+	cancel   context.CancelFunc
+}
+
+func newChildBee(child *Queen, t *task.Task) *childBee {
+	b := &childBee{
+		id:       fmt.Sprintf("queen-%d", time.Now().UnixNano()),
+		queen:    child,
+		status:   worker.StatusIdle,
+		statuses: make(map[string]task.Status),
+	}
+	// Bus handlers run synchronously under the child's graph lock, so they
+	// only touch the bee's own state.
+	child.bus.Subscribe(bus.MsgTaskCreated, func(msg bus.Message) {
+		b.track(msg.TaskID, "", task.StatusPending)
+	})
+	child.bus.Subscribe(bus.MsgTaskStatusChanged, func(msg bus.Message) {
+		if change, ok := msg.Payload.(map[string]task.Status); ok {
+			b.track(msg.TaskID, change["old"], change["new"])
+		}
+	})
+	return b
+}
+
+// track records a child task's status change and reports progress upstream.
+func (b *childBee) track(taskID string, old, status task.Status) {
+	b.mu.Lock()
+	b.statuses[taskID] = status
+	if old == "" {
+		b.progress = append(b.progress, fmt.Sprintf("+ %s", taskID))
+	} else {
+		b.progress = append(b.progress, fmt.Sprintf("%s: %s -> %s", taskID, old, status))
+	}
+	done := 0
+	for _, s := range b.statuses {
+		if s == task.StatusComplete {
+			done++
+		}
+	}
+	total := len(b.statuses)
+	b.mu.Unlock()
+
+	b.queen.announceSubSession(done, total)
+}
+
+func (b *childBee) ID() string   { return b.id }
+func (b *childBee) Type() string { return childAdapter }
+
+func (b *childBee) Spawn(ctx context.Context, t *task.Task) error {
+	parent := b.queen.parent
+	parent.mu.RLock()
+	objective := childObjective(parent.objective, t)
+	parent.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	b.mu.Lock()
+	b.status = worker.StatusRunning
+	b.cancel = cancel
+	b.mu.Unlock()
+
+	go b.run(ctx, objective)
+	return nil
+}
+
+func (b *childBee) run(ctx context.Context, objective string) {
+	defer b.queen.pool.Release()
+
+	err := b.queen.RunAgent(ctx, objective)
+	if ctx.Err() != nil && b.queen.sessionID != "" {
+		// Interrupted: the parent re-runs the objective task on resume.
+		if err := b.queen.db.UpdateSessionStatus(context.Background(), b.queen.sessionID, "stopped"); err != nil {
+			b.queen.logger.Printf("⚠ Warning: failed to update session status: %v", err)
+		}
+	}
+	result := b.queen.subResult(err)
+
+	b.mu.Lock()
+	b.result = result
+	if result.Success {
+		b.status = worker.StatusComplete
+	} else {
+		b.status = worker.StatusFailed
+	}
+	b.mu.Unlock()
+}
+
+func (b *childBee) Monitor() worker.Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.status
+}
+
+func (b *childBee) Result() *task.Result {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.result
+}
+
+func (b *childBee) Kill() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.status = worker.StatusFailed
+	return nil
+}
+
+func (b *childBee) Output() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.result != nil {
+		return b.result.Output
+	}
+	return strings.Join(b.progress, "\n")
+}
@@ -0,0 +1,115 @@
+package queen
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/task"
+	"github.com/HexSleeves/waggle/internal/worker"
+)
+
+func TestCreateTasks_ObjectiveDepthLimit(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Queen.MaxDepth = 1
+	q.depth = 1
+
+	_, err := handleCreateTasks(context.Background(), q, toJSON(map[string]interface{}{
+		"tasks": []map[string]interface{}{
+			{"id": "sub", "title": "Sub", "description": "nested", "type": "objective"},
+		},
+	}))
+	if err == nil || !strings.Contains(err.Error(), "nesting depth") {
+		t.Fatalf("expected nesting depth error, got %v", err)
+	}
+}
+
+func TestObjectiveTask_RunsChildQueen(t *testing.T) {
+	q, dir := testQueen(t)
+	useExecRouter(q, dir)
+	q.cfg.Queen.MaxDepth = 1
+	ctx := context.Background()
+
+	var subs []SubSession
+	q.bus.Subscribe(bus.MsgSubSession, func(msg bus.Message) {
+		subs = append(subs, msg.Payload.(SubSession))
+	})
+
+	// The child Queen plans one task, then reports back.
+	q.llm = &mockToolClient{responses: []*llm.Response{
+		makeToolResponse("c-1", "create_tasks", toJSON(map[string]interface{}{
+			"tasks": []map[string]interface{}{
+				{"id": "invoices", "title": "Port invoices", "description": "use the v2 client", "type": "code"},
+			},
+		})),
+		makeToolResponse("c-2", "complete", toJSON(map[string]string{"summary": "billing plan ready"})),
+	}}
+
+	if _, err := handleCreateTasks(ctx, q, toJSON(map[string]interface{}{
+		"tasks": []map[string]interface{}{
+			{"id": "billing", "title": "Migrate billing", "description": "move billing to the v2 API", "type": "objective", "max_turns": 5},
+		},
+	})); err != nil {
+		t.Fatalf("create_tasks: %v", err)
+	}
+	if _, err := handleAssignTask(ctx, q, toJSON(map[string]string{"task_id": "billing"})); err != nil {
+		t.Fatalf("assign_task: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var bee worker.Bee
+	for time.Now().Before(deadline) {
+		if bees := q.pool.Active(); len(bees) == 0 {
+			break
+		} else {
+			bee = bees[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if bee == nil || bee.Type() != childAdapter {
+		t.Fatalf("expected a child Queen worker, got %v", bee)
+	}
+	q.processWorkerResults(ctx)
+
+	tk, _ := q.tasks.Get("billing")
+	if tk.GetStatus() != task.StatusComplete {
+		t.Fatalf("expected objective task complete, got %s", tk.GetStatus())
+	}
+	out := tk.GetResult().Output
+	for _, want := range []string{"Summary: billing plan ready", "[pending] invoices: Port invoices", "0/1 task(s) complete"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("result missing %q:\n%s", want, out)
+		}
+	}
+
+	children, err := q.db.ListChildSessions(ctx, q.sessionID)
+	if err != nil || len(children) != 1 {
+		t.Fatalf("expected one child session, got %v (%v)", children, err)
+	}
+	child := children[0]
+	if child.ParentTaskID != "billing" || child.Status != "done" || child.TotalTasks != 1 {
+		t.Errorf("unexpected child session: %+v", child)
+	}
+	row, err := q.db.GetTask(ctx, child.ID, "invoices")
+	if err != nil || row.ParentID != "billing" {
+		t.Errorf("expected child task linked to billing, got %+v (%v)", row, err)
+	}
+
+	if len(subs) == 0 || subs[0].SessionID != child.ID {
+		t.Fatalf("expected sub-session announcements for %s, got %+v", child.ID, subs)
+	}
+	if last := subs[len(subs)-1]; last.Total != 1 {
+		t.Errorf("expected final progress to count 1 task, got %+v", last)
+	}
+
+	_, infos, err := LoadSessionGraph(ctx, q.db, q.sessionID)
+	if err != nil {
+		t.Fatalf("LoadSessionGraph: %v", err)
+	}
+	if infos["billing"].SubSession != child.ID || infos["billing"].Adapter != childAdapter {
+		t.Errorf("expected DAG node info to link the child session: %+v", infos["billing"])
+	}
+}
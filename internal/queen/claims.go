@@ -0,0 +1,195 @@
+package queen
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// defaultReadOnlyTypes are the task types that may share claimed paths when
+// workers.read_only_types is not configured.
+var defaultReadOnlyTypes = []string{string(task.TypeResearch), string(task.TypeReview)}
+
+// pathClaim is a task's claim on a project path. "." claims the whole project.
+type pathClaim struct {
+	path  string
+	write bool
+}
+
+// TaskHold explains why a ready task is not being scheduled.
+type TaskHold struct {
+	TaskID    string `json:"task_id"`
+	BlockedBy string `json:"blocked_by"`
+	Path      string `json:"path"`
+}
+
+func (h TaskHold) String() string {
+	return fmt.Sprintf("path %s is claimed by running task %s", h.Path, h.BlockedBy)
+}
+
+// claimsFor returns the path claims of t. Tasks without AllowedPaths claim
+// the whole project unless workers.unscoped_claim is "none".
+func (q *Queen) claimsFor(t *task.Task) []pathClaim {
+	write := !q.isReadOnlyType(t.Type)
+	if len(t.AllowedPaths) == 0 {
+		if q.cfg.Workers.UnscopedClaim == config.UnscopedClaimNone {
+			return nil
+		}
+		return []pathClaim{{path: ".", write: write}}
+	}
+	claims := make([]pathClaim, 0, len(t.AllowedPaths))
+	for _, p := range t.AllowedPaths {
+		claims = append(claims, pathClaim{path: q.normalizeClaimPath(p), write: write})
+	}
+	return claims
+}
+
+func (q *Queen) isReadOnlyType(typ task.Type) bool {
+	types := q.cfg.Workers.ReadOnlyTypes
+	if types == nil {
+		types = defaultReadOnlyTypes
+	}
+	for _, t := range types {
+		if strings.EqualFold(t, string(typ)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeClaimPath turns an allowed path (relative, absolute or glob) into a
+// slash-separated directory or file path relative to the project root.
+func (q *Queen) normalizeClaimPath(p string) string {
+	if i := strings.IndexAny(p, "*?["); i >= 0 {
+		p = filepath.Dir(p[:i] + "x")
+	}
+	if filepath.IsAbs(p) {
+		if abs, err := filepath.Abs(q.cfg.ProjectDir); err == nil {
+			if rel, err := filepath.Rel(abs, p); err == nil {
+				p = rel
+			}
+		}
+	}
+	p = filepath.ToSlash(filepath.Clean(p))
+	if p == "" || strings.HasPrefix(p, "../") || p == ".." {
+		return "."
+	}
+	return p
+}
+
+// pathsOverlap reports whether one path contains the other.
+func pathsOverlap(a, b string) bool {
+	if a == "." || b == "." || a == b {
+		return true
+	}
+	return strings.HasPrefix(b, a+"/") || strings.HasPrefix(a, b+"/")
+}
+
+// claimConflict returns the first overlapping path between two claim sets
+// where at least one side writes.
+func claimConflict(a, b []pathClaim) (string, bool) {
+	for _, ca := range a {
+		for _, cb := range b {
+			if !ca.write && !cb.write {
+				continue
+			}
+			if pathsOverlap(ca.path, cb.path) {
+				// Prefer a concrete path over the whole-project claim.
+				if ca.path == "." {
+					return cb.path, true
+				}
+				return ca.path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// holdFor checks t's claims against every running or assigned task and
+// returns the hold if one of them conflicts.
+func (q *Queen) holdFor(t *task.Task) *TaskHold {
+	claims := q.claimsFor(t)
+	if len(claims) == 0 {
+		return nil
+	}
+	for _, other := range q.tasks.All() {
+		if other.ID == t.ID {
+			continue
+		}
+		if s := other.GetStatus(); s != task.StatusRunning && s != task.StatusAssigned {
+			continue
+		}
+		if path, ok := claimConflict(claims, q.claimsFor(other)); ok {
+			return &TaskHold{TaskID: t.ID, BlockedBy: other.ID, Path: path}
+		}
+	}
+	return nil
+}
+
+// computeHolds returns the holds for all ready tasks keyed by task ID. It
+// neither stores nor publishes them.
+func (q *Queen) computeHolds() map[string]TaskHold {
+	current := make(map[string]TaskHold)
+	for _, t := range q.tasks.Ready() {
+		if h := q.holdFor(t); h != nil {
+			current[t.ID] = *h
+		}
+	}
+	return current
+}
+
+// currentHolds returns the holds for all ready tasks sorted by task ID,
+// without recording them or publishing changes. Read-only tools use it.
+func (q *Queen) currentHolds() []TaskHold {
+	return sortedHolds(q.computeHolds())
+}
+
+// refreshHolds recomputes holds for all ready tasks, publishes a MsgTaskHeld
+// for every change (an empty BlockedBy means released) and returns the
+// current holds sorted by task ID.
+func (q *Queen) refreshHolds() []TaskHold {
+	current := q.computeHolds()
+
+	q.mu.Lock()
+	prev := q.holds
+	q.holds = current
+	q.mu.Unlock()
+
+	for id, h := range current {
+		if old, ok := prev[id]; !ok || old != h {
+			q.publishHold(h)
+		}
+	}
+	for id := range prev {
+		if _, ok := current[id]; !ok {
+			q.publishHold(TaskHold{TaskID: id})
+		}
+	}
+
+	return sortedHolds(current)
+}
+
+func sortedHolds(m map[string]TaskHold) []TaskHold {
+	holds := make([]TaskHold, 0, len(m))
+	for _, h := range m {
+		holds = append(holds, h)
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].TaskID < holds[j].TaskID })
+	return holds
+}
+
+func (q *Queen) publishHold(h TaskHold) {
+	if q.bus == nil {
+		return
+	}
+	q.bus.Publish(bus.Message{
+		Type:    bus.MsgTaskHeld,
+		TaskID:  h.TaskID,
+		Payload: h,
+	})
+}
@@ -0,0 +1,163 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func TestClaimConflict(t *testing.T) {
+	w := func(p string) []pathClaim { return []pathClaim{{path: p, write: true}} }
+	r := func(p string) []pathClaim { return []pathClaim{{path: p}} }
+
+	tests := []struct {
+		name string
+		a, b []pathClaim
+		want bool
+	}{
+		{"same dir writes", w("src"), w("src"), true},
+		{"nested writes", w("src"), w("src/api"), true},
+		{"sibling prefix is not nested", w("src/api"), w("src/apix"), false},
+		{"disjoint writes", w("src"), w("docs"), false},
+		{"reads share", r("src"), r("src"), false},
+		{"read vs write", r("src"), w("src/api"), true},
+		{"project claim", w("."), r("docs"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := claimConflict(tt.a, tt.b); got != tt.want {
+				t.Errorf("claimConflict = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeClaimPath(t *testing.T) {
+	q, dir := testQueen(t)
+	for in, want := range map[string]string{
+		"src/":            "src",
+		"./src/api":       "src/api",
+		"src/**/*.go":     "src",
+		"*.md":            ".",
+		dir + "/internal": "internal",
+		"../outside":      ".",
+		"":                ".",
+	} {
+		if got := q.normalizeClaimPath(in); got != want {
+			t.Errorf("normalizeClaimPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestClaimsFor_Unscoped(t *testing.T) {
+	q, _ := testQueen(t)
+	code := &task.Task{Type: task.TypeCode}
+	research := &task.Task{Type: task.TypeResearch}
+
+	if c := q.claimsFor(code); len(c) != 1 || c[0].path != "." || !c[0].write {
+		t.Errorf("unscoped code task should write-claim the project, got %+v", c)
+	}
+	if c := q.claimsFor(research); len(c) != 1 || c[0].write {
+		t.Errorf("research task should read-claim, got %+v", c)
+	}
+
+	q.cfg.Workers.UnscopedClaim = config.UnscopedClaimNone
+	if c := q.claimsFor(code); len(c) != 0 {
+		t.Errorf("unscoped_claim=none should claim nothing, got %+v", c)
+	}
+}
+
+func TestAssignTask_HeldByPathClaim(t *testing.T) {
+	q, dir := testQueen(t)
+	useExecRouter(q, dir)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var events []TaskHold
+	q.bus.Subscribe(bus.MsgTaskHeld, func(msg bus.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, msg.Payload.(TaskHold))
+	})
+
+	addPersistedTask(t, q, &task.Task{ID: "api", Title: "API", Type: task.TypeCode, Status: task.StatusRunning, AllowedPaths: []string{"src"}})
+	addPersistedTask(t, q, &task.Task{ID: "handler", Title: "Handler", Type: task.TypeCode, Status: task.StatusPending, AllowedPaths: []string{"src/handlers"}})
+	addPersistedTask(t, q, &task.Task{ID: "survey", Title: "Survey", Type: task.TypeResearch, Status: task.StatusPending, AllowedPaths: []string{"docs"}})
+
+	_, err := handleAssignTask(ctx, q, toJSON(map[string]interface{}{"task_id": "handler"}))
+	if err == nil || !strings.Contains(err.Error(), "is held") || !strings.Contains(err.Error(), "api") {
+		t.Fatalf("expected hold error naming the blocking task, got %v", err)
+	}
+
+	out, err := handleGetStatus(ctx, q, nil)
+	if err != nil {
+		t.Fatalf("get_status: %v", err)
+	}
+	var status struct {
+		Tasks []struct {
+			ID     string `json:"id"`
+			HeldBy string `json:"held_by"`
+		} `json:"tasks"`
+		HeldTasks []TaskHold `json:"held_tasks"`
+	}
+	if err := json.Unmarshal([]byte(out.LLMContent), &status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if len(status.HeldTasks) != 1 || status.HeldTasks[0].TaskID != "handler" || status.HeldTasks[0].Path != "src/handlers" {
+		t.Errorf("unexpected held_tasks: %+v", status.HeldTasks)
+	}
+	for _, ti := range status.Tasks {
+		if ti.ID == "survey" && ti.HeldBy != "" {
+			t.Errorf("disjoint research task should not be held")
+		}
+	}
+
+	// Releasing the blocker publishes a release event.
+	_ = q.tasks.UpdateStatus("api", task.StatusComplete)
+	if holds := q.refreshHolds(); len(holds) != 0 {
+		t.Errorf("expected no holds after blocker completed, got %+v", holds)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0].BlockedBy != "api" || events[1].BlockedBy != "" {
+		t.Errorf("expected hold then release events, got %+v", events)
+	}
+}
+
+func TestGetStatus_DoesNotRecordHolds(t *testing.T) {
+	q, _ := testQueen(t)
+
+	var mu sync.Mutex
+	published := 0
+	q.bus.Subscribe(bus.MsgTaskHeld, func(bus.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		published++
+	})
+
+	addPersistedTask(t, q, &task.Task{ID: "api", Title: "API", Type: task.TypeCode, Status: task.StatusRunning, AllowedPaths: []string{"src"}})
+	addPersistedTask(t, q, &task.Task{ID: "handler", Title: "Handler", Type: task.TypeCode, Status: task.StatusPending, AllowedPaths: []string{"src/handlers"}})
+
+	out, err := handleGetStatus(context.Background(), q, nil)
+	if err != nil {
+		t.Fatalf("get_status: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, `"held_by": "api"`) && !strings.Contains(out.LLMContent, `"held_by":"api"`) {
+		t.Errorf("get_status should report the hold, got %s", out.LLMContent)
+	}
+
+	q.mu.Lock()
+	recorded := len(q.holds)
+	q.mu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	if recorded != 0 || published != 0 {
+		t.Errorf("read-only get_status changed holds: recorded=%d published=%d", recorded, published)
+	}
+}
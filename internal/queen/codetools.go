@@ -0,0 +1,521 @@
+package queen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Read-only code inspection tools. Every path goes through the safety guard;
+// results are capped so a broad query cannot flood the Queen's context.
+
+const (
+	defaultSearchResults = 50
+	maxSearchResults     = 200
+	maxMatchLineLen      = 200
+	maxGitOutput         = 20000 // bytes of diff or log text sent to the LLM
+	defaultLogCount      = 10
+	maxLogCount          = 50
+	binarySniffLen       = 8000
+)
+
+// resolveProjectPath returns p relative to the project root as an absolute path.
+func (q *Queen) resolveProjectPath(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(q.cfg.ProjectDir, p)
+}
+
+// projectFiles lists the files under dir, slash-separated and relative to the
+// project root. In a git repository .gitignore is honoured; otherwise the tree
+// is walked, skipping hidden directories.
+func (q *Queen) projectFiles(ctx context.Context, dir string) ([]string, error) {
+	root := q.cfg.ProjectDir
+	if out, err := runGitContext(ctx, root, "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--", dir); err == nil {
+		var files []string
+		for _, f := range strings.Split(out, "\x00") {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+		return files, nil
+	}
+
+	var files []string
+	start := q.resolveProjectPath(dir)
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if d.IsDir() {
+			if p != start && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(root, p); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return ctx.Err()
+	})
+	return files, err
+}
+
+// globRegexp compiles a path glob. "*" and "?" stay within one path segment
+// and "**" spans directories. A glob without "/" matches the base name.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// ---------- search_code ----------
+
+type searchCodeInput struct {
+	Pattern    string `json:"pattern"`
+	Path       string `json:"path"`
+	Glob       string `json:"glob"`
+	MaxResults int    `json:"max_results"`
+	IgnoreCase bool   `json:"ignore_case"`
+}
+
+type fileMatches struct {
+	path  string
+	count int
+}
+
+func handleSearchCode(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in searchCodeInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if in.Pattern == "" {
+		return ToolOutput{}, fmt.Errorf("pattern is required")
+	}
+	expr := in.Pattern
+	if in.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+	var glob *regexp.Regexp
+	if in.Glob != "" {
+		if glob, err = globRegexp(in.Glob); err != nil {
+			return ToolOutput{}, fmt.Errorf("invalid glob: %w", err)
+		}
+	}
+	limit := in.MaxResults
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	limit = min(limit, maxSearchResults)
+
+	dir := in.Path
+	if dir == "" {
+		dir = "."
+	}
+	if err := q.guard.CheckPath(dir); err != nil {
+		return ToolOutput{}, err
+	}
+	files, err := q.projectFiles(ctx, dir)
+	if err != nil {
+		return ToolOutput{}, fmt.Errorf("list files: %w", err)
+	}
+
+	var lines []string
+	var perFile []fileMatches
+	total := 0
+	for _, rel := range files {
+		if err := ctx.Err(); err != nil {
+			return ToolOutput{}, err
+		}
+		if glob != nil {
+			name := rel
+			if !strings.Contains(in.Glob, "/") {
+				name = path.Base(rel)
+			}
+			if !glob.MatchString(name) {
+				continue
+			}
+		}
+		n := q.searchFile(rel, re, limit-len(lines), &lines)
+		if n > 0 {
+			perFile = append(perFile, fileMatches{rel, n})
+			total += n
+		}
+	}
+
+	if total == 0 {
+		return ToolOutput{LLMContent: fmt.Sprintf("No matches for /%s/.", in.Pattern)}, nil
+	}
+	content := strings.Join(lines, "\n")
+	if total > len(lines) {
+		content += fmt.Sprintf("\n(showing %d of %d matches in %d files; narrow the pattern, path or glob)", len(lines), total, len(perFile))
+	}
+
+	sort.SliceStable(perFile, func(i, j int) bool { return perFile[i].count > perFile[j].count })
+	var disp strings.Builder
+	fmt.Fprintf(&disp, "🔎 /%s/: %d matches in %d files\n", in.Pattern, total, len(perFile))
+	for i, f := range perFile {
+		if i == 10 {
+			fmt.Fprintf(&disp, "  … %d more files\n", len(perFile)-i)
+			break
+		}
+		fmt.Fprintf(&disp, "  %4d  %s\n", f.count, f.path)
+	}
+	return ToolOutput{LLMContent: content, Display: strings.TrimRight(disp.String(), "\n")}, nil
+}
+
+// searchFile appends up to room "path:line: text" matches from one file and
+// returns how many lines matched in total. Files the guard rejects, oversized
+// files and binary files are skipped.
+func (q *Queen) searchFile(rel string, re *regexp.Regexp, room int, lines *[]string) int {
+	if q.guard.CheckPath(rel) != nil {
+		return 0
+	}
+	full := q.resolveProjectPath(rel)
+	if q.guard.CheckFileSize(full) != nil {
+		return 0
+	}
+	data, err := os.ReadFile(full)
+	if err != nil || bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0 {
+		return 0
+	}
+	count := 0
+	for i, line := range strings.Split(string(data), "\n") {
+		if !re.MatchString(line) {
+			continue
+		}
+		count++
+		if room > 0 {
+			line = strings.TrimSpace(line)
+			if len(line) > maxMatchLineLen {
+				line = line[:maxMatchLineLen] + "…"
+			}
+			*lines = append(*lines, fmt.Sprintf("%s:%d: %s", rel, i+1, line))
+			room--
+		}
+	}
+	return count
+}
+
+// ---------- git_diff ----------
+
+type gitDiffInput struct {
+	TaskID string `json:"task_id"`
+	Path   string `json:"path"`
+	Stat   bool   `json:"stat"`
+}
+
+func handleGitDiff(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in gitDiffInput
+	_ = json.Unmarshal(input, &in) // all fields optional
+
+	var paths []string
+	scope := "working tree"
+	if in.TaskID != "" {
+		t, ok := q.tasks.Get(in.TaskID)
+		if !ok {
+			return ToolOutput{}, fmt.Errorf("task %q not found", in.TaskID)
+		}
+		if len(t.AllowedPaths) == 0 {
+			return ToolOutput{}, fmt.Errorf("task %q has no allowed_paths, so its changes cannot be told apart; call git_diff without task_id", in.TaskID)
+		}
+		paths = slices.Clone(t.AllowedPaths)
+		scope = "task " + in.TaskID
+	}
+	if in.Path != "" {
+		// A path narrows a task's diff; it must lie within the task's scope.
+		if paths != nil && !slices.ContainsFunc(paths, func(p string) bool {
+			return pathsOverlap(q.normalizeClaimPath(p), q.normalizeClaimPath(in.Path))
+		}) {
+			return ToolOutput{}, fmt.Errorf("path %q is outside task %q's allowed_paths", in.Path, in.TaskID)
+		}
+		paths = []string{in.Path}
+		scope += ": " + in.Path
+	}
+	for _, p := range paths {
+		if err := q.guard.CheckPath(q.normalizeClaimPath(p)); err != nil {
+			return ToolOutput{}, err
+		}
+	}
+
+	args := []string{"diff", "HEAD"}
+	if in.Stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, "--")
+	args = append(args, paths...)
+	diff, err := runGitContext(ctx, q.cfg.ProjectDir, args...)
+	if err != nil {
+		return ToolOutput{}, err
+	}
+
+	statusArgs := append([]string{"status", "--porcelain", "--untracked-files=all", "--"}, paths...)
+	status, _ := runGitContext(ctx, q.cfg.ProjectDir, statusArgs...)
+	var untracked []string
+	for _, line := range strings.Split(status, "\n") {
+		if rest, ok := strings.CutPrefix(line, "?? "); ok {
+			untracked = append(untracked, rest)
+		}
+	}
+
+	if strings.TrimSpace(diff) == "" && len(untracked) == 0 {
+		return ToolOutput{LLMContent: fmt.Sprintf("No changes in %s.", scope)}, nil
+	}
+	content := truncateGitOutput(diff)
+	if len(untracked) > 0 {
+		content = strings.TrimRight(content, "\n") + "\n\nUntracked files:\n  " + strings.Join(untracked, "\n  ")
+	}
+
+	short, _ := runGitContext(ctx, q.cfg.ProjectDir, append([]string{"diff", "HEAD", "--shortstat", "--"}, paths...)...)
+	summary := strings.TrimSpace(short)
+	if summary == "" {
+		summary = "no tracked changes"
+	}
+	display := fmt.Sprintf("±  %s: %s", scope, summary)
+	if len(untracked) > 0 {
+		display += fmt.Sprintf(", %d untracked", len(untracked))
+	}
+	return ToolOutput{LLMContent: strings.TrimRight(content, "\n"), Display: display}, nil
+}
+
+func truncateGitOutput(s string) string {
+	if len(s) <= maxGitOutput {
+		return s
+	}
+	return s[:maxGitOutput] + fmt.Sprintf("\n… (truncated, %d more bytes; pass path or stat to narrow it)\n", len(s)-maxGitOutput)
+}
+
+// ---------- git_log ----------
+
+type gitLogInput struct {
+	Path     string `json:"path"`
+	MaxCount int    `json:"max_count"`
+}
+
+func handleGitLog(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in gitLogInput
+	_ = json.Unmarshal(input, &in) // all fields optional
+
+	n := in.MaxCount
+	if n <= 0 {
+		n = defaultLogCount
+	}
+	n = min(n, maxLogCount)
+
+	args := []string{"log", fmt.Sprintf("--max-count=%d", n), "--date=short", "--format=%h %ad %an: %s"}
+	if in.Path != "" {
+		if err := q.guard.CheckPath(in.Path); err != nil {
+			return ToolOutput{}, err
+		}
+		args = append(args, "--", in.Path)
+	}
+	out, err := runGitContext(ctx, q.cfg.ProjectDir, args...)
+	if err != nil {
+		return ToolOutput{}, err
+	}
+	out = strings.TrimRight(out, "\n")
+	if out == "" {
+		return ToolOutput{LLMContent: "No commits found."}, nil
+	}
+	count := strings.Count(out, "\n") + 1
+	return ToolOutput{
+		LLMContent: truncateGitOutput(out),
+		Display:    fmt.Sprintf("📜 %d commits\n%s", count, out),
+	}, nil
+}
+
+// ---------- file_outline ----------
+
+type fileOutlineInput struct {
+	Path string `json:"path"`
+}
+
+// outlinePatterns match top-level declarations in languages without a parser
+// in the standard library. The first submatch is the symbol's label.
+var outlinePatterns = map[string][]*regexp.Regexp{
+	".py": {
+		regexp.MustCompile(`^((?:async\s+)?def\s+\w+|class\s+\w+)`),
+	},
+	".js": {
+		regexp.MustCompile(`^(?:export\s+(?:default\s+)?)?((?:async\s+)?function\*?\s+\w+|class\s+\w+)`),
+		regexp.MustCompile(`^(?:export\s+)?((?:const|let|var)\s+\w+)\s*=`),
+		regexp.MustCompile(`^(?:export\s+)?((?:interface|type|enum)\s+\w+)`),
+	},
+	".rs": {
+		regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?((?:async\s+)?fn\s+\w+|struct\s+\w+|enum\s+\w+|trait\s+\w+|type\s+\w+|const\s+\w+|static\s+\w+|mod\s+\w+)`),
+		regexp.MustCompile(`^(impl\b[^{]*)`),
+	},
+	".java": {
+		regexp.MustCompile(`^(?:(?:public|protected|private|abstract|final|static)\s+)*((?:class|interface|enum|record)\s+\w+)`),
+		regexp.MustCompile(`^\s{4}(?:(?:public|protected|private|abstract|final|static|synchronized)\s+)+[\w<>\[\],\s]+?\s(\w+)\s*\(`),
+	},
+	".rb": {
+		regexp.MustCompile(`^\s*((?:class|module)\s+[\w:]+|def\s+[\w.?!]+)`),
+	},
+}
+
+func init() {
+	for _, ext := range []string{".jsx", ".ts", ".tsx", ".mjs", ".cjs"} {
+		outlinePatterns[ext] = outlinePatterns[".js"]
+	}
+}
+
+func handleFileOutline(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in fileOutlineInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if in.Path == "" {
+		return ToolOutput{}, fmt.Errorf("path is required")
+	}
+	if err := q.guard.CheckPath(in.Path); err != nil {
+		return ToolOutput{}, err
+	}
+	full := q.resolveProjectPath(in.Path)
+	if err := q.guard.CheckFileSize(full); err != nil {
+		return ToolOutput{}, err
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return ToolOutput{}, fmt.Errorf("read file: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(in.Path))
+	var symbols []string
+	switch {
+	case ext == ".go":
+		if symbols, err = outlineGo(in.Path, data); err != nil {
+			return ToolOutput{}, err
+		}
+	case outlinePatterns[ext] != nil:
+		symbols = outlineLines(data, outlinePatterns[ext])
+	default:
+		return ToolOutput{}, fmt.Errorf("no outline support for %q files; use read_file or search_code instead", ext)
+	}
+
+	if len(symbols) == 0 {
+		return ToolOutput{LLMContent: fmt.Sprintf("No top-level symbols found in %s.", in.Path)}, nil
+	}
+	content := strings.Join(symbols, "\n")
+	return ToolOutput{
+		LLMContent: content,
+		Display:    fmt.Sprintf("🗂  %s (%d symbols)\n%s", in.Path, len(symbols), content),
+	}, nil
+}
+
+// outlineGo lists a Go file's top-level declarations as "line: kind name".
+func outlineGo(name string, src []byte) ([]string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	var symbols []string
+	add := func(pos token.Pos, kind, label string) {
+		symbols = append(symbols, fmt.Sprintf("%4d: %s %s", fset.Position(pos).Line, kind, label))
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			label := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				label = fmt.Sprintf("(%s) %s", receiverType(d.Recv.List[0].Type), label)
+			}
+			add(d.Pos(), "func", label)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Pos(), "type", strings.TrimSpace(s.Name.Name+" "+typeKind(s.Type)))
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name != "_" {
+							add(n.Pos(), d.Tok.String(), n.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+func receiverType(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
+
+func typeKind(e ast.Expr) string {
+	switch e.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		return "slice"
+	case *ast.ChanType:
+		return "chan"
+	}
+	return ""
+}
+
+// outlineLines matches each line against patterns, reporting "line: label".
+func outlineLines(src []byte, patterns []*regexp.Regexp) []string {
+	var symbols []string
+	for i, line := range strings.Split(string(src), "\n") {
+		for _, re := range patterns {
+			if m := re.FindStringSubmatch(line); m != nil {
+				symbols = append(symbols, fmt.Sprintf("%4d: %s", i+1, strings.TrimSpace(m[1])))
+				break
+			}
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,210 @@
+package queen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// codeRepo sets up a small committed git repository in the Queen's project dir.
+func codeRepo(t *testing.T) (*Queen, string) {
+	t.Helper()
+	q, dir := testQueen(t)
+	initGitRepo(t, dir)
+	writeFiles(t, dir, map[string]string{
+		".gitignore":        ".hive/\nbuild/\n",
+		"main.go":           "package main\n\nfunc main() {\n\tServe()\n}\n",
+		"server/server.go":  "package server\n\n// Serve starts the server.\nfunc Serve() {}\n",
+		"server/handler.go": "package server\n\nfunc handle() { Serve() }\n",
+		"build/out.go":      "func Serve() {}\n",
+	})
+	runCmd(t, dir, "git", "add", ".")
+	runCmd(t, dir, "git", "commit", "-m", "initial commit")
+	return q, dir
+}
+
+func TestSearchCode(t *testing.T) {
+	q, _ := codeRepo(t)
+	ctx := context.Background()
+
+	out, err := handleSearchCode(ctx, q, toJSON(map[string]string{"pattern": `Serve\(`}))
+	if err != nil {
+		t.Fatalf("search_code: %v", err)
+	}
+	for _, want := range []string{"main.go:4: Serve()", "server/server.go:4: func Serve() {}", "server/handler.go:3:"} {
+		if !strings.Contains(out.LLMContent, want) {
+			t.Errorf("expected %q in:\n%s", want, out.LLMContent)
+		}
+	}
+	if strings.Contains(out.LLMContent, "build/") {
+		t.Errorf("expected .gitignore respected, got:\n%s", out.LLMContent)
+	}
+	if !strings.Contains(out.Display, "3 matches in 3 files") {
+		t.Errorf("unexpected display %q", out.Display)
+	}
+
+	out, _ = handleSearchCode(ctx, q, toJSON(map[string]interface{}{"pattern": `\bserve\b`, "ignore_case": true, "path": "server", "glob": "s*.go", "max_results": 1}))
+	if !strings.HasPrefix(out.LLMContent, "server/server.go:3:") || !strings.Contains(out.LLMContent, "showing 1 of 2 matches") {
+		t.Errorf("expected capped, filtered results, got:\n%s", out.LLMContent)
+	}
+
+	out, _ = handleSearchCode(ctx, q, toJSON(map[string]string{"pattern": "nothing-here"}))
+	if out.LLMContent != "No matches for /nothing-here/." {
+		t.Errorf("unexpected output %q", out.LLMContent)
+	}
+	if _, err := handleSearchCode(ctx, q, toJSON(map[string]string{"pattern": "("})); err == nil {
+		t.Error("expected invalid pattern error")
+	}
+	if _, err := handleSearchCode(ctx, q, toJSON(map[string]string{"pattern": "x", "path": "/etc"})); err == nil {
+		t.Error("expected path outside the project rejected")
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "main.py", false},
+		{"internal/**/*_test.go", "internal/queen/tools_test.go", true},
+		{"internal/**/*_test.go", "internal/tools_test.go", true},
+		{"internal/*.go", "internal/queen/tools.go", false},
+	}
+	for _, tt := range tests {
+		re, err := globRegexp(tt.glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("glob %q on %q = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGitDiff(t *testing.T) {
+	q, dir := codeRepo(t)
+	ctx := context.Background()
+
+	out, err := handleGitDiff(ctx, q, toJSON(map[string]string{}))
+	if err != nil || out.LLMContent != "No changes in working tree." {
+		t.Fatalf("expected clean tree, got %q, %v", out.LLMContent, err)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"server/server.go": "package server\n\n// Serve starts the server on :8080.\nfunc Serve() {}\n",
+		"main.go":          "package main\n\nfunc main() {}\n",
+		"server/routes.go": "package server\n",
+	})
+	out, err = handleGitDiff(ctx, q, toJSON(map[string]string{}))
+	if err != nil {
+		t.Fatalf("git_diff: %v", err)
+	}
+	for _, want := range []string{"+// Serve starts the server on :8080.", "main.go", "Untracked files:\n  server/routes.go"} {
+		if !strings.Contains(out.LLMContent, want) {
+			t.Errorf("expected %q in:\n%s", want, out.LLMContent)
+		}
+	}
+	if !strings.Contains(out.Display, "2 files changed") {
+		t.Errorf("unexpected display %q", out.Display)
+	}
+
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "Server", Type: task.TypeCode, Status: task.StatusRunning, AllowedPaths: []string{"server"}})
+	out, err = handleGitDiff(ctx, q, toJSON(map[string]string{"task_id": "t1"}))
+	if err != nil {
+		t.Fatalf("git_diff task: %v", err)
+	}
+	if strings.Contains(out.LLMContent, "main.go") || !strings.Contains(out.LLMContent, "server/server.go") {
+		t.Errorf("expected diff limited to the task's paths, got:\n%s", out.LLMContent)
+	}
+	if _, err := handleGitDiff(ctx, q, toJSON(map[string]string{"task_id": "t1", "path": "main.go"})); err == nil {
+		t.Error("expected path outside the task's allowed_paths rejected")
+	}
+
+	addPersistedTask(t, q, &task.Task{ID: "t2", Title: "Anything", Type: task.TypeCode, Status: task.StatusPending})
+	if _, err := handleGitDiff(ctx, q, toJSON(map[string]string{"task_id": "t2"})); err == nil {
+		t.Error("expected error for a task without allowed_paths")
+	}
+}
+
+func TestGitLog(t *testing.T) {
+	q, dir := codeRepo(t)
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+	runCmd(t, dir, "git", "commit", "-am", "simplify main")
+
+	out, err := handleGitLog(context.Background(), q, toJSON(map[string]interface{}{"max_count": 1}))
+	if err != nil {
+		t.Fatalf("git_log: %v", err)
+	}
+	if strings.Count(out.LLMContent, "\n") != 0 || !strings.HasSuffix(out.LLMContent, "Test: simplify main") {
+		t.Errorf("expected only the latest commit, got %q", out.LLMContent)
+	}
+
+	out, _ = handleGitLog(context.Background(), q, toJSON(map[string]string{"path": "server"}))
+	if !strings.HasSuffix(out.LLMContent, "initial commit") || !strings.HasPrefix(out.Display, "📜 1 commits") {
+		t.Errorf("expected server history only, got %q / %q", out.LLMContent, out.Display)
+	}
+}
+
+func TestFileOutline(t *testing.T) {
+	q, dir := testQueen(t)
+	writeFiles(t, dir, map[string]string{
+		"pool.go": `package pool
+
+const MaxSize = 4
+
+var ErrFull, _ = errFull()
+
+type Pool struct{}
+
+type Option func(*Pool)
+
+func New() *Pool { return &Pool{} }
+
+func (p *Pool) Spawn() error { return nil }
+`,
+		"app.py":    "import os\n\nclass App:\n    def run(self):\n        pass\n\nasync def main():\n    pass\n",
+		"index.ts":  "export interface Props {}\nexport default function render() {}\nconst helper = () => 1\n",
+		"notes.txt": "hello\n",
+	})
+	ctx := context.Background()
+
+	out, err := handleFileOutline(ctx, q, toJSON(map[string]string{"path": "pool.go"}))
+	if err != nil {
+		t.Fatalf("file_outline: %v", err)
+	}
+	want := "   3: const MaxSize\n   5: var ErrFull\n   7: type Pool struct\n   9: type Option func\n  11: func New\n  13: func (*Pool) Spawn"
+	if out.LLMContent != want {
+		t.Errorf("unexpected Go outline:\n%s\nwant:\n%s", out.LLMContent, want)
+	}
+
+	out, _ = handleFileOutline(ctx, q, toJSON(map[string]string{"path": "app.py"}))
+	if out.LLMContent != "   3: class App\n   7: async def main" {
+		t.Errorf("unexpected Python outline:\n%s", out.LLMContent)
+	}
+	out, _ = handleFileOutline(ctx, q, toJSON(map[string]string{"path": "index.ts"}))
+	if out.LLMContent != "   1: interface Props\n   2: function render\n   3: const helper" {
+		t.Errorf("unexpected TypeScript outline:\n%s", out.LLMContent)
+	}
+
+	if _, err := handleFileOutline(ctx, q, toJSON(map[string]string{"path": "notes.txt"})); err == nil {
+		t.Error("expected unsupported file type error")
+	}
+}
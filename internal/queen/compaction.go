@@ -0,0 +1,346 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/compact"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+const (
+	compactKeepLast     = 20   // messages kept verbatim after a compaction
+	compactTailShare    = 4    // kept messages use at most 1/compactTailShare of the window
+	defaultCompactAt    = 0.7  // fraction of the context window that triggers compaction
+	maxDigestTasks      = 60   // tasks listed individually in the state digest
+	maxCompactToolInput = 200  // chars of a tool call's input shown to the summarizer
+	maxCompactPreview   = 1000 // chars of a tool result shown to the summarizer
+
+	compactedHeader   = "[Conversation history compacted. Summary of earlier turns:]"
+	stateDigestHeader = "## State digest"
+)
+
+// contextWindow returns the context size of the Queen's model in tokens: the
+// smallest of its fallbacks' too, as any of them may get the conversation.
+func (q *Queen) contextWindow() int {
+	if n := q.cfg.Queen.ContextWindow; n > 0 {
+		return n
+	}
+	window := llm.ContextWindow(q.route(purposeAgent).model)
+	for _, fb := range q.cfg.Queen.Fallbacks {
+		window = min(window, llm.ContextWindow(fb.Model))
+	}
+	return window
+}
+
+// promptTokens is the prompt size a provider reported for a call: cached
+// prompt tokens are reported apart from the uncached ones.
+func promptTokens(u llm.Usage) int {
+	return u.InputTokens + u.CacheReadTokens + u.CacheCreationTokens
+}
+
+// messageTokens estimates the tokens of messages as sent to the provider.
+func messageTokens(messages []llm.ToolMessage) int {
+	n := 0
+	for _, m := range messages {
+		b, _ := json.Marshal(m)
+		n += compact.EstimateTokens(string(b))
+	}
+	return n
+}
+
+// conversationTokens is the size the next prompt will have: what the provider
+// reported for the last call, which sent the first sent messages, plus an
+// estimate of the messages appended since. Without usage the whole
+// conversation is estimated.
+func conversationTokens(messages []llm.ToolMessage, usage llm.Usage, sent int) int {
+	reported := promptTokens(usage)
+	if reported == 0 || sent > len(messages) {
+		return messageTokens(messages)
+	}
+	return reported + messageTokens(messages[sent:])
+}
+
+// maybeCompact compacts the conversation once the next prompt would reach
+// queen.compact_at of the context window, or it is longer than
+// queen.compact_after_messages. The compaction is recorded, the messages it
+// drops are indexed for recall, and the compacted history is persisted
+// straight away, so a resumed session continues from it.
+func (q *Queen) maybeCompact(ctx context.Context, messages []llm.ToolMessage, turn int, usage llm.Usage, sent int) []llm.ToolMessage {
+	window := q.contextWindow()
+	at := q.cfg.Queen.CompactAt
+	if at <= 0 || at > 1 {
+		at = defaultCompactAt
+	}
+	tokens := conversationTokens(messages, usage, sent)
+	overTokens := float64(tokens) >= at*float64(window)
+	overCount := q.cfg.Queen.CompactAfter > 0 && len(messages) > q.cfg.Queen.CompactAfter
+	if !overTokens && !overCount {
+		return messages
+	}
+
+	compacted := q.compactMessages(ctx, messages)
+	if len(compacted) >= len(messages) {
+		return messages
+	}
+	q.Printer().Debug("Compaction at ~%d of %d context tokens", tokens, window)
+	if err := q.db.RecordCompaction(ctx, state.CompactionRow{
+		SessionID:      q.sessionID,
+		Turn:           turn,
+		MessagesBefore: len(messages),
+		MessagesAfter:  len(compacted),
+		TokensBefore:   tokens,
+		ContextWindow:  window,
+		Summary:        compacted[1].Content[0].Text,
+	}); err != nil {
+		q.logger.Printf("⚠ Warning: failed to record compaction: %v", err)
+	}
+	q.archiveMessages(ctx, messages, len(messages)-len(compacted)+1)
+	q.persistConversation(ctx, compacted)
+	q.persistMessages(ctx, compacted)
+	return compacted
+}
+
+// compactMessages reduces the conversation size by summarizing older turns.
+// Keeps the first message (objective) and the last messages intact, at most
+// compactKeepLast of them and a quarter of the context window. The summary
+// ends with a digest of the current task state, rebuilt at every compaction.
+// Never splits tool_use/tool_result pairs. The summary call is cancelled
+// with ctx.
+func (q *Queen) compactMessages(ctx context.Context, messages []llm.ToolMessage) []llm.ToolMessage {
+	if len(messages) <= compactKeepLast+2 {
+		return messages
+	}
+
+	// Find a safe cut point that doesn't split tool_use/tool_result pairs.
+	idealCut := len(messages) - compactKeepLast
+	tailBudget := q.contextWindow() / compactTailShare
+	for idealCut < len(messages)-2 && messageTokens(messages[idealCut:]) > tailBudget {
+		idealCut++
+	}
+	cutPoint := idealCut
+	for cutPoint > 1 {
+		msg := messages[cutPoint]
+		// Don't start the kept section with a tool_result
+		if msg.Role == "tool_result" || len(msg.ToolResults) > 0 {
+			cutPoint--
+			continue
+		}
+		// Don't cut right after an assistant message with tool_use
+		if cutPoint > 0 {
+			prev := messages[cutPoint-1]
+			if prev.Role == "assistant" && hasToolUse(prev) {
+				cutPoint--
+				continue
+			}
+		}
+		break
+	}
+	if cutPoint <= 1 {
+		// Scan forward from idealCut to find a safe boundary
+		cutPoint = idealCut
+		for cutPoint < len(messages)-1 {
+			msg := messages[cutPoint]
+			if msg.Role == "tool_result" || len(msg.ToolResults) > 0 {
+				cutPoint++
+				continue
+			}
+			if cutPoint > 0 {
+				prev := messages[cutPoint-1]
+				if prev.Role == "assistant" && hasToolUse(prev) {
+					cutPoint++
+					continue
+				}
+			}
+			break
+		}
+	}
+
+	section := messages[1:cutPoint]
+	summarize := compact.DefaultSummarizer
+	if q.llm != nil {
+		// LLMSummarizer passes a background context; use the session's.
+		summarize = compact.LLMSummarizer(func(_ context.Context, systemPrompt, message string) (string, error) {
+			return q.chat(ctx, purposeCompaction, systemPrompt, message)
+		})
+	}
+	text, err := summarize(summaryInput(section))
+	if err != nil || strings.TrimSpace(text) == "" {
+		text, _ = compact.DefaultSummarizer(summaryInput(section))
+	}
+
+	var summary strings.Builder
+	summary.WriteString(compactedHeader + "\n")
+	summary.WriteString(strings.TrimSpace(text))
+	fmt.Fprintf(&summary, "\n\n[%d tool calls in compacted section; use recall to look up exact details]\n\n", countToolCalls(section))
+	summary.WriteString(q.stateDigest())
+
+	compacted := make([]llm.ToolMessage, 0, len(messages)-cutPoint+2)
+	compacted = append(compacted, messages[0]) // objective
+	compacted = append(compacted, llm.ToolMessage{
+		Role: "user",
+		Content: []llm.ContentBlock{{
+			Type: "text",
+			Text: summary.String(),
+		}},
+	})
+	compacted = append(compacted, messages[cutPoint:]...)
+
+	// Verify: the first kept message after the summary must not be tool_result
+	if len(compacted) > 2 {
+		if compacted[2].Role == "tool_result" || len(compacted[2].ToolResults) > 0 {
+			q.logger.Printf("⚠ compactMessages: first kept message is tool_result, adjusting")
+			// Skip tool_result messages at the start of the kept section
+			idx := 2
+			for idx < len(compacted) && (compacted[idx].Role == "tool_result" || len(compacted[idx].ToolResults) > 0) {
+				idx++
+			}
+			compacted = append(compacted[:2], compacted[idx:]...)
+		}
+	}
+
+	if !q.quiet {
+		q.Printer().Info("Compacted conversation: %d → %d messages", len(messages), len(compacted))
+	}
+	return compacted
+}
+
+// summaryInput converts conversation messages for the summarizer: text,
+// tool calls with their input, and previews of tool results. A previous
+// compaction summary loses its state digest, which is rebuilt anyway.
+func summaryInput(messages []llm.ToolMessage) []compact.Message {
+	toolNames := make(map[string]string)
+	var out []compact.Message
+	for _, msg := range messages {
+		var parts []string
+		for _, block := range msg.Content {
+			switch {
+			case block.Type == "text" && block.Text != "":
+				text, _, _ := strings.Cut(block.Text, stateDigestHeader)
+				parts = append(parts, strings.TrimSpace(text))
+			case block.Type == "tool_use" && block.ToolCall != nil:
+				toolNames[block.ToolCall.ID] = block.ToolCall.Name
+				parts = append(parts, fmt.Sprintf("called %s(%s)", block.ToolCall.Name, clip(string(block.ToolCall.Input), maxCompactToolInput)))
+			}
+		}
+		for _, r := range msg.ToolResults {
+			label := "result"
+			if r.IsError {
+				label = "error"
+			}
+			parts = append(parts, fmt.Sprintf("%s of %s: %s", label, toolNames[r.ToolCallID], clip(r.Content, maxCompactPreview)))
+		}
+		if len(parts) > 0 {
+			out = append(out, compact.Message{Role: msg.Role, Content: strings.Join(parts, "\n")})
+		}
+	}
+	return out
+}
+
+// countToolCalls returns the number of tool calls in messages.
+func countToolCalls(messages []llm.ToolMessage) int {
+	n := 0
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == "tool_use" && block.ToolCall != nil {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// stateDigest describes the current task graph and what the Queen still has
+// to decide, so the facts a summary might lose are always in the conversation.
+func (q *Queen) stateDigest() string {
+	var b strings.Builder
+	b.WriteString(stateDigestHeader + " (current state, replaces any earlier digest)\n")
+
+	tasks := q.tasks.All()
+	slices.SortFunc(tasks, func(a, b *task.Task) int { return strings.Compare(a.ID, b.ID) })
+	counts := map[task.Status]int{}
+	for _, t := range tasks {
+		counts[t.GetStatus()]++
+	}
+	if len(tasks) == 0 {
+		b.WriteString("No tasks created yet.\n")
+	} else {
+		var parts []string
+		for _, s := range []task.Status{task.StatusComplete, task.StatusRunning, task.StatusAssigned, task.StatusPending, task.StatusRetrying, task.StatusFailed, task.StatusCancelled} {
+			if counts[s] > 0 {
+				parts = append(parts, fmt.Sprintf("%s %d", s, counts[s]))
+			}
+		}
+		fmt.Fprintf(&b, "Tasks: %s\n", strings.Join(parts, ", "))
+		for i, t := range tasks {
+			if i == maxDigestTasks {
+				fmt.Fprintf(&b, "- … %d more (see get_status)\n", len(tasks)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- %s [%s] %s (%s)", t.ID, t.GetStatus(), t.Title, t.Type)
+			if deps := t.GetDependsOn(); len(deps) > 0 {
+				fmt.Fprintf(&b, " after %s", strings.Join(deps, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	var open []string
+	for _, t := range q.tasks.Failed() {
+		msg, _ := t.GetLastError()
+		if msg == "" {
+			if r := t.GetResult(); r != nil && len(r.Errors) > 0 {
+				msg = r.Errors[0]
+			}
+		}
+		open = append(open, fmt.Sprintf("- %s failed after %d retries: %s — retry, work around or fail", t.ID, t.GetRetryCount(), clip(msg, maxHandoffNoteLen)))
+	}
+	holds := q.refreshHolds()
+	held := make(map[string]bool, len(holds))
+	for _, h := range holds {
+		held[h.TaskID] = true
+		open = append(open, fmt.Sprintf("- %s is held: %s", h.TaskID, h))
+	}
+	var ready []string
+	for _, t := range q.tasks.Ready() {
+		if !held[t.ID] {
+			ready = append(ready, t.ID)
+		}
+	}
+	if len(ready) > 0 {
+		slices.Sort(ready)
+		open = append(open, "- ready to assign: "+strings.Join(ready, ", "))
+	}
+	if n := counts[task.StatusRunning]; n > 0 {
+		open = append(open, fmt.Sprintf("- %d task(s) running: wait_for_workers, then review their output", n))
+	}
+	if len(open) > 0 {
+		b.WriteString("\nOpen decisions:\n")
+		b.WriteString(strings.Join(open, "\n") + "\n")
+	}
+
+	var notes []string
+	for _, e := range q.board.ReadByTag(tagWorkers) {
+		notes = append(notes, e.Key)
+	}
+	if len(notes) > 0 {
+		slices.Sort(notes)
+		fmt.Fprintf(&b, "\nNotes shared with workers: %s\n", strings.Join(notes, ", "))
+	}
+	return b.String()
+}
+
+// hasToolUse returns true if the message contains any tool_use content blocks.
+func hasToolUse(msg llm.ToolMessage) bool {
+	for _, block := range msg.Content {
+		if block.Type == "tool_use" {
+			return true
+		}
+	}
+	return false
+}
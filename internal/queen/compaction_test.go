@@ -0,0 +1,153 @@
+package queen
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// statusConversation returns an objective followed by n get_status calls.
+func statusConversation(n int) []llm.ToolMessage {
+	messages := []llm.ToolMessage{{
+		Role:    "user",
+		Content: []llm.ContentBlock{{Type: "text", Text: "Build a thing"}},
+	}}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("call-%d", i)
+		messages = append(messages,
+			llm.ToolMessage{Role: "assistant", Content: []llm.ContentBlock{{
+				Type: "tool_use", ToolCall: &llm.ToolCall{ID: id, Name: "get_status", Input: []byte(`{}`)},
+			}}},
+			llm.ToolMessage{Role: "tool_result", ToolResults: []llm.ToolResult{{ToolCallID: id, Content: "ok"}}},
+		)
+	}
+	return messages
+}
+
+func TestMaybeCompact_TokenTrigger(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	q.llm = &mockPlanLLM{response: "Checked status repeatedly; nothing assigned yet."}
+	q.cfg.Queen.Model = "claude-sonnet-4-20250514"
+	q.cfg.Queen.CompactAt = 0.5
+	messages := statusConversation(30)
+	sent := len(messages) - 2
+
+	// Well under half of the 200k window: nothing happens.
+	if got := q.maybeCompact(ctx, messages, 3, llm.Usage{InputTokens: 50_000, CacheReadTokens: 40_000}, sent); len(got) != len(messages) {
+		t.Fatalf("expected no compaction under the threshold, got %d messages", len(got))
+	}
+
+	// Cached prompt tokens count towards the window.
+	got := q.maybeCompact(ctx, messages, 4, llm.Usage{InputTokens: 10_000, CacheReadTokens: 95_000}, sent)
+	if len(got) >= len(messages) {
+		t.Fatalf("expected compaction over the threshold, got %d messages", len(got))
+	}
+	summary := got[1].Content[0].Text
+	for _, want := range []string{compactedHeader, "Checked status repeatedly", stateDigestHeader} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %q in summary:\n%s", want, summary)
+		}
+	}
+
+	rows, err := q.db.ListCompactions(ctx, q.sessionID)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("expected one recorded compaction, got %+v, %v", rows, err)
+	}
+	r := rows[0]
+	if r.Turn != 4 || r.MessagesBefore != len(messages) || r.MessagesAfter != len(got) || r.ContextWindow != 200_000 || r.TokensBefore < 105_000 || r.Summary != summary {
+		t.Errorf("unexpected compaction record %+v", r)
+	}
+
+	// The compacted history is what a resumed session loads.
+	restored, err := q.loadConversation(ctx, q.sessionID)
+	if err != nil || len(restored) != len(got) || restored[1].Content[0].Text != summary {
+		t.Errorf("expected compacted history persisted, got %d messages, %v", len(restored), err)
+	}
+}
+
+// waitingLLM answers only once its call is cancelled.
+type waitingLLM struct{}
+
+func (waitingLLM) Chat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (w waitingLLM) ChatWithHistory(ctx context.Context, systemPrompt string, messages []llm.Message) (string, error) {
+	return w.Chat(ctx, systemPrompt, "")
+}
+
+func TestCompactMessages_Cancelled(t *testing.T) {
+	q, _ := testQueen(t)
+	q.llm = waitingLLM{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan []llm.ToolMessage)
+	go func() { done <- q.compactMessages(ctx, statusConversation(30)) }()
+	select {
+	case got := <-done:
+		if !strings.Contains(got[1].Content[0].Text, compactedHeader) {
+			t.Errorf("expected a fallback summary, got %q", got[1].Content[0].Text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("compaction did not stop when the session was cancelled")
+	}
+}
+
+func TestMaybeCompact_MessageCountAndWindowOverride(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	messages := statusConversation(30)
+
+	// Usage unknown: the conversation itself is estimated against the window.
+	q.cfg.Queen.ContextWindow = 500
+	if got := q.maybeCompact(ctx, messages, 1, llm.Usage{}, len(messages)); len(got) >= len(messages) {
+		t.Error("expected estimated tokens over a small window to compact")
+	}
+
+	q.cfg.Queen.ContextWindow = 0
+	q.cfg.Queen.CompactAfter = 40
+	if got := q.maybeCompact(ctx, messages, 2, llm.Usage{InputTokens: 1000}, len(messages)); len(got) >= len(messages) {
+		t.Error("expected compact_after_messages to compact")
+	}
+}
+
+func TestStateDigest(t *testing.T) {
+	q, _ := testQueen(t)
+	addCompletedTask(t, q, "schema", "done", nil)
+	addPersistedTask(t, q, &task.Task{ID: "api", Title: "API", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"schema"}})
+	addPersistedTask(t, q, &task.Task{ID: "ui", Title: "UI", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"api"}})
+	broken := &task.Task{ID: "lint", Title: "Lint", Type: task.TypeTest, Status: task.StatusFailed, LastError: "golangci-lint not found"}
+	addPersistedTask(t, q, broken)
+	q.board.Post(&blackboard.Entry{Key: "conventions", Value: "Use slog", Tags: []string{tagWorkers}})
+
+	got := q.stateDigest()
+	for _, want := range []string{
+		"Tasks: complete 1, pending 2, failed 1",
+		"- api [pending] API (code) after schema",
+		"- lint failed after 0 retries: golangci-lint not found",
+		"- ready to assign: api",
+		"Notes shared with workers: conventions",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in digest:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ready to assign: api, ui") {
+		t.Errorf("ui is blocked on api, got:\n%s", got)
+	}
+
+	// An earlier digest is dropped before summarizing; a fresh one is appended.
+	in := summaryInput([]llm.ToolMessage{{Role: "user", Content: []llm.ContentBlock{{Type: "text", Text: "old summary\n\n" + got}}}})
+	if len(in) != 1 || in[0].Content != "old summary" {
+		t.Errorf("expected stale digest stripped, got %+v", in)
+	}
+}
@@ -0,0 +1,163 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+// Control request kinds accepted from operators (CLI, TUI) while a session runs.
+// Each task edit reuses the payload format and handler of the tool with the
+// same name. ControlSay carries a message for the Queen ({"text": "..."}).
+const (
+	ControlCancelTask = "cancel_task"
+	ControlUpdateTask = "update_task"
+	ControlDeleteTask = "delete_task"
+	ControlSay        = "say"
+)
+
+type sayInput struct {
+	Text string `json:"text"`
+}
+
+// controlHandler returns the tool handler that applies a control request kind.
+// Kept as a switch (not a toolHandlers lookup) so only operator-safe tools are reachable.
+func controlHandler(kind string) (ToolHandler, bool) {
+	switch kind {
+	case ControlCancelTask:
+		return handleCancelTask, true
+	case ControlUpdateTask:
+		return handleUpdateTask, true
+	case ControlDeleteTask:
+		return handleDeleteTask, true
+	}
+	return nil, false
+}
+
+// EnqueueControl queues an operator request for the current session. The
+// Queen applies it before its next turn.
+func (q *Queen) EnqueueControl(ctx context.Context, kind string, payload interface{}) error {
+	if _, ok := controlHandler(kind); !ok && kind != ControlSay {
+		return fmt.Errorf("unknown control request %q", kind)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode control payload: %w", err)
+	}
+	if q.sessionID == "" {
+		return fmt.Errorf("no active session")
+	}
+	_, err = q.db.EnqueueControl(ctx, q.sessionID, kind, string(data))
+	return err
+}
+
+// applyControlRequests drains queued operator requests. Task edits are
+// applied through the matching tool handler and summarised one line each;
+// operator messages are returned as they were sent.
+func (q *Queen) applyControlRequests(ctx context.Context) (notes, said []string) {
+	if q.db == nil || q.sessionID == "" {
+		return nil, nil
+	}
+	rows, err := q.db.PendingControls(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load control requests: %v", err)
+		return nil, nil
+	}
+
+	for _, row := range rows {
+		if row.Kind == ControlSay {
+			var in sayInput
+			status, summary := "applied", "delivered to the Queen"
+			if err := json.Unmarshal([]byte(row.Payload), &in); err != nil || strings.TrimSpace(in.Text) == "" {
+				status, summary = "rejected", "empty message"
+			} else {
+				said = append(said, strings.TrimSpace(in.Text))
+				q.logger.Printf("💬 Operator: %s", in.Text)
+			}
+			if err := q.db.ResolveControl(ctx, row.ID, status, summary); err != nil {
+				q.logger.Printf("⚠ Warning: failed to resolve control request: %v", err)
+			}
+			continue
+		}
+
+		status := "applied"
+		var summary string
+		handler, ok := controlHandler(row.Kind)
+		if !ok {
+			status = "rejected"
+			summary = fmt.Sprintf("unknown control request %q", row.Kind)
+		} else if out, err := handler(ctx, q, json.RawMessage(row.Payload)); err != nil {
+			status = "rejected"
+			summary = err.Error()
+		} else {
+			summary = out.LLMContent
+		}
+
+		if err := q.db.ResolveControl(ctx, row.ID, status, summary); err != nil {
+			q.logger.Printf("⚠ Warning: failed to resolve control request: %v", err)
+		}
+		q.logger.Printf("🛂 Operator %s (%s): %s", row.Kind, status, summary)
+		notes = append(notes, fmt.Sprintf("- %s %s: %s", row.Kind, status, summary))
+	}
+	return notes, said
+}
+
+// controlsPending reports whether operator requests are waiting to be applied.
+func (q *Queen) controlsPending(ctx context.Context) bool {
+	if q.db == nil || q.sessionID == "" {
+		return false
+	}
+	rows, err := q.db.PendingControls(ctx, q.sessionID)
+	return err == nil && len(rows) > 0
+}
+
+// applyLegacyControls applies pending operator requests in legacy mode.
+// There is no conversation to carry operator messages, so they are posted
+// to the blackboard, and later planning prompts list them.
+func (q *Queen) applyLegacyControls(ctx context.Context) {
+	_, said := q.applyControlRequests(ctx)
+	for _, text := range said {
+		q.postBoard(ctx, &blackboard.Entry{
+			Key:      fmt.Sprintf("operator-%d", time.Now().UnixNano()),
+			Value:    text,
+			PostedBy: "operator",
+			Tags:     []string{"operator"},
+		})
+	}
+}
+
+// injectControlNotes applies pending operator requests and, if any were
+// processed, appends a user message so the Queen sees what changed and what
+// the user said. The conversation is saved right away so a resumed session
+// replays the message even if this turn never completes.
+func (q *Queen) injectControlNotes(ctx context.Context, messages []llm.ToolMessage) []llm.ToolMessage {
+	notes, said := q.applyControlRequests(ctx)
+	if len(notes) == 0 && len(said) == 0 {
+		return messages
+	}
+
+	var content []llm.ContentBlock
+	if len(notes) > 0 {
+		content = append(content, llm.ContentBlock{
+			Type: "text",
+			Text: "[OPERATOR: The user changed the task graph while you were working:\n" +
+				strings.Join(notes, "\n") +
+				"\nTake these changes into account; do not recreate cancelled or deleted tasks unless asked.]",
+		})
+	}
+	for _, text := range said {
+		content = append(content, llm.ContentBlock{
+			Type: "text",
+			Text: "[OPERATOR: The user sent you a message while you were working. Follow it from now on, " +
+				"adjusting the plan if needed:]\n" + text,
+		})
+	}
+	messages = append(messages, llm.ToolMessage{Role: "user", Content: content})
+	q.persistConversation(ctx, messages)
+	return messages
+}
@@ -0,0 +1,405 @@
+package queen
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/task"
+	"github.com/HexSleeves/waggle/internal/worker"
+)
+
+// addPersistedTask adds a task to both the graph and the DB.
+func addPersistedTask(t *testing.T, q *Queen, tk *task.Task) {
+	t.Helper()
+	q.tasks.Add(tk)
+	if err := q.db.InsertTask(context.Background(), q.sessionID, taskToRow(tk)); err != nil {
+		t.Fatalf("insert task: %v", err)
+	}
+}
+
+// --- cancel_task ---
+
+func TestHandleCancelTask_Pending(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending})
+
+	out, err := handleCancelTask(ctx, q, toJSON(map[string]interface{}{"task_id": "t1", "reason": "not needed"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, "Cancelled 1 task(s): t1") {
+		t.Errorf("unexpected output: %s", out.LLMContent)
+	}
+
+	tk, _ := q.tasks.Get("t1")
+	if tk.GetStatus() != task.StatusCancelled {
+		t.Errorf("graph status = %s, want cancelled", tk.GetStatus())
+	}
+	row, err := q.db.GetTask(ctx, q.sessionID, "t1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if row.Status != "cancelled" {
+		t.Errorf("db status = %s, want cancelled", row.Status)
+	}
+	if _, found := q.board.Read("cancellation-t1"); !found {
+		t.Error("expected cancellation reason on blackboard")
+	}
+}
+
+func TestHandleCancelTask_Cascade(t *testing.T) {
+	q, _ := testQueen(t)
+	addPersistedTask(t, q, &task.Task{ID: "a", Title: "A", Type: task.TypeCode, Status: task.StatusPending})
+	addPersistedTask(t, q, &task.Task{ID: "b", Title: "B", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"a"}})
+	addPersistedTask(t, q, &task.Task{ID: "c", Title: "C", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"b"}})
+	addPersistedTask(t, q, &task.Task{ID: "d", Title: "D", Type: task.TypeCode, Status: task.StatusPending})
+
+	out, err := handleCancelTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "a", "cascade": true}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, "Cancelled 3 task(s)") {
+		t.Errorf("unexpected output: %s", out.LLMContent)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		tk, _ := q.tasks.Get(id)
+		if tk.GetStatus() != task.StatusCancelled {
+			t.Errorf("task %s status = %s, want cancelled", id, tk.GetStatus())
+		}
+	}
+	d, _ := q.tasks.Get("d")
+	if d.GetStatus() != task.StatusPending {
+		t.Errorf("unrelated task d should stay pending, got %s", d.GetStatus())
+	}
+}
+
+func TestHandleCancelTask_WithoutCascadeWarnsAboutDependents(t *testing.T) {
+	q, _ := testQueen(t)
+	addPersistedTask(t, q, &task.Task{ID: "a", Title: "A", Type: task.TypeCode, Status: task.StatusPending})
+	addPersistedTask(t, q, &task.Task{ID: "b", Title: "B", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"a"}})
+
+	out, err := handleCancelTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "a"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, "can no longer run: b") {
+		t.Errorf("expected dependent warning, got: %s", out.LLMContent)
+	}
+	b, _ := q.tasks.Get("b")
+	if b.GetStatus() != task.StatusPending {
+		t.Errorf("dependent should remain pending, got %s", b.GetStatus())
+	}
+}
+
+func TestHandleCancelTask_KillsRunningWorker(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	var bee *EnhancedMockBee
+	q.pool = worker.NewPool(4, func(id, adapterName string) (worker.Bee, error) {
+		bee = NewEnhancedMockBee(id, adapterName)
+		bee.SetAutoComplete(false)
+		return bee, nil
+	}, q.bus)
+
+	tk := &task.Task{ID: "t1", Title: "Run", Type: task.TypeCode, Status: task.StatusPending}
+	addPersistedTask(t, q, tk)
+	spawned, err := q.pool.Spawn(ctx, tk, "exec")
+	if err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+	_ = q.tasks.UpdateStatus("t1", task.StatusRunning)
+	tk.SetWorkerID(spawned.ID())
+	q.assignments[spawned.ID()] = "t1"
+
+	if _, err := handleCancelTask(ctx, q, toJSON(map[string]interface{}{"task_id": "t1"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bee.WasKillCalled() {
+		t.Error("expected running worker to be killed")
+	}
+	if _, ok := q.assignments[spawned.ID()]; ok {
+		t.Error("expected assignment to be removed")
+	}
+}
+
+func TestHandleCancelTask_AlreadyComplete(t *testing.T) {
+	q, _ := testQueen(t)
+	q.tasks.Add(&task.Task{ID: "t1", Title: "Done", Type: task.TypeCode, Status: task.StatusComplete})
+
+	_, err := handleCancelTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "t1"}))
+	if err == nil || !strings.Contains(err.Error(), "already complete") {
+		t.Fatalf("expected already complete error, got: %v", err)
+	}
+}
+
+// --- update_task ---
+
+func TestHandleUpdateTask_Fields(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "a", Title: "A", Type: task.TypeCode, Status: task.StatusPending})
+	addPersistedTask(t, q, &task.Task{ID: "b", Title: "B", Type: task.TypeCode, Status: task.StatusPending, Priority: task.PriorityNormal})
+
+	out, err := handleUpdateTask(ctx, q, toJSON(map[string]interface{}{
+		"task_id":         "b",
+		"description":     "new description",
+		"constraints":     []string{"only touch b.go"},
+		"depends_on":      []string{"a"},
+		"priority":        3,
+		"timeout_seconds": 90,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, "updated") {
+		t.Errorf("unexpected output: %s", out.LLMContent)
+	}
+
+	b, _ := q.tasks.Get("b")
+	if b.GetDescription() != "new description" || b.GetPriority() != task.PriorityCritical || b.Timeout != 90*time.Second {
+		t.Errorf("task not updated in graph: desc=%q prio=%d timeout=%v", b.GetDescription(), b.GetPriority(), b.Timeout)
+	}
+
+	row, err := q.db.GetTask(ctx, q.sessionID, "b")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if row.Description != "new description" || row.Priority != 3 || row.DependsOn != "a" || row.TimeoutNs != int64(90*time.Second) {
+		t.Errorf("task not persisted: %+v", row)
+	}
+	if !strings.Contains(row.Constraints, "only touch b.go") {
+		t.Errorf("constraints not persisted: %s", row.Constraints)
+	}
+}
+
+func TestHandleUpdateTask_CycleRollsBack(t *testing.T) {
+	q, _ := testQueen(t)
+	addPersistedTask(t, q, &task.Task{ID: "a", Title: "A", Type: task.TypeCode, Status: task.StatusPending})
+	addPersistedTask(t, q, &task.Task{ID: "b", Title: "B", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"a"}})
+
+	_, err := handleUpdateTask(context.Background(), q, toJSON(map[string]interface{}{
+		"task_id":    "a",
+		"depends_on": []string{"b"},
+	}))
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got: %v", err)
+	}
+	a, _ := q.tasks.Get("a")
+	if len(a.GetDependsOn()) != 0 {
+		t.Errorf("dependencies should be rolled back, got %v", a.GetDependsOn())
+	}
+}
+
+func TestHandleUpdateTask_NotPending(t *testing.T) {
+	q, _ := testQueen(t)
+	q.tasks.Add(&task.Task{ID: "t1", Title: "Run", Type: task.TypeCode, Status: task.StatusRunning})
+
+	_, err := handleUpdateTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "t1", "priority": 2}))
+	if err == nil || !strings.Contains(err.Error(), "not pending") {
+		t.Fatalf("expected not pending error, got: %v", err)
+	}
+}
+
+func TestHandleUpdateTask_PriorityDeltaClamps(t *testing.T) {
+	q, _ := testQueen(t)
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending, Priority: task.PriorityHigh})
+
+	if _, err := handleUpdateTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "t1", "priority_delta": 5})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tk, _ := q.tasks.Get("t1")
+	if tk.GetPriority() != task.PriorityCritical {
+		t.Errorf("priority = %d, want %d", tk.GetPriority(), task.PriorityCritical)
+	}
+}
+
+func TestHandleUpdateTask_UnknownDependency(t *testing.T) {
+	q, _ := testQueen(t)
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending})
+
+	_, err := handleUpdateTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "t1", "depends_on": []string{"ghost"}}))
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+}
+
+// --- delete_task ---
+
+func TestHandleDeleteTask(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending})
+
+	if _, err := handleDeleteTask(ctx, q, toJSON(map[string]interface{}{"task_id": "t1"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := q.tasks.Get("t1"); ok {
+		t.Error("task should be removed from graph")
+	}
+	if _, err := q.db.GetTask(ctx, q.sessionID, "t1"); err == nil {
+		t.Error("task should be removed from DB")
+	}
+}
+
+func TestHandleDeleteTask_HasDependents(t *testing.T) {
+	q, _ := testQueen(t)
+	addPersistedTask(t, q, &task.Task{ID: "a", Title: "A", Type: task.TypeCode, Status: task.StatusPending})
+	addPersistedTask(t, q, &task.Task{ID: "b", Title: "B", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"a"}})
+
+	_, err := handleDeleteTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "a"}))
+	if err == nil || !strings.Contains(err.Error(), "dependency of b") {
+		t.Fatalf("expected dependents error, got: %v", err)
+	}
+}
+
+func TestHandleDeleteTask_Running(t *testing.T) {
+	q, _ := testQueen(t)
+	q.tasks.Add(&task.Task{ID: "t1", Title: "Run", Type: task.TypeCode, Status: task.StatusRunning})
+
+	_, err := handleDeleteTask(context.Background(), q, toJSON(map[string]interface{}{"task_id": "t1"}))
+	if err == nil || !strings.Contains(err.Error(), "cancel it before deleting") {
+		t.Fatalf("expected running error, got: %v", err)
+	}
+}
+
+// --- control requests ---
+
+func TestApplyControlRequests(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending})
+
+	if err := q.EnqueueControl(ctx, ControlUpdateTask, map[string]interface{}{"task_id": "t1", "priority": 3}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := q.EnqueueControl(ctx, ControlDeleteTask, map[string]interface{}{"task_id": "missing"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	messages := q.injectControlNotes(ctx, []llm.ToolMessage{{Role: "user", Content: []llm.ContentBlock{{Type: "text", Text: "Objective"}}}})
+	if len(messages) != 2 {
+		t.Fatalf("expected an operator note to be appended, got %d messages", len(messages))
+	}
+	note := messages[1].Content[0].Text
+	if !strings.Contains(note, "update_task applied") || !strings.Contains(note, "delete_task rejected") {
+		t.Errorf("unexpected operator note: %s", note)
+	}
+
+	tk, _ := q.tasks.Get("t1")
+	if tk.GetPriority() != task.PriorityCritical {
+		t.Errorf("priority = %d, want 3", tk.GetPriority())
+	}
+
+	pending, err := q.db.PendingControls(ctx, q.sessionID)
+	if err != nil {
+		t.Fatalf("pending controls: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected all requests resolved, %d pending", len(pending))
+	}
+
+	// Nothing queued: conversation is left alone
+	again := q.injectControlNotes(ctx, messages)
+	if len(again) != len(messages) {
+		t.Error("expected no new messages when queue is empty")
+	}
+}
+
+func TestApplyLegacyControls(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending})
+
+	if err := q.EnqueueControl(ctx, ControlCancelTask, map[string]interface{}{"task_id": "t1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := q.EnqueueControl(ctx, ControlSay, map[string]string{"text": "keep the API stable"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	q.applyLegacyControls(ctx)
+
+	if tk, _ := q.tasks.Get("t1"); tk.GetStatus() != task.StatusCancelled {
+		t.Errorf("status = %s, want cancelled", tk.GetStatus())
+	}
+	if prompt := q.buildPlanPrompt(); !strings.Contains(prompt, "- keep the API stable") {
+		t.Errorf("expected the operator message in the plan prompt, got:\n%s", prompt)
+	}
+	if pending, _ := q.db.PendingControls(ctx, q.sessionID); len(pending) != 0 {
+		t.Errorf("expected all requests resolved, %d pending", len(pending))
+	}
+}
+
+func TestEnqueueControl_UnknownKind(t *testing.T) {
+	q, _ := testQueen(t)
+	if err := q.EnqueueControl(context.Background(), "complete", map[string]string{}); err == nil {
+		t.Fatal("expected error for non-operator tool")
+	}
+}
+
+func TestInjectControlNotes_OperatorMessage(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+
+	if err := q.EnqueueControl(ctx, ControlSay, map[string]string{"text": "skip the docs tasks"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := q.EnqueueControl(ctx, ControlSay, map[string]string{"text": "  "}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	start := []llm.ToolMessage{{Role: "user", Content: []llm.ContentBlock{{Type: "text", Text: "Objective"}}}}
+	messages := q.injectControlNotes(ctx, start)
+	if len(messages) != 2 || len(messages[1].Content) != 1 {
+		t.Fatalf("expected one operator message appended, got %+v", messages)
+	}
+	if text := messages[1].Content[0].Text; !strings.Contains(text, "sent you a message") || !strings.HasSuffix(text, "skip the docs tasks") {
+		t.Errorf("unexpected operator message: %q", text)
+	}
+
+	// Saved immediately, so a resumed session replays it.
+	saved, err := q.loadConversation(ctx, q.sessionID)
+	if err != nil || len(saved) != 2 || !strings.Contains(saved[1].Content[0].Text, "skip the docs tasks") {
+		t.Fatalf("expected message persisted with the conversation, got %+v, %v", saved, err)
+	}
+
+	if pending, _ := q.db.PendingControls(ctx, q.sessionID); len(pending) != 0 {
+		t.Errorf("expected messages resolved, %d pending", len(pending))
+	}
+}
+
+func TestWaitForWorkers_DeliversOperatorMessage(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	q.pool = worker.NewPool(4, func(id, adapterName string) (worker.Bee, error) {
+		bee := NewEnhancedMockBee(id, adapterName)
+		bee.SetAutoComplete(false)
+		return bee, nil
+	}, q.bus)
+	tk := &task.Task{ID: "t1", Title: "Run", Type: task.TypeCode, Status: task.StatusPending}
+	addPersistedTask(t, q, tk)
+	if _, err := q.pool.Spawn(ctx, tk, "exec"); err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+	_ = q.tasks.UpdateStatus("t1", task.StatusRunning)
+
+	if err := q.EnqueueControl(ctx, ControlSay, map[string]string{"text": "use table-driven tests"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	out, err := handleWaitForWorkers(ctx, q, toJSON(map[string]int{"timeout_seconds": 10}))
+	if err != nil {
+		t.Fatalf("wait_for_workers: %v", err)
+	}
+	if !strings.Contains(out.LLMContent, "Operator input pending") || strings.Contains(out.LLMContent, "use table-driven tests") {
+		t.Errorf("expected the wait to end without the message, got %q", out.LLMContent)
+	}
+
+	// The message comes on the next turn, from the user.
+	msgs := q.injectControlNotes(ctx, nil)
+	if len(msgs) != 1 || msgs[0].Role != "user" || !strings.Contains(msgs[0].Content[0].Text, "use table-driven tests") {
+		t.Errorf("expected the message delivered as a user message, got %+v", msgs)
+	}
+}
@@ -0,0 +1,288 @@
+package queen
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// Spend is a session's running cost. It is published on the bus
+// (MsgCostUpdate) after every Queen turn and every finished worker attempt.
+type Spend struct {
+	SessionUSD float64 `json:"session_usd"`
+	Tokens     int     `json:"tokens"`
+	BudgetUSD  float64 `json:"budget_usd,omitempty"` // 0 = no session budget
+}
+
+// spending tracks what the session has spent so far. Guarded by Queen.mu.
+type spending struct {
+	usd      float64
+	tokens   int
+	tasks    map[string]float64 // taskID -> USD across attempts
+	warned   bool               // budget warning sent to the Queen
+	exceeded bool               // wrap-up notice sent; stop if still over next turn
+}
+
+// newPricing builds the price table from the config's overrides.
+func newPricing(overrides map[string]config.ModelPrice) *llm.Pricing {
+	prices := make(map[string]llm.Price, len(overrides))
+	for k, v := range overrides {
+		prices[k] = llm.Price(v)
+	}
+	return llm.NewPricing(prices)
+}
+
+// prices returns the Queen's price table, building it on first use.
+func (q *Queen) prices() *llm.Pricing {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pricing == nil {
+		q.pricing = newPricing(q.cfg.Pricing)
+	}
+	return q.pricing
+}
+
+// turnUsage returns the provider, model and usage of a Queen LLM call,
+// defaulting to the provider and model configured for purpose.
+func (q *Queen) turnUsage(purpose string, resp *llm.Response) *llm.TurnUsage {
+	u := &llm.TurnUsage{Provider: resp.Provider, Model: resp.Model, Usage: resp.Usage}
+	if u.Provider == "" || u.Model == "" {
+		r := q.route(purpose)
+		if u.Provider == "" {
+			u.Provider = r.provider
+		}
+		if u.Model == "" {
+			u.Model = r.model
+		}
+	}
+	return u
+}
+
+// recordTurnCost prices one turn of the agent loop and adds it to the
+// session's spend.
+func (q *Queen) recordTurnCost(ctx context.Context, turn int, resp *llm.Response) {
+	q.recordCallCost(ctx, purposeAgent, turn, resp)
+}
+
+// recordTaskCost adds what a worker attempt reported spending (result metrics
+// cost_usd, input_tokens, output_tokens, tokens) to the task's and session's
+// spend. Workers that report nothing cost nothing.
+func (q *Queen) recordTaskCost(ctx context.Context, taskID string, metrics map[string]float64) {
+	usd := metrics["cost_usd"]
+	tokens := int(metrics["tokens"])
+	if usd == 0 && tokens == 0 {
+		return
+	}
+	row := state.CostRow{
+		SessionID:    q.sessionID,
+		TaskID:       taskID,
+		Source:       state.CostSourceWorker,
+		InputTokens:  int(metrics["input_tokens"]),
+		OutputTokens: int(metrics["output_tokens"]),
+		CostUSD:      usd,
+	}
+	if row.InputTokens+row.OutputTokens == 0 {
+		row.OutputTokens = tokens
+	}
+	q.addCost(ctx, row)
+
+	if limit := q.cfg.Budget.MaxTaskUSD; limit > 0 && q.taskSpend(taskID) >= limit {
+		q.Printer().Warning("Task %s spent %s, over its %s budget", taskID, formatUSD(q.taskSpend(taskID)), formatUSD(limit))
+	}
+}
+
+// addCost persists a cost row and updates the running totals.
+func (q *Queen) addCost(ctx context.Context, row state.CostRow) {
+	if row.CostUSD == 0 && row.Tokens() == 0 {
+		return
+	}
+	if err := q.db.RecordCost(ctx, row); err != nil {
+		q.logger.Printf("⚠ Warning: failed to record cost: %v", err)
+	}
+
+	q.mu.Lock()
+	q.spend.usd += row.CostUSD
+	q.spend.tokens += row.Tokens()
+	if row.TaskID != "" {
+		if q.spend.tasks == nil {
+			q.spend.tasks = make(map[string]float64)
+		}
+		q.spend.tasks[row.TaskID] += row.CostUSD
+	}
+	spend := Spend{SessionUSD: q.spend.usd, Tokens: q.spend.tokens, BudgetUSD: q.cfg.Budget.MaxSessionUSD}
+	q.mu.Unlock()
+
+	if q.bus != nil {
+		q.bus.Publish(bus.Message{Type: bus.MsgCostUpdate, TaskID: row.TaskID, Payload: spend, Time: time.Now()})
+	}
+}
+
+// loadSpend restores a resumed session's spend from the database.
+func (q *Queen) loadSpend(ctx context.Context) {
+	total, err := q.db.SessionCost(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load session cost: %v", err)
+		return
+	}
+	byTask, err := q.db.TaskCosts(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load task costs: %v", err)
+	}
+	tasks := make(map[string]float64, len(byTask))
+	for id, c := range byTask {
+		tasks[id] = c.CostUSD
+	}
+	q.mu.Lock()
+	q.spend = spending{usd: total.CostUSD, tokens: total.Tokens, tasks: tasks}
+	q.mu.Unlock()
+}
+
+// costByPurpose describes where the session's money went, most expensive
+// purpose first, or returns "" when it all went to one.
+func (q *Queen) costByPurpose(ctx context.Context) string {
+	costs, err := q.db.PurposeCosts(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load costs by purpose: %v", err)
+		return ""
+	}
+	if len(costs) < 2 {
+		return ""
+	}
+	names := slices.Collect(maps.Keys(costs))
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(costs[b].CostUSD, costs[a].CostUSD), cmp.Compare(a, b))
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + " " + formatUSD(costs[name].CostUSD)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Spent returns the session's spend so far.
+func (q *Queen) Spent() Spend {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return Spend{SessionUSD: q.spend.usd, Tokens: q.spend.tokens, BudgetUSD: q.cfg.Budget.MaxSessionUSD}
+}
+
+// taskSpend returns what a task has cost across its attempts.
+func (q *Queen) taskSpend(taskID string) float64 {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.spend.tasks[taskID]
+}
+
+// overBudget describes the first session limit that has been reached, or
+// returns "" when the session is within budget.
+func (q *Queen) overBudget() string {
+	b := q.cfg.Budget
+	s := q.Spent()
+	if b.MaxSessionUSD > 0 && s.SessionUSD >= b.MaxSessionUSD {
+		return fmt.Sprintf("spent %s of the %s session budget", formatUSD(s.SessionUSD), formatUSD(b.MaxSessionUSD))
+	}
+	if b.MaxTokens > 0 && s.Tokens >= b.MaxTokens {
+		return fmt.Sprintf("used %d of the %d token budget", s.Tokens, b.MaxTokens)
+	}
+	return ""
+}
+
+// nearBudget describes a session limit that has crossed the warning
+// threshold, or returns "".
+func (q *Queen) nearBudget() string {
+	b := q.cfg.Budget
+	if b.WarnAt <= 0 {
+		return ""
+	}
+	s := q.Spent()
+	if b.MaxSessionUSD > 0 && s.SessionUSD >= b.WarnAt*b.MaxSessionUSD {
+		return fmt.Sprintf("spent %s of the %s session budget", formatUSD(s.SessionUSD), formatUSD(b.MaxSessionUSD))
+	}
+	if b.MaxTokens > 0 && float64(s.Tokens) >= b.WarnAt*float64(b.MaxTokens) {
+		return fmt.Sprintf("used %d of the %d token budget", s.Tokens, b.MaxTokens)
+	}
+	return ""
+}
+
+// checkBudget runs before every Queen turn. Near a limit it warns the Queen
+// once. Over a limit it first tells the Queen to wrap up; if the Queen is
+// still going on the following turn, it kills the workers and returns an
+// error that ends the session.
+func (q *Queen) checkBudget(messages []llm.ToolMessage) ([]llm.ToolMessage, error) {
+	if over := q.overBudget(); over != "" {
+		q.mu.Lock()
+		notified := q.spend.exceeded
+		q.spend.exceeded = true
+		q.mu.Unlock()
+		if notified {
+			q.Printer().Error("Budget exceeded (%s), stopping", over)
+			q.pool.KillAll()
+			return messages, fmt.Errorf("budget exceeded: %s", over)
+		}
+		q.Printer().Warning("Budget exceeded (%s), asking the Queen to wrap up", over)
+		return appendSystemNote(messages, fmt.Sprintf(
+			"[SYSTEM: Budget exceeded: you have %s. Do not assign new tasks. Wrap up now: "+
+				"call complete with a summary of what was achieved, or fail if the objective cannot be met. "+
+				"The session will be stopped after this turn.]", over)), nil
+	}
+
+	if near := q.nearBudget(); near != "" {
+		q.mu.Lock()
+		warned := q.spend.warned
+		q.spend.warned = true
+		q.mu.Unlock()
+		if !warned {
+			q.Printer().Warning("Budget warning: %s", near)
+			return appendSystemNote(messages, fmt.Sprintf(
+				"[SYSTEM: Budget warning: you have %s. Prioritise the remaining work and avoid unnecessary retries.]", near)), nil
+		}
+	}
+	return messages, nil
+}
+
+// checkTaskBudget returns an error if t may not be started because the
+// session or the task itself is over budget.
+func (q *Queen) checkTaskBudget(t *task.Task) error {
+	if over := q.overBudget(); over != "" {
+		return fmt.Errorf("budget exceeded (%s): no new tasks can be assigned; wrap up and call complete or fail", over)
+	}
+	if limit := q.cfg.Budget.MaxTaskUSD; limit > 0 {
+		if spent := q.taskSpend(t.ID); spent >= limit {
+			return fmt.Errorf("task %q has spent %s, over its %s budget", t.ID, formatUSD(spent), formatUSD(limit))
+		}
+	}
+	return nil
+}
+
+// taskOverBudget reports whether a task has used up its per-task budget.
+func (q *Queen) taskOverBudget(taskID string) bool {
+	limit := q.cfg.Budget.MaxTaskUSD
+	return limit > 0 && q.taskSpend(taskID) >= limit
+}
+
+// appendSystemNote appends a user message carrying a system note.
+func appendSystemNote(messages []llm.ToolMessage, text string) []llm.ToolMessage {
+	return append(messages, llm.ToolMessage{
+		Role:    "user",
+		Content: []llm.ContentBlock{{Type: "text", Text: text}},
+	})
+}
+
+// formatUSD renders a dollar amount with cent precision, or more for
+// amounts under a cent.
+func formatUSD(v float64) string {
+	if v > 0 && v < 0.01 {
+		return fmt.Sprintf("$%.4f", v)
+	}
+	return fmt.Sprintf("$%.2f", v)
+}
@@ -0,0 +1,266 @@
+package queen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// costlyResponse is a get_status call whose usage costs $0.60 on Sonnet.
+func costlyResponse(id string) *llm.Response {
+	resp := makeToolResponse(id, "get_status", toJSON(map[string]string{}))
+	resp.Usage = llm.Usage{InputTokens: 200_000}
+	return resp
+}
+
+func TestRecordCosts(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Queen.Provider = "anthropic"
+	q.cfg.Queen.Model = "claude-sonnet-4-20250514"
+	ctx := context.Background()
+
+	var updates []Spend
+	q.bus.Subscribe(bus.MsgCostUpdate, func(msg bus.Message) {
+		updates = append(updates, msg.Payload.(Spend))
+	})
+
+	q.recordTurnCost(ctx, 0, &llm.Response{Usage: llm.Usage{InputTokens: 1_000_000, OutputTokens: 100_000}})
+	tk := &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusRunning}
+	addPersistedTask(t, q, tk)
+	q.startAttempt(ctx, tk, "w-1", "claude-code")
+	q.finishAttempt(ctx, "w-1", AttemptSucceeded, "", &task.Result{
+		Success: true, Metrics: map[string]float64{"cost_usd": 0.25, "input_tokens": 1000, "output_tokens": 500, "tokens": 1500},
+	})
+
+	s := q.Spent()
+	if s.SessionUSD != 4.75 || s.Tokens != 1_101_500 {
+		t.Errorf("unexpected spend %+v", s)
+	}
+	if q.taskSpend("t1") != 0.25 {
+		t.Errorf("expected task spend $0.25, got %v", q.taskSpend("t1"))
+	}
+	if len(updates) != 2 || updates[1].SessionUSD != 4.75 {
+		t.Errorf("expected 2 cost updates, got %+v", updates)
+	}
+
+	// A resumed Queen picks up where the session left off.
+	fresh, _ := testQueen(t)
+	fresh.db, fresh.sessionID = q.db, q.sessionID
+	fresh.loadSpend(ctx)
+	if got := fresh.Spent(); got.SessionUSD != s.SessionUSD || got.Tokens != s.Tokens || fresh.taskSpend("t1") != 0.25 {
+		t.Errorf("expected spend restored from db, got %+v", got)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Budget.MaxSessionUSD = 1
+	q.cfg.Budget.WarnAt = 0.8
+
+	q.addCost(context.Background(), costRow(q, 0.5))
+	msgs, err := q.checkBudget(nil)
+	if err != nil || len(msgs) != 0 {
+		t.Fatalf("expected no note under the warning threshold, got %v, %v", msgs, err)
+	}
+
+	q.addCost(context.Background(), costRow(q, 0.35))
+	msgs, err = q.checkBudget(nil)
+	if err != nil || len(msgs) != 1 || !strings.Contains(msgs[0].Content[0].Text, "Budget warning") {
+		t.Fatalf("expected a budget warning, got %v, %v", msgs, err)
+	}
+	if msgs, _ = q.checkBudget(nil); len(msgs) != 0 {
+		t.Errorf("expected the warning only once, got %v", msgs)
+	}
+
+	q.addCost(context.Background(), costRow(q, 0.2))
+	msgs, err = q.checkBudget(nil)
+	if err != nil || len(msgs) != 1 || !strings.Contains(msgs[0].Content[0].Text, "Wrap up now") {
+		t.Fatalf("expected a wrap-up note, got %v, %v", msgs, err)
+	}
+	if _, err = q.checkBudget(nil); err == nil || !strings.Contains(err.Error(), "budget exceeded") {
+		t.Fatalf("expected the session to stop on the next turn, got %v", err)
+	}
+}
+
+func TestTaskBudget(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Budget.MaxTaskUSD = 0.5
+	ctx := context.Background()
+
+	tk := &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusRunning, MaxRetries: 3}
+	addPersistedTask(t, q, tk)
+	q.startAttempt(ctx, tk, "w-1", "exec")
+	q.handleTaskFailure(ctx, "t1", "w-1", &task.Result{
+		Errors:  []string{"connection reset"},
+		Metrics: map[string]float64{"cost_usd": 0.6},
+	})
+
+	if tk.GetStatus() != task.StatusFailed || tk.GetRetryCount() != 0 {
+		t.Errorf("expected over-budget task failed without retry, got %s (retries %d)", tk.GetStatus(), tk.GetRetryCount())
+	}
+
+	other := &task.Task{ID: "t2", Title: "Two", Type: task.TypeCode, Status: task.StatusPending}
+	addPersistedTask(t, q, other)
+	q.addCost(ctx, costRow(q, 0.1))
+	if err := q.checkTaskBudget(other); err != nil {
+		t.Errorf("expected t2 within budget, got %v", err)
+	}
+	if err := q.checkTaskBudget(tk); err == nil || !strings.Contains(err.Error(), "over its $0.50 budget") {
+		t.Errorf("expected t1 refused, got %v", err)
+	}
+}
+
+func TestDelegate_SkipsTaskOverBudget(t *testing.T) {
+	q, dir := testQueen(t)
+	useExecRouter(q, dir)
+	q.cfg.Budget.MaxTaskUSD = 0.5
+	ctx := context.Background()
+
+	tk := &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending, Description: "echo hi"}
+	addPersistedTask(t, q, tk)
+	q.addCost(ctx, state.CostRow{SessionID: q.sessionID, TaskID: "t1", Source: state.CostSourceWorker, CostUSD: 0.6})
+
+	if err := q.delegate(ctx); err != nil {
+		t.Fatalf("delegate: %v", err)
+	}
+	if tk.GetStatus() != task.StatusPending || q.pool.ActiveCount() != 0 {
+		t.Errorf("expected the over-budget task left unstarted, got %s with %d workers", tk.GetStatus(), q.pool.ActiveCount())
+	}
+}
+
+func TestRun_StopsWhenOverBudget(t *testing.T) {
+	q := orchTestQueen(t)
+	q.suppressReport = true
+	q.cfg.Budget.MaxSessionUSD = 1
+	q.spend.usd = 1.2
+	q.SetTasks([]*task.Task{{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusPending, Description: "echo hi"}})
+
+	err := q.Run(context.Background(), "spend money")
+	if err == nil || !strings.Contains(err.Error(), "budget exceeded") {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if tk, ok := q.tasks.Get("t1"); ok && tk.GetStatus() != task.StatusPending {
+		t.Errorf("expected no task started over budget, got %s", tk.GetStatus())
+	}
+	info, _ := q.db.GetSession(context.Background(), q.sessionID)
+	if info == nil || info.Status != "failed" {
+		t.Errorf("expected session marked failed, got %+v", info)
+	}
+}
+
+func TestRunAgent_StopsWhenOverBudget(t *testing.T) {
+	q := setupTestQueen(t)
+	q.cfg.Queen.Provider = "anthropic"
+	q.cfg.Queen.Model = "claude-sonnet-4-20250514"
+	q.cfg.Budget.MaxSessionUSD = 1
+	client := &mockToolClient{responses: []*llm.Response{
+		costlyResponse("c1"), costlyResponse("c2"), costlyResponse("c3"), costlyResponse("c4"),
+	}}
+	q.llm = client
+
+	err := q.RunAgent(context.Background(), "spend money")
+	if err == nil || !strings.Contains(err.Error(), "budget exceeded") {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if len(client.calls) != 3 {
+		t.Fatalf("expected the Queen one wrap-up turn after the budget ran out, got %d calls", len(client.calls))
+	}
+	last := client.calls[2].Messages
+	if note := last[len(last)-1].Content[0].Text; !strings.Contains(note, "Budget exceeded") {
+		t.Errorf("expected wrap-up note before the last turn, got %q", note)
+	}
+
+	info, _ := q.db.GetSession(context.Background(), q.sessionID)
+	if info == nil || info.Status != "failed" {
+		t.Errorf("expected session marked failed, got %+v", info)
+	}
+	if total, _ := q.db.SessionCost(context.Background(), q.sessionID); total.CostUSD < 1.79 || total.CostUSD > 1.81 {
+		t.Errorf("expected $1.80 recorded, got %+v", total)
+	}
+}
+
+func costRow(q *Queen, usd float64) state.CostRow {
+	return state.CostRow{SessionID: q.sessionID, Source: state.CostSourceQueen, CostUSD: usd}
+}
+
+// downClient is a tool client whose calls all fail.
+type downClient struct{ mockToolClient }
+
+func (d *downClient) ChatWithTools(ctx context.Context, systemPrompt string,
+	messages []llm.ToolMessage, tools []llm.ToolDef) (*llm.Response, error) {
+	return nil, errors.New("invalid x-api-key")
+}
+
+func TestRunAgent_FailoverRecordsModel(t *testing.T) {
+	q := setupTestQueen(t)
+	q.cfg.Queen.Provider = "anthropic"
+	q.cfg.Queen.Model = "claude-sonnet-4-20250514"
+	fallback := &mockToolClient{responses: []*llm.Response{
+		makeToolResponse("c1", "complete", toJSON(map[string]string{"summary": "done"})),
+	}}
+	q.llm = llm.NewFailoverClient([]llm.Backend{
+		{Provider: "anthropic", Model: "claude-sonnet-4-20250514", Client: &downClient{}},
+		{Provider: "openai", Model: "gpt-4.1", Client: fallback},
+	}, time.Minute, nil)
+
+	ctx := context.Background()
+	if err := q.RunAgent(ctx, "survive an outage"); err != nil {
+		t.Fatalf("expected the fallback to finish the session, got %v", err)
+	}
+
+	msgs, err := q.db.LoadMessages(ctx, q.sessionID)
+	if err != nil || len(msgs) < 2 {
+		t.Fatalf("expected persisted messages, got %d, %v", len(msgs), err)
+	}
+	if msgs[1].Role != "assistant" || !strings.Contains(msgs[1].UsageData, `"provider":"openai","model":"gpt-4.1"`) {
+		t.Errorf("expected the serving model in the usage data, got %+v", msgs[1])
+	}
+	if msgs[0].UsageData != "" {
+		t.Errorf("expected no usage data on the objective, got %q", msgs[0].UsageData)
+	}
+}
+
+func TestNewQueenLLM_Fallbacks(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Queen.APIKey = "test"
+	if c, err := newQueenLLM(cfg, log.New(io.Discard, "", 0)); err != nil {
+		t.Fatal(err)
+	} else if _, ok := c.(*llm.FailoverClient); ok {
+		t.Error("expected a plain client without fallbacks")
+	}
+
+	cfg.Queen.Fallbacks = []config.LLMBackend{
+		{Provider: "openai", Model: "gpt-4.1", APIKey: "test"},
+		{Provider: "no-such-provider"},
+	}
+	c, err := newQueenLLM(cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc, ok := c.(*llm.FailoverClient); !ok || fc.Active().Provider != "anthropic" {
+		t.Errorf("expected a failover client starting on the primary, got %T", c)
+	}
+
+	// Fallbacks answer with the agent model's generation settings.
+	temp := 0.2
+	cfg.Queen.Models = map[string]config.ModelConfig{
+		purposeAgent: {MaxTokens: 16000, Temperature: &temp, ThinkingBudget: 8000, ReasoningEffort: "high"},
+	}
+	pc := fallbackConfig(cfg, modelConfig(cfg, purposeAgent), cfg.Queen.Fallbacks[0])
+	if pc.Provider != "openai" || pc.Model != "gpt-4.1" || pc.APIKey != "test" {
+		t.Errorf("expected the fallback's own provider, model and key, got %+v", pc)
+	}
+	if o := pc.Options; o.MaxTokens != 16000 || o.Temperature == nil || *o.Temperature != 0.2 || o.ThinkingBudget != 8000 || o.ReasoningEffort != "high" {
+		t.Errorf("expected the agent's generation settings, got %+v", o)
+	}
+}
@@ -0,0 +1,76 @@
+package queen
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// LoadDurationHistory builds a duration history from every successful
+// attempt recorded in the hive DB.
+func LoadDurationHistory(ctx context.Context, db *state.DB) (*task.DurationHistory, error) {
+	stats, err := db.TaskDurationStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h := task.NewDurationHistory()
+	for _, s := range stats {
+		h.Add(task.Type(s.TaskType), s.Adapter, time.Duration(s.MeanSeconds*float64(time.Second)), s.Count)
+	}
+	return h, nil
+}
+
+// DurationHistory returns historical task durations for ETA estimates.
+func (q *Queen) DurationHistory(ctx context.Context) (*task.DurationHistory, error) {
+	return LoadDurationHistory(ctx, q.db)
+}
+
+// criticalPath analyses the current task graph using historical durations
+// for each task's type and routed adapter.
+func (q *Queen) criticalPath(ctx context.Context) *task.CriticalPath {
+	history, err := LoadDurationHistory(ctx, q.db)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load task durations: %v", err)
+	}
+
+	// Snapshot the routes: the estimator runs under the graph lock and must
+	// not probe adapter availability.
+	var routes map[task.Type]string
+	var defaultAdapter string
+	if q.router != nil {
+		routes = q.router.Routes()
+		defaultAdapter = q.router.DefaultAdapter()
+	}
+	return q.tasks.CriticalPath(func(t *task.Task) time.Duration {
+		adapterName, ok := routes[t.Type]
+		if !ok {
+			adapterName = defaultAdapter
+		}
+		return history.Estimate(t.Type, adapterName)
+	})
+}
+
+// sortReady orders ready tasks for scheduling: higher priority first, then
+// least slack so critical-path tasks start before tasks that can wait.
+func sortReady(ready []*task.Task, cp *task.CriticalPath) {
+	slack := func(t *task.Task) time.Duration {
+		if s, ok := cp.Slack[t.ID]; ok {
+			return s
+		}
+		return 0
+	}
+	sort.SliceStable(ready, func(i, j int) bool {
+		pi, pj := ready[i].GetPriority(), ready[j].GetPriority()
+		if pi != pj {
+			return pi > pj
+		}
+		si, sj := slack(ready[i]), slack(ready[j])
+		if si != sj {
+			return si < sj
+		}
+		return ready[i].ID < ready[j].ID
+	})
+}
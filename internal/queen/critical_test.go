@@ -0,0 +1,97 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func TestSortReady_PrefersCriticalPath(t *testing.T) {
+	short := &task.Task{ID: "a-short", Priority: task.PriorityNormal}
+	long := &task.Task{ID: "z-long", Priority: task.PriorityNormal}
+	urgent := &task.Task{ID: "m-urgent", Priority: task.PriorityHigh}
+	cp := &task.CriticalPath{Slack: map[string]time.Duration{
+		"a-short":  3 * time.Minute,
+		"z-long":   0,
+		"m-urgent": 5 * time.Minute,
+	}}
+
+	ready := []*task.Task{short, long, urgent}
+	sortReady(ready, cp)
+
+	got := []string{ready[0].ID, ready[1].ID, ready[2].ID}
+	want := []string{"m-urgent", "z-long", "a-short"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetStatus_CriticalPath(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+
+	// Historical code tasks take 10m, research 1m.
+	for _, h := range []struct {
+		id, typ string
+		secs    float64
+	}{{"h1", "code", 600}, {"h2", "research", 60}} {
+		id, typ, secs := h.id, h.typ, h.secs
+		if err := q.db.InsertTask(ctx, q.sessionID, state.TaskRow{ID: id, Type: typ, Status: "complete", Title: id}); err != nil {
+			t.Fatalf("insert task: %v", err)
+		}
+		n, err := q.db.StartAttempt(ctx, q.sessionID, id, "w", "exec")
+		if err != nil {
+			t.Fatalf("start attempt: %v", err)
+		}
+		b, _ := json.Marshal(map[string]float64{"duration_seconds": secs})
+		if err := q.db.FinishAttempt(ctx, state.AttemptRow{
+			SessionID: q.sessionID, TaskID: id, Attempt: n, Status: AttemptSucceeded, Metrics: string(b),
+		}); err != nil {
+			t.Fatalf("finish attempt: %v", err)
+		}
+	}
+
+	q.tasks.Add(&task.Task{ID: "impl", Type: task.TypeCode, Status: task.StatusPending})
+	q.tasks.Add(&task.Task{ID: "docs", Type: task.TypeResearch, Status: task.StatusPending})
+
+	out, err := handleGetStatus(ctx, q, nil)
+	if err != nil {
+		t.Fatalf("get_status: %v", err)
+	}
+	var status struct {
+		Tasks []struct {
+			ID       string `json:"id"`
+			Critical bool   `json:"critical"`
+			Slack    string `json:"slack"`
+		} `json:"tasks"`
+		CriticalPath []string `json:"critical_path"`
+		ETA          string   `json:"eta"`
+	}
+	if err := json.Unmarshal([]byte(out.LLMContent), &status); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(status.CriticalPath) != 1 || status.CriticalPath[0] != "impl" {
+		t.Errorf("expected critical path [impl], got %v", status.CriticalPath)
+	}
+	if status.ETA != "10m" {
+		t.Errorf("expected ETA 10m, got %q", status.ETA)
+	}
+	for _, ti := range status.Tasks {
+		switch ti.ID {
+		case "impl":
+			if !ti.Critical {
+				t.Error("impl should be critical")
+			}
+		case "docs":
+			if ti.Critical || ti.Slack != "9m" {
+				t.Errorf("docs should have 9m slack, got critical=%v slack=%q", ti.Critical, ti.Slack)
+			}
+		}
+	}
+}
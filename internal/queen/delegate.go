@@ -3,7 +3,6 @@ package queen
 import (
 	"context"
 	"fmt"
-	"sort"
 	"time"
 
 	"github.com/HexSleeves/waggle/internal/task"
@@ -20,10 +19,8 @@ func (q *Queen) delegate(ctx context.Context) error {
 		return nil
 	}
 
-	// Sort by priority
-	sort.Slice(ready, func(i, j int) bool {
-		return ready[i].Priority > ready[j].Priority
-	})
+	// Sort by priority, then critical-path tasks first
+	sortReady(ready, q.criticalPath(ctx))
 
 	for _, t := range ready {
 		select {
@@ -37,7 +34,7 @@ func (q *Queen) delegate(ctx context.Context) error {
 			break
 		}
 
-		adapterName := q.router.Route(t)
+		adapterName := q.routeTask(t)
 		if adapterName == "" {
 			q.logVerbose("  ⚠ No adapter for task %s, skipping", t.ID)
 			continue
@@ -45,12 +42,34 @@ func (q *Queen) delegate(ctx context.Context) error {
 
 		// Inject default scope constraints into every task
 		injectDefaultConstraints(t)
+		q.prepareWorker(ctx, t)
 
-		bee, err := q.pool.Spawn(ctx, t, adapterName)
+		cacheKey, cached := q.checkCache(ctx, t, adapterName)
+		if cached != nil && q.completeFromCache(ctx, t, cached) {
+			continue
+		}
+
+		if hold := q.holdFor(t); hold != nil {
+			q.logVerbose("  ⏸ Holding %s: %s", t.ID, hold)
+			continue
+		}
+
+		if err := q.checkTaskBudget(t); err != nil {
+			q.logVerbose("  ⚠ Not starting %s: %v", t.ID, err)
+			continue
+		}
+
+		if err := q.approveAssign(ctx, t, adapterName); err != nil {
+			q.Printer().Warning("Not starting %s: the operator did not approve it", t.ID)
+			continue
+		}
+
+		bee, err := q.spawnWorker(ctx, t, adapterName)
 		if err != nil {
 			q.logVerbose("  ⚠ Failed to spawn worker for %s: %v", t.ID, err)
 			continue
 		}
+		q.rememberCacheKey(bee.ID(), cacheKey, adapterName)
 
 		q.mu.Lock()
 		q.assignments[bee.ID()] = t.ID
@@ -67,10 +86,12 @@ func (q *Queen) delegate(ctx context.Context) error {
 		if err := q.db.UpdateTaskWorker(ctx, q.sessionID, t.ID, bee.ID()); err != nil {
 			q.logger.Printf("  ⚠ Warning: failed to update task worker in db: %v", err)
 		}
+		q.startAttempt(ctx, t, bee.ID(), adapterName)
 
 		q.logVerbose("  🐝 Assigned [%s] %s -> %s (%s)", t.Type, t.Title, bee.ID(), adapterName)
 	}
 
+	q.refreshHolds()
 	return nil
 }
 
@@ -96,6 +117,9 @@ func (q *Queen) monitor(ctx context.Context) error {
 			return nil
 		}
 
+		// Operator edits may cancel the very tasks being monitored
+		q.applyLegacyControls(ctx)
+
 		active := q.pool.Active()
 		if len(active) == 0 {
 			q.logVerbose("  ✓ All workers finished")
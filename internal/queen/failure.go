@@ -30,14 +30,16 @@ func (q *Queen) handleTaskFailure(ctx context.Context, taskID, workerID string,
 	if err := q.db.UpdateTaskErrorType(ctx, q.sessionID, taskID, string(errType)); err != nil {
 		q.logger.Printf("  ⚠ Warning: failed to update task error type: %v", err)
 	}
+	q.finishAttempt(ctx, workerID, AttemptFailed, string(errType), result)
 
 	q.Printer().Error("Task %s failed (%s): %s", taskID, errType, truncate(errMsg, 200))
 
 	// Check if error is retryable
 	isRetryable := errors.IsRetryable(fmt.Errorf("%s", errMsg))
+	overBudget := q.taskOverBudget(taskID)
 
 	// Don't increment retry count for permanent errors - they won't succeed on retry
-	if isRetryable && t.GetRetryCount() < t.MaxRetries {
+	if isRetryable && t.GetRetryCount() < t.MaxRetries && !overBudget {
 		newCount := t.IncrRetryCount()
 
 		// Calculate exponential backoff delay
@@ -69,7 +71,10 @@ func (q *Queen) handleTaskFailure(ctx context.Context, taskID, workerID string,
 			q.logger.Printf("  ⚠ Warning: failed to update task status in db: %v", err)
 		}
 	} else {
-		// Max retries exceeded
+		// Max retries exceeded, or the task used up its budget
+		if overBudget {
+			q.Printer().Error("Task %s is over its %s budget, not retrying", taskID, formatUSD(q.cfg.Budget.MaxTaskUSD))
+		}
 		if err := q.tasks.UpdateStatus(taskID, task.StatusFailed); err != nil {
 			q.logger.Printf("  ⚠ Warning: failed to update task status: %v", err)
 		}
@@ -1,6 +1,7 @@
 package queen
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -104,3 +105,15 @@ func runGit(dir string, args ...string) (string, error) {
 	out, err := cmd.Output()
 	return string(out), err
 }
+
+// runGitContext is runGit bounded by ctx. On failure the error carries git's
+// stderr.
+func runGitContext(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return string(out), fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return string(out), err
+}
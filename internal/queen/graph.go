@@ -0,0 +1,105 @@
+package queen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// LoadSessionGraph rebuilds a session's task graph from the hive DB, together
+// with per-task adapter, attempt and nested-session details for the DAG
+// renderers.
+func LoadSessionGraph(ctx context.Context, db *state.DB, sessionID string) (*task.TaskGraph, map[string]task.NodeInfo, error) {
+	rows, err := db.GetTasks(ctx, sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load tasks: %w", err)
+	}
+	graph := task.NewTaskGraph(nil)
+	for _, tr := range rows {
+		graph.Add(taskFromRow(&tr))
+	}
+
+	attempts, err := db.ListSessionAttempts(ctx, sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load attempts: %w", err)
+	}
+	infos := nodeInfos(attempts)
+	children, err := db.ListChildSessions(ctx, sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load child sessions: %w", err)
+	}
+	addSubSessions(infos, children)
+	return graph, infos, nil
+}
+
+// nodeInfos summarises attempts per task: the adapter of the latest attempt,
+// how many attempts ran, and the most recent error.
+func nodeInfos(attempts []state.AttemptRow) map[string]task.NodeInfo {
+	infos := make(map[string]task.NodeInfo)
+	for _, a := range attempts {
+		info := infos[a.TaskID]
+		info.Attempts++
+		if a.Adapter != "" {
+			info.Adapter = a.Adapter
+		}
+		if a.Error != "" {
+			info.LastError = a.Error
+		}
+		infos[a.TaskID] = info
+	}
+	return infos
+}
+
+// addSubSessions records the child session that ran each objective task.
+// Children are ordered oldest first, so a retried task shows its latest run.
+func addSubSessions(infos map[string]task.NodeInfo, children []state.SessionSummary) {
+	for _, c := range children {
+		info := infos[c.ParentTaskID]
+		info.SubSession = c.ID
+		infos[c.ParentTaskID] = info
+	}
+}
+
+// renderOptions gathers critical-path and attempt details for the current
+// session's task graph.
+func (q *Queen) renderOptions(ctx context.Context) task.RenderOptions {
+	opts := task.RenderOptions{
+		Title:    q.objective,
+		Critical: q.criticalPath(ctx),
+	}
+	attempts, err := q.db.ListSessionAttempts(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load task attempts: %v", err)
+	}
+	opts.Nodes = nodeInfos(attempts)
+	children, err := q.db.ListChildSessions(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load child sessions: %v", err)
+	}
+	addSubSessions(opts.Nodes, children)
+	return opts
+}
+
+// DAG returns the session's task graph in machine-readable form.
+func (q *Queen) DAG() task.DAG {
+	return q.tasks.DAG(q.renderOptions(context.Background()))
+}
+
+// writeGraphReport renders the task graph as a self-contained HTML page under
+// .hive/reports and returns its path relative to the project.
+func (q *Queen) writeGraphReport(ctx context.Context) (string, error) {
+	dir := filepath.Join(q.cfg.HivePath(), "reports")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := q.sessionID + ".html"
+	html := q.tasks.RenderHTML(q.renderOptions(ctx))
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(html), 0o644); err != nil {
+		return "", err
+	}
+	return filepath.Join(q.cfg.HiveDir, "reports", name), nil
+}
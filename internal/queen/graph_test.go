@@ -0,0 +1,55 @@
+package queen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func TestNodeInfos(t *testing.T) {
+	infos := nodeInfos([]state.AttemptRow{
+		{TaskID: "t1", Adapter: "exec", Error: "boom"},
+		{TaskID: "t1", Adapter: "codex"},
+		{TaskID: "t2", Adapter: "exec"},
+	})
+	if got := infos["t1"]; got.Attempts != 2 || got.Adapter != "codex" || got.LastError != "boom" {
+		t.Errorf("unexpected info for t1: %+v", got)
+	}
+	if got := infos["t2"]; got.Attempts != 1 || got.Adapter != "exec" {
+		t.Errorf("unexpected info for t2: %+v", got)
+	}
+}
+
+func TestWriteGraphReport(t *testing.T) {
+	q, dir := testQueen(t)
+	q.objective = "build the thing"
+	q.tasks.Add(&task.Task{ID: "t1", Title: "First", Status: task.StatusComplete})
+	q.tasks.Add(&task.Task{ID: "t2", Title: "Second", Status: task.StatusPending, DependsOn: []string{"t1"}})
+
+	path, err := q.writeGraphReport(context.Background())
+	if err != nil {
+		t.Fatalf("writeGraphReport: %v", err)
+	}
+	if path != filepath.Join(".hive", "reports", q.sessionID+".html") {
+		t.Errorf("unexpected report path %q", path)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	for _, want := range []string{"<h1>build the thing</h1>", "First", "Second"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+
+	dag := q.DAG()
+	if len(dag.Nodes) != 2 || len(dag.Edges) != 1 || dag.Title != "build the thing" {
+		t.Errorf("unexpected DAG: %+v", dag)
+	}
+}
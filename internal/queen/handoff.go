@@ -0,0 +1,159 @@
+package queen
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+const (
+	summaryKeyPrefix   = "summary-" // blackboard key of a dependency output summary
+	maxSummarizeInput  = 60000      // output chars sent to the LLM for summarization
+	maxHandoffNoteLen  = 300        // per blackboard note in a dependency block
+	minHandoffBlockLen = 200        // below this, remaining dependencies are omitted
+)
+
+const summarizeSystemPrompt = `You summarize the output of a completed task for the worker of a task that depends on it.
+Keep what the next worker needs: decisions made, files created or changed, interfaces, commands, and open issues.
+Drop progress chatter and repetition. Answer with the summary only, in at most %d characters.`
+
+// setDepResults builds the dependency results for t, stores them on the task
+// for buildPrompt and records them in the database.
+func (q *Queen) setDepResults(ctx context.Context, t *task.Task) {
+	s := q.depResults(ctx, t)
+	if s == t.GetDepResults() {
+		return
+	}
+	t.SetDepResults(s)
+	if err := q.db.UpdateTaskDepResults(ctx, q.sessionID, t.ID, s); err != nil {
+		q.logger.Printf("⚠ Warning: failed to record dependency results for %s: %v", t.ID, err)
+	}
+}
+
+// depResults builds the "Results from dependencies" section for t from its
+// completed dependencies: each one's output, artifacts and blackboard notes,
+// bounded by the handoff size limits.
+func (q *Queen) depResults(ctx context.Context, t *task.Task) string {
+	cfg := q.cfg.Handoff
+	if cfg.Disabled || t.SkipDepResults {
+		return ""
+	}
+	perDep := cfg.MaxChars
+	if perDep <= 0 {
+		perDep = 4000
+	}
+	total := cfg.MaxTotalChars
+	if total <= 0 {
+		total = 3 * perDep
+	}
+
+	var deps []*task.Task
+	for _, id := range t.GetDependsOn() {
+		if dep, ok := q.tasks.Get(id); ok && dep.GetStatus() == task.StatusComplete && dep.GetResult() != nil {
+			deps = append(deps, dep)
+		}
+	}
+
+	var blocks []string
+	used := 0
+	for i, dep := range deps {
+		limit := min(perDep, total-used)
+		if limit < minHandoffBlockLen {
+			blocks = append(blocks, fmt.Sprintf("(%d more dependencies omitted for length)", len(deps)-i))
+			break
+		}
+		block := q.depBlock(ctx, dep, limit)
+		used += len(block)
+		blocks = append(blocks, block)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// depBlock renders one dependency's results in at most about limit characters.
+func (q *Queen) depBlock(ctx context.Context, dep *task.Task, limit int) string {
+	result := dep.GetResult()
+	header := fmt.Sprintf("### %s: %s (%s)\n", dep.ID, dep.Title, dep.Type)
+
+	var extra strings.Builder
+	var keys []string
+	for k := range result.Artifacts {
+		if k != ArtifactCacheKey {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		slices.Sort(keys)
+		extra.WriteString("\nArtifacts:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&extra, "- %s: %s\n", k, clip(result.Artifacts[k], maxHandoffNoteLen))
+		}
+	}
+	notes := q.board.ReadByTask(dep.ID)
+	notes = slices.DeleteFunc(notes, func(e *blackboard.Entry) bool {
+		return strings.HasPrefix(e.Key, "result-") || strings.HasPrefix(e.Key, summaryKeyPrefix)
+	})
+	if len(notes) > 0 {
+		sortEntries(notes)
+		extra.WriteString("\nNotes:\n")
+		for _, e := range notes {
+			fmt.Fprintf(&extra, "- %s: %s\n", e.Key, clip(entryText(e), maxHandoffNoteLen))
+		}
+	}
+
+	out := strings.TrimSpace(result.Output)
+	if out == "" {
+		out = "(no output)"
+	}
+	room := max(limit-len(header)-extra.Len(), minHandoffBlockLen)
+	if len(out) > room {
+		if summary, ok := q.summarizeDep(ctx, dep, out, room); ok {
+			out = "Summary: " + summary
+		} else {
+			out = elide(out, room)
+		}
+	}
+	return strings.TrimRight(header+out+"\n"+extra.String(), "\n")
+}
+
+// summarizeDep returns an LLM summary of a dependency's output when
+// summarization is enabled. Summaries are kept on the blackboard so a task
+// retried later, or another dependent, reuses them.
+func (q *Queen) summarizeDep(ctx context.Context, dep *task.Task, out string, limit int) (string, bool) {
+	if !q.cfg.Handoff.Summarize || q.llm == nil {
+		return "", false
+	}
+	key := summaryKeyPrefix + dep.ID
+	if e, ok := q.board.Read(key); ok && dep.CompletedAt != nil && e.Timestamp.After(*dep.CompletedAt) {
+		return clip(entryText(e), limit), true
+	}
+
+	prompt := fmt.Sprintf("Task %s: %s\n\nOutput:\n%s", dep.ID, dep.Title, elide(out, maxSummarizeInput))
+	summary, err := q.chat(ctx, purposeSummarize, fmt.Sprintf(summarizeSystemPrompt, limit), prompt)
+	summary = strings.TrimSpace(summary)
+	if err != nil || summary == "" {
+		q.logger.Printf("⚠ Warning: failed to summarize output of %s, truncating instead: %v", dep.ID, err)
+		return "", false
+	}
+	q.postBoard(ctx, &blackboard.Entry{
+		Key:      key,
+		Value:    summary,
+		PostedBy: "queen",
+		TaskID:   dep.ID,
+		Tags:     []string{"summary"},
+	})
+	return clip(summary, limit), true
+}
+
+// elide shortens s to about n characters, keeping its head and tail: worker
+// output usually ends with a summary of what was done.
+func elide(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	half := n / 2
+	return s[:half] + fmt.Sprintf("\n… (%d chars omitted) …\n", len(s)-2*half) + s[len(s)-half:]
+}
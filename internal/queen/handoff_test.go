@@ -0,0 +1,112 @@
+package queen
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/blackboard"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+func addCompletedTask(t *testing.T, q *Queen, id, output string, artifacts map[string]string) {
+	t.Helper()
+	done := time.Now()
+	tk := &task.Task{ID: id, Title: "Task " + id, Type: task.TypeCode, Status: task.StatusComplete, CompletedAt: &done}
+	addPersistedTask(t, q, tk)
+	tk.SetResult(&task.Result{Success: true, Output: output, Artifacts: artifacts})
+}
+
+func TestDepResults(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addCompletedTask(t, q, "schema", "Created users table in db/schema.sql", map[string]string{"migration": "db/001_users.sql", ArtifactCacheKey: "abc"})
+	addCompletedTask(t, q, "api", strings.Repeat("x", 3000)+"DONE: added /users endpoint", nil)
+	addPersistedTask(t, q, &task.Task{ID: "docs", Title: "Docs", Type: task.TypeCode, Status: task.StatusPending})
+	q.board.Post(&blackboard.Entry{Key: "approval-schema", Value: "Good naming", TaskID: "schema"})
+	q.board.Post(&blackboard.Entry{Key: "result-schema", Value: "Created users table in db/schema.sql", TaskID: "schema"})
+
+	q.cfg.Handoff.MaxChars = 1000
+	ui := &task.Task{ID: "ui", Title: "UI", Type: task.TypeCode, Status: task.StatusPending, DependsOn: []string{"schema", "api", "docs"}}
+	addPersistedTask(t, q, ui)
+	q.setDepResults(ctx, ui)
+
+	got := ui.GetDepResults()
+	for _, want := range []string{
+		"### schema: Task schema (code)\nCreated users table in db/schema.sql",
+		"Artifacts:\n- migration: db/001_users.sql",
+		"Notes:\n- approval-schema: Good naming",
+		"### api: Task api (code)",
+		"chars omitted",
+		"DONE: added /users endpoint",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"cache_key", "result-schema", "### docs"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, got)
+		}
+	}
+	if len(got) > 2*1000+100 {
+		t.Errorf("expected results bounded by max_chars, got %d chars", len(got))
+	}
+
+	// Recorded for auditing and restored with the task.
+	row, err := q.db.GetTask(ctx, q.sessionID, "ui")
+	if err != nil || row.DepResults != got {
+		t.Errorf("expected dependency results persisted, got %q, %v", row.DepResults, err)
+	}
+	if restored := taskFromRow(row); restored.GetDepResults() != got {
+		t.Error("expected dependency results restored from the row")
+	}
+
+	q.cfg.Handoff.MaxTotalChars = 300
+	q.setDepResults(ctx, ui)
+	if got := ui.GetDepResults(); !strings.Contains(got, "(1 more dependencies omitted for length)") {
+		t.Errorf("expected total cap, got:\n%s", got)
+	}
+
+	skip := &task.Task{ID: "fresh", Type: task.TypeCode, DependsOn: []string{"schema"}, SkipDepResults: true}
+	if s := q.depResults(ctx, skip); s != "" {
+		t.Errorf("expected opt-out task to get nothing, got %q", s)
+	}
+	q.cfg.Handoff.Disabled = true
+	if s := q.depResults(ctx, ui); s != "" {
+		t.Errorf("expected disabled handoff to give nothing, got %q", s)
+	}
+}
+
+func TestDepResults_Summarize(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	mock := &mockPlanLLM{response: "Added the /users endpoint in api/users.go."}
+	q.llm = mock
+	q.cfg.Handoff.MaxChars = 500
+	q.cfg.Handoff.Summarize = true
+	addCompletedTask(t, q, "api", strings.Repeat("log line\n", 200), nil)
+	next := &task.Task{ID: "client", Type: task.TypeCode, DependsOn: []string{"api"}}
+
+	got := q.depResults(ctx, next)
+	if !strings.Contains(got, "Summary: Added the /users endpoint in api/users.go.") {
+		t.Fatalf("expected summary, got:\n%s", got)
+	}
+	if e, ok := q.board.Read("summary-api"); !ok || e.TaskID != "api" {
+		t.Errorf("expected summary kept on the blackboard, got %+v", e)
+	}
+
+	// The stored summary is reused.
+	mock.called = false
+	q.depResults(ctx, next)
+	if mock.called {
+		t.Error("expected cached summary reused without an LLM call")
+	}
+
+	// Short outputs are passed as they are.
+	addCompletedTask(t, q, "small", "ok", nil)
+	if got := q.depResults(ctx, &task.Task{ID: "x", DependsOn: []string{"small"}}); !strings.HasSuffix(got, "\nok") {
+		t.Errorf("expected short output verbatim, got %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+package queen
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
+)
+
+// Purposes of the Queen's LLM calls, the keys of queen.models in the config.
+const (
+	purposeAgent      = "agent"      // the agent loop and LLM planning
+	purposeReview     = "review"     // reviewing worker output
+	purposeReplan     = "replan"     // asking for more work once all tasks are done
+	purposeSummarize  = "summarize"  // condensing dependency results for handoff
+	purposeCompaction = "compaction" // summarizing compacted conversation
+)
+
+var purposes = []string{purposeAgent, purposeReview, purposeReplan, purposeSummarize, purposeCompaction}
+
+// route is where one purpose's calls go. A nil client means the Queen's own
+// client, q.llm.
+type route struct {
+	client   llm.Client
+	provider string
+	model    string
+}
+
+// modelConfig returns the model of a purpose with unset fields taken from
+// the Queen's provider, model and credentials. A purpose on another provider
+// only inherits the generation settings it sets itself.
+func modelConfig(cfg *config.Config, purpose string) config.ModelConfig {
+	m := cfg.Queen.Models[purpose]
+	if m.Provider != "" && m.Provider != cfg.Queen.Provider {
+		return m
+	}
+	m.Provider = cfg.Queen.Provider
+	if m.Model == "" {
+		m.Model = cfg.Queen.Model
+	}
+	if m.APIKey == "" {
+		m.APIKey = cfg.Queen.APIKey
+	}
+	if m.BaseURL == "" {
+		m.BaseURL = cfg.Queen.BaseURL
+	}
+	return m
+}
+
+// providerConfig returns what llm.NewFromConfig needs to build m.
+func providerConfig(cfg *config.Config, m config.ModelConfig) llm.ProviderConfig {
+	return llm.ProviderConfig{
+		Provider: m.Provider,
+		Model:    m.Model,
+		APIKey:   m.APIKey,
+		BaseURL:  m.BaseURL,
+		WorkDir:  cfg.ProjectDir,
+		Options: llm.Options{
+			MaxTokens:       m.MaxTokens,
+			Temperature:     m.Temperature,
+			ThinkingBudget:  m.ThinkingBudget,
+			ReasoningEffort: m.ReasoningEffort,
+		},
+	}
+}
+
+// fallbackConfig returns what llm.NewFromConfig needs to build a fallback of
+// the agent model. The fallback keeps the agent's generation settings, so a
+// failover does not change how long or how carefully the Queen answers.
+func fallbackConfig(cfg *config.Config, agent config.ModelConfig, fb config.LLMBackend) llm.ProviderConfig {
+	m := agent
+	m.Provider, m.Model, m.APIKey, m.BaseURL = fb.Provider, fb.Model, fb.APIKey, fb.BaseURL
+	return providerConfig(cfg, m)
+}
+
+// newRoutes builds a client for every purpose configured in queen.models,
+// and for every reviewer of review.reviewers. The agent purpose, and any
+// purpose whose client cannot be built, use the Queen's own client; a
+// reviewer whose client cannot be built uses the review model.
+func newRoutes(cfg *config.Config, logger *log.Logger) map[string]route {
+	routes := make(map[string]route, len(cfg.Queen.Models)+1)
+	agent := modelConfig(cfg, purposeAgent)
+	routes[purposeAgent] = route{provider: agent.Provider, model: agent.Model}
+
+	for purpose := range cfg.Queen.Models {
+		if !slices.Contains(purposes, purpose) {
+			logger.Printf("⚠ Warning: unknown model purpose %q in queen.models (want one of %v)", purpose, purposes)
+			continue
+		}
+		if purpose == purposeAgent {
+			continue
+		}
+		m := modelConfig(cfg, purpose)
+		c, err := llm.NewFromConfig(providerConfig(cfg, m))
+		if err != nil {
+			logger.Printf("⚠ Queen %s model %s/%s skipped: %v (using %s)", purpose, m.Provider, m.Model, err, agent.Model)
+			continue
+		}
+		routes[purpose] = route{client: c, provider: m.Provider, model: m.Model}
+	}
+
+	for i, m := range cfg.Review.Reviewers {
+		m = reviewerConfig(cfg, m)
+		c, err := llm.NewFromConfig(providerConfig(cfg, m))
+		if err != nil {
+			logger.Printf("⚠ Queen reviewer %s/%s skipped: %v (using the review model)", m.Provider, m.Model, err)
+			continue
+		}
+		routes[reviewerPurpose(i)] = route{client: c, provider: m.Provider, model: m.Model}
+	}
+	return routes
+}
+
+// reviewerPurpose is the route of the i-th reviewer of a review panel. Its
+// calls are accounted to the review purpose.
+func reviewerPurpose(i int) string {
+	return fmt.Sprintf("%s#%d", purposeReview, i+1)
+}
+
+// reviewerConfig returns a panel reviewer with unset fields taken from the
+// review model. A reviewer on another provider only inherits its generation
+// settings.
+func reviewerConfig(cfg *config.Config, m config.ModelConfig) config.ModelConfig {
+	review := modelConfig(cfg, purposeReview)
+	if m.MaxTokens == 0 {
+		m.MaxTokens = review.MaxTokens
+	}
+	if m.Temperature == nil {
+		m.Temperature = review.Temperature
+	}
+	if m.ThinkingBudget == 0 && m.ReasoningEffort == "" {
+		m.ThinkingBudget, m.ReasoningEffort = review.ThinkingBudget, review.ReasoningEffort
+	}
+	if m.Provider != "" && m.Provider != review.Provider {
+		return m
+	}
+	m.Provider = review.Provider
+	if m.Model == "" {
+		m.Model = review.Model
+	}
+	if m.APIKey == "" {
+		m.APIKey = review.APIKey
+	}
+	if m.BaseURL == "" {
+		m.BaseURL = review.BaseURL
+	}
+	return m
+}
+
+// route returns where calls for purpose go. A reviewer without a route of
+// its own goes where review calls go.
+func (q *Queen) route(purpose string) route {
+	r, ok := q.routes[purpose]
+	if base, _, found := strings.Cut(purpose, "#"); !ok && found {
+		r, ok = q.routes[base]
+	}
+	if !ok {
+		r = q.routes[purposeAgent]
+	}
+	if r.client == nil {
+		r.client = q.llm
+	}
+	if r.provider == "" {
+		r.provider, r.model = q.cfg.Queen.Provider, q.cfg.Queen.Model
+	}
+	return r
+}
+
+// chat sends a single-message call for purpose and accounts its cost to
+// that purpose when the client reports usage.
+func (q *Queen) chat(ctx context.Context, purpose, systemPrompt, userMessage string) (string, error) {
+	r := q.route(purpose)
+	if r.client == nil {
+		return "", fmt.Errorf("no LLM configured for %s", purpose)
+	}
+	uc, ok := r.client.(llm.UsageClient)
+	if !ok {
+		return r.client.Chat(ctx, systemPrompt, userMessage)
+	}
+	resp, err := uc.ChatWithUsage(ctx, systemPrompt, userMessage)
+	if err != nil {
+		return "", err
+	}
+	q.recordCallCost(ctx, purpose, 0, resp)
+	return resp.Text(), nil
+}
+
+// callTool sends a single-message call for purpose that must call tool,
+// and accounts its cost to that purpose.
+func (q *Queen) callTool(ctx context.Context, purpose string, tc llm.ToolClient, systemPrompt, userMessage string, tool llm.ToolDef) (*llm.Response, error) {
+	messages := []llm.ToolMessage{{Role: "user", Content: []llm.ContentBlock{{Type: "text", Text: userMessage}}}}
+	resp, err := tc.ChatWithTools(llm.WithToolChoice(ctx, tool.Name), systemPrompt, messages, []llm.ToolDef{tool})
+	if err != nil {
+		return nil, err
+	}
+	q.recordCallCost(ctx, purpose, 0, resp)
+	return resp, nil
+}
+
+// costPurpose is the purpose a call is accounted to: review for the
+// reviewers of a panel.
+func costPurpose(purpose string) string {
+	base, _, _ := strings.Cut(purpose, "#")
+	return base
+}
+
+// recordCallCost prices one Queen LLM call and adds it to the session's
+// spend under purpose. turn is the agent turn, or 0 outside the agent loop.
+func (q *Queen) recordCallCost(ctx context.Context, purpose string, turn int, resp *llm.Response) {
+	used := q.turnUsage(purpose, resp)
+	u := used.Usage
+	q.addCost(ctx, state.CostRow{
+		SessionID:        q.sessionID,
+		Turn:             turn,
+		Source:           state.CostSourceQueen,
+		Purpose:          costPurpose(purpose),
+		Model:            used.Model,
+		InputTokens:      u.InputTokens,
+		OutputTokens:     u.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens,
+		CacheWriteTokens: u.CacheCreationTokens,
+		CostUSD:          q.prices().Cost(used.Provider, used.Model, u),
+	})
+}
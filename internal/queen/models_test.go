@@ -0,0 +1,147 @@
+package queen
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// usageLLM answers plain chat calls with a fixed reply and usage.
+type usageLLM struct {
+	mockPlanLLM
+	usage llm.Usage
+	calls int
+}
+
+func (m *usageLLM) ChatWithUsage(ctx context.Context, systemPrompt, userMessage string) (*llm.Response, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &llm.Response{Content: []llm.ContentBlock{{Type: "text", Text: m.response}}, Usage: m.usage}, nil
+}
+
+func TestModelConfig(t *testing.T) {
+	temp := 0.1
+	cfg := &config.Config{Queen: config.QueenConfig{
+		Provider: "anthropic", Model: "claude-sonnet-4-20250514", APIKey: "queen-key",
+		Models: map[string]config.ModelConfig{
+			"review":    {Model: "claude-haiku-4-5", MaxTokens: 1024, Temperature: &temp},
+			"summarize": {Provider: "openai", Model: "gpt-4o-mini"},
+		},
+	}}
+
+	review := modelConfig(cfg, purposeReview)
+	if review.Provider != "anthropic" || review.Model != "claude-haiku-4-5" || review.APIKey != "queen-key" || review.MaxTokens != 1024 {
+		t.Errorf("review = %+v, want the Queen's provider and key with its own model", review)
+	}
+	if s := modelConfig(cfg, purposeSummarize); s.Provider != "openai" || s.APIKey != "" {
+		t.Errorf("summarize = %+v, want no credentials from another provider", s)
+	}
+	if r := modelConfig(cfg, purposeReplan); r.Provider != "anthropic" || r.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("replan = %+v, want the Queen's model", r)
+	}
+}
+
+func TestProviderConfig_Reasoning(t *testing.T) {
+	cfg := &config.Config{
+		Queen: config.QueenConfig{
+			Provider: "anthropic", Model: "claude-sonnet-4-20250514",
+			Models: map[string]config.ModelConfig{"review": {ThinkingBudget: 2048, MaxTokens: 4096}},
+		},
+		Review: config.ReviewConfig{Reviewers: []config.ModelConfig{
+			{Model: "claude-haiku-4-5"},
+			{Provider: "openai", Model: "o4-mini", ReasoningEffort: "low"},
+		}},
+	}
+	if o := providerConfig(cfg, modelConfig(cfg, purposeReview)).Options; o.ThinkingBudget != 2048 || o.MaxTokens != 4096 {
+		t.Errorf("review options = %+v", o)
+	}
+	if r := reviewerConfig(cfg, cfg.Review.Reviewers[0]); r.ThinkingBudget != 2048 || r.ReasoningEffort != "" {
+		t.Errorf("reviewer = %+v, want the review model's thinking budget", r)
+	}
+	if r := reviewerConfig(cfg, cfg.Review.Reviewers[1]); r.ThinkingBudget != 0 || r.ReasoningEffort != "low" {
+		t.Errorf("reviewer = %+v, want its own reasoning effort only", r)
+	}
+}
+
+func TestNewRoutes(t *testing.T) {
+	cfg := &config.Config{Queen: config.QueenConfig{
+		Provider: "anthropic", Model: "claude-sonnet-4-20250514",
+		Models: map[string]config.ModelConfig{
+			"review":  {Model: "claude-haiku-4-5"},
+			"replan":  {Provider: "nope"},
+			"banking": {Model: "x"},
+		},
+	}}
+	routes := newRoutes(cfg, log.New(io.Discard, "", 0))
+	if r := routes[purposeReview]; r.client == nil || r.model != "claude-haiku-4-5" {
+		t.Errorf("review route = %+v", r)
+	}
+	if _, ok := routes[purposeReplan]; ok {
+		t.Error("expected a route that cannot be built to fall back to the Queen's client")
+	}
+	if _, ok := routes["banking"]; ok {
+		t.Error("expected unknown purposes to be ignored")
+	}
+	if r := routes[purposeAgent]; r.client != nil || r.model != "claude-sonnet-4-20250514" {
+		t.Errorf("agent route = %+v", r)
+	}
+}
+
+func TestChat_RoutesAndAccountsByPurpose(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Queen.Provider = "anthropic"
+	q.cfg.Queen.Model = "claude-sonnet-4-20250514"
+	ctx := context.Background()
+
+	agent := &mockPlanLLM{response: "[]"}
+	review := &usageLLM{
+		mockPlanLLM: mockPlanLLM{response: `{"approved": true, "reason": "looks good"}`},
+		usage:       llm.Usage{InputTokens: 1_000_000, OutputTokens: 100_000},
+	}
+	q.llm = agent
+	q.routes = map[string]route{
+		purposeReview: {client: review, provider: "anthropic", model: "claude-haiku-4-5"},
+	}
+
+	tk := &task.Task{ID: "t1", Title: "One", Type: task.TypeCode, Status: task.StatusComplete}
+	verdict, err := q.reviewWithLLM(ctx, "t1", tk, &task.Result{Success: true, Output: "done"})
+	if err != nil || !verdict.Approved {
+		t.Fatalf("reviewWithLLM = %+v, %v", verdict, err)
+	}
+	if review.calls != 1 || agent.called {
+		t.Errorf("expected the review model to review, got review calls %d, agent called %v", review.calls, agent.called)
+	}
+
+	// Replan has no route of its own and goes to the Queen's client, which
+	// reports no usage.
+	if _, err := q.replanWithLLM(ctx); err != nil {
+		t.Fatalf("replanWithLLM: %v", err)
+	}
+	if !agent.called {
+		t.Error("expected replan to use the Queen's client")
+	}
+
+	costs, err := q.db.PurposeCosts(ctx, q.sessionID)
+	if err != nil {
+		t.Fatalf("PurposeCosts: %v", err)
+	}
+	want := state.CostTotals{CostUSD: 1.5, Tokens: 1_100_000} // Haiku 4.5: $1/M in, $5/M out
+	if len(costs) != 1 || costs[purposeReview] != want {
+		t.Errorf("purpose costs = %+v, want review %+v", costs, want)
+	}
+	if by := q.costByPurpose(ctx); by != "" {
+		t.Errorf("expected no breakdown with a single purpose, got %q", by)
+	}
+	q.recordTurnCost(ctx, 1, &llm.Response{Usage: llm.Usage{InputTokens: 100_000}})
+	if by := q.costByPurpose(ctx); by != "review $1.50, agent $0.30" {
+		t.Errorf("costByPurpose = %q", by)
+	}
+}
@@ -96,16 +96,18 @@ func orchTestQueen(t *testing.T) *Queen {
 func TestDelegateAssignsReadyTasks(t *testing.T) {
 	q := orchTestQueen(t)
 
-	// Add two independent tasks
+	// Add two independent tasks (disjoint path claims)
 	q.tasks.Add(&task.Task{
 		ID: "t1", Type: task.TypeGeneric, Status: task.StatusPending,
 		Title: "Task 1", Description: "echo hello",
-		Timeout: 10 * time.Second,
+		AllowedPaths: []string{"a"},
+		Timeout:      10 * time.Second,
 	})
 	q.tasks.Add(&task.Task{
 		ID: "t2", Type: task.TypeGeneric, Status: task.StatusPending,
 		Title: "Task 2", Description: "echo world",
-		Timeout: 10 * time.Second,
+		AllowedPaths: []string{"b"},
+		Timeout:      10 * time.Second,
 	})
 
 	if err := q.delegate(context.Background()); err != nil {
@@ -128,6 +130,39 @@ func TestDelegateAssignsReadyTasks(t *testing.T) {
 	}
 }
 
+func TestDelegateSerializesOverlappingClaims(t *testing.T) {
+	q := orchTestQueen(t)
+
+	// Unscoped write tasks claim the whole project; research tasks only read.
+	q.tasks.Add(&task.Task{
+		ID: "w1", Type: task.TypeCode, Status: task.StatusPending, Priority: task.PriorityHigh,
+		Title: "Write 1", Description: "echo one", Timeout: 10 * time.Second,
+	})
+	q.tasks.Add(&task.Task{
+		ID: "w2", Type: task.TypeCode, Status: task.StatusPending,
+		Title: "Write 2", Description: "echo two", Timeout: 10 * time.Second,
+	})
+
+	if err := q.delegate(context.Background()); err != nil {
+		t.Fatalf("delegate: %v", err)
+	}
+
+	w1, _ := q.tasks.Get("w1")
+	w2, _ := q.tasks.Get("w2")
+	if w1.GetStatus() != task.StatusRunning {
+		t.Errorf("w1 expected running, got %s", w1.GetStatus())
+	}
+	if w2.GetStatus() != task.StatusPending {
+		t.Errorf("w2 expected to be held pending, got %s", w2.GetStatus())
+	}
+	q.mu.RLock()
+	hold, held := q.holds["w2"]
+	q.mu.RUnlock()
+	if !held || hold.BlockedBy != "w1" || hold.Path != "." {
+		t.Errorf("expected w2 held by w1 on '.', got %+v (held=%v)", hold, held)
+	}
+}
+
 func TestDelegateRespectsMaxParallel(t *testing.T) {
 	q := orchTestQueen(t)
 	q.cfg.Workers.MaxParallel = 1
@@ -0,0 +1,154 @@
+package queen
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/state"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// reviewPanel has every reviewer in review.reviewers review the output and
+// combines their votes: review.quorum approvals (default a majority) accept
+// it. A reviewer that fails does not vote, and the review fails when the
+// missing votes could have changed the outcome. When review.on_disagreement
+// is "human", the operator decides split votes.
+func (q *Queen) reviewPanel(ctx context.Context, t *task.Task, rubric []string, userMessage string) (*ReviewVerdict, error) {
+	n := len(q.cfg.Review.Reviewers)
+	quorum := q.cfg.Review.Quorum
+	if quorum <= 0 {
+		quorum = n/2 + 1
+	}
+	quorum = min(quorum, n)
+
+	// One reviewer after another, so a recorded session replays in order.
+	var verdicts []*ReviewVerdict
+	var votes []ReviewVote
+	approvals, failed := 0, 0
+	for i := range n {
+		purpose := reviewerPurpose(i)
+		r := q.route(purpose)
+		name := r.provider + "/" + r.model
+		v, err := q.reviewBy(ctx, purpose, rubric, userMessage)
+		if err != nil {
+			q.logger.Printf("⚠ Warning: reviewer %s failed: %v", name, err)
+			failed++
+			continue
+		}
+		if v.Approved {
+			approvals++
+		}
+		verdicts = append(verdicts, v)
+		votes = append(votes, ReviewVote{Reviewer: name, Approved: v.Approved, Score: v.Score, Reason: v.Reason})
+	}
+	if approvals < quorum && approvals+failed >= quorum {
+		return nil, fmt.Errorf("review inconclusive: %d of %d reviewers approved and %d failed, %d approvals needed", approvals, n, failed, quorum)
+	}
+
+	approved := approvals >= quorum
+	disputed := approvals > 0 && approvals < len(votes)
+	summary := fmt.Sprintf("%d of %d reviewers approved (%d needed)", approvals, len(votes), quorum)
+	if disputed {
+		q.logger.Printf("⚖ Reviewers disagree on task %s: %s", t.ID, summary)
+		if q.cfg.Review.OnDisagreement == config.DisagreementHuman {
+			var details strings.Builder
+			for _, vote := range votes {
+				fmt.Fprintf(&details, "- %s\n", describeVote(vote))
+			}
+			qu := Question{
+				Kind:    QuestionReview,
+				Prompt:  fmt.Sprintf("Reviewers disagree on task %q (%s). Accept the output?", t.Title, summary),
+				Details: details.String(),
+				TaskID:  t.ID,
+			}
+			answer := q.ask(ctx, qu)
+			approved = answer.Approved
+			summary += "; the operator " + describeAnswer(qu, answer)
+		}
+	}
+
+	verdict := mergeVerdicts(verdicts, votes, approved)
+	verdict.Disputed = disputed
+	verdict.Reason = summary + ". " + verdict.Reason
+	q.recordReviewVotes(ctx, t.ID, verdict)
+	return verdict, nil
+}
+
+// mergeVerdicts combines a panel's verdicts into one with the given outcome.
+// The reason is that of the reviewers on the winning side, and so are the
+// required fixes of a rejection; other fixes become suggestions. Findings,
+// follow-up tasks and suggestions are pooled and the score is the mean.
+func mergeVerdicts(verdicts []*ReviewVerdict, votes []ReviewVote, approved bool) *ReviewVerdict {
+	m := &ReviewVerdict{Approved: approved, Votes: votes}
+	var reasons []string
+	total := 0
+	for i, v := range verdicts {
+		reviewer := votes[i].Reviewer
+		total += v.Score
+		for _, f := range v.Findings {
+			f.Detail = strings.TrimSuffix(reviewer+": "+f.Detail, ": ")
+			m.Findings = append(m.Findings, f)
+		}
+		if v.Approved == approved {
+			reasons = append(reasons, reviewer+": "+v.Reason)
+		}
+		if v.Approved == approved && !approved {
+			m.RequiredFixes = appendNew(m.RequiredFixes, v.RequiredFixes...)
+		} else {
+			m.Suggestions = appendNew(m.Suggestions, v.RequiredFixes...)
+		}
+		m.Suggestions = appendNew(m.Suggestions, v.Suggestions...)
+		for _, f := range v.FollowUps {
+			if !slices.ContainsFunc(m.FollowUps, func(o FollowUpTask) bool { return strings.EqualFold(o.Title, f.Title) }) {
+				m.FollowUps = append(m.FollowUps, f)
+			}
+		}
+	}
+	if len(verdicts) > 0 {
+		m.Score = (total + len(verdicts)/2) / len(verdicts)
+	}
+	m.Reason = strings.Join(reasons, " ")
+	return m
+}
+
+// appendNew appends the items not already in list.
+func appendNew(list []string, items ...string) []string {
+	for _, s := range items {
+		if !slices.Contains(list, s) {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// describeVote renders a reviewer's vote on one line.
+func describeVote(v ReviewVote) string {
+	verdict := "reject"
+	if v.Approved {
+		verdict = "approve"
+	}
+	return fmt.Sprintf("%s: %s (score %d/10): %s", v.Reviewer, verdict, v.Score, v.Reason)
+}
+
+// recordReviewVotes stores a panel's votes and outcome for the reviewer
+// agreement statistics.
+func (q *Queen) recordReviewVotes(ctx context.Context, taskID string, v *ReviewVerdict) {
+	rows := make([]state.ReviewVoteRow, len(v.Votes))
+	for i, vote := range v.Votes {
+		rows[i] = state.ReviewVoteRow{
+			SessionID: q.sessionID,
+			TaskID:    taskID,
+			Reviewer:  vote.Reviewer,
+			Approved:  vote.Approved,
+			Score:     vote.Score,
+			Outcome:   v.Approved,
+			Disputed:  v.Disputed,
+		}
+	}
+	if err := q.db.RecordReviewVotes(ctx, rows); err != nil {
+		q.logger.Printf("⚠ Warning: failed to record review votes: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package queen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/config"
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// panelQueen returns a Queen whose review panel has one reviewer per reply.
+func panelQueen(t *testing.T, replies ...llm.Client) *Queen {
+	t.Helper()
+	q, _ := testQueen(t)
+	q.cfg.Queen.Provider = "anthropic"
+	q.cfg.Queen.Model = "claude-sonnet-4-20250514"
+	q.llm = &mockPlanLLM{response: `{"approved": true, "reason": "agent model"}`}
+	q.routes = map[string]route{}
+	for i, r := range replies {
+		model := []string{"haiku", "mini", "flash"}[i]
+		q.cfg.Review.Reviewers = append(q.cfg.Review.Reviewers, config.ModelConfig{Model: model})
+		q.routes[reviewerPurpose(i)] = route{client: r, provider: "anthropic", model: model}
+	}
+	return q
+}
+
+func panelTask(q *Queen) *task.Task {
+	tk := &task.Task{ID: "t1", Title: "Write the handler", Type: task.TypeCode, Status: task.StatusComplete,
+		Result: &task.Result{Success: true, Output: "done"}}
+	q.tasks.Add(tk)
+	return tk
+}
+
+const (
+	approveReply = `{"approved": true, "score": 8, "reason": "Works", "suggestions": ["Add a comment"],
+		"follow_up_tasks": [{"title": "Add handler tests", "type": "test"}]}`
+	rejectReply = `{"approved": false, "score": 3, "reason": "Errors are dropped", "required_fixes": ["Return the write error"],
+		"findings": [{"criterion": "correctness", "passed": false, "detail": "write errors dropped"}],
+		"follow_up_tasks": [{"title": "add handler tests"}]}`
+)
+
+func TestReviewPanel_Quorum(t *testing.T) {
+	ctx := context.Background()
+	q := panelQueen(t, &mockPlanLLM{response: approveReply}, &mockPlanLLM{response: rejectReply}, &mockPlanLLM{response: approveReply})
+	tk := panelTask(q)
+
+	verdict, err := q.reviewWithLLM(ctx, "t1", tk, tk.Result)
+	if err != nil {
+		t.Fatalf("reviewWithLLM: %v", err)
+	}
+	if !verdict.Approved || !verdict.Disputed || len(verdict.Votes) != 3 || verdict.Score != 6 {
+		t.Errorf("expected a disputed approval by 2 of 3 with mean score 6, got %+v", verdict)
+	}
+	if !strings.HasPrefix(verdict.Reason, "2 of 3 reviewers approved (2 needed). anthropic/haiku: Works") {
+		t.Errorf("unexpected reason: %q", verdict.Reason)
+	}
+	if len(verdict.RequiredFixes) != 0 || strings.Join(verdict.Suggestions, "|") != "Add a comment|Return the write error" {
+		t.Errorf("expected the dissenter's fixes as suggestions, got fixes %v, suggestions %v", verdict.RequiredFixes, verdict.Suggestions)
+	}
+	if len(verdict.FollowUps) != 1 || len(verdict.Findings) != 1 || verdict.Findings[0].Detail != "anthropic/mini: write errors dropped" {
+		t.Errorf("unexpected pooled follow-ups %+v and findings %+v", verdict.FollowUps, verdict.Findings)
+	}
+
+	stats, err := q.db.ReviewerStats(ctx)
+	if err != nil || len(stats) != 3 {
+		t.Fatalf("ReviewerStats = %+v, %v", stats, err)
+	}
+	for _, s := range stats {
+		if want := s.Reviewer != "anthropic/mini"; (s.Agreed == 1) != want || s.Disputed != 1 {
+			t.Errorf("unexpected stats: %+v", s)
+		}
+	}
+
+	// A unanimous quorum rejects the same votes.
+	q = panelQueen(t, &mockPlanLLM{response: approveReply}, &mockPlanLLM{response: rejectReply}, &mockPlanLLM{response: approveReply})
+	q.cfg.Review.Quorum = 3
+	tk = panelTask(q)
+	verdict, err = q.reviewWithLLM(ctx, "t1", tk, tk.Result)
+	if err != nil {
+		t.Fatalf("reviewWithLLM: %v", err)
+	}
+	if verdict.Approved || strings.Join(verdict.RequiredFixes, "|") != "Return the write error" {
+		t.Errorf("expected a rejection with the rejecting reviewer's fixes, got %+v", verdict)
+	}
+	if !strings.Contains(verdict.Feedback(), "Required fixes: Return the write error") {
+		t.Errorf("unexpected feedback: %q", verdict.Feedback())
+	}
+}
+
+func TestReviewPanel_FailedReviewers(t *testing.T) {
+	ctx := context.Background()
+	down := &mockPlanLLM{err: errors.New("overloaded")}
+
+	// The missing vote could have tipped the review either way.
+	q := panelQueen(t, &mockPlanLLM{response: approveReply}, &mockPlanLLM{response: rejectReply}, down)
+	tk := panelTask(q)
+	if _, err := q.reviewWithLLM(ctx, "t1", tk, tk.Result); err == nil || !strings.Contains(err.Error(), "inconclusive") {
+		t.Errorf("expected an inconclusive review, got %v", err)
+	}
+
+	// Two rejections decide it without the third vote.
+	q = panelQueen(t, &mockPlanLLM{response: rejectReply}, &mockPlanLLM{response: rejectReply}, down)
+	tk = panelTask(q)
+	verdict, err := q.reviewWithLLM(ctx, "t1", tk, tk.Result)
+	if err != nil || verdict.Approved || verdict.Disputed || len(verdict.Votes) != 2 {
+		t.Errorf("expected an undisputed rejection by the two reviewers that answered, got %+v, %v", verdict, err)
+	}
+}
+
+func TestReviewPanel_HumanDecidesDisagreement(t *testing.T) {
+	q := panelQueen(t, &mockPlanLLM{response: approveReply}, &mockPlanLLM{response: rejectReply}, &mockPlanLLM{response: approveReply})
+	q.cfg.Review.OnDisagreement = config.DisagreementHuman
+	asker := &scriptedAsker{answers: []Answer{{Approved: false, Text: "the error handling matters"}}}
+	q.SetAsker(asker)
+	tk := panelTask(q)
+
+	verdict, err := q.reviewWithLLM(context.Background(), "t1", tk, tk.Result)
+	if err != nil {
+		t.Fatalf("reviewWithLLM: %v", err)
+	}
+	if verdict.Approved || !strings.Contains(verdict.Reason, "the operator rejected (the error handling matters)") {
+		t.Errorf("expected the operator's rejection, got %+v", verdict)
+	}
+	if len(asker.questions) != 1 || asker.questions[0].Kind != QuestionReview || !strings.Contains(asker.questions[0].Details, "anthropic/mini: reject (score 3/10)") {
+		t.Errorf("unexpected questions: %+v", asker.questions)
+	}
+	if strings.Join(verdict.RequiredFixes, "|") != "Return the write error" {
+		t.Errorf("expected the rejecting reviewer's fixes, got %v", verdict.RequiredFixes)
+	}
+}
+
+func TestReviewPanel_CostsAndRoutes(t *testing.T) {
+	ctx := context.Background()
+	reviewer := &usageLLM{mockPlanLLM: mockPlanLLM{response: approveReply}, usage: llm.Usage{InputTokens: 1_000_000}}
+	q := panelQueen(t, &mockPlanLLM{response: approveReply}, reviewer)
+	tk := panelTask(q)
+	if _, err := q.reviewWithLLM(ctx, "t1", tk, tk.Result); err != nil {
+		t.Fatalf("reviewWithLLM: %v", err)
+	}
+	costs, err := q.db.PurposeCosts(ctx, q.sessionID)
+	if err != nil || len(costs) != 1 || costs[purposeReview].Tokens != 1_000_000 {
+		t.Errorf("expected panel calls accounted to review, got %+v, %v", costs, err)
+	}
+
+	// A reviewer without a route of its own goes where review calls go.
+	q.routes = map[string]route{purposeReview: {client: reviewer, provider: "openai", model: "gpt-4o-mini"}}
+	if r := q.route(reviewerPurpose(1)); r.client != reviewer || r.model != "gpt-4o-mini" {
+		t.Errorf("route = %+v, want the review route", r)
+	}
+
+	temp := 0.2
+	cfg := &config.Config{
+		Queen: config.QueenConfig{Provider: "anthropic", Model: "claude-sonnet-4-20250514", APIKey: "key",
+			Models: map[string]config.ModelConfig{"review": {Model: "claude-haiku-4-5", Temperature: &temp}}},
+		Review: config.ReviewConfig{Reviewers: []config.ModelConfig{{}, {Provider: "openai", Model: "gpt-4o-mini"}, {Provider: "nope"}}},
+	}
+	if m := reviewerConfig(cfg, cfg.Review.Reviewers[0]); m.Model != "claude-haiku-4-5" || m.APIKey != "key" || m.Temperature != &temp {
+		t.Errorf("reviewer 1 = %+v, want the review model", m)
+	}
+	if m := reviewerConfig(cfg, cfg.Review.Reviewers[1]); m.APIKey != "" || m.Temperature != &temp {
+		t.Errorf("reviewer 2 = %+v, want generation settings only", m)
+	}
+	routes := newRoutes(cfg, log.New(io.Discard, "", 0))
+	if r := routes[reviewerPurpose(1)]; r.client == nil || r.model != "gpt-4o-mini" {
+		t.Errorf("reviewer route = %+v", r)
+	}
+	if _, ok := routes[reviewerPurpose(2)]; ok {
+		t.Error("expected a reviewer that cannot be built to have no route")
+	}
+}
+
+func TestRubric(t *testing.T) {
+	q, _ := testQueen(t)
+	q.cfg.Review.Rubrics = map[string][]string{
+		"code":     {"ignored: the file wins"},
+		"research": {"Cites the files it read"},
+		"default":  {"Answers the task"},
+	}
+	dir := q.cfg.HivePath("rubrics")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rubric := "# Code rubric\n\n- Compiles\n* Has tests\n3. Follows the constraints\n"
+	if err := os.WriteFile(filepath.Join(dir, "code.md"), []byte(rubric), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(q.rubric(task.TypeCode), "|"); got != "Compiles|Has tests|Follows the constraints" {
+		t.Errorf("code rubric = %q", got)
+	}
+	if got := q.rubric(task.TypeResearch); len(got) != 1 || got[0] != "Cites the files it read" {
+		t.Errorf("research rubric = %q", got)
+	}
+	if got := q.rubric(task.TypeTest); len(got) != 1 || got[0] != "Answers the task" {
+		t.Errorf("test rubric = %q", got)
+	}
+
+	prompt := reviewSystemPrompt(true, q.rubric(task.TypeCode))
+	if !strings.Contains(prompt, "1. Compiles\n2. Has tests\n3. Follows the constraints") || strings.Contains(prompt, "SCOPE") {
+		t.Errorf("expected the rubric in place of the built-in axes:\n%s", prompt)
+	}
+	if prompt := reviewSystemPrompt(false, nil); !strings.Contains(prompt, "SCOPE") || !strings.Contains(prompt, `"required_fixes"`) {
+		t.Errorf("expected the built-in axes and JSON schema:\n%s", prompt)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -77,7 +78,7 @@ func (q *Queen) planWithLLM(ctx context.Context) error {
 	const systemPrompt = "You are a task planning agent. Output ONLY a JSON array of tasks."
 	planPrompt := q.buildPlanPrompt()
 
-	response, err := q.llm.Chat(ctx, systemPrompt, planPrompt)
+	response, err := q.chat(ctx, purposeAgent, systemPrompt, planPrompt)
 	if err != nil {
 		// Fall back to worker-based planning if LLM call fails
 		q.logVerbose("  ⚠ Queen LLM planning failed: %v — falling back to worker", err)
@@ -186,6 +187,15 @@ func (q *Queen) buildPlanPrompt() string {
 		b.WriteString(summary)
 	}
 
+	// Operator messages sent with `waggle say` or from the TUI
+	if notes := q.board.ReadByTag("operator"); len(notes) > 0 {
+		sort.Slice(notes, func(i, j int) bool { return notes[i].Timestamp.Before(notes[j].Timestamp) })
+		b.WriteString("\nOPERATOR INSTRUCTIONS (follow these):\n")
+		for _, e := range notes {
+			b.WriteString(fmt.Sprintf("- %v\n", e.Value))
+		}
+	}
+
 	return b.String()
 }
 
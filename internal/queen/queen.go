@@ -58,8 +58,19 @@ type Queen struct {
 	logger    *log.Logger
 	lastErr   error
 
-	llm   llm.Client    // LLM client for AI-backed review/replan (nil = disabled)
-	guard *safety.Guard // shared safety guard for tool calls
+	llm    llm.Client       // LLM client for AI-backed review/replan (nil = disabled)
+	routes map[string]route // purpose -> model of the Queen's other LLM calls
+	guard  *safety.Guard    // shared safety guard for tool calls
+
+	recorder *llm.RecordingClient // cassette being recorded (nil = not recording)
+
+	pricing *llm.Pricing // model prices (built from cfg on first use)
+	spend   spending     // session cost so far, for budgets
+
+	asker     Asker // routes approval gates and ask_user to the operator
+	questions int   // questions asked so far, for question IDs
+
+	msgSeq int // sequence ID of the first live message in the messages table
 
 	printer *output.Printer // styled output (nil-safe: falls back to logger)
 
@@ -67,9 +78,19 @@ type Queen struct {
 	quiet          bool // Quiet mode: only print completions/failures
 	suppressBanner bool // JSON mode: don't print header banner
 
+	// Nested Queens (objective tasks)
+	parent     *Queen // non-nil for a child Queen working on a sub-objective
+	parentTask string // parent task the child Queen is working on
+	depth      int    // nesting level (0 = top-level Queen)
+	stream     string // cassette stream of the Queen's LLM calls: parent task IDs, "/"-joined
+	summary    string // summary passed to complete/fail
+
 	// For tracking worker->task assignments
-	assignments  map[string]string // workerID -> taskID
-	pendingTasks []*task.Task      // pre-defined tasks (skip AI planning)
+	assignments  map[string]string       // workerID -> taskID
+	attempts     map[string]openAttempt  // workerID -> attempt in progress
+	cacheKeys    map[string]pendingCache // workerID -> result cache key
+	holds        map[string]TaskHold     // taskID -> path claim holding it back
+	pendingTasks []*task.Task            // pre-defined tasks (skip AI planning)
 }
 
 // newSessionID generates a short random session ID (8 chars, base32, lowercase).
@@ -112,6 +133,10 @@ func (q *Queen) ResumeSession(ctx context.Context, sessionID string) (string, er
 	if err := q.db.ResetRunningTasks(ctx, sessionID); err != nil {
 		q.logger.Printf("⚠ Warning: failed to reset running tasks: %v", err)
 	}
+	if err := q.db.InterruptRunningAttempts(ctx, sessionID); err != nil {
+		q.logger.Printf("⚠ Warning: failed to close interrupted attempts: %v", err)
+	}
+	q.loadSpend(ctx)
 
 	// Load all tasks from the session
 	taskRows, err := q.db.GetTasks(ctx, sessionID)
@@ -160,6 +185,7 @@ func (q *Queen) ResumeSession(ctx context.Context, sessionID string) (string, er
 func taskToRow(t *task.Task) state.TaskRow {
 	row := state.TaskRow{
 		ID:          t.ID,
+		ParentID:    t.ParentID,
 		Type:        string(t.Type),
 		Status:      string(t.Status),
 		Priority:    int(t.Priority),
@@ -167,6 +193,7 @@ func taskToRow(t *task.Task) state.TaskRow {
 		Description: t.GetDescription(),
 		MaxRetries:  t.MaxRetries,
 		DependsOn:   strings.Join(t.DependsOn, ","),
+		TimeoutNs:   int64(t.Timeout),
 	}
 	if c := t.GetConstraints(); len(c) > 0 {
 		data, _ := json.Marshal(c)
@@ -180,6 +207,14 @@ func taskToRow(t *task.Task) state.TaskRow {
 		data, _ := json.Marshal(t.Context)
 		row.Context = string(data)
 	}
+	row.SkipDepResults = t.SkipDepResults
+	row.DepResults = t.GetDepResults()
+	if t.Cache != nil {
+		row.CachePolicy = "off"
+		if *t.Cache {
+			row.CachePolicy = "on"
+		}
+	}
 	return row
 }
 
@@ -193,6 +228,7 @@ func taskFromRow(tr *state.TaskRow) *task.Task {
 
 	t := &task.Task{
 		ID:          tr.ID,
+		ParentID:    tr.ParentID,
 		Type:        task.Type(tr.Type),
 		Status:      task.Status(tr.Status),
 		Priority:    task.Priority(tr.Priority),
@@ -201,11 +237,19 @@ func taskFromRow(tr *state.TaskRow) *task.Task {
 		MaxRetries:  tr.MaxRetries,
 		RetryCount:  tr.RetryCount,
 		DependsOn:   dependsOn,
+		Timeout:     time.Duration(tr.TimeoutNs),
+
+		SkipDepResults: tr.SkipDepResults,
+		DepResults:     tr.DepResults,
 	}
 
 	if tr.WorkerID != nil {
 		t.WorkerID = *tr.WorkerID
 	}
+	if tr.CachePolicy != "" {
+		enabled := tr.CachePolicy == "on"
+		t.Cache = &enabled
+	}
 
 	// Parse timestamps if present
 	if tr.CreatedAt != "" {
@@ -352,22 +396,30 @@ func New(cfg *config.Config, logger *log.Logger) (*Queen, error) {
 	// Context management
 	ctxMgr := compact.NewContext(200000) // ~200k tokens
 
-	// Initialize LLM client for Queen's own reasoning (review, replan)
-	var llmClient llm.Client
-	if cfg.Queen.Provider != "" {
-		var err error
-		llmClient, err = llm.NewFromConfig(llm.ProviderConfig{
-			Provider: cfg.Queen.Provider,
-			Model:    cfg.Queen.Model,
-			APIKey:   cfg.Queen.APIKey,
-			BaseURL:  cfg.Queen.BaseURL,
-			WorkDir:  cfg.ProjectDir,
-		})
+	// Initialize LLM client for Queen's own reasoning (review, replan),
+	// answered from a cassette when replaying
+	llmClient, err := replayLLM(cfg, logger)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	var routes map[string]route
+	if llmClient == nil && cfg.Queen.Provider != "" {
+		llmClient, err = newQueenLLM(cfg, logger)
 		if err != nil {
 			logger.Printf("⚠ Queen LLM init failed: %v (review/replan disabled)", err)
 			llmClient = nil
 		} else {
 			logger.Printf("✓ Queen LLM enabled (%s) for review/replan", cfg.Queen.Provider)
+			routes = newRoutes(cfg, logger)
+		}
+	}
+	var recorder *llm.RecordingClient
+	if cfg.Cassette.Record != "" && llmClient != nil {
+		llmClient, recorder, err = recordLLM(cfg, logger, llmClient, routes)
+		if err != nil {
+			db.Close()
+			return nil, err
 		}
 	}
 
@@ -382,23 +434,53 @@ func New(cfg *config.Config, logger *log.Logger) (*Queen, error) {
 		registry:    registry,
 		ctx:         ctxMgr,
 		llm:         llmClient,
+		routes:      routes,
+		recorder:    recorder,
 		guard:       guard,
 		phase:       PhasePlan,
 		logger:      logger,
 		assignments: make(map[string]string),
 	}
 
-	// Wire up event logging to SQLite
-	msgBus.SubscribeAll(func(msg bus.Message) {
+	q.logEvents()
+
+	return q, nil
+}
+
+// newQueenLLM builds the Queen's LLM client: the agent model, wrapped in a
+// FailoverClient when fallbacks are configured. Fallbacks that cannot be
+// built are skipped.
+func newQueenLLM(cfg *config.Config, logger *log.Logger) (llm.Client, error) {
+	agent := modelConfig(cfg, purposeAgent)
+	primary, err := llm.NewFromConfig(providerConfig(cfg, agent))
+	if err != nil || len(cfg.Queen.Fallbacks) == 0 {
+		return primary, err
+	}
+
+	backends := []llm.Backend{{Provider: agent.Provider, Model: agent.Model, Client: primary}}
+	for _, fb := range cfg.Queen.Fallbacks {
+		c, err := llm.NewFromConfig(fallbackConfig(cfg, agent, fb))
+		if err != nil {
+			logger.Printf("⚠ Queen LLM fallback %s/%s skipped: %v", fb.Provider, fb.Model, err)
+			continue
+		}
+		backends = append(backends, llm.Backend{Provider: fb.Provider, Model: fb.Model, Client: c})
+	}
+	return llm.NewFailoverClient(backends, cfg.Queen.FailbackAfter, logger), nil
+}
+
+// logEvents wires up event logging from the Queen's bus to SQLite.
+func (q *Queen) logEvents() {
+	q.bus.SubscribeAll(func(msg bus.Message) {
+		if bus.IsTransient(msg.Type) {
+			return
+		}
 		if sid := q.sessionID; sid != "" {
-			_, err = q.db.AppendEvent(context.Background(), sid, string(msg.Type), msg)
-			if err != nil {
+			if _, err := q.db.AppendEvent(context.Background(), sid, string(msg.Type), msg); err != nil {
 				q.logger.Printf("⚠ Warning: failed to append event %s: %v", msg.Type, err)
 			}
 		}
 	})
-
-	return q, nil
 }
 
 // setupAdapters verifies adapter availability, routes all task types to the
@@ -454,9 +536,12 @@ func (q *Queen) Run(ctx context.Context, objective string) error {
 	q.Printer().Header("Waggle \u2014 Legacy Mode")
 	q.Printer().Info("Objective: %s", objective)
 
-	// Preflight: verify and configure adapters
-	if err := q.setupAdapters(ctx); err != nil {
-		return err
+	// Preflight: verify and configure adapters (a child Queen shares its
+	// parent's, already configured)
+	if q.parent == nil {
+		if err := q.setupAdapters(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Create DB session
@@ -464,6 +549,7 @@ func (q *Queen) Run(ctx context.Context, objective string) error {
 	if err := q.db.CreateSession(ctx, q.sessionID, objective); err != nil {
 		q.logger.Printf("⚠ DB: failed to create session: %v", err)
 	}
+	q.linkParent(ctx)
 
 	for i := 0; i < q.cfg.Queen.MaxIterations; i++ {
 		q.setIteration(i)
@@ -475,6 +561,19 @@ func (q *Queen) Run(ctx context.Context, objective string) error {
 		default:
 		}
 
+		// Apply operator edits (CLI/TUI) queued since the last iteration
+		q.applyLegacyControls(ctx)
+
+		// Enforce spending limits: there is no Queen to wrap up, so stop
+		if over := q.overBudget(); over != "" {
+			q.Printer().Error("Budget exceeded (%s), stopping", over)
+			q.pool.KillAll()
+			if err := q.db.UpdateSessionStatus(ctx, q.sessionID, "failed"); err != nil {
+				q.logger.Printf("⚠ Warning: failed to update session status: %v", err)
+			}
+			return fmt.Errorf("budget exceeded: %s", over)
+		}
+
 		q.mu.RLock()
 		curPhase := q.phase
 		curIter := q.iteration
@@ -600,6 +699,8 @@ func (q *Queen) review(ctx context.Context) (bool, error) {
 				if err != nil {
 					q.logger.Printf("⚠ Warning: failed to update task result %s: %v", taskID, err)
 				}
+				q.finishAttempt(ctx, workerID, AttemptSucceeded, "", result)
+				q.storeCachedResult(ctx, workerID, t, result)
 
 				// Post result to blackboard
 				bbKey := fmt.Sprintf("result-%s", taskID)
@@ -607,17 +708,13 @@ func (q *Queen) review(ctx context.Context) (bool, error) {
 				if t != nil {
 					tags = append(tags, string(t.Type))
 				}
-				q.board.Post(&blackboard.Entry{
+				q.postBoard(ctx, &blackboard.Entry{
 					Key:      bbKey,
 					Value:    result.Output,
 					PostedBy: workerID,
 					TaskID:   taskID,
 					Tags:     tags,
 				})
-				err = q.db.PostBlackboard(ctx, q.sessionID, bbKey, result.Output, workerID, taskID, strings.Join(tags, ","))
-				if err != nil {
-					q.logger.Printf("⚠ Warning: failed to post blackboard entry %s: %v", bbKey, err)
-				}
 
 				q.Printer().Success("Task %s completed by %s", taskID, workerID)
 
@@ -628,19 +725,19 @@ func (q *Queen) review(ctx context.Context) (bool, error) {
 						q.Printer().Warning("LLM review failed: %v (accepting result)", err)
 					} else if !verdict.Approved {
 						q.Printer().Warning("LLM rejected task %s: %s", taskID, verdict.Reason)
-						if len(verdict.Suggestions) > 0 {
-							for _, s := range verdict.Suggestions {
-								q.Printer().Debug("  %s", s)
-							}
+						for _, s := range verdict.RequiredFixes {
+							q.Printer().Debug("  fix: %s", s)
+						}
+						for _, s := range verdict.Suggestions {
+							q.Printer().Debug("  %s", s)
 						}
 						// Re-queue with suggestions appended to description
 						if t.GetRetryCount() < t.MaxRetries {
 							newCount := t.IncrRetryCount()
-							rejectionMsg := "\n\nPREVIOUS ATTEMPT REJECTED: " + verdict.Reason
-							if len(verdict.Suggestions) > 0 {
-								rejectionMsg += "\nSuggestions: " + strings.Join(verdict.Suggestions, "; ")
-							}
-							t.AppendDescription(rejectionMsg)
+							feedback := verdict.Feedback()
+							t.AppendDescription("\n\nPREVIOUS ATTEMPT REJECTED: " + feedback)
+							q.recordAttemptFeedback(ctx, taskID, feedback)
+							q.invalidateCache(ctx, taskID)
 							_ = newCount // used for logging below
 							if err := q.tasks.UpdateStatus(taskID, task.StatusPending); err != nil {
 								q.logger.Printf("⚠ Warning: failed to update task status: %v", err)
@@ -717,7 +814,7 @@ func (q *Queen) review(ctx context.Context) (bool, error) {
 	}
 
 	// Check for deadlock (no tasks running, none ready, not all complete)
-	if q.pool.ActiveCount() == 0 && len(ready) == 0 && !q.tasks.AllComplete() {
+	if len(q.pool.Active()) == 0 && len(ready) == 0 && !q.tasks.AllComplete() {
 		failed := q.tasks.Failed()
 		if len(failed) > 0 {
 			q.Printer().Error("%d tasks failed with no recovery possible", len(failed))
@@ -733,6 +830,9 @@ func (q *Queen) review(ctx context.Context) (bool, error) {
 // injectDefaultConstraints adds scope-limiting constraints to a task.
 // Used by both delegate() and agent-mode handleAssignTask().
 func injectDefaultConstraints(t *task.Task) {
+	if t.Type == task.TypeObjective {
+		return // the child Queen scopes its own tasks
+	}
 	existing := t.GetConstraints()
 	t.SetConstraints(appendUnique(existing,
 		"Do NOT make changes outside the scope described in this task",
@@ -766,6 +866,13 @@ func (q *Queen) setObjective(obj string) {
 	q.mu.Unlock()
 }
 
+// setSummary records the complete/fail summary under the write lock.
+func (q *Queen) setSummary(summary string) {
+	q.mu.Lock()
+	q.summary = summary
+	q.mu.Unlock()
+}
+
 // setIteration sets q.iteration under the write lock.
 func (q *Queen) setIteration(i int) {
 	q.mu.Lock()
@@ -801,6 +908,14 @@ func (q *Queen) Close() error {
 				}
 			}
 		}
+		if q.recorder != nil {
+			if err := q.recorder.Close(); err != nil {
+				q.logger.Printf("⚠ Warning: failed to close cassette: %v", err)
+			}
+		}
+		if rc, ok := q.llm.(*llm.ReplayClient); ok && rc.Remaining() > 0 {
+			q.logger.Printf("⚠ Warning: replay ended with %d recorded calls unused", rc.Remaining())
+		}
 		closeErr = q.db.Close()
 	})
 	return closeErr
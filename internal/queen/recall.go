@@ -0,0 +1,228 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
+)
+
+const (
+	defaultRecallResults = 10
+	maxRecallResults     = 30
+	maxRecallSnippet     = 1500  // chars of one matching message
+	maxRecallChars       = 12000 // chars of the whole answer
+)
+
+// archiveMessages takes the n messages after the objective out of the live
+// conversation in the messages table, indexes them for recall, and moves the
+// live conversation past them. The messages table must hold messages as
+// persisted by persistMessages.
+func (q *Queen) archiveMessages(ctx context.Context, messages []llm.ToolMessage, n int) {
+	for i := 1; i <= n; i++ {
+		if err := q.db.MarkMessageExcluded(ctx, q.sessionID, q.msgSeq+i); err != nil {
+			q.logger.Printf("⚠ Warning: failed to exclude message %d: %v", q.msgSeq+i, err)
+		}
+	}
+	if err := q.db.IndexMessages(ctx, q.sessionID, q.recallRows(messages[1:n+1], q.msgSeq+1)); err != nil {
+		q.logger.Printf("⚠ Warning: failed to index compacted messages: %v", err)
+	}
+	next, err := q.db.ResetLiveMessages(ctx, q.sessionID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to reset live messages: %v", err)
+		next = q.msgSeq + len(messages)
+	}
+	q.msgSeq = next
+}
+
+// recallRows splits messages into index rows: one per text, tool call and
+// tool result. Rows are labelled with the tool they belong to and the tasks
+// they refer to, by task_id in a tool's input or by mentioning a known ID.
+func (q *Queen) recallRows(messages []llm.ToolMessage, firstSeq int) []state.MessageIndexRow {
+	var known []string
+	for _, t := range q.tasks.All() {
+		known = append(known, t.ID)
+	}
+	toolNames := make(map[string]string)
+	toolTasks := make(map[string][]string)
+
+	var rows []state.MessageIndexRow
+	for i, msg := range messages {
+		seq := firstSeq + i
+		for _, block := range msg.Content {
+			switch {
+			case block.Type == "text" && block.Text != "":
+				rows = append(rows, state.MessageIndexRow{
+					SequenceID: seq, Role: msg.Role, TaskIDs: mentionedTasks(block.Text, known), Content: block.Text,
+				})
+			case block.Type == "tool_use" && block.ToolCall != nil:
+				tc := block.ToolCall
+				ids := mergeIDs(inputTaskIDs(tc.Input), mentionedTasks(string(tc.Input), known))
+				toolNames[tc.ID] = tc.Name
+				toolTasks[tc.ID] = ids
+				rows = append(rows, state.MessageIndexRow{
+					SequenceID: seq, Role: msg.Role, ToolName: tc.Name, TaskIDs: ids, Content: string(tc.Input),
+				})
+			}
+		}
+		for _, r := range msg.ToolResults {
+			rows = append(rows, state.MessageIndexRow{
+				SequenceID: seq,
+				Role:       msg.Role,
+				ToolName:   toolNames[r.ToolCallID],
+				TaskIDs:    mergeIDs(toolTasks[r.ToolCallID], mentionedTasks(r.Content, known)),
+				Content:    r.Content,
+			})
+		}
+	}
+	return rows
+}
+
+// inputTaskIDs returns the task IDs a tool call's input names.
+func inputTaskIDs(input json.RawMessage) []string {
+	var in struct {
+		TaskID  string   `json:"task_id"`
+		TaskIDs []string `json:"task_ids"`
+		Tasks   []struct {
+			ID string `json:"id"`
+		} `json:"tasks"`
+	}
+	if json.Unmarshal(input, &in) != nil {
+		return nil
+	}
+	ids := in.TaskIDs
+	if in.TaskID != "" {
+		ids = append(ids, in.TaskID)
+	}
+	for _, t := range in.Tasks {
+		if t.ID != "" {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
+// mentionedTasks returns the IDs in known that appear in s as whole words.
+func mentionedTasks(s string, known []string) []string {
+	var ids []string
+	for _, id := range known {
+		if containsWord(s, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// containsWord reports whether w occurs in s delimited by characters that
+// cannot be part of an ID.
+func containsWord(s, w string) bool {
+	if w == "" {
+		return false
+	}
+	for off := 0; ; {
+		i := strings.Index(s[off:], w)
+		if i < 0 {
+			return false
+		}
+		start, end := off+i, off+i+len(w)
+		if (start == 0 || !isIDChar(s[start-1])) && (end == len(s) || !isIDChar(s[end])) {
+			return true
+		}
+		off = start + 1
+	}
+}
+
+func isIDChar(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// mergeIDs returns the IDs of a and b, sorted and without duplicates.
+func mergeIDs(a, b []string) []string {
+	ids := slices.Concat(a, b)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// snippet returns about n characters of s around the first match of query,
+// or its start when there is none.
+func snippet(s, query string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	i := -1
+	if query != "" {
+		i = strings.Index(strings.ToLower(s), strings.ToLower(query))
+	}
+	if i < 0 || i+len(query) <= n {
+		return clip(s, n)
+	}
+	start := max(0, min(i-n/2, len(s)-n))
+	end := min(len(s), start+n)
+	out := "…" + s[start:end]
+	if end < len(s) {
+		out += "…"
+	}
+	return strings.ToValidUTF8(out, "")
+}
+
+// ---------- recall ----------
+
+type recallInput struct {
+	Query  string `json:"query"`
+	Tool   string `json:"tool"`
+	TaskID string `json:"task_id"`
+	Limit  int    `json:"limit"`
+}
+
+func handleRecall(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in recallInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	in.Query = strings.TrimSpace(in.Query)
+	if in.Query == "" && in.Tool == "" && in.TaskID == "" {
+		return ToolOutput{}, fmt.Errorf("give a query, tool or task_id to recall")
+	}
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultRecallResults
+	}
+	limit = min(limit, maxRecallResults)
+
+	rows, err := q.db.SearchMessages(ctx, q.sessionID, state.MessageQuery{
+		Text: in.Query, Tool: in.Tool, TaskID: in.TaskID, Limit: limit,
+	})
+	if err != nil {
+		return ToolOutput{}, fmt.Errorf("search compacted history: %w", err)
+	}
+	if len(rows) == 0 {
+		return ToolOutput{LLMContent: "No compacted messages match.", Display: "recall: no matches"}, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d matches in compacted history (newest first):\n", len(rows))
+	for i, r := range rows {
+		var head strings.Builder
+		fmt.Fprintf(&head, "\n#%d %s", r.SequenceID, r.Role)
+		if r.ToolName != "" {
+			fmt.Fprintf(&head, " %s", r.ToolName)
+		}
+		if len(r.TaskIDs) > 0 {
+			fmt.Fprintf(&head, " [%s]", strings.Join(r.TaskIDs, ", "))
+		}
+		entry := head.String() + ":\n" + snippet(r.Content, in.Query, maxRecallSnippet) + "\n"
+		if b.Len()+len(entry) > maxRecallChars {
+			fmt.Fprintf(&b, "\n(%d more matches omitted for length; narrow the search)\n", len(rows)-i)
+			break
+		}
+		b.WriteString(entry)
+	}
+	return ToolOutput{
+		LLMContent: strings.TrimRight(b.String(), "\n"),
+		Display:    fmt.Sprintf("recall: %d matches", len(rows)),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/task"
+)
+
+// toolTurn returns an assistant tool call and its result.
+func toolTurn(id, name, input, result string) []llm.ToolMessage {
+	return []llm.ToolMessage{
+		{Role: "assistant", Content: []llm.ContentBlock{{
+			Type: "tool_use", ToolCall: &llm.ToolCall{ID: id, Name: name, Input: json.RawMessage(input)},
+		}}},
+		{Role: "tool_result", ToolResults: []llm.ToolResult{{ToolCallID: id, Content: result}}},
+	}
+}
+
+func TestRecallCompactedMessages(t *testing.T) {
+	q, _ := testQueen(t)
+	ctx := context.Background()
+	addPersistedTask(t, q, &task.Task{ID: "api", Title: "API", Type: task.TypeCode, Status: task.StatusPending})
+	q.cfg.Queen.CompactAfter = 30
+
+	messages := statusConversation(0)
+	messages = append(messages, toolTurn("c1", "read_file", `{"path":"internal/server/routes.go"}`, "package server\n// routes are registered in setupRoutes")...)
+	messages = append(messages, toolTurn("c2", "reject_task", `{"task_id":"api","feedback":"Handlers must return JSON errors"}`, `Task "api" rejected`)...)
+	messages = append(messages, statusConversation(20)[1:]...)
+	q.persistMessages(ctx, messages)
+
+	compacted := q.maybeCompact(ctx, messages, 5, llm.Usage{}, len(messages))
+	if len(compacted) >= len(messages) {
+		t.Fatalf("expected compaction, got %d messages", len(compacted))
+	}
+
+	// Dropped messages are kept as excluded rows; the live rows are the compacted history.
+	live, err := q.db.LoadMessages(ctx, q.sessionID)
+	if err != nil || len(live) != len(compacted) || live[0].SequenceID != len(messages)-len(compacted)+2 {
+		t.Fatalf("expected %d live messages after the archived ones, got %d (%v)", len(compacted), len(live), err)
+	}
+
+	out, err := handleRecall(ctx, q, toJSON(map[string]string{"query": "setupRoutes"}))
+	if err != nil || !strings.Contains(out.LLMContent, "tool_result read_file:\npackage server") {
+		t.Errorf("unexpected recall by keyword: %q, %v", out.LLMContent, err)
+	}
+	out, _ = handleRecall(ctx, q, toJSON(map[string]string{"task_id": "api"}))
+	if !strings.Contains(out.LLMContent, "2 matches") || !strings.Contains(out.LLMContent, "reject_task [api]:\n{\"task_id\":\"api\",\"feedback\":\"Handlers must return JSON errors\"}") {
+		t.Errorf("unexpected recall by task: %q", out.LLMContent)
+	}
+	out, _ = handleRecall(ctx, q, toJSON(map[string]interface{}{"tool": "get_status", "limit": 3}))
+	if !strings.HasPrefix(out.LLMContent, "3 matches") {
+		t.Errorf("expected limited recall by tool, got %q", out.LLMContent)
+	}
+	out, _ = handleRecall(ctx, q, toJSON(map[string]string{"query": "nowhere to be found"}))
+	if out.LLMContent != "No compacted messages match." {
+		t.Errorf("unexpected empty recall %q", out.LLMContent)
+	}
+	if _, err := handleRecall(ctx, q, toJSON(map[string]string{})); err == nil {
+		t.Error("expected error without a filter")
+	}
+
+	// A second compaction archives the first summary without overwriting earlier rows.
+	more := append(compacted, statusConversation(20)[1:]...)
+	q.persistMessages(ctx, more)
+	q.maybeCompact(ctx, more, 9, llm.Usage{}, len(more))
+	out, _ = handleRecall(ctx, q, toJSON(map[string]string{"query": "setupRoutes"}))
+	if !strings.Contains(out.LLMContent, "read_file") {
+		t.Errorf("expected first archive kept after a second compaction, got %q", out.LLMContent)
+	}
+	out, _ = handleRecall(ctx, q, toJSON(map[string]string{"query": "Conversation history compacted"}))
+	if !strings.Contains(out.LLMContent, "1 matches") {
+		t.Errorf("expected first summary archived, got %q", out.LLMContent)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a", 100) + "NEEDLE" + strings.Repeat("b", 100)
+	got := snippet(long, "needle", 40)
+	if !strings.Contains(got, "NEEDLE") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || len(got) > 40+2*len("…") {
+		t.Errorf("unexpected snippet %q", got)
+	}
+	if got := snippet("short", "x", 40); got != "short" {
+		t.Errorf("expected short text unchanged, got %q", got)
+	}
+	if !containsWord("see task-1.", "task-1") || containsWord("task-10", "task-1") {
+		t.Error("containsWord must match whole IDs only")
+	}
+}
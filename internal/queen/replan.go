@@ -20,7 +20,7 @@ func (q *Queen) replanWithLLM(ctx context.Context) ([]*task.Task, error) {
 
 	q.logger.Println("🔄 Re-planning: consulting LLM for additional tasks...")
 
-	response, err := q.llm.Chat(ctx, replanSystemPrompt, prompt)
+	response, err := q.chat(ctx, purposeReplan, replanSystemPrompt, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("replan LLM call: %w", err)
 	}
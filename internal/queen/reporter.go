@@ -1,6 +1,7 @@
 package queen
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -18,11 +19,20 @@ type TaskResult struct {
 	Status      task.Status
 	Result      *task.Result
 	WorkerID    string
+	Attempts    int     // number of recorded worker attempts
+	CostUSD     float64 // reported spend across attempts
 	CompletedAt *time.Time
 }
 
 // Results returns all task results in creation order
 func (q *Queen) Results() []TaskResult {
+	attempts := map[string]int{}
+	if rows, err := q.db.ListSessionAttempts(context.Background(), q.sessionID); err == nil {
+		for _, a := range rows {
+			attempts[a.TaskID]++
+		}
+	}
+
 	var results []TaskResult
 	for _, t := range q.tasks.All() {
 		tr := TaskResult{
@@ -32,6 +42,8 @@ func (q *Queen) Results() []TaskResult {
 			Status:      t.GetStatus(),
 			Result:      t.GetResult(),
 			WorkerID:    t.GetWorkerID(),
+			Attempts:    attempts[t.ID],
+			CostUSD:     q.taskSpend(t.ID),
 			CompletedAt: t.CompletedAt,
 		}
 		results = append(results, tr)
@@ -55,12 +67,16 @@ func (q *Queen) printReport() {
 
 	completed := 0
 	failed := 0
+	retried := 0
 	for _, r := range results {
 		if r.Status == task.StatusComplete {
 			completed++
 		} else if r.Status == task.StatusFailed {
 			failed++
 		}
+		if r.Attempts > 1 {
+			retried++
+		}
 	}
 
 	p.KeyValue([][]string{
@@ -73,6 +89,7 @@ func (q *Queen) printReport() {
 		[][]string{
 			{"Completed", fmt.Sprintf("%d", completed)},
 			{"Failed", fmt.Sprintf("%d", failed)},
+			{"Retried", fmt.Sprintf("%d", retried)},
 			{"Total", fmt.Sprintf("%d", len(results))},
 		},
 	)
@@ -80,7 +97,14 @@ func (q *Queen) printReport() {
 
 	for _, r := range results {
 		icon := output.StatusIcon(string(r.Status))
-		p.Section(fmt.Sprintf("%s [%s] %s", icon, r.Type, r.Title))
+		title := fmt.Sprintf("%s [%s] %s", icon, r.Type, r.Title)
+		if r.Attempts > 1 {
+			title += fmt.Sprintf(" (%d attempts, see 'waggle attempts %s')", r.Attempts, r.ID)
+		}
+		if r.CostUSD > 0 {
+			title += " · " + formatUSD(r.CostUSD)
+		}
+		p.Section(title)
 
 		if r.Result != nil && r.Result.Output != "" {
 			for _, line := range strings.Split(strings.TrimSpace(r.Result.Output), "\n") {
@@ -98,10 +122,26 @@ func (q *Queen) printReport() {
 	}
 
 	p.Divider()
-	p.KeyValue([][]string{
+	summary := [][]string{
 		{"Session", q.sessionID},
 		{"Log", ".hive/hive.db"},
-	})
+	}
+	if s := q.Spent(); s.SessionUSD > 0 || s.Tokens > 0 {
+		cost := fmt.Sprintf("%s (%d tokens)", formatUSD(s.SessionUSD), s.Tokens)
+		if s.BudgetUSD > 0 {
+			cost += fmt.Sprintf(", budget %s", formatUSD(s.BudgetUSD))
+		}
+		summary = append(summary, []string{"Cost", cost})
+		if by := q.costByPurpose(context.Background()); by != "" {
+			summary = append(summary, []string{"Cost by purpose", by})
+		}
+	}
+	if path, err := q.writeGraphReport(context.Background()); err != nil {
+		q.logger.Printf("⚠ Warning: failed to write task graph: %v", err)
+	} else {
+		summary = append(summary, []string{"Task graph", path})
+	}
+	p.KeyValue(summary)
 	p.Println("")
 }
 
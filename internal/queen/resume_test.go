@@ -697,7 +697,7 @@ func TestCompactMessagesPreservesToolPairs(t *testing.T) {
 		})
 	}
 
-	compacted := q.compactMessages(messages)
+	compacted := q.compactMessages(context.Background(), messages)
 
 	// Verify no orphaned tool_results at the start of the kept section
 	// (skip index 0=objective and 1=summary)
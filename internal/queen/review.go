@@ -4,20 +4,119 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/HexSleeves/waggle/internal/llm"
 	"github.com/HexSleeves/waggle/internal/task"
 )
 
 // ReviewVerdict is the LLM's assessment of a completed task.
 type ReviewVerdict struct {
-	Approved    bool     `json:"approved"`
-	Reason      string   `json:"reason"`
-	Suggestions []string `json:"suggestions,omitempty"`
+	Approved      bool            `json:"approved"`
+	Score         int             `json:"score,omitempty"` // 0 (unusable) to 10 (excellent)
+	Reason        string          `json:"reason"`
+	Findings      []ReviewFinding `json:"findings,omitempty"`
+	RequiredFixes []string        `json:"required_fixes,omitempty"`
+	FollowUps     []FollowUpTask  `json:"follow_up_tasks,omitempty"`
+	Suggestions   []string        `json:"suggestions,omitempty"`
+
+	// Panel reviews: every reviewer's vote, and whether they disagreed.
+	Votes    []ReviewVote `json:"votes,omitempty"`
+	Disputed bool         `json:"disputed,omitempty"`
+}
+
+// ReviewVote is one panel reviewer's verdict.
+type ReviewVote struct {
+	Reviewer string `json:"reviewer"` // provider/model
+	Approved bool   `json:"approved"`
+	Score    int    `json:"score"`
+	Reason   string `json:"reason"`
+}
+
+// ReviewFinding is the verdict on one review criterion.
+type ReviewFinding struct {
+	Criterion string `json:"criterion"`
+	Passed    bool   `json:"passed"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// FollowUpTask is a task the reviewer suggests creating.
+type FollowUpTask struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// Feedback is what a worker is told when its output is rejected: the reason,
+// the fixes required and any suggestions.
+func (v *ReviewVerdict) Feedback() string {
+	feedback := v.Reason
+	if len(v.RequiredFixes) > 0 {
+		feedback += "\nRequired fixes: " + strings.Join(v.RequiredFixes, "; ")
+	}
+	if len(v.Suggestions) > 0 {
+		feedback += "\nSuggestions: " + strings.Join(v.Suggestions, "; ")
+	}
+	return feedback
+}
+
+// verdictToolName is the tool a reviewer must call with its verdict.
+const verdictToolName = "submit_verdict"
+
+// verdictTool is the tool a tool-capable reviewer submits its verdict with.
+func verdictTool() llm.ToolDef {
+	stringList := func(desc string) map[string]interface{} {
+		return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": desc}
+	}
+	return llm.ToolDef{
+		Name:        verdictToolName,
+		Description: "Submit your verdict on the worker's output. Call this exactly once.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"approved": map[string]interface{}{"type": "boolean", "description": "Whether the output satisfies the task"},
+				"score":    map[string]interface{}{"type": "integer", "description": "Overall quality from 0 (unusable) to 10 (excellent)"},
+				"reason":   map[string]interface{}{"type": "string", "description": "One-paragraph explanation of the verdict"},
+				"findings": map[string]interface{}{
+					"type":        "array",
+					"description": "One finding per review criterion",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"criterion": map[string]interface{}{"type": "string", "description": "The criterion, e.g. scope, correctness, completeness"},
+							"passed":    map[string]interface{}{"type": "boolean"},
+							"detail":    map[string]interface{}{"type": "string", "description": "What was found"},
+						},
+						"required": []string{"criterion", "passed"},
+					},
+				},
+				"required_fixes": stringList("Changes that must be made before the output can be approved (required when rejecting)"),
+				"follow_up_tasks": map[string]interface{}{
+					"type":        "array",
+					"description": "Further tasks the objective needs that are outside this task's scope",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"title":       map[string]interface{}{"type": "string"},
+							"description": map[string]interface{}{"type": "string"},
+							"type":        map[string]interface{}{"type": "string", "enum": []string{"code", "research", "test", "review", "generic"}},
+						},
+						"required": []string{"title"},
+					},
+				},
+				"suggestions": stringList("Optional improvements that do not block approval"),
+			},
+			"required": []string{"approved", "score", "reason", "findings"},
+		},
+	}
 }
 
 // reviewWithLLM asks the LLM to evaluate a worker's output against the
-// original task requirements and returns a structured verdict.
+// original task requirements and returns a structured verdict. Reviewers
+// that support tool use must submit it through submit_verdict; others are
+// asked for JSON in their reply. The verdict is stored on the task's latest
+// attempt.
 func (q *Queen) reviewWithLLM(ctx context.Context, taskID string, t *task.Task, result *task.Result) (*ReviewVerdict, error) {
 	// llm client added in queen.go
 	if q.llm == nil {
@@ -36,10 +135,58 @@ func (q *Queen) reviewWithLLM(ctx context.Context, taskID string, t *task.Task,
 		defer cancel()
 	}
 
-	systemPrompt := reviewSystemPrompt()
+	rubric := q.rubric(t.Type)
 	userMessage := buildReviewPrompt(t, result)
 
-	raw, err := q.llm.Chat(ctx, systemPrompt, userMessage)
+	var verdict *ReviewVerdict
+	var err error
+	if len(q.cfg.Review.Reviewers) > 0 {
+		verdict, err = q.reviewPanel(ctx, t, rubric, userMessage)
+	} else {
+		verdict, err = q.reviewBy(ctx, purposeReview, rubric, userMessage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.recordAttemptReview(ctx, taskID, verdict)
+	return verdict, nil
+}
+
+// reviewBy has the reviewer of purpose review the output: through
+// submit_verdict when it supports tool use, else as JSON in its reply.
+func (q *Queen) reviewBy(ctx context.Context, purpose string, rubric []string, userMessage string) (*ReviewVerdict, error) {
+	if tc, ok := q.route(purpose).client.(llm.ToolClient); ok {
+		return q.reviewWithTool(ctx, purpose, tc, reviewSystemPrompt(true, rubric), userMessage)
+	}
+	return q.reviewWithText(ctx, purpose, reviewSystemPrompt(false, rubric), userMessage)
+}
+
+// reviewWithTool has the reviewer call submit_verdict. A reply without the
+// call (a provider that ignores the tool choice) is parsed as text instead.
+func (q *Queen) reviewWithTool(ctx context.Context, purpose string, tc llm.ToolClient, systemPrompt, userMessage string) (*ReviewVerdict, error) {
+	resp, err := q.callTool(ctx, purpose, tc, systemPrompt, userMessage, verdictTool())
+	if err != nil {
+		return nil, fmt.Errorf("review LLM call: %w", err)
+	}
+	for _, b := range resp.Content {
+		if b.Type == "tool_use" && b.ToolCall != nil && b.ToolCall.Name == verdictToolName {
+			verdict, err := decodeVerdict(b.ToolCall.Input)
+			if err != nil {
+				return nil, fmt.Errorf("parse review verdict: %w", err)
+			}
+			return verdict, nil
+		}
+	}
+	verdict, err := parseReviewVerdict(resp.Text())
+	if err != nil {
+		return nil, fmt.Errorf("parse review verdict: no %s call, and %w", verdictToolName, err)
+	}
+	return verdict, nil
+}
+
+// reviewWithText asks for the verdict as JSON in a plain reply.
+func (q *Queen) reviewWithText(ctx context.Context, purpose, systemPrompt, userMessage string) (*ReviewVerdict, error) {
+	raw, err := q.chat(ctx, purpose, systemPrompt, userMessage)
 	if err != nil {
 		return nil, fmt.Errorf("review LLM call: %w", err)
 	}
@@ -51,22 +198,158 @@ func (q *Queen) reviewWithLLM(ctx context.Context, taskID string, t *task.Task,
 	return verdict, nil
 }
 
-// reviewSystemPrompt returns the system-level instructions for the review LLM.
-func reviewSystemPrompt() string {
-	return `You are a strict code-review agent inside an automated orchestration system.
+// decodeVerdict decodes and checks a submit_verdict input.
+func decodeVerdict(input json.RawMessage) (*ReviewVerdict, error) {
+	var v ReviewVerdict
+	if err := json.Unmarshal(input, &v); err != nil {
+		return nil, fmt.Errorf("invalid %s input: %w", verdictToolName, err)
+	}
+	if v.Score < 0 || v.Score > 10 {
+		return nil, fmt.Errorf("score %d is outside 0-10", v.Score)
+	}
+	if strings.TrimSpace(v.Reason) == "" {
+		return nil, fmt.Errorf("verdict has no reason")
+	}
+	return &v, nil
+}
+
+// formatReview renders a stored verdict for the Queen. It returns "" when
+// there is none, and the raw text when it cannot be decoded.
+func formatReview(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	var v ReviewVerdict
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return "Review: " + raw + "\n"
+	}
+	var b strings.Builder
+	verdict := "rejected"
+	if v.Approved {
+		verdict = "approved"
+	}
+	fmt.Fprintf(&b, "Review: %s (score %d/10): %s\n", verdict, v.Score, v.Reason)
+	for _, f := range v.Findings {
+		mark := "✗"
+		if f.Passed {
+			mark = "✓"
+		}
+		fmt.Fprintf(&b, "  %s %s", mark, f.Criterion)
+		if f.Detail != "" {
+			fmt.Fprintf(&b, ": %s", f.Detail)
+		}
+		b.WriteString("\n")
+	}
+	for _, fix := range v.RequiredFixes {
+		fmt.Fprintf(&b, "  Required fix: %s\n", fix)
+	}
+	for _, f := range v.FollowUps {
+		fmt.Fprintf(&b, "  Follow-up task: %s", f.Title)
+		if f.Type != "" {
+			fmt.Fprintf(&b, " [%s]", f.Type)
+		}
+		if f.Description != "" {
+			fmt.Fprintf(&b, " — %s", f.Description)
+		}
+		b.WriteString("\n")
+	}
+	for _, s := range v.Suggestions {
+		fmt.Fprintf(&b, "  Suggestion: %s\n", s)
+	}
+	if v.Disputed {
+		b.WriteString("  The reviewers disagreed.\n")
+	}
+	for _, vote := range v.Votes {
+		fmt.Fprintf(&b, "  Vote: %s\n", describeVote(vote))
+	}
+	return b.String()
+}
+
+// reviewSystemPrompt returns the system-level instructions for the review
+// LLM: the criteria to judge by (the rubric, or scope, correctness and
+// follow-up without one), and to call submit_verdict or else to reply with
+// the verdict as JSON.
+func reviewSystemPrompt(tool bool, rubric []string) string {
+	var b strings.Builder
+	b.WriteString(`You are a strict code-review agent inside an automated orchestration system.
 Your job is to evaluate whether a worker's output satisfies the original task.
 
-Evaluate on three axes:
+`)
+	if len(rubric) == 0 {
+		b.WriteString(`Evaluate on three axes, and give a finding for each:
 1. SCOPE — did the worker stay within the task description and constraints?
 2. CORRECTNESS — is the output technically correct and complete?
 3. FOLLOW-UP — are there obvious next steps or missing pieces?
-
+`)
+	} else {
+		b.WriteString("Evaluate the output against each of these criteria, and give a finding for each:\n")
+		for i, c := range rubric {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, c)
+		}
+		b.WriteString("Reject the output if it fails any criterion that applies to the task.\n")
+	}
+	b.WriteString(`
+When you reject, list the required fixes. Work the objective needs beyond this task goes in
+follow-up tasks, not required fixes.
+`)
+	if tool {
+		b.WriteString("\nSubmit your verdict by calling the " + verdictToolName + " tool.")
+		return b.String()
+	}
+	b.WriteString(`
 Respond with ONLY a JSON object (no markdown fences, no commentary) using this schema:
 {
   "approved": true|false,
+  "score": 0-10,
   "reason": "one-paragraph explanation",
-  "suggestions": ["actionable suggestion", ...]
-}`
+  "findings": [{"criterion": "scope", "passed": true|false, "detail": "..."}, ...],
+  "required_fixes": ["change needed before approval", ...],
+  "follow_up_tasks": [{"title": "...", "description": "...", "type": "code"}, ...],
+  "suggestions": ["optional improvement", ...]
+}`)
+	return b.String()
+}
+
+// rubric returns the review criteria for a task type: .hive/rubrics/<type>.md,
+// else review.rubrics[<type>], else the same for "default". Nil means the
+// built-in axes.
+func (q *Queen) rubric(taskType task.Type) []string {
+	for _, name := range []string{string(taskType), "default"} {
+		if criteria := q.readRubric(q.cfg.HivePath("rubrics", name+".md")); len(criteria) > 0 {
+			return criteria
+		}
+		if criteria := q.cfg.Review.Rubrics[name]; len(criteria) > 0 {
+			return criteria
+		}
+	}
+	return nil
+}
+
+// readRubric reads a rubric file: one criterion per line, optionally as a
+// markdown list item. Blank lines and headings are skipped.
+func (q *Queen) readRubric(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			q.logger.Printf("⚠ Warning: failed to read rubric: %v", err)
+		}
+		return nil
+	}
+	var criteria []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimLeft(line, "-*+ ")
+		if n := strings.IndexAny(line, ".)"); n > 0 && strings.Trim(line[:n], "0123456789") == "" {
+			line = line[n+1:]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			criteria = append(criteria, line)
+		}
+	}
+	return criteria
 }
 
 const maxOutputChars = 8000
@@ -112,13 +395,14 @@ func buildReviewPrompt(t *task.Task, result *task.Result) string {
 	b.WriteString("\n== INSTRUCTIONS ==\n")
 	b.WriteString("Evaluate this output against the original task. Did the worker stay in scope? ")
 	b.WriteString("Is the output correct and complete? Are there follow-up tasks needed?\n")
-	b.WriteString("Respond with ONLY a JSON object matching the schema described in your instructions.\n")
+	b.WriteString("Give your verdict in the form your instructions describe.\n")
 
 	return b.String()
 }
 
 // parseReviewVerdict extracts a ReviewVerdict JSON object from potentially
 // noisy LLM output. It is tolerant of markdown fences and surrounding text.
+// It is the fallback for reviewers that cannot call submit_verdict.
 func parseReviewVerdict(raw string) (*ReviewVerdict, error) {
 	raw = strings.TrimSpace(raw)
 
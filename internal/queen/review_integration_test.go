@@ -603,3 +603,86 @@ func TestIntegration_ReviewAndReject(t *testing.T) {
 		t.Errorf("expected retry count 1, got %d", t1.RetryCount)
 	}
 }
+
+func TestReviewWithLLM_SubmitVerdictTool(t *testing.T) {
+	q := reviewTestQueen(t, nil)
+	mock := &mockToolClient{responses: []*llm.Response{makeToolResponse("v1", verdictToolName, toJSON(map[string]interface{}{
+		"approved": false,
+		"score":    4,
+		"reason":   "The handler ignores errors",
+		"findings": []map[string]interface{}{
+			{"criterion": "scope", "passed": true},
+			{"criterion": "correctness", "passed": false, "detail": "write errors are dropped"},
+		},
+		"required_fixes":  []string{"Return the write error"},
+		"follow_up_tasks": []map[string]interface{}{{"title": "Add handler tests", "type": "test"}},
+		"suggestions":     []string{"Log the request ID"},
+	}))}}
+	q.llm = mock
+
+	tk := &task.Task{ID: "t1", Type: task.TypeCode, Title: "Write the handler", Status: task.StatusComplete,
+		Result: &task.Result{Success: true, Output: "handler written"}}
+	q.tasks.Add(tk)
+	ctx := context.Background()
+	if _, err := q.db.StartAttempt(ctx, q.sessionID, "t1", "w1", "exec"); err != nil {
+		t.Fatal(err)
+	}
+
+	verdict, err := q.reviewWithLLM(ctx, "t1", tk, tk.Result)
+	if err != nil {
+		t.Fatalf("review error: %v", err)
+	}
+	if verdict.Approved || verdict.Score != 4 || len(verdict.Findings) != 2 || verdict.Findings[1].Passed ||
+		len(verdict.FollowUps) != 1 || verdict.FollowUps[0].Type != "test" {
+		t.Errorf("unexpected verdict: %+v", verdict)
+	}
+	if fb := verdict.Feedback(); !strings.Contains(fb, "Required fixes: Return the write error") || !strings.Contains(fb, "Suggestions: Log the request ID") {
+		t.Errorf("unexpected feedback: %q", fb)
+	}
+	if len(mock.calls) != 1 || len(mock.calls[0].Tools) != 1 || mock.calls[0].Tools[0].Name != verdictToolName {
+		t.Errorf("expected one call offering only %s, got %+v", verdictToolName, mock.calls)
+	}
+
+	attempts, err := q.db.ListAttempts(ctx, q.sessionID, "t1")
+	if err != nil || len(attempts) != 1 || !strings.Contains(attempts[0].Review, `"score":4`) {
+		t.Fatalf("expected the verdict stored on the attempt, got %+v, %v", attempts, err)
+	}
+
+	out, err := handleGetTaskOutput(ctx, q, toJSON(map[string]string{"task_id": "t1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Review: rejected (score 4/10): The handler ignores errors",
+		"✗ correctness: write errors are dropped",
+		"Required fix: Return the write error",
+		"Follow-up task: Add handler tests [test]",
+	} {
+		if !strings.Contains(out.LLMContent, want) {
+			t.Errorf("get_task_output missing %q:\n%s", want, out.LLMContent)
+		}
+	}
+}
+
+func TestReviewWithLLM_SubmitVerdictFallsBackToText(t *testing.T) {
+	q := reviewTestQueen(t, nil)
+	q.llm = &mockToolClient{responses: []*llm.Response{{
+		Content: []llm.ContentBlock{{Type: "text", Text: `{"approved": true, "score": 9, "reason": "Complete"}`}},
+	}}}
+
+	tk := &task.Task{ID: "t1", Type: task.TypeCode, Title: "Write the handler"}
+	verdict, err := q.reviewWithLLM(context.Background(), "t1", tk, &task.Result{Success: true, Output: "done"})
+	if err != nil {
+		t.Fatalf("review error: %v", err)
+	}
+	if !verdict.Approved || verdict.Score != 9 {
+		t.Errorf("unexpected verdict: %+v", verdict)
+	}
+
+	q.llm = &mockToolClient{responses: []*llm.Response{makeToolResponse("v1", verdictToolName, toJSON(map[string]interface{}{
+		"approved": true, "score": 11, "reason": "Great",
+	}))}}
+	if _, err := q.reviewWithLLM(context.Background(), "t1", tk, &task.Result{Success: true}); err == nil || !strings.Contains(err.Error(), "outside 0-10") {
+		t.Errorf("expected an out-of-range score to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package queen
+
+import (
+	"context"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+// chatWithTools makes one Queen LLM call, retrying transient failures. When
+// the client can stream, its text and tool-call deltas are published on the
+// bus (MsgQueenStream) as they arrive. Every attempt opens with a StreamStart
+// event so consumers can drop the partial output of a failed attempt.
+func (q *Queen) chatWithTools(ctx context.Context, client llm.ToolClient, systemPrompt string,
+	messages []llm.ToolMessage, tools []llm.ToolDef) (*llm.Response, error) {
+	sc, streaming := client.(llm.StreamingToolClient)
+	retries := 3
+	if llm.RetriesInternally(client) {
+		retries = 0
+	}
+	return llm.RetryLLMCall(ctx, retries, q.logger, func() (*llm.Response, error) {
+		if !streaming || q.bus == nil {
+			return client.ChatWithTools(ctx, systemPrompt, messages, tools)
+		}
+		q.publishStream(llm.StreamEvent{Type: llm.StreamStart})
+		return sc.ChatWithToolsStream(ctx, systemPrompt, messages, tools, q.publishStream)
+	})
+}
+
+func (q *Queen) publishStream(e llm.StreamEvent) {
+	q.bus.Publish(bus.Message{
+		Type:    bus.MsgQueenStream,
+		Payload: e,
+		Time:    time.Now(),
+	})
+}
@@ -0,0 +1,102 @@
+package queen
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/HexSleeves/waggle/internal/bus"
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+// streamingClient streams the text of each scripted response as one delta
+// per character. The first call fails midway with a retryable error.
+type streamingClient struct {
+	mockToolClient
+	failFirst bool
+}
+
+func (c *streamingClient) ChatWithToolsStream(ctx context.Context, systemPrompt string,
+	messages []llm.ToolMessage, tools []llm.ToolDef, onEvent func(llm.StreamEvent)) (*llm.Response, error) {
+	if c.failFirst {
+		c.failFirst = false
+		onEvent(llm.StreamEvent{Type: llm.StreamText, Text: "partial"})
+		return nil, errors.New("connection reset by peer")
+	}
+	resp, err := c.ChatWithTools(ctx, systemPrompt, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range resp.Content {
+		switch b.Type {
+		case "text":
+			for _, r := range b.Text {
+				onEvent(llm.StreamEvent{Type: llm.StreamText, Text: string(r)})
+			}
+		case "tool_use":
+			onEvent(llm.StreamEvent{Type: llm.StreamToolCall, ToolCallID: b.ToolCall.ID, ToolName: b.ToolCall.Name})
+			onEvent(llm.StreamEvent{Type: llm.StreamToolInput, ToolCallID: b.ToolCall.ID, Text: string(b.ToolCall.Input)})
+		}
+	}
+	return resp, nil
+}
+
+func TestChatWithTools_PublishesStream(t *testing.T) {
+	q, _ := testQueen(t)
+
+	var events []llm.StreamEvent
+	q.bus.Subscribe(bus.MsgQueenStream, func(msg bus.Message) {
+		events = append(events, msg.Payload.(llm.StreamEvent))
+	})
+
+	want := &llm.Response{
+		Content: []llm.ContentBlock{
+			{Type: "text", Text: "ok"},
+			{Type: "tool_use", ToolCall: &llm.ToolCall{ID: "t1", Name: "get_status", Input: toJSON(map[string]string{})}},
+		},
+		StopReason: "tool_use",
+		Usage:      llm.Usage{InputTokens: 10, OutputTokens: 3},
+	}
+	client := &streamingClient{mockToolClient: mockToolClient{responses: []*llm.Response{want}}, failFirst: true}
+
+	got, err := q.chatWithTools(context.Background(), client, "sys", nil, nil)
+	if err != nil {
+		t.Fatalf("chatWithTools: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected the streamed response to be returned unchanged")
+	}
+
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, e.Type+":"+e.Text+e.ToolName)
+	}
+	wantKinds := []string{"start:", "text:partial", "start:", "text:o", "text:k", "tool_call:get_status", "tool_input:{}"}
+	if len(kinds) != len(wantKinds) {
+		t.Fatalf("events = %v, want %v", kinds, wantKinds)
+	}
+	for i := range kinds {
+		if kinds[i] != wantKinds[i] {
+			t.Errorf("event %d = %q, want %q", i, kinds[i], wantKinds[i])
+		}
+	}
+
+	if h := q.bus.History(0); len(h) != 0 {
+		t.Errorf("stream events should stay out of the bus history, got %d", len(h))
+	}
+}
+
+func TestChatWithTools_NonStreamingClient(t *testing.T) {
+	q, _ := testQueen(t)
+
+	published := 0
+	q.bus.Subscribe(bus.MsgQueenStream, func(msg bus.Message) { published++ })
+
+	client := &mockToolClient{responses: []*llm.Response{{StopReason: "end_turn"}}}
+	if _, err := q.chatWithTools(context.Background(), client, "sys", nil, nil); err != nil {
+		t.Fatalf("chatWithTools: %v", err)
+	}
+	if published != 0 {
+		t.Errorf("non-streaming client should not publish stream events, got %d", published)
+	}
+}
@@ -0,0 +1,124 @@
+package queen
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+// toolKind classifies Queen tools for scheduling within a single turn.
+type toolKind int
+
+const (
+	// toolMutating tools change the task graph, workers or session. They run
+	// one at a time, in the order the Queen issued them.
+	toolMutating toolKind = iota
+	// toolReadOnly tools only inspect state. Consecutive read-only calls run
+	// concurrently.
+	toolReadOnly
+	// toolBlocking tools wait on workers. They run alone.
+	toolBlocking
+)
+
+// maxParallelTools bounds how many read-only tool calls run at once.
+const maxParallelTools = 4
+
+// toolKinds lists the tools that are not mutating. Unknown tools are treated
+// as mutating.
+var toolKinds = map[string]toolKind{
+	"get_status":        toolReadOnly,
+	"get_task_output":   toolReadOnly,
+	"read_file":         toolReadOnly,
+	"list_files":        toolReadOnly,
+	"search_code":       toolReadOnly,
+	"git_diff":          toolReadOnly,
+	"git_log":           toolReadOnly,
+	"file_outline":      toolReadOnly,
+	"blackboard_read":   toolReadOnly,
+	"blackboard_search": toolReadOnly,
+	"recall":            toolReadOnly,
+	"wait_for_workers":  toolBlocking,
+}
+
+func kindOf(name string) toolKind {
+	if k, ok := toolKinds[name]; ok {
+		return k
+	}
+	return toolMutating
+}
+
+// toolRun is the outcome of one tool call.
+type toolRun struct {
+	call   *llm.ToolCall
+	output ToolOutput
+	err    error
+	dur    time.Duration
+}
+
+// toolCalls returns the tool calls of an assistant response in order.
+func toolCalls(content []llm.ContentBlock) []*llm.ToolCall {
+	var calls []*llm.ToolCall
+	for _, block := range content {
+		if block.Type == "tool_use" && block.ToolCall != nil {
+			calls = append(calls, block.ToolCall)
+		}
+	}
+	return calls
+}
+
+// runToolCalls executes the tool calls of one assistant response. Runs of
+// consecutive read-only calls execute concurrently; mutating and blocking
+// calls act as barriers, so every call still sees the effects of the calls
+// issued before it. Results are returned in call order.
+func (q *Queen) runToolCalls(ctx context.Context, calls []*llm.ToolCall) []toolRun {
+	runs := make([]toolRun, len(calls))
+	for i := 0; i < len(calls); {
+		j := i + 1
+		if kindOf(calls[i].Name) == toolReadOnly {
+			for j < len(calls) && kindOf(calls[j].Name) == toolReadOnly {
+				j++
+			}
+		}
+		q.runToolBatch(ctx, calls[i:j], runs[i:j])
+		i = j
+	}
+	return runs
+}
+
+// runToolBatch runs independent calls with at most maxParallelTools in flight.
+func (q *Queen) runToolBatch(ctx context.Context, calls []*llm.ToolCall, runs []toolRun) {
+	run := func(i int) {
+		start := time.Now()
+		out, err := q.executeTool(ctx, calls[i])
+		runs[i] = toolRun{call: calls[i], output: out, err: err, dur: time.Since(start)}
+	}
+	if len(calls) == 1 {
+		run(0)
+		return
+	}
+
+	sem := make(chan struct{}, maxParallelTools)
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// recordToolTiming adds a call's duration to the per-tool totals.
+func recordToolTiming(timings map[string]*toolTiming, name string, d time.Duration) {
+	if tt, ok := timings[name]; ok {
+		tt.calls++
+		tt.totalDur += d
+		return
+	}
+	timings[name] = &toolTiming{calls: 1, totalDur: d}
+}
@@ -0,0 +1,109 @@
+package queen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HexSleeves/waggle/internal/llm"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := map[string]toolKind{
+		"read_file":        toolReadOnly,
+		"get_status":       toolReadOnly,
+		"wait_for_workers": toolBlocking,
+		"assign_task":      toolMutating,
+		"no_such_tool":     toolMutating,
+	}
+	for name, want := range tests {
+		if got := kindOf(name); got != want {
+			t.Errorf("kindOf(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestRunToolCalls_ConcurrentReadsKeepOrder(t *testing.T) {
+	q, _ := testQueen(t)
+
+	var mu sync.Mutex
+	inflight, maxInflight := 0, 0
+	var mutateSaw []int
+	enter := func() {
+		mu.Lock()
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mu.Unlock()
+	}
+	leave := func() {
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+	}
+
+	toolHandlers["test_probe"] = func(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+		enter()
+		defer leave()
+		time.Sleep(50 * time.Millisecond)
+		var s string
+		_ = json.Unmarshal(input, &s)
+		if s == "bad" {
+			return ToolOutput{}, fmt.Errorf("probe failed")
+		}
+		return ToolOutput{LLMContent: s}, nil
+	}
+	toolHandlers["test_mutate"] = func(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+		mu.Lock()
+		mutateSaw = append(mutateSaw, inflight)
+		mu.Unlock()
+		return ToolOutput{LLMContent: "mutated"}, nil
+	}
+	toolKinds["test_probe"] = toolReadOnly
+	t.Cleanup(func() {
+		delete(toolHandlers, "test_probe")
+		delete(toolHandlers, "test_mutate")
+		delete(toolKinds, "test_probe")
+	})
+
+	call := func(id, name, arg string) *llm.ToolCall {
+		return &llm.ToolCall{ID: id, Name: name, Input: toJSON(arg)}
+	}
+	calls := []*llm.ToolCall{
+		call("1", "test_probe", "a"),
+		call("2", "test_probe", "bad"),
+		call("3", "test_probe", "c"),
+		call("4", "test_mutate", ""),
+		call("5", "test_probe", "d"),
+	}
+
+	runs := q.runToolCalls(context.Background(), calls)
+	if len(runs) != len(calls) {
+		t.Fatalf("expected %d results, got %d", len(calls), len(runs))
+	}
+	want := []string{"a", "", "c", "mutated", "d"}
+	for i, r := range runs {
+		if r.call.ID != calls[i].ID {
+			t.Errorf("result %d is for call %s, want %s", i, r.call.ID, calls[i].ID)
+		}
+		if r.output.LLMContent != want[i] {
+			t.Errorf("result %d = %q, want %q", i, r.output.LLMContent, want[i])
+		}
+		if r.dur <= 0 {
+			t.Errorf("result %d has no timing", i)
+		}
+	}
+	if runs[1].err == nil {
+		t.Error("expected the failing probe to report its error")
+	}
+	if maxInflight < 2 {
+		t.Errorf("expected read-only calls to overlap, max in flight was %d", maxInflight)
+	}
+	if len(mutateSaw) != 1 || mutateSaw[0] != 0 {
+		t.Errorf("mutating call should run alone, saw %v calls in flight", mutateSaw)
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/HexSleeves/waggle/internal/blackboard"
 	"github.com/HexSleeves/waggle/internal/llm"
+	"github.com/HexSleeves/waggle/internal/state"
 	"github.com/HexSleeves/waggle/internal/task"
 	"github.com/HexSleeves/waggle/internal/worker"
 )
@@ -62,17 +64,29 @@ type ToolHandler func(ctx context.Context, q *Queen, input json.RawMessage) (Too
 
 // toolHandlers maps tool names to their handler functions.
 var toolHandlers = map[string]ToolHandler{
-	"create_tasks":     handleCreateTasks,
-	"assign_task":      handleAssignTask,
-	"get_status":       handleGetStatus,
-	"get_task_output":  handleGetTaskOutput,
-	"approve_task":     handleApproveTask,
-	"reject_task":      handleRejectTask,
-	"wait_for_workers": handleWaitForWorkers,
-	"read_file":        handleReadFile,
-	"list_files":       handleListFiles,
-	"complete":         handleComplete,
-	"fail":             handleFail,
+	"create_tasks":      handleCreateTasks,
+	"assign_task":       handleAssignTask,
+	"get_status":        handleGetStatus,
+	"get_task_output":   handleGetTaskOutput,
+	"approve_task":      handleApproveTask,
+	"reject_task":       handleRejectTask,
+	"cancel_task":       handleCancelTask,
+	"update_task":       handleUpdateTask,
+	"delete_task":       handleDeleteTask,
+	"wait_for_workers":  handleWaitForWorkers,
+	"read_file":         handleReadFile,
+	"list_files":        handleListFiles,
+	"search_code":       handleSearchCode,
+	"git_diff":          handleGitDiff,
+	"git_log":           handleGitLog,
+	"file_outline":      handleFileOutline,
+	"blackboard_read":   handleBlackboardRead,
+	"blackboard_write":  handleBlackboardWrite,
+	"blackboard_search": handleBlackboardSearch,
+	"recall":            handleRecall,
+	"ask_user":          handleAskUser,
+	"complete":          handleComplete,
+	"fail":              handleFail,
 }
 
 // executeTool runs a tool call and returns the result.
@@ -99,15 +113,18 @@ func queenTools() []llm.ToolDef {
 						"items": map[string]interface{}{
 							"type": "object",
 							"properties": map[string]interface{}{
-								"id":            map[string]interface{}{"type": "string", "description": "Unique task identifier"},
-								"title":         map[string]interface{}{"type": "string", "description": "Short task title"},
-								"description":   map[string]interface{}{"type": "string", "description": "Detailed task description"},
-								"type":          map[string]interface{}{"type": "string", "enum": []string{"code", "research", "test", "review", "generic"}},
-								"priority":      map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 3},
-								"depends_on":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-								"constraints":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-								"allowed_paths": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-								"max_retries":   map[string]interface{}{"type": "integer"},
+								"id":                      map[string]interface{}{"type": "string", "description": "Unique task identifier"},
+								"title":                   map[string]interface{}{"type": "string", "description": "Short task title"},
+								"description":             map[string]interface{}{"type": "string", "description": "Detailed task description"},
+								"type":                    map[string]interface{}{"type": "string", "enum": []string{"code", "research", "test", "review", "generic", "objective"}},
+								"priority":                map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 3},
+								"depends_on":              map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+								"constraints":             map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+								"allowed_paths":           map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+								"max_retries":             map[string]interface{}{"type": "integer"},
+								"cache":                   map[string]interface{}{"type": "boolean", "description": "Reuse a prior result when inputs are unchanged (default: project cache settings)"},
+								"max_turns":               map[string]interface{}{"type": "integer", "description": "Objective tasks only: turn budget of the child Queen (default: queen.sub_max_iterations)"},
+								"skip_dependency_results": map[string]interface{}{"type": "boolean", "description": "Do not pass the outputs of depends_on tasks to this task's worker (they are included by default)"},
 							},
 							"required": []string{"id", "title", "description", "type"},
 						},
@@ -137,11 +154,12 @@ func queenTools() []llm.ToolDef {
 		},
 		{
 			Name:        "get_task_output",
-			Description: "Get the output or error information from a completed or failed task.",
+			Description: "Get the output or error information from a completed or failed task. Pass attempt to inspect an earlier attempt of a retried task.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"task_id": map[string]interface{}{"type": "string", "description": "ID of the task"},
+					"attempt": map[string]interface{}{"type": "integer", "description": "Attempt number (1-based). Omit for the latest result."},
 				},
 				"required": []string{"task_id"},
 			},
@@ -170,6 +188,47 @@ func queenTools() []llm.ToolDef {
 				"required": []string{"task_id", "feedback"},
 			},
 		},
+		{
+			Name:        "cancel_task",
+			Description: "Cancel a task that is not yet complete. Kills its worker if running. Optionally cascade to every task that depends on it.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{"type": "string", "description": "ID of the task to cancel"},
+					"cascade": map[string]interface{}{"type": "boolean", "description": "Also cancel all dependent tasks (default false)"},
+					"reason":  map[string]interface{}{"type": "string", "description": "Optional reason, recorded on the blackboard"},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			Name:        "update_task",
+			Description: "Edit a pending task. Only the fields provided are changed. Dependency changes are re-checked for cycles.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id":         map[string]interface{}{"type": "string", "description": "ID of the pending task to edit"},
+					"description":     map[string]interface{}{"type": "string", "description": "Replacement description"},
+					"constraints":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Replacement constraints list"},
+					"depends_on":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Replacement dependency list"},
+					"priority":        map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 3},
+					"priority_delta":  map[string]interface{}{"type": "integer", "description": "Relative priority change (ignored when priority is set)"},
+					"timeout_seconds": map[string]interface{}{"type": "integer", "description": "Worker timeout in seconds"},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			Name:        "delete_task",
+			Description: "Remove a task that is not running from the task graph. Fails if other tasks still depend on it.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{"type": "string", "description": "ID of the task to delete"},
+				},
+				"required": []string{"task_id"},
+			},
+		},
 		{
 			Name:        "wait_for_workers",
 			Description: "Block until at least one running worker completes or fails. Returns summary of changes.",
@@ -204,6 +263,131 @@ func queenTools() []llm.ToolDef {
 				},
 			},
 		},
+		{
+			Name:        "search_code",
+			Description: "Search project files for a regular expression (respects .gitignore). Returns path:line: text matches, capped at max_results.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pattern":     map[string]interface{}{"type": "string", "description": "Regular expression (Go RE2 syntax)"},
+					"path":        map[string]interface{}{"type": "string", "description": "Directory to search, relative to project root (default: .)"},
+					"glob":        map[string]interface{}{"type": "string", "description": "Only search matching files, e.g. *.go or internal/**/*_test.go"},
+					"max_results": map[string]interface{}{"type": "integer", "description": "Maximum matches to return (default 50, max 200)"},
+					"ignore_case": map[string]interface{}{"type": "boolean", "description": "Case-insensitive match"},
+				},
+				"required": []string{"pattern"},
+			},
+		},
+		{
+			Name:        "git_diff",
+			Description: "Show uncommitted changes against HEAD, for the whole working tree, one path, or a task's allowed_paths.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{"type": "string", "description": "Limit the diff to this task's allowed_paths"},
+					"path":    map[string]interface{}{"type": "string", "description": "Limit the diff to this file or directory"},
+					"stat":    map[string]interface{}{"type": "boolean", "description": "Only show a per-file summary of changes"},
+				},
+			},
+		},
+		{
+			Name:        "git_log",
+			Description: "List recent commits (hash, date, author, subject), optionally for one path.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path":      map[string]interface{}{"type": "string", "description": "Only commits touching this file or directory"},
+					"max_count": map[string]interface{}{"type": "integer", "description": "Number of commits (default 10, max 50)"},
+				},
+			},
+		},
+		{
+			Name:        "file_outline",
+			Description: "List a source file's top-level symbols with line numbers (Go, Python, JS/TS, Rust, Java, Ruby). Cheaper than read_file for finding your way around.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{"type": "string", "description": "File path relative to project root"},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "blackboard_read",
+			Description: "Read the shared blackboard: one entry by key, entries by tag and/or task_id, or (with no arguments) the list of keys. Task results are posted as result-<task_id>.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"key":     map[string]interface{}{"type": "string", "description": "Exact entry key"},
+					"tag":     map[string]interface{}{"type": "string", "description": "Entries with this tag (e.g. result, feedback, workers)"},
+					"task_id": map[string]interface{}{"type": "string", "description": "Entries posted for this task"},
+				},
+			},
+		},
+		{
+			Name:        "blackboard_write",
+			Description: "Post a shared fact (convention, decision, file location) to the blackboard. With share_with_workers it is included in the prompts of tasks assigned afterwards, optionally only for some task types or for task_id.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"key":   map[string]interface{}{"type": "string", "description": "Entry key (e.g. conventions, db-schema); overwrites an entry with the same key"},
+					"value": map[string]interface{}{"type": "string", "description": "The content to share"},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional tags for later lookup (no commas)",
+					},
+					"task_id":            map[string]interface{}{"type": "string", "description": "Task this entry relates to (with share_with_workers, only that task sees it)"},
+					"share_with_workers": map[string]interface{}{"type": "boolean", "description": "Include this entry in worker prompts"},
+					"task_types": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "With share_with_workers, only share with these task types (e.g. code, test)",
+					},
+				},
+				"required": []string{"key", "value"},
+			},
+		},
+		{
+			Name:        "blackboard_search",
+			Description: "Search blackboard entries whose key, tags or text contain the query (case-insensitive).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string", "description": "Text to look for"},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "recall",
+			Description: "Look up the original text of conversation turns removed by compaction: tool inputs and results, your own messages and operator notes. Filter by keyword, tool name and/or task ID; newest matches first.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query":   map[string]interface{}{"type": "string", "description": "Text to look for (case-insensitive)"},
+					"tool":    map[string]interface{}{"type": "string", "description": "Only calls and results of this tool (e.g. read_file, reject_task)"},
+					"task_id": map[string]interface{}{"type": "string", "description": "Only messages about this task"},
+					"limit":   map[string]interface{}{"type": "integer", "description": "Maximum matches (default 10, max 30)"},
+				},
+			},
+		},
+		{
+			Name:        "ask_user",
+			Description: "Ask the user a question and wait for the answer. Use it sparingly, for decisions you cannot make from the objective and the code (e.g. ambiguous requirements). If nobody answers in time you get a default answer.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"question": map[string]interface{}{"type": "string", "description": "The question to ask"},
+					"options": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Suggested answers (optional; the user may still answer freely)",
+					},
+				},
+				"required": []string{"question"},
+			},
+		},
 		{
 			Name:        "complete",
 			Description: "Declare the objective done. Provide a summary of what was accomplished.",
@@ -245,6 +429,10 @@ type createTaskEntry struct {
 	Constraints  []string `json:"constraints"`
 	AllowedPaths []string `json:"allowed_paths"`
 	MaxRetries   int      `json:"max_retries"`
+	Cache        *bool    `json:"cache"`
+	MaxTurns     int      `json:"max_turns"` // objective tasks: child Queen turn budget
+
+	SkipDepResults bool `json:"skip_dependency_results"`
 }
 
 func handleCreateTasks(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
@@ -270,6 +458,9 @@ func handleCreateTasks(ctx context.Context, q *Queen, input json.RawMessage) (To
 		if te.Type == "" {
 			return ToolOutput{}, fmt.Errorf("task[%d]: type is required", i)
 		}
+		if task.Type(te.Type) == task.TypeObjective && q.depth >= q.cfg.Queen.MaxDepth {
+			return ToolOutput{}, fmt.Errorf("task[%d]: objective tasks are not allowed at nesting depth %d (max_depth %d); create regular tasks instead", i, q.depth, q.cfg.Queen.MaxDepth)
+		}
 		// Check for duplicate IDs with existing tasks
 		if _, exists := q.tasks.Get(te.ID); exists {
 			return ToolOutput{}, fmt.Errorf("task[%d]: id %q already exists in task graph", i, te.ID)
@@ -285,6 +476,7 @@ func handleCreateTasks(ctx context.Context, q *Queen, input json.RawMessage) (To
 		}
 		t := &task.Task{
 			ID:           te.ID,
+			ParentID:     q.parentTask,
 			Type:         task.Type(te.Type),
 			Status:       task.StatusPending,
 			Priority:     task.Priority(te.Priority),
@@ -296,6 +488,12 @@ func handleCreateTasks(ctx context.Context, q *Queen, input json.RawMessage) (To
 			MaxRetries:   maxRetries,
 			CreatedAt:    time.Now(),
 			Timeout:      q.cfg.Workers.DefaultTimeout,
+			Cache:        te.Cache,
+
+			SkipDepResults: te.SkipDepResults,
+		}
+		if te.MaxTurns > 0 {
+			t.Context = map[string]string{maxTurnsKey: strconv.Itoa(te.MaxTurns)}
 		}
 		created = append(created, t)
 	}
@@ -314,6 +512,14 @@ func handleCreateTasks(ctx context.Context, q *Queen, input json.RawMessage) (To
 		return ToolOutput{}, fmt.Errorf("cycle detected, tasks rolled back: %w", err)
 	}
 
+	// The first plan of a session may need the operator's approval
+	if err := q.approvePlan(ctx, created); err != nil {
+		for _, t := range created {
+			q.tasks.Remove(t.ID)
+		}
+		return ToolOutput{}, err
+	}
+
 	// Persist to DB (tasks already added to graph above for cycle detection)
 	for _, t := range created {
 		if err := q.db.InsertTask(ctx, q.sessionID, taskToRow(t)); err != nil {
@@ -373,23 +579,47 @@ func handleAssignTask(ctx context.Context, q *Queen, input json.RawMessage) (Too
 		}
 	}
 
-	// Check pool capacity
-	if q.pool.ActiveCount() >= q.cfg.Workers.MaxParallel {
-		return ToolOutput{}, fmt.Errorf("max parallel workers (%d) reached, wait for a worker to finish", q.cfg.Workers.MaxParallel)
-	}
-
-	adapterName := q.router.Route(t)
+	adapterName := q.routeTask(t)
 	if adapterName == "" {
 		return ToolOutput{}, fmt.Errorf("no adapter available for task type %s", t.Type)
 	}
 
 	// Inject default scope constraints (shared with delegate())
 	injectDefaultConstraints(t)
+	q.prepareWorker(ctx, t)
+
+	// Reuse a prior result when the task's inputs are unchanged
+	cacheKey, cached := q.checkCache(ctx, t, adapterName)
+	if cached != nil && q.completeFromCache(ctx, t, cached) {
+		llmContent := fmt.Sprintf("Task %q completed from cache (result of task %s in session %s, cached %s). No worker was spawned; use get_task_output to review it.",
+			t.ID, cached.TaskID, cached.SessionID, cached.CreatedAt)
+		return ToolOutput{LLMContent: llmContent, Display: fmt.Sprintf("Cached: %s", t.Title)}, nil
+	}
 
-	bee, err := q.pool.Spawn(ctx, t, adapterName)
+	if err := q.checkTaskBudget(t); err != nil {
+		return ToolOutput{}, err
+	}
+
+	// Serialize overlapping path claims
+	if hold := q.holdFor(t); hold != nil {
+		q.refreshHolds()
+		return ToolOutput{}, fmt.Errorf("task %q is held: %s; assign other ready tasks or wait_for_workers and retry", in.TaskID, hold)
+	}
+
+	// Check pool capacity (a child Queen does not take a worker slot)
+	if t.Type != task.TypeObjective && q.pool.ActiveCount() >= q.cfg.Workers.MaxParallel {
+		return ToolOutput{}, fmt.Errorf("max parallel workers (%d) reached, wait for a worker to finish", q.cfg.Workers.MaxParallel)
+	}
+
+	if err := q.approveAssign(ctx, t, adapterName); err != nil {
+		return ToolOutput{}, err
+	}
+
+	bee, err := q.spawnWorker(ctx, t, adapterName)
 	if err != nil {
 		return ToolOutput{}, fmt.Errorf("spawn worker: %w", err)
 	}
+	q.rememberCacheKey(bee.ID(), cacheKey, adapterName)
 
 	q.mu.Lock()
 	q.assignments[bee.ID()] = t.ID
@@ -406,6 +636,8 @@ func handleAssignTask(ctx context.Context, q *Queen, input json.RawMessage) (Too
 	if err := q.db.UpdateTaskWorker(ctx, q.sessionID, t.ID, bee.ID()); err != nil {
 		q.logger.Printf("⚠ Warning: failed to update task worker: %v", err)
 	}
+	q.startAttempt(ctx, t, bee.ID(), adapterName)
+	q.refreshHolds()
 
 	llmContent := fmt.Sprintf("Task %q assigned to worker %s (adapter: %s)", t.ID, bee.ID(), adapterName)
 	display := fmt.Sprintf("Assigned: %s → %s", t.Title, bee.ID())
@@ -424,6 +656,16 @@ func handleGetStatus(ctx context.Context, q *Queen, input json.RawMessage) (Tool
 		Status   string `json:"status"`
 		WorkerID string `json:"worker_id,omitempty"`
 		Priority int    `json:"priority"`
+		HeldBy   string `json:"held_by,omitempty"`
+		Critical bool   `json:"critical,omitempty"`
+		Slack    string `json:"slack,omitempty"`
+	}
+
+	holds := q.currentHolds()
+	cp := q.criticalPath(ctx)
+	heldBy := make(map[string]string, len(holds))
+	for _, h := range holds {
+		heldBy[h.TaskID] = h.BlockedBy
 	}
 
 	infos := make([]taskInfo, 0, len(allTasks))
@@ -437,7 +679,12 @@ func handleGetStatus(ctx context.Context, q *Queen, input json.RawMessage) (Tool
 			Status:   string(status),
 			WorkerID: t.GetWorkerID(),
 			Priority: int(t.Priority),
+			HeldBy:   heldBy[t.ID],
+			Critical: cp.IsCritical(t.ID),
 		})
+		if s, ok := cp.Slack[t.ID]; ok && s > 0 {
+			infos[len(infos)-1].Slack = task.FormatEstimate(s)
+		}
 		counts[string(status)]++
 	}
 
@@ -447,6 +694,14 @@ func handleGetStatus(ctx context.Context, q *Queen, input json.RawMessage) (Tool
 		"active_workers": q.pool.ActiveCount(),
 		"phase":          string(q.phase),
 	}
+	if len(holds) > 0 {
+		result["held_tasks"] = holds
+	}
+	eta := cp.ETA(q.cfg.Workers.MaxParallel)
+	if len(cp.Path) > 0 {
+		result["critical_path"] = cp.Path
+		result["eta"] = task.FormatEstimate(eta)
+	}
 
 	b, _ := json.MarshalIndent(result, "", "  ")
 
@@ -458,6 +713,9 @@ func handleGetStatus(ctx context.Context, q *Queen, input json.RawMessage) (Tool
 		parts = append(parts, fmt.Sprintf("%s:%d", status, count))
 	}
 	display.WriteString(strings.Join(parts, " "))
+	if len(cp.Path) > 0 {
+		fmt.Fprintf(&display, " | ETA ~%s", task.FormatEstimate(eta))
+	}
 
 	return ToolOutput{LLMContent: string(b), Display: display.String()}, nil
 }
@@ -465,7 +723,8 @@ func handleGetStatus(ctx context.Context, q *Queen, input json.RawMessage) (Tool
 // ---------- get_task_output ----------
 
 type getTaskOutputInput struct {
-	TaskID string `json:"task_id"`
+	TaskID  string `json:"task_id"`
+	Attempt int    `json:"attempt"`
 }
 
 func handleGetTaskOutput(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
@@ -482,6 +741,14 @@ func handleGetTaskOutput(ctx context.Context, q *Queen, input json.RawMessage) (
 		return ToolOutput{}, fmt.Errorf("task %q not found", in.TaskID)
 	}
 
+	attempts, err := q.db.ListAttempts(ctx, q.sessionID, in.TaskID)
+	if err != nil {
+		q.logger.Printf("⚠ Warning: failed to load task attempts: %v", err)
+	}
+	if in.Attempt != 0 {
+		return attemptOutput(q, t, attempts, in.Attempt)
+	}
+
 	status := t.GetStatus()
 	if status != task.StatusComplete && status != task.StatusFailed {
 		return ToolOutput{LLMContent: fmt.Sprintf("Task %q is still %s — no output yet.", in.TaskID, status)}, nil
@@ -491,6 +758,9 @@ func handleGetTaskOutput(ctx context.Context, q *Queen, input json.RawMessage) (
 	if result != nil {
 		var b strings.Builder
 		fmt.Fprintf(&b, "Task: %s (%s)\nStatus: %s\nSuccess: %v\n", t.Title, t.ID, status, result.Success)
+		if from := result.Artifacts[ArtifactCachedFrom]; from != "" {
+			fmt.Fprintf(&b, "Source: result cache (originally produced by %s)\n", from)
+		}
 		if result.Output != "" {
 			fmt.Fprintf(&b, "Output:\n%s\n", result.Output)
 		}
@@ -500,6 +770,12 @@ func handleGetTaskOutput(ctx context.Context, q *Queen, input json.RawMessage) (
 				fmt.Fprintf(&b, "  - %s\n", e)
 			}
 		}
+		if len(attempts) > 0 {
+			b.WriteString(formatReview(attempts[len(attempts)-1].Review))
+		}
+		if len(attempts) > 1 {
+			fmt.Fprintf(&b, "Attempts (use attempt=N for details):\n%s", formatAttemptHistory(attempts))
+		}
 		output := b.String()
 		output = truncateLargeOutput(output, in.TaskID, q.cfg.HivePath())
 		return ToolOutput{LLMContent: output}, nil
@@ -516,6 +792,44 @@ func handleGetTaskOutput(ctx context.Context, q *Queen, input json.RawMessage) (
 	return ToolOutput{LLMContent: fmt.Sprintf("Task %q (%s) has no output available.", in.TaskID, status)}, nil
 }
 
+// attemptOutput renders a single recorded attempt of a task.
+func attemptOutput(q *Queen, t *task.Task, attempts []state.AttemptRow, n int) (ToolOutput, error) {
+	if n < 1 || n > len(attempts) {
+		return ToolOutput{}, fmt.Errorf("task %q has %d recorded attempt(s), attempt %d does not exist", t.ID, len(attempts), n)
+	}
+	a := attempts[n-1]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task: %s (%s)\nAttempt: %d/%d\nStatus: %s\n", t.Title, t.ID, a.Attempt, len(attempts), a.Status)
+	if a.WorkerID != "" {
+		fmt.Fprintf(&b, "Worker: %s (adapter: %s)\n", a.WorkerID, a.Adapter)
+	}
+	fmt.Fprintf(&b, "Started: %s\n", a.StartedAt)
+	if a.EndedAt != "" {
+		fmt.Fprintf(&b, "Ended: %s\n", a.EndedAt)
+	}
+	if a.ErrorType != "" || a.Error != "" {
+		fmt.Fprintf(&b, "Error (%s): %s\n", a.ErrorType, a.Error)
+	}
+	if a.Feedback != "" {
+		fmt.Fprintf(&b, "Review feedback: %s\n", a.Feedback)
+	}
+	b.WriteString(formatReview(a.Review))
+	if a.Metrics != "" {
+		fmt.Fprintf(&b, "Metrics: %s\n", a.Metrics)
+	}
+	if a.OutputPath != "" {
+		// Large outputs were already spilled to disk when the attempt finished.
+		fmt.Fprintf(&b, "Output (first %d bytes, full output in %s -- use read_file):\n%s\n", len(a.Output), a.OutputPath, a.Output)
+		return ToolOutput{LLMContent: b.String()}, nil
+	}
+	if a.Output != "" {
+		fmt.Fprintf(&b, "Output:\n%s\n", a.Output)
+	}
+	name := fmt.Sprintf("%s-attempt-%d", t.ID, a.Attempt)
+	return ToolOutput{LLMContent: truncateLargeOutput(b.String(), name, q.cfg.HivePath())}, nil
+}
+
 // ---------- approve_task ----------
 
 type approveTaskInput struct {
@@ -592,6 +906,9 @@ func handleRejectTask(ctx context.Context, q *Queen, input json.RawMessage) (Too
 		q.logger.Printf("⚠ Warning: failed to update task retry count: %v", err)
 	}
 
+	q.recordAttemptFeedback(ctx, in.TaskID, in.Feedback)
+	q.invalidateCache(ctx, in.TaskID)
+
 	// Post feedback to blackboard
 	q.board.Post(&blackboard.Entry{
 		Key:      fmt.Sprintf("rejection-%s-%d", in.TaskID, newCount),
@@ -605,6 +922,245 @@ func handleRejectTask(ctx context.Context, q *Queen, input json.RawMessage) (Too
 		in.TaskID, newCount, t.MaxRetries)}, nil
 }
 
+// ---------- cancel_task ----------
+
+type cancelTaskInput struct {
+	TaskID  string `json:"task_id"`
+	Cascade bool   `json:"cascade"`
+	Reason  string `json:"reason"`
+}
+
+func handleCancelTask(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in cancelTaskInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if in.TaskID == "" {
+		return ToolOutput{}, fmt.Errorf("task_id is required")
+	}
+
+	t, ok := q.tasks.Get(in.TaskID)
+	if !ok {
+		return ToolOutput{}, fmt.Errorf("task %q not found", in.TaskID)
+	}
+	switch t.GetStatus() {
+	case task.StatusComplete, task.StatusCancelled:
+		return ToolOutput{}, fmt.Errorf("task %q is already %s", in.TaskID, t.GetStatus())
+	}
+
+	// Walk dependents breadth-first when cascading; otherwise only report them.
+	targets := []*task.Task{t}
+	var blocked []string
+	seen := map[string]bool{t.ID: true}
+	for i := 0; i < len(targets); i++ {
+		for _, dep := range q.tasks.Dependents(targets[i].ID) {
+			if seen[dep.ID] {
+				continue
+			}
+			seen[dep.ID] = true
+			st := dep.GetStatus()
+			if st == task.StatusComplete || st == task.StatusCancelled {
+				continue
+			}
+			if in.Cascade {
+				targets = append(targets, dep)
+			} else {
+				blocked = append(blocked, dep.ID)
+			}
+		}
+	}
+
+	var cancelled []string
+	for _, ct := range targets {
+		q.cancelTask(ctx, ct)
+		cancelled = append(cancelled, ct.ID)
+	}
+
+	if in.Reason != "" {
+		q.board.Post(&blackboard.Entry{
+			Key:      fmt.Sprintf("cancellation-%s", in.TaskID),
+			Value:    in.Reason,
+			PostedBy: "queen",
+			TaskID:   in.TaskID,
+			Tags:     []string{"cancellation"},
+		})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cancelled %d task(s): %s", len(cancelled), strings.Join(cancelled, ", "))
+	if len(blocked) > 0 {
+		fmt.Fprintf(&b, "\nWARNING: these tasks depend on %q and can no longer run: %s. Cancel, update or delete them.",
+			in.TaskID, strings.Join(blocked, ", "))
+	}
+	display := fmt.Sprintf("Cancelled: %s", strings.Join(cancelled, ", "))
+	return ToolOutput{LLMContent: b.String(), Display: display}, nil
+}
+
+// cancelTask kills the task's worker (if any) and marks it cancelled in the
+// graph and the DB.
+func (q *Queen) cancelTask(ctx context.Context, t *task.Task) {
+	if t.GetStatus() == task.StatusRunning {
+		if workerID := t.GetWorkerID(); workerID != "" {
+			q.mu.Lock()
+			delete(q.assignments, workerID)
+			q.mu.Unlock()
+			q.finishAttempt(ctx, workerID, AttemptCancelled, "", nil)
+			if bee, ok := q.pool.Get(workerID); ok {
+				if err := bee.Kill(); err != nil {
+					q.logger.Printf("⚠ Warning: failed to kill worker %s: %v", workerID, err)
+				}
+			}
+		}
+	}
+
+	if err := q.tasks.UpdateStatus(t.ID, task.StatusCancelled); err != nil {
+		q.logger.Printf("⚠ Warning: failed to update task status: %v", err)
+	}
+	if err := q.db.UpdateTaskStatus(ctx, q.sessionID, t.ID, string(task.StatusCancelled)); err != nil {
+		q.logger.Printf("⚠ Warning: failed to update task status: %v", err)
+	}
+}
+
+// ---------- update_task ----------
+
+type updateTaskInput struct {
+	TaskID         string    `json:"task_id"`
+	Description    *string   `json:"description"`
+	Constraints    *[]string `json:"constraints"`
+	DependsOn      *[]string `json:"depends_on"`
+	Priority       *int      `json:"priority"`
+	PriorityDelta  int       `json:"priority_delta"`
+	TimeoutSeconds *int      `json:"timeout_seconds"`
+}
+
+func handleUpdateTask(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in updateTaskInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if in.TaskID == "" {
+		return ToolOutput{}, fmt.Errorf("task_id is required")
+	}
+
+	t, ok := q.tasks.Get(in.TaskID)
+	if !ok {
+		return ToolOutput{}, fmt.Errorf("task %q not found", in.TaskID)
+	}
+	if t.GetStatus() != task.StatusPending {
+		return ToolOutput{}, fmt.Errorf("task %q is not pending (current status: %s)", in.TaskID, t.GetStatus())
+	}
+
+	// Validate everything before mutating so a bad field leaves the task untouched.
+	priority := t.GetPriority()
+	if in.Priority != nil {
+		priority = task.Priority(*in.Priority)
+	} else if in.PriorityDelta != 0 {
+		priority += task.Priority(in.PriorityDelta)
+		if priority < task.PriorityLow {
+			priority = task.PriorityLow
+		}
+		if priority > task.PriorityCritical {
+			priority = task.PriorityCritical
+		}
+	}
+	if priority < task.PriorityLow || priority > task.PriorityCritical {
+		return ToolOutput{}, fmt.Errorf("priority must be between %d and %d", task.PriorityLow, task.PriorityCritical)
+	}
+	if in.Description != nil && strings.TrimSpace(*in.Description) == "" {
+		return ToolOutput{}, fmt.Errorf("description must not be empty")
+	}
+	if in.TimeoutSeconds != nil && *in.TimeoutSeconds <= 0 {
+		return ToolOutput{}, fmt.Errorf("timeout_seconds must be positive")
+	}
+	if in.DependsOn != nil {
+		for _, depID := range *in.DependsOn {
+			if depID == in.TaskID {
+				return ToolOutput{}, fmt.Errorf("task %q cannot depend on itself", in.TaskID)
+			}
+			if _, exists := q.tasks.Get(depID); !exists {
+				return ToolOutput{}, fmt.Errorf("dependency %q not found", depID)
+			}
+		}
+	}
+
+	var changed []string
+	if in.DependsOn != nil {
+		if err := q.tasks.SetDependsOn(t.ID, *in.DependsOn); err != nil {
+			return ToolOutput{}, fmt.Errorf("cycle detected, dependencies unchanged: %w", err)
+		}
+		changed = append(changed, "depends_on")
+	}
+	if in.Description != nil {
+		t.SetDescription(*in.Description)
+		changed = append(changed, "description")
+	}
+	if in.Constraints != nil {
+		t.SetConstraints(*in.Constraints)
+		changed = append(changed, "constraints")
+	}
+	if priority != t.GetPriority() {
+		t.SetPriority(priority)
+		changed = append(changed, "priority")
+	}
+	if in.TimeoutSeconds != nil {
+		t.SetTimeout(time.Duration(*in.TimeoutSeconds) * time.Second)
+		changed = append(changed, "timeout")
+	}
+
+	if len(changed) == 0 {
+		return ToolOutput{LLMContent: fmt.Sprintf("Task %q unchanged: no fields provided.", in.TaskID)}, nil
+	}
+
+	if err := q.db.UpdateTaskPlan(ctx, q.sessionID, taskToRow(t)); err != nil {
+		q.logger.Printf("⚠ Warning: failed to update task: %v", err)
+	}
+
+	llmContent := fmt.Sprintf("Task %q updated (%s). Priority: %d", in.TaskID, strings.Join(changed, ", "), t.GetPriority())
+	display := fmt.Sprintf("Updated: %s (%s)", t.Title, strings.Join(changed, ", "))
+	return ToolOutput{LLMContent: llmContent, Display: display}, nil
+}
+
+// ---------- delete_task ----------
+
+type deleteTaskInput struct {
+	TaskID string `json:"task_id"`
+}
+
+func handleDeleteTask(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in deleteTaskInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if in.TaskID == "" {
+		return ToolOutput{}, fmt.Errorf("task_id is required")
+	}
+
+	t, ok := q.tasks.Get(in.TaskID)
+	if !ok {
+		return ToolOutput{}, fmt.Errorf("task %q not found", in.TaskID)
+	}
+	if st := t.GetStatus(); st == task.StatusRunning || st == task.StatusAssigned {
+		return ToolOutput{}, fmt.Errorf("task %q is %s; cancel it before deleting", in.TaskID, st)
+	}
+	if deps := q.tasks.Dependents(in.TaskID); len(deps) > 0 {
+		ids := make([]string, 0, len(deps))
+		for _, d := range deps {
+			ids = append(ids, d.ID)
+		}
+		return ToolOutput{}, fmt.Errorf("task %q is a dependency of %s; update or delete those first", in.TaskID, strings.Join(ids, ", "))
+	}
+
+	q.tasks.Remove(in.TaskID)
+	if err := q.db.DeleteTask(ctx, q.sessionID, in.TaskID); err != nil {
+		q.logger.Printf("⚠ Warning: failed to delete task: %v", err)
+	}
+
+	return ToolOutput{
+		LLMContent: fmt.Sprintf("Task %q (%s) deleted.", in.TaskID, t.Title),
+		Display:    fmt.Sprintf("Deleted: %s", t.Title),
+	}, nil
+}
+
 // ---------- wait_for_workers ----------
 
 type waitForWorkersInput struct {
@@ -656,6 +1212,13 @@ func handleWaitForWorkers(ctx context.Context, q *Queen, input json.RawMessage)
 		case <-timer.C:
 			return ToolOutput{LLMContent: "Timeout reached. No workers completed during the wait period."}, nil
 		case <-ticker.C:
+			// Operator requests should not wait for a worker to finish. They
+			// stay queued for injectControlNotes, which delivers them as a
+			// user message at the start of the next turn.
+			if q.controlsPending(ctx) {
+				return ToolOutput{LLMContent: "Operator input pending; it will be delivered on your next turn."}, nil
+			}
+
 			// Check if any task changed status
 			var changed []string
 			for taskID, oldStatus := range runningBefore {
@@ -737,10 +1300,12 @@ func (q *Queen) processWorkerResults(ctx context.Context) {
 			if err := q.db.UpdateTaskResult(ctx, q.sessionID, taskID, result); err != nil {
 				q.logger.Printf("⚠ Warning: failed to update task result: %v", err)
 			}
+			q.finishAttempt(ctx, workerID, AttemptSucceeded, "", result)
+			q.storeCachedResult(ctx, workerID, t, result)
 
 			// Post to blackboard
 			bbKey := fmt.Sprintf("result-%s", taskID)
-			q.board.Post(&blackboard.Entry{
+			q.postBoard(ctx, &blackboard.Entry{
 				Key:      bbKey,
 				Value:    result.Output,
 				PostedBy: workerID,
@@ -756,6 +1321,8 @@ func (q *Queen) processWorkerResults(ctx context.Context) {
 		delete(q.assignments, workerID)
 		q.mu.Unlock()
 	}
+
+	q.refreshHolds()
 }
 
 // ---------- read_file ----------
@@ -893,6 +1460,29 @@ func handleListFiles(ctx context.Context, q *Queen, input json.RawMessage) (Tool
 	return ToolOutput{LLMContent: strings.Join(files, "\n")}, nil
 }
 
+// ---------- ask_user ----------
+
+type askUserInput struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+func handleAskUser(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutput, error) {
+	var in askUserInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolOutput{}, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(in.Question) == "" {
+		return ToolOutput{}, fmt.Errorf("question is required")
+	}
+
+	answer := q.ask(ctx, Question{Kind: QuestionAsk, Prompt: in.Question, Options: in.Options})
+	return ToolOutput{
+		LLMContent: fmt.Sprintf("The user answered: %s", answer.Text),
+		Display:    fmt.Sprintf("Asked: %s → %s", in.Question, answer.Text),
+	}, nil
+}
+
 // ---------- complete ----------
 
 type completeInput struct {
@@ -907,8 +1497,12 @@ func handleComplete(ctx context.Context, q *Queen, input json.RawMessage) (ToolO
 	if in.Summary == "" {
 		return ToolOutput{}, fmt.Errorf("summary is required")
 	}
+	if err := q.approveComplete(ctx, in.Summary); err != nil {
+		return ToolOutput{}, err
+	}
 
 	q.setPhase(PhaseDone)
+	q.setSummary(in.Summary)
 	if err := q.db.UpdateSessionStatus(ctx, q.sessionID, "done"); err != nil {
 		q.logger.Printf("⚠ Warning: failed to update session status: %v", err)
 	}
@@ -976,6 +1570,7 @@ func handleFail(ctx context.Context, q *Queen, input json.RawMessage) (ToolOutpu
 	}
 
 	q.setPhase(PhaseFailed)
+	q.setSummary(in.Reason)
 	if err := q.db.UpdateSessionStatus(ctx, q.sessionID, "failed"); err != nil {
 		q.logger.Printf("⚠ Warning: failed to update session status: %v", err)
 	}
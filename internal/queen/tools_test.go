@@ -106,8 +106,9 @@ func TestQueenToolsReturnsAllTools(t *testing.T) {
 	tools := queenTools()
 	expected := []string{
 		"create_tasks", "assign_task", "get_status", "get_task_output",
-		"approve_task", "reject_task", "wait_for_workers",
-		"read_file", "list_files", "complete", "fail",
+		"approve_task", "reject_task", "cancel_task", "update_task", "delete_task",
+		"wait_for_workers",
+		"read_file", "list_files", "search_code", "git_diff", "git_log", "file_outline", "blackboard_read", "blackboard_write", "blackboard_search", "recall", "ask_user", "complete", "fail",
 	}
 	if len(tools) != len(expected) {
 		t.Fatalf("expected %d tools, got %d", len(expected), len(tools))
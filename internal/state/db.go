@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -153,16 +154,131 @@ func (s *DB) migrate() error {
 		FOREIGN KEY (session_id) REFERENCES sessions(id)
 	);
 	CREATE INDEX IF NOT EXISTS idx_messages_session ON messages(session_id);
+
+	CREATE TABLE IF NOT EXISTS control_requests (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id  TEXT NOT NULL,
+		kind        TEXT NOT NULL,
+		payload     TEXT,
+		status      TEXT NOT NULL DEFAULT 'pending',
+		result      TEXT,
+		created_at  TEXT NOT NULL,
+		applied_at  TEXT,
+		FOREIGN KEY (session_id) REFERENCES sessions(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_control_session ON control_requests(session_id, status);
+
+	CREATE TABLE IF NOT EXISTS task_attempts (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id  TEXT NOT NULL,
+		task_id     TEXT NOT NULL,
+		attempt     INTEGER NOT NULL,
+		worker_id   TEXT,
+		adapter     TEXT,
+		status      TEXT NOT NULL DEFAULT 'running',
+		error_type  TEXT,
+		error       TEXT,
+		output      TEXT,
+		output_path TEXT,
+		feedback    TEXT,
+		metrics     TEXT,
+		started_at  TEXT NOT NULL,
+		ended_at    TEXT,
+		UNIQUE(session_id, task_id, attempt),
+		FOREIGN KEY (session_id) REFERENCES sessions(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_attempts_task ON task_attempts(session_id, task_id);
+
+	CREATE TABLE IF NOT EXISTS result_cache (
+		key         TEXT PRIMARY KEY,
+		task_type   TEXT NOT NULL,
+		title       TEXT,
+		adapter     TEXT,
+		result      TEXT NOT NULL,
+		session_id  TEXT,
+		task_id     TEXT,
+		hits        INTEGER NOT NULL DEFAULT 0,
+		created_at  TEXT NOT NULL,
+		last_hit_at TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_cache_task ON result_cache(session_id, task_id);
+
+	CREATE TABLE IF NOT EXISTS costs (
+		id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id         TEXT NOT NULL,
+		task_id            TEXT,
+		turn               INTEGER NOT NULL DEFAULT 0,
+		source             TEXT NOT NULL,
+		model              TEXT,
+		input_tokens       INTEGER NOT NULL DEFAULT 0,
+		output_tokens      INTEGER NOT NULL DEFAULT 0,
+		cache_read_tokens  INTEGER NOT NULL DEFAULT 0,
+		cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+		cost_usd           REAL NOT NULL DEFAULT 0,
+		created_at         TEXT NOT NULL,
+		FOREIGN KEY (session_id) REFERENCES sessions(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_costs_session ON costs(session_id, task_id);
+
+	CREATE TABLE IF NOT EXISTS compactions (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id      TEXT NOT NULL,
+		turn            INTEGER NOT NULL DEFAULT 0,
+		messages_before INTEGER NOT NULL,
+		messages_after  INTEGER NOT NULL,
+		tokens_before   INTEGER NOT NULL DEFAULT 0,
+		context_window  INTEGER NOT NULL DEFAULT 0,
+		summary         TEXT,
+		created_at      TEXT NOT NULL,
+		FOREIGN KEY (session_id) REFERENCES sessions(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_compactions_session ON compactions(session_id);
+
+	CREATE TABLE IF NOT EXISTS message_index (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id  TEXT NOT NULL,
+		sequence_id INTEGER NOT NULL,
+		role        TEXT NOT NULL,
+		tool_name   TEXT,
+		task_ids    TEXT,
+		content     TEXT NOT NULL,
+		created_at  TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_message_index_tool ON message_index(session_id, tool_name);
+	CREATE INDEX IF NOT EXISTS idx_message_index_seq ON message_index(session_id, sequence_id);
+
+	CREATE TABLE IF NOT EXISTS review_votes (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id  TEXT NOT NULL,
+		task_id     TEXT NOT NULL,
+		attempt     INTEGER NOT NULL DEFAULT 0,
+		reviewer    TEXT NOT NULL,
+		approved    INTEGER NOT NULL,
+		score       INTEGER NOT NULL DEFAULT 0,
+		outcome     INTEGER NOT NULL,
+		disputed    INTEGER NOT NULL DEFAULT 0,
+		created_at  TEXT NOT NULL,
+		FOREIGN KEY (session_id) REFERENCES sessions(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_review_votes_reviewer ON review_votes(reviewer);
 	`
 	_, err := s.writer.Exec(ddl)
 	if err != nil {
 		return err
 	}
 
-	// Add columns for task constraints/context/allowed_paths (idempotent).
+	// Add columns for task constraints/context/allowed_paths and nested
+	// session linkage (idempotent).
 	for _, col := range []string{
 		"ALTER TABLE tasks ADD COLUMN constraints TEXT",
 		"ALTER TABLE tasks ADD COLUMN allowed_paths TEXT",
+		"ALTER TABLE tasks ADD COLUMN cache_policy TEXT",
+		"ALTER TABLE tasks ADD COLUMN skip_dep_results INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE tasks ADD COLUMN dep_results TEXT",
+		"ALTER TABLE sessions ADD COLUMN parent_id TEXT",
+		"ALTER TABLE sessions ADD COLUMN parent_task_id TEXT",
+		"ALTER TABLE costs ADD COLUMN purpose TEXT",
+		"ALTER TABLE task_attempts ADD COLUMN review TEXT",
 	} {
 		_, _ = s.writer.Exec(col) // ignore "duplicate column" errors
 	}
@@ -216,6 +332,16 @@ func (s *DB) StopSession(id string) error {
 	return err
 }
 
+// SetSessionParent links a nested Queen's session to the parent session and
+// the parent task it is working on.
+func (s *DB) SetSessionParent(ctx context.Context, id, parentID, parentTaskID string) error {
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE sessions SET parent_id = ?, parent_task_id = ? WHERE id = ?`,
+		parentID, parentTaskID, id,
+	)
+	return err
+}
+
 type SessionInfo struct {
 	ID        string `json:"id"`
 	Objective string `json:"objective"`
@@ -234,7 +360,7 @@ func (s *DB) GetSession(ctx context.Context, id string) (*SessionInfo, error) {
 }
 
 func (s *DB) LatestSession(ctx context.Context) (*SessionInfo, error) {
-	row := s.reader.QueryRowContext(ctx, `SELECT id, objective, status, created_at, updated_at FROM sessions ORDER BY created_at DESC LIMIT 1`)
+	row := s.reader.QueryRowContext(ctx, `SELECT id, objective, status, created_at, updated_at FROM sessions WHERE parent_id IS NULL ORDER BY created_at DESC LIMIT 1`)
 	var si SessionInfo
 	if err := row.Scan(&si.ID, &si.Objective, &si.Status, &si.CreatedAt, &si.UpdatedAt); err != nil {
 		return nil, err
@@ -243,12 +369,13 @@ func (s *DB) LatestSession(ctx context.Context) (*SessionInfo, error) {
 }
 
 // FindResumableSession returns the most recent session that is not 'done' and can be resumed.
-// It excludes sessions with status 'done' or 'cancelled'.
+// It excludes sessions with status 'done' or 'cancelled', and nested sessions
+// (those are resumed by re-running their parent task).
 func (s *DB) FindResumableSession(ctx context.Context) (*SessionInfo, error) {
 	row := s.reader.QueryRowContext(ctx, `
 		SELECT id, objective, status, created_at, updated_at
 		FROM sessions
-		WHERE status NOT IN ('done', 'cancelled')
+		WHERE status NOT IN ('done', 'cancelled') AND parent_id IS NULL
 		ORDER BY created_at DESC LIMIT 1`)
 	var si SessionInfo
 	if err := row.Scan(&si.ID, &si.Objective, &si.Status, &si.CreatedAt, &si.UpdatedAt); err != nil {
@@ -339,36 +466,42 @@ func (s *DB) EventCount(ctx context.Context, sessionID string) (int, error) {
 // --- Task operations ---
 
 type TaskRow struct {
-	ID           string  `json:"id"`
-	SessionID    string  `json:"session_id"`
-	Type         string  `json:"type"`
-	Status       string  `json:"status"`
-	Priority     int     `json:"priority"`
-	Title        string  `json:"title"`
-	Description  string  `json:"description"`
-	Constraints  string  `json:"constraints,omitempty"`   // JSON array of strings
-	Context      string  `json:"context,omitempty"`       // JSON object of key-value pairs
-	AllowedPaths string  `json:"allowed_paths,omitempty"` // JSON array of strings
-	WorkerID     *string `json:"worker_id,omitempty"`
-	Result       *string `json:"result,omitempty"`
-	ResultData   *string `json:"result_data,omitempty"`
-	MaxRetries   int     `json:"max_retries"`
-	RetryCount   int     `json:"retry_count"`
-	DependsOn    string  `json:"depends_on"`
-	CreatedAt    string  `json:"created_at"`
-	StartedAt    *string `json:"started_at,omitempty"`
-	CompletedAt  *string `json:"completed_at,omitempty"`
+	ID             string  `json:"id"`
+	SessionID      string  `json:"session_id"`
+	ParentID       string  `json:"parent_id,omitempty"` // parent-session task a nested Queen's task belongs to
+	Type           string  `json:"type"`
+	Status         string  `json:"status"`
+	Priority       int     `json:"priority"`
+	Title          string  `json:"title"`
+	Description    string  `json:"description"`
+	Constraints    string  `json:"constraints,omitempty"`   // JSON array of strings
+	Context        string  `json:"context,omitempty"`       // JSON object of key-value pairs
+	AllowedPaths   string  `json:"allowed_paths,omitempty"` // JSON array of strings
+	CachePolicy    string  `json:"cache_policy,omitempty"`  // "on", "off" or "" (follow config)
+	SkipDepResults bool    `json:"skip_dep_results,omitempty"`
+	DepResults     string  `json:"dep_results,omitempty"` // dependency results last handed to the worker
+	WorkerID       *string `json:"worker_id,omitempty"`
+	Result         *string `json:"result,omitempty"`
+	ResultData     *string `json:"result_data,omitempty"`
+	MaxRetries     int     `json:"max_retries"`
+	RetryCount     int     `json:"retry_count"`
+	DependsOn      string  `json:"depends_on"`
+	TimeoutNs      int64   `json:"timeout_ns,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	StartedAt      *string `json:"started_at,omitempty"`
+	CompletedAt    *string `json:"completed_at,omitempty"`
 }
 
 func (s *DB) InsertTask(ctx context.Context, sessionID string, t TaskRow) error {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	_, err := s.writer.ExecContext(ctx,
 		`INSERT OR REPLACE INTO tasks
-		(id, session_id, type, status, priority, title, description, constraints, allowed_paths, context, max_retries, retry_count, depends_on, timeout_ns, created_at, result_data)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		t.ID, sessionID, t.Type, t.Status, t.Priority, t.Title, t.Description,
+		(id, session_id, parent_id, type, status, priority, title, description, constraints, allowed_paths, context, max_retries, retry_count, depends_on, timeout_ns, created_at, result_data, cache_policy, skip_dep_results, dep_results)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, sessionID, nilIfEmpty(t.ParentID), t.Type, t.Status, t.Priority, t.Title, t.Description,
 		nilIfEmpty(t.Constraints), nilIfEmpty(t.AllowedPaths), nilIfEmpty(t.Context),
-		t.MaxRetries, t.RetryCount, t.DependsOn, 0, now, t.ResultData,
+		t.MaxRetries, t.RetryCount, t.DependsOn, t.TimeoutNs, now, t.ResultData, nilIfEmpty(t.CachePolicy),
+		t.SkipDepResults, nilIfEmpty(t.DepResults),
 	)
 	return err
 }
@@ -433,6 +566,16 @@ func (s *DB) UpdateTaskRetryCount(ctx context.Context, sessionID, taskID string,
 	return err
 }
 
+// UpdateTaskDepResults records the dependency results handed to a task's
+// worker.
+func (s *DB) UpdateTaskDepResults(ctx context.Context, sessionID, taskID, depResults string) error {
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE tasks SET dep_results = ? WHERE id = ? AND session_id = ?`,
+		nilIfEmpty(depResults), taskID, sessionID,
+	)
+	return err
+}
+
 // UpdateTaskErrorType sets the last error type for a task.
 func (s *DB) UpdateTaskErrorType(ctx context.Context, sessionID, taskID, errorType string) error {
 	tx, err := s.writer.BeginTx(ctx, nil)
@@ -461,6 +604,27 @@ func (s *DB) UpdateTaskErrorType(ctx context.Context, sessionID, taskID, errorTy
 	return tx.Commit()
 }
 
+// UpdateTaskPlan rewrites the editable planning fields of a task
+// (description, constraints, dependencies, priority and timeout).
+func (s *DB) UpdateTaskPlan(ctx context.Context, sessionID string, t TaskRow) error {
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE tasks SET description = ?, constraints = ?, depends_on = ?, priority = ?, timeout_ns = ?
+		 WHERE id = ? AND session_id = ?`,
+		t.Description, nilIfEmpty(t.Constraints), t.DependsOn, t.Priority, t.TimeoutNs,
+		t.ID, sessionID,
+	)
+	return err
+}
+
+// DeleteTask removes a single task from a session.
+func (s *DB) DeleteTask(ctx context.Context, sessionID, taskID string) error {
+	_, err := s.writer.ExecContext(ctx,
+		`DELETE FROM tasks WHERE id = ? AND session_id = ?`,
+		taskID, sessionID,
+	)
+	return err
+}
+
 func (s *DB) IncrementTaskRetry(ctx context.Context, sessionID, taskID string) (int, error) {
 	tx, err := s.writer.BeginTx(ctx, nil)
 	if err != nil {
@@ -487,7 +651,9 @@ func (s *DB) IncrementTaskRetry(ctx context.Context, sessionID, taskID string) (
 const taskSelectCols = `id, session_id, type, status, priority, title, description,
 	constraints, context, allowed_paths,
 	worker_id, result, max_retries, retry_count, depends_on,
-	created_at, started_at, completed_at, result_data`
+	created_at, started_at, completed_at, result_data, COALESCE(timeout_ns, 0),
+	COALESCE(cache_policy, ''), COALESCE(parent_id, ''),
+	COALESCE(skip_dep_results, 0), COALESCE(dep_results, '')`
 
 func (s *DB) GetTask(ctx context.Context, sessionID, taskID string) (*TaskRow, error) {
 	row := s.reader.QueryRowContext(ctx,
@@ -678,6 +844,698 @@ func (s *DB) MarkMessageExcluded(ctx context.Context, sessionID string, sequence
 	return err
 }
 
+// ResetLiveMessages deletes a session's non-excluded messages and returns the
+// next free sequence ID, so the live conversation can be written again after
+// the messages excluded from it.
+func (s *DB) ResetLiveMessages(ctx context.Context, sessionID string) (int, error) {
+	if _, err := s.writer.ExecContext(ctx,
+		`DELETE FROM messages WHERE session_id = ? AND excluded = 0`, sessionID); err != nil {
+		return 0, err
+	}
+	var next int
+	err := s.writer.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(sequence_id) + 1, 0) FROM messages WHERE session_id = ?`, sessionID,
+	).Scan(&next)
+	return next, err
+}
+
+// MessageIndexRow is one searchable piece of an excluded message: its text,
+// a tool call's input or a tool result.
+type MessageIndexRow struct {
+	SequenceID int      `json:"sequence_id"`
+	Role       string   `json:"role"`
+	ToolName   string   `json:"tool_name,omitempty"`
+	TaskIDs    []string `json:"task_ids,omitempty"`
+	Content    string   `json:"content"`
+}
+
+// MessageQuery selects index rows. Empty fields match everything; Text is
+// matched case-insensitively.
+type MessageQuery struct {
+	Text   string
+	Tool   string
+	TaskID string
+	Limit  int
+}
+
+// IndexMessages adds rows to a session's message index.
+func (s *DB) IndexMessages(ctx context.Context, sessionID string, rows []MessageIndexRow) error {
+	tx, err := s.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, r := range rows {
+		// Task IDs are stored space-delimited so one can be matched with LIKE.
+		taskIDs := ""
+		if len(r.TaskIDs) > 0 {
+			taskIDs = " " + strings.Join(r.TaskIDs, " ") + " "
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO message_index (session_id, sequence_id, role, tool_name, task_ids, content, created_at)
+			VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)`,
+			sessionID, r.SequenceID, r.Role, r.ToolName, taskIDs, r.Content, now,
+		); err != nil {
+			return fmt.Errorf("index message %d: %w", r.SequenceID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchMessages returns the most recent index rows of a session matching q,
+// newest first.
+func (s *DB) SearchMessages(ctx context.Context, sessionID string, q MessageQuery) ([]MessageIndexRow, error) {
+	query := `SELECT sequence_id, role, COALESCE(tool_name, ''), COALESCE(task_ids, ''), content
+		FROM message_index WHERE session_id = ?`
+	args := []any{sessionID}
+	if q.Text != "" {
+		query += ` AND instr(lower(content), lower(?)) > 0`
+		args = append(args, q.Text)
+	}
+	if q.Tool != "" {
+		query += ` AND tool_name = ?`
+		args = append(args, q.Tool)
+	}
+	if q.TaskID != "" {
+		query += ` AND task_ids LIKE ? ESCAPE '\'`
+		args = append(args, "% "+escapeLike(q.TaskID)+" %")
+	}
+	query += ` ORDER BY sequence_id DESC, id DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MessageIndexRow
+	for rows.Next() {
+		var r MessageIndexRow
+		var taskIDs string
+		if err := rows.Scan(&r.SequenceID, &r.Role, &r.ToolName, &taskIDs, &r.Content); err != nil {
+			return nil, err
+		}
+		r.TaskIDs = strings.Fields(taskIDs)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// --- Control requests ---
+
+// ControlRow is an operator request queued against a session, e.g. a task
+// cancellation issued from the CLI while the Queen is running.
+type ControlRow struct {
+	ID        int64  `json:"id"`
+	SessionID string `json:"session_id"`
+	Kind      string `json:"kind"`
+	Payload   string `json:"payload"`
+	Status    string `json:"status"`
+	Result    string `json:"result,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// EnqueueControl queues a control request for the session's Queen to apply.
+func (s *DB) EnqueueControl(ctx context.Context, sessionID, kind, payload string) (int64, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	result, err := s.writer.ExecContext(ctx,
+		`INSERT INTO control_requests (session_id, kind, payload, created_at) VALUES (?, ?, ?, ?)`,
+		sessionID, kind, payload, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// PendingControls returns unapplied control requests for a session in arrival order.
+func (s *DB) PendingControls(ctx context.Context, sessionID string) ([]ControlRow, error) {
+	rows, err := s.reader.QueryContext(ctx, `
+		SELECT id, session_id, kind, COALESCE(payload, ''), status, COALESCE(result, ''), created_at
+		FROM control_requests WHERE session_id = ? AND status = 'pending'
+		ORDER BY id`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ControlRow
+	for rows.Next() {
+		var c ControlRow
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.Kind, &c.Payload, &c.Status, &c.Result, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ResolveControl records the outcome of a control request ("applied" or "rejected").
+func (s *DB) ResolveControl(ctx context.Context, id int64, status, result string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE control_requests SET status = ?, result = ?, applied_at = ? WHERE id = ?`,
+		status, result, now, id,
+	)
+	return err
+}
+
+// --- Task attempts ---
+
+// AttemptRow records one execution of a task by a worker. A task gains a new
+// attempt each time it is assigned; earlier attempts are never overwritten.
+type AttemptRow struct {
+	ID         int64  `json:"id"`
+	SessionID  string `json:"session_id"`
+	TaskID     string `json:"task_id"`
+	Attempt    int    `json:"attempt"`
+	WorkerID   string `json:"worker_id,omitempty"`
+	Adapter    string `json:"adapter,omitempty"`
+	Status     string `json:"status"`
+	ErrorType  string `json:"error_type,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Output     string `json:"output,omitempty"`
+	OutputPath string `json:"output_path,omitempty"`
+	Feedback   string `json:"feedback,omitempty"`
+	Review     string `json:"review,omitempty"` // JSON review verdict of the output
+	Metrics    string `json:"metrics,omitempty"`
+	StartedAt  string `json:"started_at"`
+	EndedAt    string `json:"ended_at,omitempty"`
+}
+
+// StartAttempt records a new running attempt for a task and returns its
+// 1-based attempt number.
+func (s *DB) StartAttempt(ctx context.Context, sessionID, taskID, workerID, adapter string) (int, error) {
+	tx, err := s.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	var n int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(attempt), 0) + 1 FROM task_attempts WHERE session_id = ? AND task_id = ?`,
+		sessionID, taskID,
+	).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO task_attempts (session_id, task_id, attempt, worker_id, adapter, started_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		sessionID, taskID, n, nilIfEmpty(workerID), nilIfEmpty(adapter), now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// FinishAttempt records the outcome of an attempt. Status, ErrorType, Error,
+// Output, OutputPath and Metrics are taken from a; the attempt is identified
+// by session, task and attempt number.
+func (s *DB) FinishAttempt(ctx context.Context, a AttemptRow) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE task_attempts SET status = ?, error_type = ?, error = ?, output = ?, output_path = ?, metrics = ?, ended_at = ?
+		WHERE session_id = ? AND task_id = ? AND attempt = ?`,
+		a.Status, nilIfEmpty(a.ErrorType), nilIfEmpty(a.Error), nilIfEmpty(a.Output),
+		nilIfEmpty(a.OutputPath), nilIfEmpty(a.Metrics), now,
+		a.SessionID, a.TaskID, a.Attempt,
+	)
+	return err
+}
+
+// SetAttemptFeedback stores review feedback on the latest attempt of a task,
+// typically when the Queen rejects its output.
+func (s *DB) SetAttemptFeedback(ctx context.Context, sessionID, taskID, feedback string) error {
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE task_attempts SET feedback = ? WHERE id = (
+			SELECT id FROM task_attempts WHERE session_id = ? AND task_id = ? ORDER BY attempt DESC LIMIT 1)`,
+		feedback, sessionID, taskID,
+	)
+	return err
+}
+
+// SetAttemptReview stores the review verdict (as JSON) of the latest attempt
+// of a task.
+func (s *DB) SetAttemptReview(ctx context.Context, sessionID, taskID, review string) error {
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE task_attempts SET review = ? WHERE id = (
+			SELECT id FROM task_attempts WHERE session_id = ? AND task_id = ? ORDER BY attempt DESC LIMIT 1)`,
+		review, sessionID, taskID,
+	)
+	return err
+}
+
+const attemptSelectCols = `id, session_id, task_id, attempt, COALESCE(worker_id, ''), COALESCE(adapter, ''),
+	status, COALESCE(error_type, ''), COALESCE(error, ''), COALESCE(output, ''), COALESCE(output_path, ''),
+	COALESCE(feedback, ''), COALESCE(review, ''), COALESCE(metrics, ''), started_at, COALESCE(ended_at, '')`
+
+// ListAttempts returns every attempt of a task, oldest first.
+func (s *DB) ListAttempts(ctx context.Context, sessionID, taskID string) ([]AttemptRow, error) {
+	return s.queryAttempts(ctx,
+		`SELECT `+attemptSelectCols+` FROM task_attempts WHERE session_id = ? AND task_id = ? ORDER BY attempt`,
+		sessionID, taskID)
+}
+
+// ListSessionAttempts returns every attempt in a session ordered by task and attempt.
+func (s *DB) ListSessionAttempts(ctx context.Context, sessionID string) ([]AttemptRow, error) {
+	return s.queryAttempts(ctx,
+		`SELECT `+attemptSelectCols+` FROM task_attempts WHERE session_id = ? ORDER BY task_id, attempt`,
+		sessionID)
+}
+
+// GetAttempt returns a single attempt of a task.
+func (s *DB) GetAttempt(ctx context.Context, sessionID, taskID string, attempt int) (*AttemptRow, error) {
+	rows, err := s.queryAttempts(ctx,
+		`SELECT `+attemptSelectCols+` FROM task_attempts WHERE session_id = ? AND task_id = ? AND attempt = ?`,
+		sessionID, taskID, attempt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &rows[0], nil
+}
+
+func (s *DB) queryAttempts(ctx context.Context, query string, args ...interface{}) ([]AttemptRow, error) {
+	rows, err := s.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AttemptRow
+	for rows.Next() {
+		var a AttemptRow
+		if err := rows.Scan(&a.ID, &a.SessionID, &a.TaskID, &a.Attempt, &a.WorkerID, &a.Adapter,
+			&a.Status, &a.ErrorType, &a.Error, &a.Output, &a.OutputPath,
+			&a.Feedback, &a.Review, &a.Metrics, &a.StartedAt, &a.EndedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// InterruptRunningAttempts closes attempts left open by a crashed or stopped
+// Queen so a resumed session starts its next attempt cleanly.
+func (s *DB) InterruptRunningAttempts(ctx context.Context, sessionID string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.writer.ExecContext(ctx,
+		`UPDATE task_attempts SET status = 'interrupted', ended_at = ? WHERE session_id = ? AND status = 'running'`,
+		now, sessionID,
+	)
+	return err
+}
+
+// DurationStat is the mean duration of successful attempts for one task type
+// and adapter, across all sessions.
+type DurationStat struct {
+	TaskType    string  `json:"task_type"`
+	Adapter     string  `json:"adapter"`
+	Count       int     `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+}
+
+// TaskDurationStats aggregates the recorded duration of every successful
+// attempt by task type and adapter.
+func (s *DB) TaskDurationStats(ctx context.Context) ([]DurationStat, error) {
+	rows, err := s.reader.QueryContext(ctx,
+		`SELECT t.type, COALESCE(a.adapter, ''), COUNT(*),
+			AVG(CAST(json_extract(a.metrics, '$.duration_seconds') AS REAL))
+		FROM task_attempts a
+		JOIN tasks t ON t.session_id = a.session_id AND t.id = a.task_id
+		WHERE a.status = 'succeeded' AND json_extract(a.metrics, '$.duration_seconds') IS NOT NULL
+		GROUP BY t.type, a.adapter
+		ORDER BY t.type, a.adapter`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DurationStat
+	for rows.Next() {
+		var d DurationStat
+		if err := rows.Scan(&d.TaskType, &d.Adapter, &d.Count, &d.MeanSeconds); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// --- Costs ---
+
+// Cost sources.
+const (
+	CostSourceQueen  = "queen"
+	CostSourceWorker = "worker"
+)
+
+// CostRow is the spend of one Queen turn or one worker attempt.
+type CostRow struct {
+	SessionID        string  `json:"session_id"`
+	TaskID           string  `json:"task_id,omitempty"` // empty for Queen turns
+	Turn             int     `json:"turn"`
+	Source           string  `json:"source"`            // queen | worker
+	Purpose          string  `json:"purpose,omitempty"` // what a Queen call was for: agent, review, ...
+	Model            string  `json:"model,omitempty"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// Tokens is the total number of tokens in the row.
+func (c CostRow) Tokens() int {
+	return c.InputTokens + c.OutputTokens + c.CacheReadTokens + c.CacheWriteTokens
+}
+
+// RecordCost appends a cost row.
+func (s *DB) RecordCost(ctx context.Context, c CostRow) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.writer.ExecContext(ctx,
+		`INSERT INTO costs (session_id, task_id, turn, source, purpose, model, input_tokens, output_tokens,
+			cache_read_tokens, cache_write_tokens, cost_usd, created_at)
+		VALUES (?, NULLIF(?, ''), ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)`,
+		c.SessionID, c.TaskID, c.Turn, c.Source, c.Purpose, c.Model, c.InputTokens, c.OutputTokens,
+		c.CacheReadTokens, c.CacheWriteTokens, c.CostUSD, now,
+	)
+	return err
+}
+
+// CostTotals is aggregated spend.
+type CostTotals struct {
+	CostUSD float64 `json:"cost_usd"`
+	Tokens  int     `json:"tokens"`
+}
+
+const costTotalsCols = `COALESCE(SUM(cost_usd), 0),
+	COALESCE(SUM(input_tokens + output_tokens + cache_read_tokens + cache_write_tokens), 0)`
+
+// SessionCost returns the total spend of a session, Queen and workers.
+func (s *DB) SessionCost(ctx context.Context, sessionID string) (CostTotals, error) {
+	var t CostTotals
+	err := s.reader.QueryRowContext(ctx,
+		`SELECT `+costTotalsCols+` FROM costs WHERE session_id = ?`, sessionID,
+	).Scan(&t.CostUSD, &t.Tokens)
+	return t, err
+}
+
+// TaskCosts returns the spend of each task in a session, across attempts.
+func (s *DB) TaskCosts(ctx context.Context, sessionID string) (map[string]CostTotals, error) {
+	rows, err := s.reader.QueryContext(ctx,
+		`SELECT task_id, `+costTotalsCols+` FROM costs
+		WHERE session_id = ? AND task_id IS NOT NULL GROUP BY task_id`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]CostTotals)
+	for rows.Next() {
+		var id string
+		var t CostTotals
+		if err := rows.Scan(&id, &t.CostUSD, &t.Tokens); err != nil {
+			return nil, err
+		}
+		out[id] = t
+	}
+	return out, rows.Err()
+}
+
+// PurposeCosts returns the spend of a session by purpose. Rows recorded
+// without a purpose are grouped under their source.
+func (s *DB) PurposeCosts(ctx context.Context, sessionID string) (map[string]CostTotals, error) {
+	rows, err := s.reader.QueryContext(ctx,
+		`SELECT COALESCE(purpose, source), `+costTotalsCols+` FROM costs
+		WHERE session_id = ? GROUP BY COALESCE(purpose, source)`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]CostTotals)
+	for rows.Next() {
+		var purpose string
+		var t CostTotals
+		if err := rows.Scan(&purpose, &t.CostUSD, &t.Tokens); err != nil {
+			return nil, err
+		}
+		out[purpose] = t
+	}
+	return out, rows.Err()
+}
+
+// --- Compactions ---
+
+// CompactionRow records one compaction of the Queen's conversation.
+type CompactionRow struct {
+	SessionID      string `json:"session_id"`
+	Turn           int    `json:"turn"`
+	MessagesBefore int    `json:"messages_before"`
+	MessagesAfter  int    `json:"messages_after"`
+	TokensBefore   int    `json:"tokens_before"`  // prompt size that triggered it
+	ContextWindow  int    `json:"context_window"` // model context size at the time
+	Summary        string `json:"summary"`        // text that replaced the earlier turns
+	CreatedAt      string `json:"created_at"`
+}
+
+// RecordCompaction appends a compaction event.
+func (s *DB) RecordCompaction(ctx context.Context, c CompactionRow) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.writer.ExecContext(ctx,
+		`INSERT INTO compactions (session_id, turn, messages_before, messages_after, tokens_before,
+			context_window, summary, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.SessionID, c.Turn, c.MessagesBefore, c.MessagesAfter, c.TokensBefore,
+		c.ContextWindow, c.Summary, now,
+	)
+	return err
+}
+
+// ListCompactions returns a session's compactions, oldest first.
+func (s *DB) ListCompactions(ctx context.Context, sessionID string) ([]CompactionRow, error) {
+	rows, err := s.reader.QueryContext(ctx,
+		`SELECT session_id, turn, messages_before, messages_after, tokens_before, context_window,
+			COALESCE(summary, ''), created_at
+		FROM compactions WHERE session_id = ? ORDER BY id`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CompactionRow
+	for rows.Next() {
+		var c CompactionRow
+		if err := rows.Scan(&c.SessionID, &c.Turn, &c.MessagesBefore, &c.MessagesAfter,
+			&c.TokensBefore, &c.ContextWindow, &c.Summary, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// --- Review votes ---
+
+// ReviewVoteRow is one reviewer's vote in a review panel, with the panel's
+// outcome.
+type ReviewVoteRow struct {
+	SessionID string `json:"session_id"`
+	TaskID    string `json:"task_id"`
+	Attempt   int    `json:"attempt"`  // 0 = the task's latest attempt when recorded
+	Reviewer  string `json:"reviewer"` // provider/model
+	Approved  bool   `json:"approved"`
+	Score     int    `json:"score"`
+	Outcome   bool   `json:"outcome"`  // whether the output was accepted
+	Disputed  bool   `json:"disputed"` // the panel did not vote unanimously
+	CreatedAt string `json:"created_at"`
+}
+
+// RecordReviewVotes appends the votes of one review. Votes without an
+// attempt number are recorded against the task's latest attempt.
+func (s *DB) RecordReviewVotes(ctx context.Context, votes []ReviewVoteRow) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	tx, err := s.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	for _, v := range votes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO review_votes (session_id, task_id, attempt, reviewer, approved, score, outcome, disputed, created_at)
+			VALUES (?, ?, COALESCE(NULLIF(?, 0), (SELECT MAX(attempt) FROM task_attempts WHERE session_id = ? AND task_id = ?), 0),
+				?, ?, ?, ?, ?, ?)`,
+			v.SessionID, v.TaskID, v.Attempt, v.SessionID, v.TaskID,
+			v.Reviewer, v.Approved, v.Score, v.Outcome, v.Disputed, now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReviewerStat is how one reviewer has voted, across all sessions.
+type ReviewerStat struct {
+	Reviewer  string  `json:"reviewer"`
+	Votes     int     `json:"votes"`
+	Approvals int     `json:"approvals"`
+	Agreed    int     `json:"agreed"`   // votes that matched the outcome
+	Disputed  int     `json:"disputed"` // votes in reviews that were not unanimous
+	MeanScore float64 `json:"mean_score"`
+}
+
+// AgreementRate is the fraction of the reviewer's votes that matched the
+// outcome.
+func (r ReviewerStat) AgreementRate() float64 {
+	if r.Votes == 0 {
+		return 0
+	}
+	return float64(r.Agreed) / float64(r.Votes)
+}
+
+// ReviewerStats aggregates the recorded votes by reviewer.
+func (s *DB) ReviewerStats(ctx context.Context) ([]ReviewerStat, error) {
+	rows, err := s.reader.QueryContext(ctx,
+		`SELECT reviewer, COUNT(*), SUM(approved), SUM(approved = outcome), SUM(disputed), AVG(score)
+		FROM review_votes GROUP BY reviewer ORDER BY reviewer`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ReviewerStat
+	for rows.Next() {
+		var r ReviewerStat
+		if err := rows.Scan(&r.Reviewer, &r.Votes, &r.Approvals, &r.Agreed, &r.Disputed, &r.MeanScore); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// --- Result cache ---
+
+// CacheRow is a memoized successful task result, shared across sessions and
+// keyed on a hash of the task's inputs.
+type CacheRow struct {
+	Key       string `json:"key"`
+	TaskType  string `json:"task_type"`
+	Title     string `json:"title"`
+	Adapter   string `json:"adapter"`
+	Result    string `json:"result"` // JSON-encoded task.Result
+	SessionID string `json:"session_id"`
+	TaskID    string `json:"task_id"`
+	Hits      int    `json:"hits"`
+	CreatedAt string `json:"created_at"`
+	LastHitAt string `json:"last_hit_at,omitempty"`
+}
+
+// PutCache stores (or replaces) the cached result for a key.
+func (s *DB) PutCache(ctx context.Context, c CacheRow) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.writer.ExecContext(ctx,
+		`INSERT OR REPLACE INTO result_cache (key, task_type, title, adapter, result, session_id, task_id, hits, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?)`,
+		c.Key, c.TaskType, c.Title, c.Adapter, c.Result, c.SessionID, c.TaskID, now,
+	)
+	return err
+}
+
+const cacheSelectCols = `key, task_type, COALESCE(title, ''), COALESCE(adapter, ''), result,
+	COALESCE(session_id, ''), COALESCE(task_id, ''), hits, created_at, COALESCE(last_hit_at, '')`
+
+// GetCache looks up a cached result and bumps its hit counter.
+// Returns sql.ErrNoRows on a miss.
+func (s *DB) GetCache(ctx context.Context, key string) (*CacheRow, error) {
+	var c CacheRow
+	err := s.reader.QueryRowContext(ctx,
+		`SELECT `+cacheSelectCols+` FROM result_cache WHERE key = ?`, key,
+	).Scan(&c.Key, &c.TaskType, &c.Title, &c.Adapter, &c.Result,
+		&c.SessionID, &c.TaskID, &c.Hits, &c.CreatedAt, &c.LastHitAt)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.writer.ExecContext(ctx,
+		`UPDATE result_cache SET hits = hits + 1, last_hit_at = ? WHERE key = ?`, now, key,
+	); err != nil {
+		return nil, err
+	}
+	c.Hits++
+	c.LastHitAt = now
+	return &c, nil
+}
+
+// ListCache returns all cache entries, most recent first.
+func (s *DB) ListCache(ctx context.Context) ([]CacheRow, error) {
+	rows, err := s.reader.QueryContext(ctx,
+		`SELECT `+cacheSelectCols+` FROM result_cache ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CacheRow
+	for rows.Next() {
+		var c CacheRow
+		if err := rows.Scan(&c.Key, &c.TaskType, &c.Title, &c.Adapter, &c.Result,
+			&c.SessionID, &c.TaskID, &c.Hits, &c.CreatedAt, &c.LastHitAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// DeleteCacheForTask drops entries produced by a task, e.g. after its result
+// was rejected on review.
+func (s *DB) DeleteCacheForTask(ctx context.Context, sessionID, taskID string) error {
+	_, err := s.writer.ExecContext(ctx,
+		`DELETE FROM result_cache WHERE session_id = ? AND task_id = ?`, sessionID, taskID)
+	return err
+}
+
+// ClearCache removes cache entries and returns how many were deleted. A
+// non-empty keyPrefix limits deletion to matching keys; a non-zero olderThan
+// limits it to entries created before now-olderThan.
+func (s *DB) ClearCache(ctx context.Context, keyPrefix string, olderThan time.Duration) (int64, error) {
+	query := `DELETE FROM result_cache WHERE 1 = 1`
+	var args []interface{}
+	if keyPrefix != "" {
+		query += ` AND key LIKE ?`
+		args = append(args, keyPrefix+"%")
+	}
+	if olderThan > 0 {
+		query += ` AND created_at < ?`
+		args = append(args, time.Now().Add(-olderThan).UTC().Format(time.RFC3339Nano))
+	}
+	res, err := s.writer.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // --- Lifecycle ---
 
 func (s *DB) Close() error {
@@ -704,7 +1562,9 @@ func scanTask(row scannable) (*TaskRow, error) {
 		&constraints, &ctx, &allowedPaths,
 		&t.WorkerID, &t.Result,
 		&t.MaxRetries, &t.RetryCount, &t.DependsOn,
-		&t.CreatedAt, &t.StartedAt, &t.CompletedAt, &t.ResultData,
+		&t.CreatedAt, &t.StartedAt, &t.CompletedAt, &t.ResultData, &t.TimeoutNs,
+		&t.CachePolicy, &t.ParentID,
+		&t.SkipDepResults, &t.DepResults,
 	)
 	if err != nil {
 		return nil, err
@@ -721,27 +1581,36 @@ func scanTaskRows(rows *sql.Rows) (*TaskRow, error) {
 
 // SessionSummary includes session info plus task counts.
 type SessionSummary struct {
-	ID             string `json:"id"`
-	Objective      string `json:"objective"`
-	Status         string `json:"status"`
-	CreatedAt      string `json:"created_at"`
-	UpdatedAt      string `json:"updated_at"`
-	TotalTasks     int    `json:"total_tasks"`
-	CompletedTasks int    `json:"completed_tasks"`
-	FailedTasks    int    `json:"failed_tasks"`
-	PendingTasks   int    `json:"pending_tasks"`
+	ID             string  `json:"id"`
+	Objective      string  `json:"objective"`
+	Status         string  `json:"status"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+	TotalTasks     int     `json:"total_tasks"`
+	CompletedTasks int     `json:"completed_tasks"`
+	FailedTasks    int     `json:"failed_tasks"`
+	PendingTasks   int     `json:"pending_tasks"`
+	ParentID       string  `json:"parent_id,omitempty"`      // parent session of a nested Queen
+	ParentTaskID   string  `json:"parent_task_id,omitempty"` // parent task the nested Queen works on
+	CostUSD        float64 `json:"cost_usd"`
 }
 
-// ListSessions returns session summaries with task counts, ordered by most recent first.
-func (s *DB) ListSessions(ctx context.Context, limit int, onlyRunning bool) ([]SessionSummary, error) {
-	query := `
+// sessionSummarySelect selects session summaries with task counts; callers
+// append WHERE/GROUP BY clauses.
+const sessionSummarySelect = `
 		SELECT s.id, s.objective, s.status, s.created_at, s.updated_at,
 			COUNT(t.id) AS total_tasks,
 			COALESCE(SUM(CASE WHEN t.status = 'complete' THEN 1 ELSE 0 END), 0) AS completed,
 			COALESCE(SUM(CASE WHEN t.status = 'failed' THEN 1 ELSE 0 END), 0) AS failed,
-			COALESCE(SUM(CASE WHEN t.status = 'pending' THEN 1 ELSE 0 END), 0) AS pending
+			COALESCE(SUM(CASE WHEN t.status = 'pending' THEN 1 ELSE 0 END), 0) AS pending,
+			COALESCE(s.parent_id, ''), COALESCE(s.parent_task_id, ''),
+			(SELECT COALESCE(SUM(c.cost_usd), 0) FROM costs c WHERE c.session_id = s.id) AS cost_usd
 		FROM sessions s
 		LEFT JOIN tasks t ON s.id = t.session_id`
+
+// ListSessions returns session summaries with task counts, ordered by most recent first.
+func (s *DB) ListSessions(ctx context.Context, limit int, onlyRunning bool) ([]SessionSummary, error) {
+	query := sessionSummarySelect
 	args := []any{}
 	if onlyRunning {
 		query += ` WHERE s.status = ?`
@@ -749,6 +1618,18 @@ func (s *DB) ListSessions(ctx context.Context, limit int, onlyRunning bool) ([]S
 	}
 	query += ` GROUP BY s.id ORDER BY s.created_at DESC LIMIT ?`
 	args = append(args, limit)
+	return s.querySessionSummaries(ctx, query, args...)
+}
+
+// ListChildSessions returns the nested sessions started from parentID, oldest first.
+func (s *DB) ListChildSessions(ctx context.Context, parentID string) ([]SessionSummary, error) {
+	return s.querySessionSummaries(ctx,
+		sessionSummarySelect+` WHERE s.parent_id = ? GROUP BY s.id ORDER BY s.created_at`,
+		parentID,
+	)
+}
+
+func (s *DB) querySessionSummaries(ctx context.Context, query string, args ...any) ([]SessionSummary, error) {
 	rows, err := s.reader.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
@@ -759,7 +1640,8 @@ func (s *DB) ListSessions(ctx context.Context, limit int, onlyRunning bool) ([]S
 	for rows.Next() {
 		var ss SessionSummary
 		if err := rows.Scan(&ss.ID, &ss.Objective, &ss.Status, &ss.CreatedAt, &ss.UpdatedAt,
-			&ss.TotalTasks, &ss.CompletedTasks, &ss.FailedTasks, &ss.PendingTasks); err != nil {
+			&ss.TotalTasks, &ss.CompletedTasks, &ss.FailedTasks, &ss.PendingTasks,
+			&ss.ParentID, &ss.ParentTaskID, &ss.CostUSD); err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, ss)
@@ -777,6 +1659,12 @@ func (s *DB) RemoveSession(ctx context.Context, sessionID string) error {
 	for _, q := range []string{
 		`DELETE FROM events WHERE session_id = ?`,
 		`DELETE FROM tasks WHERE session_id = ?`,
+		`DELETE FROM control_requests WHERE session_id = ?`,
+		`DELETE FROM task_attempts WHERE session_id = ?`,
+		`DELETE FROM costs WHERE session_id = ?`,
+		`DELETE FROM compactions WHERE session_id = ?`,
+		`DELETE FROM message_index WHERE session_id = ?`,
+		`DELETE FROM review_votes WHERE session_id = ?`,
 		`DELETE FROM sessions WHERE id = ?`,
 	} {
 		if _, err := tx.ExecContext(ctx, q, sessionID); err != nil {
@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -1186,3 +1187,553 @@ func TestLoadBlackboard_MultipleEntries(t *testing.T) {
 		t.Errorf("Expected 0 entries for other session, got %d", len(otherRows))
 	}
 }
+
+func TestUpdateTaskPlanAndDelete(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.CreateSession(ctx, "s1", "objective"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := db.InsertTask(ctx, "s1", TaskRow{ID: "t1", Type: "code", Status: "pending", Priority: 1, Title: "T", Description: "old", TimeoutNs: int64(time.Minute)}); err != nil {
+		t.Fatalf("InsertTask failed: %v", err)
+	}
+
+	got, err := db.GetTask(ctx, "s1", "t1")
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.TimeoutNs != int64(time.Minute) {
+		t.Errorf("TimeoutNs = %d, want %d", got.TimeoutNs, int64(time.Minute))
+	}
+
+	got.Description = "new"
+	got.Priority = 3
+	got.DependsOn = "t0"
+	got.Constraints = `["stay in pkg/"]`
+	got.TimeoutNs = int64(2 * time.Minute)
+	if err := db.UpdateTaskPlan(ctx, "s1", *got); err != nil {
+		t.Fatalf("UpdateTaskPlan failed: %v", err)
+	}
+
+	got, err = db.GetTask(ctx, "s1", "t1")
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.Description != "new" || got.Priority != 3 || got.DependsOn != "t0" || got.Constraints != `["stay in pkg/"]` || got.TimeoutNs != int64(2*time.Minute) {
+		t.Errorf("task not updated: %+v", got)
+	}
+
+	if err := db.DeleteTask(ctx, "s1", "t1"); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if _, err := db.GetTask(ctx, "s1", "t1"); err == nil {
+		t.Error("expected task to be deleted")
+	}
+}
+
+func TestControlRequests(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.CreateSession(ctx, "s1", "objective"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	id1, err := db.EnqueueControl(ctx, "s1", "cancel_task", `{"task_id":"a"}`)
+	if err != nil {
+		t.Fatalf("EnqueueControl failed: %v", err)
+	}
+	if _, err := db.EnqueueControl(ctx, "s1", "delete_task", `{"task_id":"b"}`); err != nil {
+		t.Fatalf("EnqueueControl failed: %v", err)
+	}
+	if _, err := db.EnqueueControl(ctx, "other", "delete_task", `{"task_id":"c"}`); err != nil {
+		t.Fatalf("EnqueueControl failed: %v", err)
+	}
+
+	pending, err := db.PendingControls(ctx, "s1")
+	if err != nil {
+		t.Fatalf("PendingControls failed: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending requests, got %d", len(pending))
+	}
+	if pending[0].ID != id1 || pending[0].Kind != "cancel_task" || pending[0].Payload != `{"task_id":"a"}` {
+		t.Errorf("unexpected first request: %+v", pending[0])
+	}
+
+	if err := db.ResolveControl(ctx, id1, "applied", "ok"); err != nil {
+		t.Fatalf("ResolveControl failed: %v", err)
+	}
+	pending, err = db.PendingControls(ctx, "s1")
+	if err != nil {
+		t.Fatalf("PendingControls failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Kind != "delete_task" {
+		t.Errorf("expected only delete_task pending, got %+v", pending)
+	}
+}
+
+func TestTaskAttempts(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.CreateSession(ctx, "s1", "objective"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	n1, err := db.StartAttempt(ctx, "s1", "t1", "w-1", "exec")
+	if err != nil {
+		t.Fatalf("StartAttempt failed: %v", err)
+	}
+	if n1 != 1 {
+		t.Fatalf("expected attempt 1, got %d", n1)
+	}
+	if err := db.FinishAttempt(ctx, AttemptRow{
+		SessionID: "s1", TaskID: "t1", Attempt: n1,
+		Status: "failed", ErrorType: "retryable", Error: "boom", Output: "partial",
+	}); err != nil {
+		t.Fatalf("FinishAttempt failed: %v", err)
+	}
+
+	n2, err := db.StartAttempt(ctx, "s1", "t1", "w-2", "exec")
+	if err != nil {
+		t.Fatalf("StartAttempt failed: %v", err)
+	}
+	if n2 != 2 {
+		t.Fatalf("expected attempt 2, got %d", n2)
+	}
+	if err := db.SetAttemptFeedback(ctx, "s1", "t1", "missing tests"); err != nil {
+		t.Fatalf("SetAttemptFeedback failed: %v", err)
+	}
+	if err := db.SetAttemptReview(ctx, "s1", "t1", `{"approved":false}`); err != nil {
+		t.Fatalf("SetAttemptReview failed: %v", err)
+	}
+
+	attempts, err := db.ListAttempts(ctx, "s1", "t1")
+	if err != nil {
+		t.Fatalf("ListAttempts failed: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(attempts))
+	}
+	first := attempts[0]
+	if first.WorkerID != "w-1" || first.Adapter != "exec" || first.Status != "failed" ||
+		first.ErrorType != "retryable" || first.Error != "boom" || first.Output != "partial" || first.EndedAt == "" {
+		t.Errorf("unexpected first attempt: %+v", first)
+	}
+	if attempts[1].Status != "running" || attempts[1].Feedback != "missing tests" || first.Feedback != "" {
+		t.Errorf("feedback should land on the latest attempt only: %+v", attempts)
+	}
+	if attempts[1].Review != `{"approved":false}` || first.Review != "" {
+		t.Errorf("review should land on the latest attempt only: %+v", attempts)
+	}
+
+	if err := db.InterruptRunningAttempts(ctx, "s1"); err != nil {
+		t.Fatalf("InterruptRunningAttempts failed: %v", err)
+	}
+	a2, err := db.GetAttempt(ctx, "s1", "t1", 2)
+	if err != nil {
+		t.Fatalf("GetAttempt failed: %v", err)
+	}
+	if a2.Status != "interrupted" || a2.EndedAt == "" {
+		t.Errorf("expected interrupted attempt, got %+v", a2)
+	}
+	if _, err := db.GetAttempt(ctx, "s1", "t1", 3); err == nil {
+		t.Error("expected error for missing attempt")
+	}
+
+	all, err := db.ListSessionAttempts(ctx, "s1")
+	if err != nil {
+		t.Fatalf("ListSessionAttempts failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected 2 session attempts, got %d", len(all))
+	}
+}
+
+func TestTaskDurationStats(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if err := db.CreateSession(ctx, "s1", "objective"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if err := db.InsertTask(ctx, "s1", TaskRow{ID: id, Type: "code", Status: "complete", Title: id}); err != nil {
+			t.Fatalf("InsertTask failed: %v", err)
+		}
+	}
+	record := func(taskID, status string, seconds float64) {
+		t.Helper()
+		n, err := db.StartAttempt(ctx, "s1", taskID, "w", "exec")
+		if err != nil {
+			t.Fatalf("StartAttempt failed: %v", err)
+		}
+		if err := db.FinishAttempt(ctx, AttemptRow{
+			SessionID: "s1", TaskID: taskID, Attempt: n, Status: status,
+			Metrics: fmt.Sprintf(`{"duration_seconds":%g}`, seconds),
+		}); err != nil {
+			t.Fatalf("FinishAttempt failed: %v", err)
+		}
+	}
+	record("t1", "succeeded", 10)
+	record("t2", "succeeded", 30)
+	record("t3", "failed", 1000) // failures don't count
+
+	stats, err := db.TaskDurationStats(ctx)
+	if err != nil {
+		t.Fatalf("TaskDurationStats failed: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 stat row, got %+v", stats)
+	}
+	if s := stats[0]; s.TaskType != "code" || s.Adapter != "exec" || s.Count != 2 || s.MeanSeconds != 20 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if _, err := db.GetCache(ctx, "missing"); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows on miss, got %v", err)
+	}
+
+	for _, c := range []CacheRow{
+		{Key: "aaa1", TaskType: "research", Title: "A", Adapter: "exec", Result: `{"success":true}`, SessionID: "s1", TaskID: "t1"},
+		{Key: "bbb2", TaskType: "review", Title: "B", Adapter: "exec", Result: `{"success":true}`, SessionID: "s1", TaskID: "t2"},
+	} {
+		if err := db.PutCache(ctx, c); err != nil {
+			t.Fatalf("PutCache failed: %v", err)
+		}
+	}
+
+	hit, err := db.GetCache(ctx, "aaa1")
+	if err != nil {
+		t.Fatalf("GetCache failed: %v", err)
+	}
+	if hit.TaskID != "t1" || hit.Hits != 1 || hit.LastHitAt == "" {
+		t.Errorf("unexpected hit: %+v", hit)
+	}
+
+	if err := db.DeleteCacheForTask(ctx, "s1", "t2"); err != nil {
+		t.Fatalf("DeleteCacheForTask failed: %v", err)
+	}
+	entries, err := db.ListCache(ctx)
+	if err != nil {
+		t.Fatalf("ListCache failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Key != "aaa1" {
+		t.Fatalf("unexpected entries after delete: %+v", entries)
+	}
+
+	if n, err := db.ClearCache(ctx, "zzz", 0); err != nil || n != 0 {
+		t.Errorf("ClearCache with unmatched prefix = %d, %v", n, err)
+	}
+	if n, err := db.ClearCache(ctx, "", time.Hour); err != nil || n != 0 {
+		t.Errorf("ClearCache of fresh entries by age = %d, %v", n, err)
+	}
+	if n, err := db.ClearCache(ctx, "aaa", 0); err != nil || n != 1 {
+		t.Errorf("ClearCache by prefix = %d, %v", n, err)
+	}
+}
+
+func TestChildSessions(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if err := db.CreateSession(ctx, "parent", "Big objective"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := db.CreateSession(ctx, "child", "Sub-objective"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSessionParent(ctx, "child", "parent", "sub"); err != nil {
+		t.Fatalf("SetSessionParent failed: %v", err)
+	}
+	if err := db.InsertTask(ctx, "child", TaskRow{ID: "t1", ParentID: "sub", Type: "code", Status: "pending", Title: "T1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	children, err := db.ListChildSessions(ctx, "parent")
+	if err != nil {
+		t.Fatalf("ListChildSessions failed: %v", err)
+	}
+	if len(children) != 1 || children[0].ID != "child" || children[0].ParentTaskID != "sub" || children[0].TotalTasks != 1 {
+		t.Fatalf("unexpected children: %+v", children)
+	}
+
+	row, err := db.GetTask(ctx, "child", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ParentID != "sub" {
+		t.Errorf("expected task parent_id 'sub', got %q", row.ParentID)
+	}
+
+	// Nested sessions are neither the latest session nor resumable on their own.
+	latest, err := db.LatestSession(ctx)
+	if err != nil || latest.ID != "parent" {
+		t.Errorf("LatestSession = %v, %v; want parent", latest, err)
+	}
+	resumable, err := db.FindResumableSession(ctx)
+	if err != nil || resumable.ID != "parent" {
+		t.Errorf("FindResumableSession = %v, %v; want parent", resumable, err)
+	}
+}
+
+func TestCosts(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if err := db.CreateSession(ctx, "s1", "objective"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []CostRow{
+		{SessionID: "s1", Turn: 1, Source: CostSourceQueen, Model: "claude-sonnet-4", InputTokens: 1000, OutputTokens: 200, CacheReadTokens: 300, CostUSD: 0.01},
+		{SessionID: "s1", TaskID: "t1", Source: CostSourceWorker, OutputTokens: 500, CostUSD: 0.25},
+		{SessionID: "s1", TaskID: "t1", Source: CostSourceWorker, CostUSD: 0.5},
+		{SessionID: "s1", TaskID: "t2", Source: CostSourceWorker, InputTokens: 100, CostUSD: 0.04},
+		{SessionID: "other", TaskID: "t1", Source: CostSourceWorker, CostUSD: 9},
+	} {
+		if err := db.RecordCost(ctx, c); err != nil {
+			t.Fatalf("RecordCost failed: %v", err)
+		}
+	}
+
+	total, err := db.SessionCost(ctx, "s1")
+	if err != nil {
+		t.Fatalf("SessionCost failed: %v", err)
+	}
+	if total.Tokens != 2100 || total.CostUSD < 0.7999 || total.CostUSD > 0.8001 {
+		t.Errorf("unexpected session total: %+v", total)
+	}
+
+	tasks, err := db.TaskCosts(ctx, "s1")
+	if err != nil {
+		t.Fatalf("TaskCosts failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks["t1"].CostUSD != 0.75 || tasks["t1"].Tokens != 500 || tasks["t2"].Tokens != 100 {
+		t.Errorf("unexpected task costs: %+v", tasks)
+	}
+
+	review := CostRow{SessionID: "s1", Turn: 2, Source: CostSourceQueen, Purpose: "review", Model: "claude-haiku-4-5", InputTokens: 50, OutputTokens: 10, CostUSD: 0.001}
+	if err := db.RecordCost(ctx, review); err != nil {
+		t.Fatalf("RecordCost failed: %v", err)
+	}
+	purposes, err := db.PurposeCosts(ctx, "s1")
+	if err != nil {
+		t.Fatalf("PurposeCosts failed: %v", err)
+	}
+	if len(purposes) != 3 || purposes["review"].Tokens != 60 || purposes[CostSourceQueen].Tokens != 1500 || purposes[CostSourceWorker].Tokens != 600 {
+		t.Errorf("unexpected purpose costs: %+v", purposes)
+	}
+	total.CostUSD += review.CostUSD
+
+	sessions, err := db.ListSessions(ctx, 10, false)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].CostUSD != total.CostUSD {
+		t.Errorf("expected session summary cost %v, got %+v", total.CostUSD, sessions)
+	}
+
+	if err := db.RemoveSession(ctx, "s1"); err != nil {
+		t.Fatalf("RemoveSession failed: %v", err)
+	}
+	if total, _ := db.SessionCost(ctx, "s1"); total.CostUSD != 0 {
+		t.Errorf("expected costs removed with session, got %+v", total)
+	}
+}
+
+func TestCompactions(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if err := db.CreateSession(ctx, "s1", "objective"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []CompactionRow{
+		{SessionID: "s1", Turn: 12, MessagesBefore: 60, MessagesAfter: 18, TokensBefore: 140000, ContextWindow: 200000, Summary: "first"},
+		{SessionID: "s1", Turn: 30, MessagesBefore: 55, MessagesAfter: 20, TokensBefore: 150000, ContextWindow: 200000, Summary: "second"},
+		{SessionID: "other", Turn: 1, MessagesBefore: 2, MessagesAfter: 1},
+	} {
+		if err := db.RecordCompaction(ctx, c); err != nil {
+			t.Fatalf("RecordCompaction failed: %v", err)
+		}
+	}
+
+	rows, err := db.ListCompactions(ctx, "s1")
+	if err != nil {
+		t.Fatalf("ListCompactions failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Summary != "first" || rows[1].Turn != 30 || rows[1].TokensBefore != 150000 || rows[0].CreatedAt == "" {
+		t.Errorf("unexpected compactions: %+v", rows)
+	}
+
+	if err := db.RemoveSession(ctx, "s1"); err != nil {
+		t.Fatalf("RemoveSession failed: %v", err)
+	}
+	if rows, _ := db.ListCompactions(ctx, "s1"); len(rows) != 0 {
+		t.Errorf("expected compactions removed with session, got %+v", rows)
+	}
+}
+
+func TestReviewVotes(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	for _, id := range []string{"s1", "s2"} {
+		if err := db.CreateSession(ctx, id, "objective"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 2 {
+		if _, err := db.StartAttempt(ctx, "s1", "t1", "w-1", "exec"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	votes := []ReviewVoteRow{
+		{SessionID: "s1", TaskID: "t1", Reviewer: "anthropic/haiku", Approved: true, Score: 8, Outcome: true, Disputed: true},
+		{SessionID: "s1", TaskID: "t1", Reviewer: "openai/mini", Approved: false, Score: 3, Outcome: true, Disputed: true},
+		{SessionID: "s1", TaskID: "t1", Reviewer: "gemini-api/flash", Approved: true, Score: 7, Outcome: true, Disputed: true},
+	}
+	if err := db.RecordReviewVotes(ctx, votes); err != nil {
+		t.Fatalf("RecordReviewVotes failed: %v", err)
+	}
+	if err := db.RecordReviewVotes(ctx, []ReviewVoteRow{
+		{SessionID: "s2", TaskID: "t9", Attempt: 1, Reviewer: "openai/mini", Approved: true, Score: 9, Outcome: true},
+	}); err != nil {
+		t.Fatalf("RecordReviewVotes failed: %v", err)
+	}
+
+	var attempt int
+	if err := db.reader.QueryRowContext(ctx, `SELECT attempt FROM review_votes WHERE session_id = 's1' LIMIT 1`).Scan(&attempt); err != nil || attempt != 2 {
+		t.Errorf("expected votes on the latest attempt (2), got %d, %v", attempt, err)
+	}
+
+	stats, err := db.ReviewerStats(ctx)
+	if err != nil {
+		t.Fatalf("ReviewerStats failed: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 reviewers, got %+v", stats)
+	}
+	mini := stats[2]
+	if mini.Reviewer != "openai/mini" || mini.Votes != 2 || mini.Approvals != 1 || mini.Agreed != 1 ||
+		mini.Disputed != 1 || mini.MeanScore != 6 || mini.AgreementRate() != 0.5 {
+		t.Errorf("unexpected stats for openai/mini: %+v", mini)
+	}
+
+	if err := db.RemoveSession(ctx, "s1"); err != nil {
+		t.Fatalf("RemoveSession failed: %v", err)
+	}
+	if stats, _ := db.ReviewerStats(ctx); len(stats) != 1 || stats[0].Votes != 1 {
+		t.Errorf("expected votes removed with session, got %+v", stats)
+	}
+}
+
+func TestMessageIndex(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	for i, role := range []string{"user", "assistant", "tool_result", "assistant"} {
+		if err := db.AppendMessage(ctx, "s1", i, role, "m", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.MarkMessageExcluded(ctx, "s1", 1); err != nil {
+		t.Fatal(err)
+	}
+	next, err := db.ResetLiveMessages(ctx, "s1")
+	if err != nil || next != 2 {
+		t.Fatalf("ResetLiveMessages = %d, %v; want 2", next, err)
+	}
+	if msgs, _ := db.LoadMessages(ctx, "s1"); len(msgs) != 0 {
+		t.Errorf("expected live messages deleted, got %+v", msgs)
+	}
+
+	rows := []MessageIndexRow{
+		{SequenceID: 1, Role: "assistant", ToolName: "read_file", Content: `{"path":"internal/api/users.go"}`},
+		{SequenceID: 2, Role: "tool_result", ToolName: "reject_task", TaskIDs: []string{"t_1", "t10"}, Content: "Rejected: Missing Tests"},
+		{SequenceID: 3, Role: "assistant", Content: "Planning the API in internal/api"},
+	}
+	if err := db.IndexMessages(ctx, "s1", rows); err != nil {
+		t.Fatalf("IndexMessages failed: %v", err)
+	}
+	if err := db.IndexMessages(ctx, "other", rows[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		q    MessageQuery
+		want []int
+	}{
+		{MessageQuery{Text: "INTERNAL/API"}, []int{3, 1}},
+		{MessageQuery{Tool: "reject_task"}, []int{2}},
+		{MessageQuery{TaskID: "t10"}, []int{2}},
+		{MessageQuery{TaskID: "t1"}, nil}, // no partial or wildcard matches
+		{MessageQuery{TaskID: "t%"}, nil},
+		{MessageQuery{Text: "internal", Limit: 1}, []int{3}},
+		{MessageQuery{Text: "missing tests", TaskID: "t_1"}, []int{2}},
+	}
+	for _, tt := range tests {
+		got, err := db.SearchMessages(ctx, "s1", tt.q)
+		if err != nil {
+			t.Fatalf("SearchMessages(%+v) failed: %v", tt.q, err)
+		}
+		var seqs []int
+		for _, r := range got {
+			seqs = append(seqs, r.SequenceID)
+		}
+		if fmt.Sprint(seqs) != fmt.Sprint(tt.want) {
+			t.Errorf("SearchMessages(%+v) = %v, want %v", tt.q, seqs, tt.want)
+		}
+	}
+	if got, _ := db.SearchMessages(ctx, "s1", MessageQuery{Tool: "reject_task"}); len(got) != 1 || len(got[0].TaskIDs) != 2 {
+		t.Errorf("expected task IDs returned, got %+v", got)
+	}
+}
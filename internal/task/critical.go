@@ -0,0 +1,283 @@
+package task
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultEstimate is the duration assumed for a task when no history exists.
+const DefaultEstimate = 2 * time.Minute
+
+// Estimator returns the expected duration of a task.
+type Estimator func(t *Task) time.Duration
+
+// DurationHistory holds mean historical task durations keyed by task type
+// and adapter. Lookups fall back from type+adapter to type to the overall mean.
+type DurationHistory struct {
+	byAdapter map[string]durationMean // "type/adapter"
+	byType    map[string]durationMean
+	overall   durationMean
+}
+
+type durationMean struct {
+	total time.Duration
+	count int
+}
+
+func (m *durationMean) add(mean time.Duration, count int) {
+	m.total += mean * time.Duration(count)
+	m.count += count
+}
+
+func (m durationMean) mean() (time.Duration, bool) {
+	if m.count == 0 {
+		return 0, false
+	}
+	return m.total / time.Duration(m.count), true
+}
+
+// NewDurationHistory returns an empty history.
+func NewDurationHistory() *DurationHistory {
+	return &DurationHistory{
+		byAdapter: make(map[string]durationMean),
+		byType:    make(map[string]durationMean),
+	}
+}
+
+// Add records count runs of a task type on an adapter with the given mean duration.
+func (h *DurationHistory) Add(typ Type, adapter string, mean time.Duration, count int) {
+	if count <= 0 {
+		return
+	}
+	key := string(typ) + "/" + strings.ToLower(adapter)
+	m := h.byAdapter[key]
+	m.add(mean, count)
+	h.byAdapter[key] = m
+
+	t := h.byType[string(typ)]
+	t.add(mean, count)
+	h.byType[string(typ)] = t
+
+	h.overall.add(mean, count)
+}
+
+// Empty reports whether the history has no recorded runs.
+func (h *DurationHistory) Empty() bool {
+	return h == nil || h.overall.count == 0
+}
+
+// Lookup returns the historical mean for a task type on an adapter. An empty
+// adapter matches any adapter.
+func (h *DurationHistory) Lookup(typ Type, adapter string) (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	if adapter != "" {
+		if d, ok := h.byAdapter[string(typ)+"/"+strings.ToLower(adapter)].mean(); ok {
+			return d, true
+		}
+	}
+	if d, ok := h.byType[string(typ)].mean(); ok {
+		return d, true
+	}
+	return h.overall.mean()
+}
+
+// Estimate is like Lookup but returns DefaultEstimate when nothing is known.
+func (h *DurationHistory) Estimate(typ Type, adapter string) time.Duration {
+	if d, ok := h.Lookup(typ, adapter); ok {
+		return d
+	}
+	return DefaultEstimate
+}
+
+// PathNode is the input to ComputeCriticalPath: a task's dependencies and its
+// expected remaining duration (zero once it is finished).
+type PathNode struct {
+	ID        string
+	DependsOn []string
+	Remaining time.Duration
+}
+
+// CriticalPath is the result of a critical-path analysis over the unfinished
+// part of a task graph.
+type CriticalPath struct {
+	// Path is the longest chain of unfinished tasks, in execution order.
+	Path []string
+	// Slack is how long each unfinished task can slip without delaying the
+	// whole graph. Critical tasks have zero slack.
+	Slack map[string]time.Duration
+	// Remaining is each unfinished task's expected remaining duration.
+	Remaining map[string]time.Duration
+	// Length is the duration of the critical path, i.e. the ETA with
+	// unlimited workers.
+	Length time.Duration
+	// Work is the sum of all remaining durations.
+	Work time.Duration
+}
+
+// IsCritical reports whether id is an unfinished task with zero slack.
+func (c *CriticalPath) IsCritical(id string) bool {
+	if c == nil {
+		return false
+	}
+	s, ok := c.Slack[id]
+	return ok && s == 0
+}
+
+// ETA estimates the time until every task finishes with the given number of
+// parallel workers: never less than the critical path, and never less than
+// the remaining work spread evenly across workers.
+func (c *CriticalPath) ETA(parallel int) time.Duration {
+	if c == nil {
+		return 0
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+	return max(c.Length, c.Work/time.Duration(parallel))
+}
+
+// ComputeCriticalPath runs a forward and backward pass over the nodes and
+// returns the critical path and per-task slack. Finished nodes (zero
+// remaining) still order their dependents but are not reported. Nodes on a
+// dependency cycle are ignored.
+func ComputeCriticalPath(nodes []PathNode) *CriticalPath {
+	byID := make(map[string]*PathNode, len(nodes))
+	ids := make([]string, 0, len(nodes))
+	for i := range nodes {
+		byID[nodes[i].ID] = &nodes[i]
+		ids = append(ids, nodes[i].ID)
+	}
+	sort.Strings(ids)
+
+	// Topological order (Kahn), deterministic by ID.
+	inDeg := make(map[string]int, len(ids))
+	children := make(map[string][]string)
+	for _, id := range ids {
+		for _, dep := range byID[id].DependsOn {
+			if _, ok := byID[dep]; ok {
+				inDeg[id]++
+				children[dep] = append(children[dep], id)
+			}
+		}
+	}
+	var order []string
+	for _, id := range ids {
+		if inDeg[id] == 0 {
+			order = append(order, id)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, child := range children[order[i]] {
+			inDeg[child]--
+			if inDeg[child] == 0 {
+				order = append(order, child)
+			}
+		}
+	}
+
+	// Forward pass: earliest start/finish.
+	es := make(map[string]time.Duration, len(order))
+	ef := make(map[string]time.Duration, len(order))
+	var length time.Duration
+	for _, id := range order {
+		n := byID[id]
+		var start time.Duration
+		for _, dep := range n.DependsOn {
+			if f, ok := ef[dep]; ok && f > start {
+				start = f
+			}
+		}
+		es[id] = start
+		ef[id] = start + n.Remaining
+		length = max(length, ef[id])
+	}
+
+	// Backward pass: latest finish.
+	lf := make(map[string]time.Duration, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		finish := length
+		for _, child := range children[id] {
+			if ls, ok := lf[child]; ok {
+				finish = min(finish, ls-byID[child].Remaining)
+			}
+		}
+		lf[id] = finish
+	}
+
+	cp := &CriticalPath{
+		Slack:     make(map[string]time.Duration),
+		Remaining: make(map[string]time.Duration),
+		Length:    length,
+	}
+	for _, id := range order {
+		n := byID[id]
+		if n.Remaining <= 0 {
+			continue
+		}
+		cp.Slack[id] = lf[id] - ef[id]
+		cp.Remaining[id] = n.Remaining
+		cp.Work += n.Remaining
+	}
+
+	// Walk back from the critical task that finishes last.
+	var cur string
+	for _, id := range order {
+		if cp.IsCritical(id) && ef[id] == length {
+			cur = id
+		}
+	}
+	for cur != "" {
+		cp.Path = append(cp.Path, cur)
+		next := ""
+		for _, dep := range byID[cur].DependsOn {
+			if cp.IsCritical(dep) && ef[dep] == es[cur] {
+				next = dep
+				break
+			}
+		}
+		cur = next
+	}
+	for i, j := 0, len(cp.Path)-1; i < j; i, j = i+1, j-1 {
+		cp.Path[i], cp.Path[j] = cp.Path[j], cp.Path[i]
+	}
+	return cp
+}
+
+// CriticalPath analyses the unfinished tasks of the graph. Complete and
+// cancelled tasks take no time; running tasks are credited for the time
+// they have already spent.
+func (g *TaskGraph) CriticalPath(estimate Estimator) *CriticalPath {
+	g.mu.RLock()
+	nodes := make([]PathNode, 0, len(g.tasks))
+	for _, t := range g.tasks {
+		nodes = append(nodes, PathNode{
+			ID:        t.ID,
+			DependsOn: t.DependsOn,
+			Remaining: remainingEstimate(t, estimate),
+		})
+	}
+	g.mu.RUnlock()
+	return ComputeCriticalPath(nodes)
+}
+
+// remainingEstimate is called with the graph lock held, so it reads task
+// fields directly.
+func remainingEstimate(t *Task, estimate Estimator) time.Duration {
+	switch t.Status {
+	case StatusComplete, StatusCancelled:
+		return 0
+	}
+	d := DefaultEstimate
+	if estimate != nil {
+		d = estimate(t)
+	}
+	if t.Status == StatusRunning && t.StartedAt != nil {
+		// Never report a running task as finished; it may overrun.
+		d = max(d-time.Since(*t.StartedAt), time.Second)
+	}
+	return d
+}
@@ -0,0 +1,157 @@
+package task
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeCriticalPath_Diamond(t *testing.T) {
+	// a -> b -> d (long branch), a -> c -> d (short branch)
+	cp := ComputeCriticalPath([]PathNode{
+		{ID: "a", Remaining: 1 * time.Minute},
+		{ID: "b", DependsOn: []string{"a"}, Remaining: 5 * time.Minute},
+		{ID: "c", DependsOn: []string{"a"}, Remaining: 2 * time.Minute},
+		{ID: "d", DependsOn: []string{"b", "c"}, Remaining: 1 * time.Minute},
+	})
+
+	if got := strings.Join(cp.Path, ","); got != "a,b,d" {
+		t.Errorf("expected critical path a,b,d, got %s", got)
+	}
+	if cp.Length != 7*time.Minute {
+		t.Errorf("expected length 7m, got %s", cp.Length)
+	}
+	if cp.Slack["c"] != 3*time.Minute {
+		t.Errorf("expected slack 3m for c, got %s", cp.Slack["c"])
+	}
+	for _, id := range []string{"a", "b", "d"} {
+		if !cp.IsCritical(id) {
+			t.Errorf("expected %s to be critical", id)
+		}
+	}
+	if cp.IsCritical("c") {
+		t.Error("c should not be critical")
+	}
+	if cp.Work != 9*time.Minute {
+		t.Errorf("expected 9m of work, got %s", cp.Work)
+	}
+}
+
+func TestComputeCriticalPath_FinishedTasksIgnored(t *testing.T) {
+	cp := ComputeCriticalPath([]PathNode{
+		{ID: "done", Remaining: 0},
+		{ID: "next", DependsOn: []string{"done"}, Remaining: time.Minute},
+		{ID: "side", Remaining: 30 * time.Second},
+	})
+	if _, ok := cp.Slack["done"]; ok {
+		t.Error("finished task should not be reported")
+	}
+	if got := strings.Join(cp.Path, ","); got != "next" {
+		t.Errorf("expected critical path next, got %s", got)
+	}
+	if cp.Slack["side"] != 30*time.Second {
+		t.Errorf("expected slack 30s for side, got %s", cp.Slack["side"])
+	}
+}
+
+func TestCriticalPathETA(t *testing.T) {
+	cp := &CriticalPath{Length: 5 * time.Minute, Work: 20 * time.Minute}
+	if got := cp.ETA(2); got != 10*time.Minute {
+		t.Errorf("expected work-bound ETA 10m, got %s", got)
+	}
+	if got := cp.ETA(8); got != 5*time.Minute {
+		t.Errorf("expected path-bound ETA 5m, got %s", got)
+	}
+	var nilCP *CriticalPath
+	if nilCP.ETA(1) != 0 || nilCP.IsCritical("x") {
+		t.Error("nil critical path should be inert")
+	}
+}
+
+func TestTaskGraphCriticalPath(t *testing.T) {
+	started := time.Now().Add(-time.Minute)
+	g := newTestGraph(
+		&Task{ID: "a", Type: TypeCode, Status: StatusComplete},
+		&Task{ID: "b", Type: TypeCode, Status: StatusRunning, StartedAt: &started, DependsOn: []string{"a"}},
+		&Task{ID: "c", Type: TypeTest, Status: StatusPending, DependsOn: []string{"b"}},
+		&Task{ID: "r", Type: TypeResearch, Status: StatusPending},
+	)
+
+	h := NewDurationHistory()
+	h.Add(TypeCode, "exec", 3*time.Minute, 2)
+	h.Add(TypeTest, "exec", time.Minute, 1)
+	cp := g.CriticalPath(func(t *Task) time.Duration { return h.Estimate(t.Type, "exec") })
+
+	if got := strings.Join(cp.Path, ","); got != "b,c" {
+		t.Errorf("expected critical path b,c, got %s", got)
+	}
+	// b has ~2m left, c 1m; r falls back to the overall mean (7m/3).
+	if cp.Remaining["b"] > 2*time.Minute || cp.Remaining["b"] < 2*time.Minute-5*time.Second {
+		t.Errorf("expected ~2m remaining for running task, got %s", cp.Remaining["b"])
+	}
+	if cp.Remaining["r"] != 7*time.Minute/3 {
+		t.Errorf("expected overall mean for research task, got %s", cp.Remaining["r"])
+	}
+}
+
+func TestDurationHistoryLookup(t *testing.T) {
+	h := NewDurationHistory()
+	if !h.Empty() {
+		t.Error("new history should be empty")
+	}
+	if got := h.Estimate(TypeCode, ""); got != DefaultEstimate {
+		t.Errorf("expected default estimate, got %s", got)
+	}
+
+	h.Add(TypeCode, "exec", time.Minute, 1)
+	h.Add(TypeCode, "kimi", 3*time.Minute, 1)
+	if got := h.Estimate(TypeCode, "Kimi"); got != 3*time.Minute {
+		t.Errorf("expected adapter-specific mean, got %s", got)
+	}
+	if got := h.Estimate(TypeCode, "codex"); got != 2*time.Minute {
+		t.Errorf("expected type mean for unknown adapter, got %s", got)
+	}
+	if got := h.Estimate(TypeTest, ""); got != 2*time.Minute {
+		t.Errorf("expected overall mean for unknown type, got %s", got)
+	}
+}
+
+func TestRenderCritical(t *testing.T) {
+	g := newTestGraph(
+		&Task{ID: "a", Title: "Task A", Status: StatusPending},
+		&Task{ID: "b", Title: "Task B", Status: StatusPending, DependsOn: []string{"a"}},
+		&Task{ID: "c", Title: "Task C", Status: StatusPending},
+	)
+	cp := g.CriticalPath(func(t *Task) time.Duration {
+		if t.ID == "c" {
+			return time.Minute
+		}
+		return 2 * time.Minute
+	})
+
+	dot := g.RenderDOTCritical(cp)
+	if !strings.Contains(dot, `"a" [label="Task A", style=filled, fillcolor="gold", color="red", penwidth=3]`) {
+		t.Errorf("expected critical node a to be outlined:\n%s", dot)
+	}
+	if !strings.Contains(dot, `"a" -> "b" [color="red", penwidth=2]`) {
+		t.Errorf("expected critical edge a -> b:\n%s", dot)
+	}
+	if strings.Contains(dot, `"c" [label="Task C", style=filled, fillcolor="gold", color="red"`) {
+		t.Errorf("non-critical node c should not be outlined:\n%s", dot)
+	}
+
+	ascii := g.RenderASCIICritical(80, cp)
+	if !strings.Contains(ascii, "Task A] \u2605") {
+		t.Errorf("expected critical marker on Task A:\n%s", ascii)
+	}
+	if !strings.Contains(ascii, "Task C] (slack 3m)") {
+		t.Errorf("expected slack on Task C:\n%s", ascii)
+	}
+	if !strings.Contains(ascii, "Critical path (4m): a -> b") {
+		t.Errorf("expected critical path summary:\n%s", ascii)
+	}
+
+	if g.RenderDOT() == dot {
+		t.Error("RenderDOT without a critical path should not highlight")
+	}
+}
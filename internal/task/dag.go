@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 // statusDOTColor returns a Graphviz color for the given task status.
@@ -50,6 +51,12 @@ func statusASCIIIcon(s Status) string {
 // Nodes are labeled with the task title and colored by status.
 // Edges run from dependency to dependent task.
 func (g *TaskGraph) RenderDOT() string {
+	return g.RenderDOTCritical(nil)
+}
+
+// RenderDOTCritical is RenderDOT with the tasks and edges of the critical
+// path outlined in red. A nil cp renders the plain graph.
+func (g *TaskGraph) RenderDOTCritical(cp *CriticalPath) string {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -77,8 +84,24 @@ func (g *TaskGraph) RenderDOT() string {
 			label = id
 		}
 		color := statusDOTColor(t.Status)
-		b.WriteString(fmt.Sprintf("  %q [label=%q, style=filled, fillcolor=%q];\n",
-			id, label, color))
+		extra := ""
+		if t.Type == TypeObjective {
+			extra = ", peripheries=2" // delegated to a nested Queen
+		}
+		if cp.IsCritical(id) {
+			b.WriteString(fmt.Sprintf("  %q [label=%q, style=filled, fillcolor=%q, color=\"red\", penwidth=3%s];\n",
+				id, label, color, extra))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  %q [label=%q, style=filled, fillcolor=%q%s];\n",
+			id, label, color, extra))
+	}
+
+	onPath := make(map[string]string) // task -> its predecessor on the critical path
+	if cp != nil {
+		for i := 1; i < len(cp.Path); i++ {
+			onPath[cp.Path[i]] = cp.Path[i-1]
+		}
 	}
 
 	// Emit edges: dependency -> dependent.
@@ -87,6 +110,10 @@ func (g *TaskGraph) RenderDOT() string {
 		for _, depID := range t.DependsOn {
 			// Only emit edge if both nodes exist in the graph.
 			if _, ok := g.tasks[depID]; ok {
+				if onPath[id] == depID {
+					b.WriteString(fmt.Sprintf("  %q -> %q [color=\"red\", penwidth=2];\n", depID, id))
+					continue
+				}
 				b.WriteString(fmt.Sprintf("  %q -> %q;\n", depID, id))
 			}
 		}
@@ -155,6 +182,13 @@ func taskDepth(tasks map[string]*Task) map[string]int {
 // Tasks are grouped by depth (distance from root nodes) and displayed
 // with status icons and dependency arrows.
 func (g *TaskGraph) RenderASCII(width int) string {
+	return g.RenderASCIICritical(width, nil)
+}
+
+// RenderASCIICritical is RenderASCII with critical tasks marked "★", the
+// slack of other unfinished tasks shown, and the critical path summarised at
+// the end. A nil cp renders the plain graph.
+func (g *TaskGraph) RenderASCIICritical(width int, cp *CriticalPath) string {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -209,7 +243,7 @@ func (g *TaskGraph) RenderASCII(width int) string {
 			if len(title) > maxTitle {
 				title = title[:maxTitle-1] + "\u2026"
 			}
-			b.WriteString(fmt.Sprintf("  [%s %s]\n", icon, title))
+			b.WriteString(fmt.Sprintf("  [%s %s]%s\n", icon, title, criticalSuffix(cp, id)))
 		}
 	}
 
@@ -245,5 +279,41 @@ func (g *TaskGraph) RenderASCII(width int) string {
 		}
 	}
 
+	if cp != nil && len(cp.Path) > 0 {
+		b.WriteString(fmt.Sprintf("\n\u2605 Critical path (%s): %s\n",
+			FormatEstimate(cp.Length), strings.Join(cp.Path, " -> ")))
+	}
+
 	return b.String()
 }
+
+// criticalSuffix annotates a task line with its critical-path status.
+func criticalSuffix(cp *CriticalPath, id string) string {
+	if cp == nil {
+		return ""
+	}
+	slack, ok := cp.Slack[id]
+	switch {
+	case !ok:
+		return ""
+	case slack == 0:
+		return " \u2605"
+	default:
+		return " (slack " + FormatEstimate(slack) + ")"
+	}
+}
+
+// FormatEstimate renders an estimated duration compactly, e.g. "45s", "3m10s", "1h5m".
+func FormatEstimate(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	if d < time.Hour {
+		d = d.Round(time.Second)
+		if d%time.Minute == 0 {
+			return strings.TrimSuffix(d.String(), "0s")
+		}
+		return d.String()
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
@@ -0,0 +1,469 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NodeInfo carries per-task details that live outside the Task itself,
+// such as the adapter that ran it and its attempt history.
+type NodeInfo struct {
+	Adapter    string
+	Attempts   int
+	LastError  string
+	SubSession string // session of the nested Queen that ran an objective task
+}
+
+// RenderOptions configures the Mermaid, JSON, SVG and HTML renderers.
+// All fields are optional.
+type RenderOptions struct {
+	Title    string
+	Critical *CriticalPath
+	Nodes    map[string]NodeInfo
+}
+
+// statusHexColor returns a fill colour for SVG output, matching statusDOTColor.
+func statusHexColor(s Status) string {
+	switch s {
+	case StatusComplete:
+		return "#b7e4c7"
+	case StatusRunning, StatusAssigned:
+		return "#a9cce3"
+	case StatusPending:
+		return "#fde68a"
+	case StatusFailed:
+		return "#f5b7b1"
+	case StatusRetrying:
+		return "#f8c471"
+	case StatusCancelled:
+		return "#d5d8dc"
+	default:
+		return "#ffffff"
+	}
+}
+
+// sortedIDs returns the graph's task IDs in order. Callers hold g.mu.
+func (g *TaskGraph) sortedIDs() []string {
+	ids := make([]string, 0, len(g.tasks))
+	for id := range g.tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// taskLabel returns the task title, or its ID when untitled.
+func taskLabel(t *Task) string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return t.ID
+}
+
+// taskDuration returns how long a task ran (or has been running), or zero.
+func taskDuration(t *Task) time.Duration {
+	if t.StartedAt == nil {
+		return 0
+	}
+	if t.CompletedAt != nil {
+		return t.CompletedAt.Sub(*t.StartedAt)
+	}
+	if t.Status == StatusRunning {
+		return time.Since(*t.StartedAt)
+	}
+	return 0
+}
+
+// taskError returns the most relevant error message for a task.
+func taskError(t *Task, info NodeInfo) string {
+	if t.Result != nil {
+		for _, e := range t.Result.Errors {
+			if strings.TrimSpace(e) != "" {
+				return e
+			}
+		}
+	}
+	if t.LastError != "" {
+		return t.LastError
+	}
+	return info.LastError
+}
+
+// tooltip summarises a task for hover text: status, duration, retries and error.
+func tooltip(t *Task, info NodeInfo, cp *CriticalPath) string {
+	lines := []string{fmt.Sprintf("%s (%s, %s)", taskLabel(t), t.ID, t.Status)}
+	if d := taskDuration(t); d > 0 {
+		lines = append(lines, "duration: "+FormatEstimate(d))
+	}
+	if info.Adapter != "" {
+		lines = append(lines, "adapter: "+info.Adapter)
+	}
+	if info.SubSession != "" {
+		lines = append(lines, "sub-session: "+info.SubSession)
+	}
+	if info.Attempts > 1 {
+		lines = append(lines, fmt.Sprintf("attempts: %d", info.Attempts))
+	}
+	if t.RetryCount > 0 {
+		lines = append(lines, fmt.Sprintf("retries: %d", t.RetryCount))
+	}
+	if slack, ok := cp.slack(t.ID); ok {
+		if slack == 0 {
+			lines = append(lines, "on critical path")
+		} else {
+			lines = append(lines, "slack: "+FormatEstimate(slack))
+		}
+	}
+	if e := taskError(t, info); e != "" {
+		lines = append(lines, "error: "+truncateRunes(strings.ReplaceAll(e, "\n", " "), 200))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *CriticalPath) slack(id string) (time.Duration, bool) {
+	if c == nil {
+		return 0, false
+	}
+	s, ok := c.Slack[id]
+	return s, ok
+}
+
+// criticalEdges returns the set of "dep->id" edges along the critical path.
+func criticalEdges(cp *CriticalPath) map[string]bool {
+	edges := make(map[string]bool)
+	if cp == nil {
+		return edges
+	}
+	for i := 1; i < len(cp.Path); i++ {
+		edges[cp.Path[i-1]+"->"+cp.Path[i]] = true
+	}
+	return edges
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// RenderMermaid outputs the task graph as a Mermaid flowchart, suitable for
+// pasting into Markdown. Critical-path edges are drawn thick.
+func (g *TaskGraph) RenderMermaid(opts RenderOptions) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var b strings.Builder
+	if opts.Title != "" {
+		fmt.Fprintf(&b, "---\ntitle: %q\n---\n", opts.Title)
+	}
+	b.WriteString("flowchart LR\n")
+
+	// Mermaid node IDs are restricted, so number nodes and keep task IDs in labels.
+	ids := g.sortedIDs()
+	nodeID := make(map[string]string, len(ids))
+	for i, id := range ids {
+		nodeID[id] = fmt.Sprintf("n%d", i)
+	}
+
+	var critical []string
+	for _, id := range ids {
+		t := g.tasks[id]
+		label := strings.ReplaceAll(taskLabel(t), `"`, "#quot;")
+		if t.Type == TypeObjective {
+			// Subroutine shape marks work delegated to a nested Queen
+			if sub := opts.Nodes[id].SubSession; sub != "" {
+				label += " ↳ " + sub
+			}
+			fmt.Fprintf(&b, "  %s[[\"%s %s\"]]:::%s\n", nodeID[id], statusASCIIIcon(t.Status), label, t.Status)
+		} else {
+			fmt.Fprintf(&b, "  %s[\"%s %s\"]:::%s\n", nodeID[id], statusASCIIIcon(t.Status), label, t.Status)
+		}
+		if opts.Critical.IsCritical(id) {
+			critical = append(critical, nodeID[id])
+		}
+	}
+
+	crit := criticalEdges(opts.Critical)
+	for _, id := range ids {
+		for _, dep := range g.tasks[id].DependsOn {
+			if _, ok := g.tasks[dep]; !ok {
+				continue
+			}
+			arrow := "-->"
+			if crit[dep+"->"+id] {
+				arrow = "==>"
+			}
+			fmt.Fprintf(&b, "  %s %s %s\n", nodeID[dep], arrow, nodeID[id])
+		}
+	}
+
+	for _, s := range []Status{StatusPending, StatusAssigned, StatusRunning, StatusComplete, StatusFailed, StatusRetrying, StatusCancelled} {
+		fmt.Fprintf(&b, "  classDef %s fill:%s,stroke:#555\n", s, statusHexColor(s))
+	}
+	b.WriteString("  classDef critical stroke:#d62728,stroke-width:3px\n")
+	if len(critical) > 0 {
+		fmt.Fprintf(&b, "  class %s critical\n", strings.Join(critical, ","))
+	}
+	return b.String()
+}
+
+// DAGNode is a task in the JSON rendering of a graph.
+type DAGNode struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Type            Type       `json:"type"`
+	Status          Status     `json:"status"`
+	Priority        Priority   `json:"priority"`
+	DependsOn       []string   `json:"depends_on,omitempty"`
+	WorkerID        string     `json:"worker_id,omitempty"`
+	Adapter         string     `json:"adapter,omitempty"`
+	SubSession      string     `json:"sub_session,omitempty"`
+	Attempts        int        `json:"attempts,omitempty"`
+	Retries         int        `json:"retries,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at,omitzero"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds,omitempty"`
+	Critical        bool       `json:"critical,omitempty"`
+	SlackSeconds    *float64   `json:"slack_seconds,omitempty"`
+}
+
+// DAGEdge is a dependency in the JSON rendering: From must finish before To.
+type DAGEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Critical bool   `json:"critical,omitempty"`
+}
+
+// DAG is the machine-readable form of a task graph.
+type DAG struct {
+	Title        string    `json:"title,omitempty"`
+	Nodes        []DAGNode `json:"nodes"`
+	Edges        []DAGEdge `json:"edges"`
+	CriticalPath []string  `json:"critical_path,omitempty"`
+	// RemainingSeconds is the critical-path length of the unfinished work.
+	RemainingSeconds float64 `json:"remaining_seconds,omitempty"`
+}
+
+// DAG returns the graph as plain data, ready for JSON encoding.
+func (g *TaskGraph) DAG(opts RenderOptions) DAG {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	d := DAG{Title: opts.Title, Nodes: []DAGNode{}, Edges: []DAGEdge{}}
+	crit := criticalEdges(opts.Critical)
+	for _, id := range g.sortedIDs() {
+		t := g.tasks[id]
+		info := opts.Nodes[id]
+		n := DAGNode{
+			ID:          t.ID,
+			Title:       t.Title,
+			Type:        t.Type,
+			Status:      t.Status,
+			Priority:    t.Priority,
+			DependsOn:   t.DependsOn,
+			WorkerID:    t.WorkerID,
+			Adapter:     info.Adapter,
+			SubSession:  info.SubSession,
+			Attempts:    info.Attempts,
+			Retries:     t.RetryCount,
+			Error:       taskError(t, info),
+			CreatedAt:   t.CreatedAt,
+			StartedAt:   t.StartedAt,
+			CompletedAt: t.CompletedAt,
+			Critical:    opts.Critical.IsCritical(id),
+		}
+		if dur := taskDuration(t); dur > 0 {
+			n.DurationSeconds = dur.Seconds()
+		}
+		if slack, ok := opts.Critical.slack(id); ok {
+			s := slack.Seconds()
+			n.SlackSeconds = &s
+		}
+		d.Nodes = append(d.Nodes, n)
+
+		for _, dep := range t.DependsOn {
+			if _, ok := g.tasks[dep]; ok {
+				d.Edges = append(d.Edges, DAGEdge{From: dep, To: id, Critical: crit[dep+"->"+id]})
+			}
+		}
+	}
+	if opts.Critical != nil {
+		d.CriticalPath = opts.Critical.Path
+		d.RemainingSeconds = opts.Critical.Length.Seconds()
+	}
+	return d
+}
+
+// RenderJSON outputs the graph as indented JSON (see DAG).
+func (g *TaskGraph) RenderJSON(opts RenderOptions) ([]byte, error) {
+	return json.MarshalIndent(g.DAG(opts), "", "  ")
+}
+
+// SVG layout constants.
+const (
+	svgNodeW   = 200
+	svgNodeH   = 46
+	svgColGap  = 70
+	svgRowGap  = 18
+	svgMargin  = 20
+	svgTitleH  = 30
+	svgMaxText = 26
+)
+
+// RenderSVG draws the graph as a standalone SVG with tasks laid out in
+// columns by dependency depth. Each node has a hover tooltip with its
+// duration, retries and last error; critical tasks are outlined in red.
+func (g *TaskGraph) RenderSVG(opts RenderOptions) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	depths := taskDepth(g.tasks)
+	columns := make(map[int][]string)
+	maxDepth, maxRows := 0, 0
+	for _, id := range g.sortedIDs() {
+		d := depths[id]
+		columns[d] = append(columns[d], id)
+		maxDepth = max(maxDepth, d)
+		maxRows = max(maxRows, len(columns[d]))
+	}
+
+	top := svgMargin
+	if opts.Title != "" {
+		top += svgTitleH
+	}
+	width := 2*svgMargin + (maxDepth+1)*svgNodeW + maxDepth*svgColGap
+	height := top + svgMargin + maxRows*svgNodeH + max(maxRows-1, 0)*svgRowGap
+	if len(g.tasks) == 0 {
+		width, height = 2*svgMargin+svgNodeW, top+svgMargin+svgNodeH
+	}
+
+	type point struct{ x, y int }
+	pos := make(map[string]point, len(g.tasks))
+	for d := 0; d <= maxDepth; d++ {
+		for row, id := range columns[d] {
+			pos[id] = point{
+				x: svgMargin + d*(svgNodeW+svgColGap),
+				y: top + row*(svgNodeH+svgRowGap),
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	b.WriteString(`  <defs>
+    <marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#666"/></marker>
+    <marker id="arrow-critical" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#d62728"/></marker>
+  </defs>
+`)
+	if opts.Title != "" {
+		fmt.Fprintf(&b, `  <text x="%d" y="%d" font-size="16" font-weight="bold">%s</text>`+"\n",
+			svgMargin, svgMargin+16, html.EscapeString(opts.Title))
+	}
+	if len(g.tasks) == 0 {
+		fmt.Fprintf(&b, `  <text x="%d" y="%d" fill="#888">(no tasks)</text>`+"\n", svgMargin, top+svgNodeH/2)
+		b.WriteString("</svg>\n")
+		return b.String()
+	}
+
+	// Edges first so nodes are drawn on top.
+	crit := criticalEdges(opts.Critical)
+	for _, id := range g.sortedIDs() {
+		for _, dep := range g.tasks[id].DependsOn {
+			from, ok := pos[dep]
+			if !ok {
+				continue
+			}
+			to := pos[id]
+			x1, y1 := from.x+svgNodeW, from.y+svgNodeH/2
+			x2, y2 := to.x, to.y+svgNodeH/2
+			mid := (x1 + x2) / 2
+			stroke, widthAttr, marker := "#666", 1.5, "arrow"
+			if crit[dep+"->"+id] {
+				stroke, widthAttr, marker = "#d62728", 3, "arrow-critical"
+			}
+			fmt.Fprintf(&b, `  <path d="M%d,%d C%d,%d %d,%d %d,%d" fill="none" stroke="%s" stroke-width="%g" marker-end="url(#%s)"/>`+"\n",
+				x1, y1, mid, y1, mid, y2, x2, y2, stroke, widthAttr, marker)
+		}
+	}
+
+	for _, id := range g.sortedIDs() {
+		t := g.tasks[id]
+		p := pos[id]
+		stroke, strokeW := "#555", 1
+		if opts.Critical.IsCritical(id) {
+			stroke, strokeW = "#d62728", 3
+		}
+		fmt.Fprintf(&b, `  <g class="task %s" id="task-%s">`+"\n", t.Status, html.EscapeString(id))
+		fmt.Fprintf(&b, "    <title>%s</title>\n", html.EscapeString(tooltip(t, opts.Nodes[id], opts.Critical)))
+		dash := ""
+		if t.Type == TypeObjective {
+			dash = ` stroke-dasharray="6 3"`
+		}
+		fmt.Fprintf(&b, `    <rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="%s" stroke="%s" stroke-width="%d"%s/>`+"\n",
+			p.x, p.y, svgNodeW, svgNodeH, statusHexColor(t.Status), stroke, strokeW, dash)
+		fmt.Fprintf(&b, `    <text x="%d" y="%d" font-weight="bold">%s</text>`+"\n",
+			p.x+8, p.y+19, html.EscapeString(truncateRunes(taskLabel(t), svgMaxText)))
+		sub := string(t.Status)
+		if d := taskDuration(t); d > 0 {
+			sub += " · " + FormatEstimate(d)
+		}
+		if t.RetryCount > 0 {
+			sub += fmt.Sprintf(" · %d retries", t.RetryCount)
+		}
+		if s := opts.Nodes[id].SubSession; s != "" {
+			sub += " · ↳ " + s
+		}
+		fmt.Fprintf(&b, `    <text x="%d" y="%d" fill="#444">%s</text>`+"\n",
+			p.x+8, p.y+36, html.EscapeString(sub))
+		b.WriteString("  </g>\n")
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// RenderHTML wraps RenderSVG in a self-contained HTML page with a legend.
+func (g *TaskGraph) RenderHTML(opts RenderOptions) string {
+	title := opts.Title
+	if title == "" {
+		title = "Task graph"
+	}
+	svgOpts := opts
+	svgOpts.Title = ""
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	b.WriteString(`<style>
+body { font-family: sans-serif; margin: 24px; color: #222; }
+.legend span { display: inline-block; margin-right: 14px; }
+.legend i { display: inline-block; width: 12px; height: 12px; border: 1px solid #555; margin-right: 4px; vertical-align: middle; }
+.task:hover rect { filter: brightness(0.93); }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(title))
+	b.WriteString(`<p class="legend">`)
+	for _, s := range []Status{StatusPending, StatusRunning, StatusComplete, StatusFailed, StatusRetrying, StatusCancelled} {
+		fmt.Fprintf(&b, `<span><i style="background:%s"></i>%s</span>`, statusHexColor(s), s)
+	}
+	b.WriteString(`<span><i style="border:3px solid #d62728"></i>critical path</span>`)
+	b.WriteString(`<span><i style="border:1px dashed #555"></i>nested Queen</span></p>` + "\n")
+	if cp := opts.Critical; cp != nil && len(cp.Path) > 0 {
+		fmt.Fprintf(&b, "<p>Critical path (%s remaining): %s</p>\n",
+			FormatEstimate(cp.Length), html.EscapeString(strings.Join(cp.Path, " → ")))
+	}
+	b.WriteString(g.RenderSVG(svgOpts))
+	b.WriteString("<p><small>Hover over a task for details.</small></p>\n</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func renderTestGraph() (*TaskGraph, RenderOptions) {
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	g := newTestGraph(
+		&Task{ID: "a", Title: `Parse "config"`, Status: StatusComplete, StartedAt: &start, CompletedAt: &end},
+		&Task{ID: "b", Title: "Build <api>", Status: StatusFailed, RetryCount: 2, DependsOn: []string{"a"},
+			Result: &Result{Errors: []string{"compile error & more"}}},
+		&Task{ID: "c", Title: "Docs", Status: StatusPending, DependsOn: []string{"a"}},
+	)
+	cp := g.CriticalPath(func(t *Task) time.Duration {
+		if t.ID == "b" {
+			return 5 * time.Minute
+		}
+		return time.Minute
+	})
+	return g, RenderOptions{
+		Title:    "Ship it",
+		Critical: cp,
+		Nodes:    map[string]NodeInfo{"b": {Adapter: "codex", Attempts: 3}},
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	g, opts := renderTestGraph()
+	out := g.RenderMermaid(opts)
+
+	for _, want := range []string{
+		"flowchart LR",
+		`n0["` + statusASCIIIcon(StatusComplete) + ` Parse #quot;config#quot;"]:::complete`,
+		"n0 --> n1",
+		"n0 --> n2",
+		"classDef failed fill:",
+		"class n1 critical",
+		`title: "Ship it"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("mermaid output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	g, opts := renderTestGraph()
+	data, err := g.RenderJSON(opts)
+	if err != nil {
+		t.Fatalf("RenderJSON: %v", err)
+	}
+	var d DAG
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(d.Nodes) != 3 || len(d.Edges) != 2 {
+		t.Fatalf("expected 3 nodes and 2 edges, got %d/%d", len(d.Nodes), len(d.Edges))
+	}
+	a, b := d.Nodes[0], d.Nodes[1]
+	if a.DurationSeconds != 90 || a.StartedAt == nil || a.CompletedAt == nil {
+		t.Errorf("expected timings on a: %+v", a)
+	}
+	if b.Adapter != "codex" || b.Attempts != 3 || b.Retries != 2 || b.Error != "compile error & more" {
+		t.Errorf("unexpected node b: %+v", b)
+	}
+	if !b.Critical || b.SlackSeconds == nil || *b.SlackSeconds != 0 {
+		t.Errorf("expected b on the critical path: %+v", b)
+	}
+	if d.Nodes[2].SlackSeconds == nil || *d.Nodes[2].SlackSeconds != 240 {
+		t.Errorf("expected 240s slack on c: %+v", d.Nodes[2])
+	}
+	if len(d.CriticalPath) != 1 || d.CriticalPath[0] != "b" {
+		t.Errorf("unexpected critical path %v", d.CriticalPath)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	g, opts := renderTestGraph()
+	svg := g.RenderSVG(opts)
+
+	// Must be well-formed XML.
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("invalid SVG: %v\n%s", err, svg)
+			}
+			break
+		}
+	}
+
+	for _, want := range []string{
+		"Build &lt;api&gt;",
+		"retries: 2",
+		"adapter: codex",
+		"error: compile error &amp; more",
+		"duration: 1m30s",
+		`stroke="#d62728" stroke-width="3"`,
+		"slack: 4m",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+	if strings.Count(svg, "<rect") != 3 || strings.Count(svg, "marker-end=") != 2 {
+		t.Errorf("expected 3 nodes and 2 edges:\n%s", svg)
+	}
+}
+
+func TestRenderSVG_Empty(t *testing.T) {
+	svg := NewTaskGraph(nil).RenderSVG(RenderOptions{})
+	if !strings.Contains(svg, "(no tasks)") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("unexpected empty SVG:\n%s", svg)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	g, opts := renderTestGraph()
+	out := g.RenderHTML(opts)
+	for _, want := range []string{"<!DOCTYPE html>", "<title>Ship it</title>", "<svg", "Critical path (5m remaining): b"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if strings.Contains(out, "http://") && !strings.Contains(out, "http://www.w3.org/2000/svg") {
+		t.Error("HTML should not reference external resources")
+	}
+}
@@ -2,6 +2,7 @@ package task
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -37,6 +38,9 @@ const (
 	TypeTest     Type = "test"
 	TypeReview   Type = "review"
 	TypeGeneric  Type = "generic"
+	// TypeObjective delegates a sub-objective to a nested Queen with its own
+	// agent loop, sharing the parent's worker pool and safety guard.
+	TypeObjective Type = "objective"
 )
 
 type Task struct {
@@ -64,6 +68,13 @@ type Task struct {
 	Timeout       time.Duration     `json:"timeout,omitempty"`
 	RetryAfter    time.Time         `json:"retry_after,omitempty"` // backoff: don't schedule before this time
 	DependsOn     []string          `json:"depends_on,omitempty"`
+	Cache         *bool             `json:"cache,omitempty"` // nil = follow config
+	Notes         []string          `json:"notes,omitempty"` // shared blackboard notes included in the worker prompt
+
+	// Results from dependencies included in the worker prompt. DepResults
+	// records what the last worker was given.
+	SkipDepResults bool   `json:"skip_dependency_results,omitempty"`
+	DepResults     string `json:"dependency_results,omitempty"`
 }
 
 // SetResult sets the task result (thread-safe).
@@ -168,6 +179,66 @@ func (t *Task) GetConstraints() []string {
 	return cp
 }
 
+// SetPriority updates the scheduling priority (thread-safe).
+func (t *Task) SetPriority(p Priority) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Priority = p
+}
+
+// GetPriority returns the scheduling priority (thread-safe).
+func (t *Task) GetPriority() Priority {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Priority
+}
+
+// SetTimeout updates the per-task worker timeout (thread-safe).
+func (t *Task) SetTimeout(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Timeout = d
+}
+
+// GetDependsOn returns a copy of the dependency list (thread-safe).
+func (t *Task) GetDependsOn() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	cp := make([]string, len(t.DependsOn))
+	copy(cp, t.DependsOn)
+	return cp
+}
+
+// SetNotes replaces the shared notes passed to the worker (thread-safe).
+func (t *Task) SetNotes(notes []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Notes = notes
+}
+
+// GetNotes returns a copy of the shared notes (thread-safe).
+func (t *Task) GetNotes() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	cp := make([]string, len(t.Notes))
+	copy(cp, t.Notes)
+	return cp
+}
+
+// SetDepResults records the dependency results passed to the worker (thread-safe).
+func (t *Task) SetDepResults(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.DepResults = s
+}
+
+// GetDepResults returns the dependency results passed to the worker (thread-safe).
+func (t *Task) GetDepResults() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.DepResults
+}
+
 // GetStatus returns the current status (thread-safe).
 func (t *Task) GetStatus() Status {
 	t.mu.RLock()
@@ -215,7 +286,34 @@ func (g *TaskGraph) Add(t *Task) {
 func (g *TaskGraph) Remove(id string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	if _, ok := g.tasks[id]; !ok {
+		return
+	}
 	delete(g.tasks, id)
+	if g.bus != nil {
+		g.bus.Publish(bus.Message{
+			Type:   bus.MsgTaskRemoved,
+			TaskID: id,
+			Time:   time.Now(),
+		})
+	}
+}
+
+// Dependents returns the tasks that directly depend on the given task ID.
+func (g *TaskGraph) Dependents(id string) []*Task {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var deps []*Task
+	for _, t := range g.tasks {
+		for _, d := range t.DependsOn {
+			if d == id {
+				deps = append(deps, t)
+				break
+			}
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].ID < deps[j].ID })
+	return deps
 }
 
 func (g *TaskGraph) Get(id string) (*Task, bool) {
@@ -313,12 +411,38 @@ func (g *TaskGraph) Failed() []*Task {
 	return failed
 }
 
+// SetDependsOn replaces a task's dependency list. If the new list would
+// create a cycle the old one is kept and the cycle is returned as an error.
+func (g *TaskGraph) SetDependsOn(id string, deps []string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t, ok := g.tasks[id]
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+	old := t.DependsOn
+	t.mu.Lock()
+	t.DependsOn = deps
+	t.mu.Unlock()
+	if err := g.detectCycles(); err != nil {
+		t.mu.Lock()
+		t.DependsOn = old
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
 // DetectCycles detects circular dependencies in the task graph using DFS.
 // Returns an error describing the cycle if found, or nil if no cycles exist.
 func (g *TaskGraph) DetectCycles() error {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
+	return g.detectCycles()
+}
 
+// detectCycles is DetectCycles for a caller holding g.mu.
+func (g *TaskGraph) detectCycles() error {
 	// Track visited nodes (fully processed)
 	visited := make(map[string]bool)
 	// Track nodes in current recursion stack (being processed)
@@ -264,3 +264,65 @@ func TestDetectCycles_BranchingWithCycle(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskGraphDependents(t *testing.T) {
+	g := NewTaskGraph(nil)
+	g.Add(&Task{ID: "a", Status: StatusPending})
+	g.Add(&Task{ID: "c", Status: StatusPending, DependsOn: []string{"a"}})
+	g.Add(&Task{ID: "b", Status: StatusPending, DependsOn: []string{"a", "x"}})
+	g.Add(&Task{ID: "d", Status: StatusPending, DependsOn: []string{"b"}})
+
+	deps := g.Dependents("a")
+	if len(deps) != 2 || deps[0].ID != "b" || deps[1].ID != "c" {
+		t.Fatalf("expected [b c], got %v", deps)
+	}
+	if len(g.Dependents("d")) != 0 {
+		t.Error("expected no dependents for leaf task")
+	}
+}
+
+func TestTaskGraphRemovePublishes(t *testing.T) {
+	b := bus.New(10)
+	g := NewTaskGraph(b)
+	g.Add(&Task{ID: "a", Status: StatusPending})
+
+	var removed []string
+	b.Subscribe(bus.MsgTaskRemoved, func(msg bus.Message) {
+		removed = append(removed, msg.TaskID)
+	})
+	g.Remove("a")
+	g.Remove("missing")
+
+	if len(removed) != 1 || removed[0] != "a" {
+		t.Errorf("expected one removal event for a, got %v", removed)
+	}
+}
+
+func TestSetDependsOn(t *testing.T) {
+	g := NewTaskGraph(bus.New(100))
+	g.Add(&Task{ID: "C", Status: StatusPending})
+	g.Add(&Task{ID: "B", Status: StatusPending, DependsOn: []string{"C"}})
+	g.Add(&Task{ID: "A", Status: StatusPending, DependsOn: []string{"B"}})
+
+	// C -> A would close the loop A -> B -> C -> A
+	if err := g.SetDependsOn("C", []string{"A"}); err == nil || !strings.Contains(err.Error(), "circular dependency detected") {
+		t.Fatalf("Expected a cycle error, got: %v", err)
+	}
+	c, _ := g.Get("C")
+	if deps := c.GetDependsOn(); len(deps) != 0 {
+		t.Errorf("Expected C's dependencies restored, got %v", deps)
+	}
+	if err := g.DetectCycles(); err != nil {
+		t.Errorf("Expected the graph left acyclic, got: %v", err)
+	}
+
+	if err := g.SetDependsOn("A", []string{"C"}); err != nil {
+		t.Fatalf("SetDependsOn: %v", err)
+	}
+	if deps := g.Dependents("C"); len(deps) != 2 || deps[0].ID != "A" || deps[1].ID != "B" {
+		t.Errorf("Expected A and B to depend on C, got %v", deps)
+	}
+	if err := g.SetDependsOn("missing", nil); err == nil {
+		t.Error("Expected an unknown task to be rejected")
+	}
+}
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -16,25 +18,89 @@ type Program struct {
 	mu          sync.Mutex
 	started     bool
 	buffer      []tea.Msg
-	quiet       bool        // Quiet mode: don't start TUI, print only essentials
-	objectiveCh chan string // receives objective in interactive mode
+	quiet       bool            // Quiet mode: don't start TUI, print only essentials
+	objectiveCh chan string     // receives objective in interactive mode
+	actions     chan TaskAction // operator edits from the task panel
+
+	answers chan PromptAnswer            // replies to prompts, from the model
+	waiters map[string]chan PromptAnswer // prompt ID -> pending Ask
 }
 
 // NewProgram creates a TUI program with a pre-set objective.
 func NewProgram(objective string, maxTurns int) *Program {
-	model := New(objective, maxTurns)
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	return &Program{program: p}
+	return newProgram(New(objective, maxTurns), nil)
 }
 
 // NewInteractiveProgram creates a TUI that prompts for an objective.
 // The objective is sent to the returned channel when the user presses Enter.
 func NewInteractiveProgram(maxTurns int) (*Program, <-chan string) {
 	ch := make(chan string, 1)
-	model := NewInteractive(maxTurns, ch)
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	prog := &Program{program: p, objectiveCh: ch}
-	return prog, ch
+	return newProgram(NewInteractive(maxTurns, ch), ch), ch
+}
+
+func newProgram(model Model, objectiveCh chan string) *Program {
+	actions := make(chan TaskAction, 16)
+	answers := make(chan PromptAnswer, 4)
+	model.actionCh = actions
+	model.answerCh = answers
+	p := &Program{
+		program:     tea.NewProgram(model, tea.WithAltScreen()),
+		objectiveCh: objectiveCh,
+		actions:     actions,
+		answers:     answers,
+		waiters:     make(map[string]chan PromptAnswer),
+	}
+	go p.dispatchAnswers()
+	return p
+}
+
+// TaskActions returns the channel of operator edits made in the task panel.
+func (p *Program) TaskActions() <-chan TaskAction {
+	return p.actions
+}
+
+// Ask shows a prompt and blocks until the operator answers it. If ctx ends
+// first the prompt is withdrawn and ctx's error returned. In quiet mode there
+// is nobody to ask, so Ask fails immediately.
+func (p *Program) Ask(ctx context.Context, prompt PromptMsg) (PromptAnswer, error) {
+	p.mu.Lock()
+	if p.quiet {
+		p.mu.Unlock()
+		return PromptAnswer{}, errors.New("no interactive terminal in quiet mode")
+	}
+	reply := make(chan PromptAnswer, 1)
+	p.waiters[prompt.ID] = reply
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.waiters, prompt.ID)
+		p.mu.Unlock()
+	}()
+
+	p.Send(prompt)
+	select {
+	case a := <-reply:
+		return a, nil
+	case <-ctx.Done():
+		p.Send(PromptClosedMsg{ID: prompt.ID})
+		return PromptAnswer{}, ctx.Err()
+	}
+}
+
+// dispatchAnswers hands each answer from the model to the Ask waiting for it.
+func (p *Program) dispatchAnswers() {
+	for a := range p.answers {
+		p.mu.Lock()
+		reply := p.waiters[a.ID]
+		p.mu.Unlock()
+		if reply != nil {
+			select {
+			case reply <- a:
+			default:
+			}
+		}
+	}
 }
 
 // SetQuiet enables quiet mode where the TUI is not started and only
@@ -163,6 +229,9 @@ func (w *tuiWriter) routeLine(line string) {
 			w.p.Send(QueenThinkingMsg{Text: text})
 		}
 
+	case strings.HasPrefix(trimmed, "💭 "):
+		w.p.Send(QueenReasoningMsg{Text: strings.TrimPrefix(trimmed, "💭 ")})
+
 	case strings.Contains(line, "🔧 Tool:"):
 		if idx := strings.Index(line, "🔧 Tool: "); idx != -1 {
 			name := strings.TrimSpace(line[idx+len("🔧 Tool: "):])
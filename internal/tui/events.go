@@ -1,5 +1,7 @@
 package tui
 
+import "github.com/HexSleeves/waggle/internal/task"
+
 // TUI event types — sent from the Queen/workers to the TUI via tea.Program.Send()
 
 // QueenThinkingMsg is the Queen's text output (her reasoning).
@@ -7,6 +9,22 @@ type QueenThinkingMsg struct {
 	Text string
 }
 
+// QueenReasoningMsg is a line of the model's reasoning behind a Queen
+// response (extended thinking), logged once the response is complete.
+type QueenReasoningMsg struct {
+	Text string
+}
+
+// QueenStreamMsg is a fragment of the Queen's LLM response while it streams.
+// Kind is "start" (a new attempt: drop partial output), "thinking", "text",
+// "tool_call" (ToolName) or "tool_input" (a fragment of the tool call's JSON
+// input).
+type QueenStreamMsg struct {
+	Kind     string
+	Text     string
+	ToolName string
+}
+
 // ToolCallMsg is when the Queen invokes a tool.
 type ToolCallMsg struct {
 	Name  string
@@ -30,6 +48,42 @@ type TaskUpdateMsg struct {
 	DependsOn []string
 }
 
+// TaskRemovedMsg is sent when a task is deleted from the graph.
+type TaskRemovedMsg struct {
+	ID string
+}
+
+// TaskHeldMsg reports that a ready task is held back because a running task
+// claims an overlapping path. An empty BlockedBy means the hold was released.
+type TaskHeldMsg struct {
+	ID        string
+	BlockedBy string
+	Path      string
+}
+
+// SubSessionMsg reports the progress of a child Queen working on an objective
+// task in its own session.
+type SubSessionMsg struct {
+	ID        string // parent task
+	SessionID string
+	Done      int
+	Total     int
+}
+
+// CostMsg reports the session's running cost.
+type CostMsg struct {
+	USD       float64
+	Tokens    int
+	BudgetUSD float64 // 0 = no session budget
+}
+
+// DurationHistoryMsg supplies historical task durations and the worker pool
+// size so the TUI can compute a critical-path-aware ETA.
+type DurationHistoryMsg struct {
+	History  *task.DurationHistory
+	Parallel int
+}
+
 // WorkerUpdateMsg is a worker status change.
 type WorkerUpdateMsg struct {
 	ID     string
@@ -63,3 +117,37 @@ type WorkerOutputMsg struct {
 	WorkerID string
 	Output   string
 }
+
+// TaskAction is an operator edit requested from the task panel. It flows the
+// other way — from the TUI to the command layer, which queues it for the Queen.
+type TaskAction struct {
+	Kind          string // control request kind: "cancel_task", "update_task", "delete_task", "say"
+	TaskID        string
+	Cascade       bool   // cancel_task: also cancel dependents
+	PriorityDelta int    // update_task: relative priority change
+	Text          string // say: message for the Queen
+}
+
+// PromptMsg asks the operator a question: an approval gate (Approval is set;
+// answered yes or no with an optional reason) or a free-form question from
+// the Queen's ask_user tool.
+type PromptMsg struct {
+	ID       string
+	Title    string
+	Details  string
+	Options  []string
+	Approval bool
+}
+
+// PromptClosedMsg withdraws a prompt that was not answered in time.
+type PromptClosedMsg struct {
+	ID string
+}
+
+// PromptAnswer is the operator's reply to a PromptMsg. Like TaskAction it
+// flows from the TUI to the command layer.
+type PromptAnswer struct {
+	ID       string
+	Approved bool
+	Text     string // answer, or the reason for a rejection
+}
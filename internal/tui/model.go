@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/HexSleeves/waggle/internal/task"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/progress"
@@ -54,18 +55,26 @@ type keyMap struct {
 	TaskFocus  key.Binding
 	TaskSelect key.Binding
 
+	TaskCancel  key.Binding
+	TaskCascade key.Binding
+	TaskDelete  key.Binding
+	TaskRaise   key.Binding
+	TaskLower   key.Binding
+
+	Message    key.Binding
 	ToggleHelp key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.ScrollUp, k.ScrollDown, k.NextView, k.TaskFocus, k.ToggleHelp, k.Quit}
+	return []key.Binding{k.ScrollUp, k.ScrollDown, k.NextView, k.TaskFocus, k.Message, k.ToggleHelp, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.ScrollUp, k.ScrollDown, k.NextView, k.PrevView},
 		{k.WorkerLeft, k.WorkerRight, k.QueenView, k.ToggleDAG},
-		{k.TaskFocus, k.TaskSelect, k.ToggleHelp, k.Quit},
+		{k.TaskFocus, k.TaskSelect, k.Message, k.ToggleHelp, k.Quit},
+		{k.TaskCancel, k.TaskCascade, k.TaskDelete, k.TaskRaise, k.TaskLower},
 	}
 }
 
@@ -77,7 +86,14 @@ type TaskInfo struct {
 	Status    string
 	WorkerID  string
 	DependsOn []string
+	HeldBy    string // running task whose path claim blocks this one
+	HeldPath  string
 	Order     int // insertion order
+
+	// Objective tasks: the child Queen's session and its progress
+	SubSession string
+	SubDone    int
+	SubTotal   int
 }
 
 // WorkerInfo tracks worker state for display.
@@ -94,14 +110,17 @@ type Model struct {
 	// Content
 	objective  string
 	queenLines []queenLine    // Queen panel lines
+	stream     queenStream    // partial output of the Queen's LLM call in flight
 	tasks      []TaskInfo     // ordered task list
 	taskMap    map[string]int // task ID -> index in tasks slice
 	workers    map[string]*WorkerInfo
 
 	// Progress / ETA tracking
-	taskStartTimes      map[string]time.Time // task ID -> when it started running
-	taskCompletionTimes []time.Duration      // how long each completed task took
-	firstTaskStarted    time.Time            // when the very first task began
+	taskStartTimes      map[string]time.Time  // task ID -> when it started running
+	taskCompletionTimes []time.Duration       // how long each completed task took
+	history             *task.DurationHistory // durations from earlier sessions
+	parallel            int                   // worker pool size
+	firstTaskStarted    time.Time             // when the very first task began
 
 	// State
 	turn      int
@@ -110,6 +129,7 @@ type Model struct {
 	done      bool
 	success   bool
 	finalMsg  string
+	cost      CostMsg // session spend so far
 
 	// UI state
 	width          int
@@ -128,6 +148,16 @@ type Model struct {
 	input          inputState
 	objectiveInput textinput.Model
 	objectiveCh    chan<- string // channel to send objective when submitted
+	composing      bool          // typing a message for the Queen in objectiveInput
+
+	// Task edits (cancel/delete/reprioritize) sent to the command layer
+	actionCh chan<- TaskAction
+
+	// Operator prompts (approval gates, ask_user), answered oldest first
+	prompts      []PromptMsg
+	promptInput  textinput.Model
+	promptReason bool // approval prompt: typing the reason for a rejection
+	answerCh     chan<- PromptAnswer
 
 	keys     keyMap
 	help     help.Model
@@ -138,9 +168,24 @@ type Model struct {
 	quitting bool
 }
 
+// queenStream accumulates a streaming Queen response until the complete
+// response is logged.
+type queenStream struct {
+	thinking string
+	text     string
+	tools    []streamTool
+}
+
+type streamTool struct {
+	name  string
+	input string
+}
+
+func (s queenStream) empty() bool { return s.thinking == "" && s.text == "" && len(s.tools) == 0 }
+
 type queenLine struct {
 	text  string
-	style string // "think", "tool", "result", "error", "info"
+	style string // "think", "reason", "tool", "result", "error", "info"
 }
 
 // New creates a new TUI model with a pre-set objective.
@@ -216,6 +261,13 @@ func (m *Model) initBubbles() {
 		TaskFocus:   key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tasks focus")),
 		TaskSelect:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open worker")),
 
+		TaskCancel:  key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "cancel task")),
+		TaskCascade: key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "cancel + dependents")),
+		TaskDelete:  key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "delete task")),
+		TaskRaise:   key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", "raise priority")),
+		TaskLower:   key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "lower priority")),
+
+		Message:    key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "message queen")),
 		ToggleHelp: key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
 	}
 
@@ -239,6 +291,12 @@ func (m *Model) initBubbles() {
 	m.objectiveInput.Cursor.Style = subtleStyle.Copy().Reverse(true)
 	m.objectiveInput.Width = 60
 
+	m.promptInput = textinput.New()
+	m.promptInput.Prompt = "> "
+	m.promptInput.CharLimit = 2000
+	m.promptInput.Cursor.Style = subtleStyle.Copy().Reverse(true)
+	m.promptInput.Width = 60
+
 	m.taskTable = table.New(
 		table.WithColumns([]table.Column{
 			{Title: "S", Width: 3},
@@ -265,6 +323,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.input == inputWaiting {
 			return m.handleInputKey(msg)
 		}
+		if len(m.prompts) > 0 {
+			return m.handlePromptKey(msg)
+		}
+		if m.composing {
+			return m.handleMessageKey(msg)
+		}
 
 		if m.taskTable.Focused() {
 			switch {
@@ -274,6 +338,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, m.keys.TaskSelect):
 				m.openSelectedTaskWorker()
 				return m, nil
+			case key.Matches(msg, m.keys.TaskCancel):
+				m.sendTaskAction(TaskAction{Kind: "cancel_task"})
+				return m, nil
+			case key.Matches(msg, m.keys.TaskCascade):
+				m.sendTaskAction(TaskAction{Kind: "cancel_task", Cascade: true})
+				return m, nil
+			case key.Matches(msg, m.keys.TaskDelete):
+				m.sendTaskAction(TaskAction{Kind: "delete_task"})
+				return m, nil
+			case key.Matches(msg, m.keys.TaskRaise):
+				m.sendTaskAction(TaskAction{Kind: "update_task", PriorityDelta: 1})
+				return m, nil
+			case key.Matches(msg, m.keys.TaskLower):
+				m.sendTaskAction(TaskAction{Kind: "update_task", PriorityDelta: -1})
+				return m, nil
 			}
 
 			var cmd tea.Cmd
@@ -335,6 +414,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case key.Matches(msg, m.keys.TaskFocus):
 			m.taskTable.Focus()
+		case key.Matches(msg, m.keys.Message):
+			if !m.done && m.actionCh != nil {
+				m.composing = true
+				m.objectiveInput.Reset()
+				m.objectiveInput.Placeholder = "Message for the Queen"
+				m.objectiveInput.Focus()
+			}
 		case key.Matches(msg, m.keys.ToggleHelp):
 			m.help.ShowAll = !m.help.ShowAll
 		default:
@@ -359,11 +445,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case QueenStreamMsg:
+		switch msg.Kind {
+		case "start":
+			m.stream = queenStream{}
+		case "thinking":
+			m.stream.thinking += msg.Text
+		case "text":
+			m.stream.text += msg.Text
+		case "tool_call":
+			m.stream.tools = append(m.stream.tools, streamTool{name: msg.ToolName})
+		case "tool_input":
+			if n := len(m.stream.tools); n > 0 {
+				m.stream.tools[n-1].input += msg.Text
+			}
+		}
+		m.syncQueenViewport(true)
+
+	case QueenReasoningMsg:
+		// The complete reasoning replaces its streamed form.
+		m.stream.thinking = ""
+		m.addQueenLine("💭 "+msg.Text, "reason")
+		m.syncQueenViewport(true)
+
 	case QueenThinkingMsg:
+		m.stream = queenStream{}
 		m.addQueenLine(msg.Text, "think")
 		m.syncQueenViewport(true)
 
 	case ToolCallMsg:
+		m.stream = queenStream{}
 		line := "→ " + msg.Name
 		if msg.Input != "" {
 			input := msg.Input
@@ -403,16 +514,64 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateTask(msg)
 		m.refreshViewports(false, false)
 
+	case TaskRemovedMsg:
+		m.removeTask(msg.ID)
+		m.refreshViewports(false, false)
+
+	case DurationHistoryMsg:
+		m.history = msg.History
+		m.parallel = msg.Parallel
+		m.refreshViewports(false, false)
+
+	case TaskHeldMsg:
+		if idx, ok := m.taskMap[msg.ID]; ok {
+			m.tasks[idx].HeldBy = msg.BlockedBy
+			m.tasks[idx].HeldPath = msg.Path
+			m.refreshViewports(false, false)
+		}
+
+	case CostMsg:
+		m.cost = msg
+
+	case PromptMsg:
+		m.prompts = append(m.prompts, msg)
+		if len(m.prompts) == 1 {
+			m.resetPrompt()
+		}
+
+	case PromptClosedMsg:
+		for i, p := range m.prompts {
+			if p.ID == msg.ID {
+				m.prompts = append(m.prompts[:i], m.prompts[i+1:]...)
+				m.addQueenLine("⌛ No answer in time: "+p.Title, "info")
+				m.syncQueenViewport(true)
+				if i == 0 {
+					m.resetPrompt()
+				}
+				break
+			}
+		}
+
+	case SubSessionMsg:
+		if idx, ok := m.taskMap[msg.ID]; ok {
+			m.tasks[idx].SubSession = msg.SessionID
+			m.tasks[idx].SubDone = msg.Done
+			m.tasks[idx].SubTotal = msg.Total
+			m.refreshViewports(false, false)
+		}
+
 	case WorkerUpdateMsg:
 		m.updateWorker(msg)
 
 	case TurnMsg:
+		m.stream = queenStream{}
 		m.turn = msg.Turn
 		m.maxTurn = msg.MaxTurn
 		m.addQueenLine("", "info") // blank separator
 		m.syncQueenViewport(true)
 
 	case DoneMsg:
+		m.stream = queenStream{}
 		m.done = true
 		m.success = msg.Success
 		if msg.Error != "" {
@@ -481,6 +640,121 @@ func (m Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handleMessageKey edits a message for the Queen. Enter queues it for the
+// Queen's next turn; Esc discards it.
+func (m Model) handleMessageKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.composing = false
+		m.objectiveInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		text := strings.TrimSpace(m.objectiveInput.Value())
+		if text == "" {
+			return m, nil
+		}
+		m.composing = false
+		m.objectiveInput.Blur()
+		m.sendMessage(text)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.objectiveInput, cmd = m.objectiveInput.Update(msg)
+	return m, cmd
+}
+
+// sendMessage queues a message for the Queen, delivered before its next turn.
+func (m *Model) sendMessage(text string) {
+	select {
+	case m.actionCh <- TaskAction{Kind: "say", Text: text}:
+		m.addQueenLine("💬 You: "+text, "info")
+	default:
+		m.addQueenLine("⚠ Too many pending messages, try again", "error")
+	}
+	m.syncQueenViewport(true)
+}
+
+// handlePromptKey answers the oldest pending prompt. An approval takes y or n;
+// after n the operator may type a reason (Enter sends, Esc goes back). A
+// question takes a typed answer.
+func (m Model) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		m.quitting = true
+		return m, tea.Quit
+	}
+	p := m.prompts[0]
+	if p.Approval && !m.promptReason {
+		switch msg.String() {
+		case "y", "Y":
+			m.answerPrompt(PromptAnswer{ID: p.ID, Approved: true})
+		case "n", "N":
+			m.promptReason = true
+			m.promptInput.Placeholder = "Reason (optional)"
+			m.promptInput.Focus()
+		}
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		if p.Approval {
+			m.resetPrompt()
+		}
+		return m, nil
+	case tea.KeyEnter:
+		text := strings.TrimSpace(m.promptInput.Value())
+		if p.Approval {
+			m.answerPrompt(PromptAnswer{ID: p.ID, Text: text})
+		} else if text != "" {
+			m.answerPrompt(PromptAnswer{ID: p.ID, Approved: true, Text: text})
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.promptInput, cmd = m.promptInput.Update(msg)
+	return m, cmd
+}
+
+// answerPrompt sends the answer to the oldest prompt and moves on to the next.
+func (m *Model) answerPrompt(a PromptAnswer) {
+	p := m.prompts[0]
+	m.prompts = m.prompts[1:]
+	m.resetPrompt()
+
+	if m.answerCh != nil {
+		select {
+		case m.answerCh <- a:
+		default:
+		}
+	}
+	reply := a.Text
+	switch {
+	case !a.Approved:
+		reply = strings.TrimSpace("rejected " + a.Text)
+	case p.Approval:
+		reply = strings.TrimSpace("approved " + a.Text)
+	}
+	m.addQueenLine(fmt.Sprintf("🙋 %s → %s", p.Title, reply), "info")
+	m.syncQueenViewport(true)
+}
+
+// resetPrompt clears the input for the prompt now at the front of the queue.
+func (m *Model) resetPrompt() {
+	m.promptReason = false
+	m.promptInput.Reset()
+	m.promptInput.Placeholder = "Type your answer"
+	if len(m.prompts) > 0 && !m.prompts[0].Approval {
+		m.promptInput.Focus()
+	} else {
+		m.promptInput.Blur()
+	}
+}
+
 func (m Model) normalizedSize() (w, h int) {
 	w = m.width
 	if w < 40 {
@@ -504,6 +778,7 @@ func (m *Model) syncInputWidth() {
 	}
 	// Border + padding in the view consume 2 columns.
 	m.objectiveInput.Width = inputW - 2
+	m.promptInput.Width = inputW - 4
 	if m.objectiveInput.Width < 1 {
 		m.objectiveInput.Width = 1
 	}
@@ -640,6 +915,18 @@ func (m *Model) syncTaskTable() {
 			worker = wid
 		}
 
+		if t.SubSession != "" {
+			taskTitle += fmt.Sprintf(" ↳ %s %d/%d", t.SubSession, t.SubDone, t.SubTotal)
+		}
+
+		if t.HeldBy != "" && t.Status == "pending" {
+			taskTitle += " ⏸ " + t.HeldPath
+			worker = "held:" + t.HeldBy
+			if len(worker) > workerW {
+				worker = worker[:workerW-1] + "…"
+			}
+		}
+
 		if !m.taskTable.Focused() && m.viewMode == viewWorker && t.WorkerID == m.viewWorkerID {
 			selectedRow = idx
 		}
@@ -682,6 +969,31 @@ func (m *Model) openSelectedTaskWorker() {
 	m.syncTaskTable()
 }
 
+// sendTaskAction queues an edit for the selected task. The Queen applies it at
+// its next turn and reports the outcome in the Queen panel.
+func (m *Model) sendTaskAction(a TaskAction) {
+	idx := m.taskTable.Cursor()
+	if idx < 0 || idx >= len(m.tasks) || m.actionCh == nil {
+		return
+	}
+	a.TaskID = m.tasks[idx].ID
+
+	select {
+	case m.actionCh <- a:
+		label := strings.TrimSuffix(a.Kind, "_task")
+		if a.Cascade {
+			label += " (cascade)"
+		}
+		if a.PriorityDelta != 0 {
+			label = fmt.Sprintf("priority %+d", a.PriorityDelta)
+		}
+		m.addQueenLine(fmt.Sprintf("⏳ Queued %s for %s", label, a.TaskID), "info")
+	default:
+		m.addQueenLine("⚠ Too many pending task edits, try again", "error")
+	}
+	m.syncQueenViewport(true)
+}
+
 func (m *Model) refreshViewports(gotoQueenBottom, gotoWorkerBottom bool) {
 	m.syncQueenViewport(gotoQueenBottom)
 	m.syncWorkerViewport(gotoWorkerBottom)
@@ -777,6 +1089,19 @@ func (m *Model) updateTask(msg TaskUpdateMsg) {
 	}
 }
 
+func (m *Model) removeTask(id string) {
+	idx, ok := m.taskMap[id]
+	if !ok {
+		return
+	}
+	m.tasks = append(m.tasks[:idx], m.tasks[idx+1:]...)
+	m.taskMap = make(map[string]int, len(m.tasks))
+	for i, t := range m.tasks {
+		m.taskMap[t.ID] = i
+	}
+	delete(m.taskStartTimes, id)
+}
+
 func (m *Model) updateWorker(msg WorkerUpdateMsg) {
 	if msg.Status == "done" || msg.Status == "failed" {
 		delete(m.workers, msg.ID)
@@ -817,20 +1142,51 @@ func (m Model) taskStats() (done, running, failed, total int) {
 
 // estimateETA returns the estimated time remaining, or zero if unavailable.
 func (m Model) estimateETA() time.Duration {
-	if len(m.taskCompletionTimes) == 0 {
+	cp := m.criticalPath()
+	if cp == nil {
 		return 0
 	}
-	done, _, _, total := m.taskStats()
-	remaining := total - done
-	if remaining <= 0 {
-		return 0
+	parallel := m.parallel
+	if parallel <= 0 {
+		parallel = len(m.workers)
+	}
+	return cp.ETA(parallel)
+}
+
+// criticalPath analyses the task DAG using historical durations per task
+// type, falling back to the mean of tasks completed this session. It returns
+// nil until there is some timing data to go on.
+func (m Model) criticalPath() *task.CriticalPath {
+	var sessionAvg time.Duration
+	if len(m.taskCompletionTimes) > 0 {
+		var sum time.Duration
+		for _, d := range m.taskCompletionTimes {
+			sum += d
+		}
+		sessionAvg = sum / time.Duration(len(m.taskCompletionTimes))
+	}
+	if m.history.Empty() && sessionAvg == 0 {
+		return nil
 	}
-	var sum time.Duration
-	for _, d := range m.taskCompletionTimes {
-		sum += d
+
+	nodes := make([]task.PathNode, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		n := task.PathNode{ID: t.ID, DependsOn: t.DependsOn}
+		switch t.Status {
+		case "complete", "cancelled":
+		default:
+			est, ok := m.history.Lookup(task.Type(t.Type), "")
+			if !ok {
+				est = sessionAvg
+			}
+			n.Remaining = est
+			if started, ok := m.taskStartTimes[t.ID]; ok && t.Status == "running" {
+				n.Remaining = max(est-time.Since(started), time.Second)
+			}
+		}
+		nodes = append(nodes, n)
 	}
-	avg := sum / time.Duration(len(m.taskCompletionTimes))
-	return avg * time.Duration(remaining)
+	return task.ComputeCriticalPath(nodes)
 }
 
 // formatETA formats a duration into a concise human-readable string.
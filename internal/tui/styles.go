@@ -49,6 +49,10 @@ var (
 	queenTextStyle = lipgloss.NewStyle().
 			Foreground(colorWhite)
 
+	queenReasoningStyle = lipgloss.NewStyle().
+				Foreground(colorSubtle).
+				Italic(true)
+
 	toolCallStyle = lipgloss.NewStyle().
 			Foreground(colorCyan)
 
@@ -64,6 +68,10 @@ var (
 	workerOutputStyle = lipgloss.NewStyle().
 				Foreground(colorWhite)
 
+	criticalStyle = lipgloss.NewStyle().
+			Foreground(colorAmber).
+			Bold(true)
+
 	// Task status styles
 	statusStyles = map[string]lipgloss.Style{
 		"pending":  lipgloss.NewStyle().Foreground(colorSubtle),
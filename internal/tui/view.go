@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/HexSleeves/waggle/internal/task"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
 )
@@ -25,10 +26,12 @@ func (m Model) View() string {
 	}
 
 	var mainPanel string
-	switch m.viewMode {
-	case viewWorker:
+	switch {
+	case len(m.prompts) > 0:
+		mainPanel = m.renderPromptPanel(innerW, queenH)
+	case m.viewMode == viewWorker:
 		mainPanel = m.renderWorkerOutputPanel(innerW, queenH)
-	case viewDAG:
+	case m.viewMode == viewDAG:
 		mainPanel = m.renderDAGPanel(innerW, queenH)
 	default:
 		mainPanel = m.renderQueenPanel(innerW, queenH)
@@ -36,6 +39,9 @@ func (m Model) View() string {
 
 	taskPanel := m.renderTaskPanel(innerW, taskH)
 	sbar := m.renderStatusBar(w)
+	if m.composing {
+		sbar = m.renderMessageBar(w)
+	}
 
 	return mainPanel + "\n" + taskPanel + "\n" + sbar
 }
@@ -100,6 +106,73 @@ func (m Model) renderQueenPanel(w, h int) string {
 	return queenBorder.Width(w).Render(content)
 }
 
+// renderMessageBar replaces the status bar while the operator types a
+// message for the Queen.
+func (m Model) renderMessageBar(w int) string {
+	label := lipgloss.NewStyle().Foreground(colorHoney).Bold(true).Render("💬 Queen:")
+	hint := subtleStyle.Render("  Enter send · Esc cancel")
+	in := m.objectiveInput
+	in.Width = w - lipgloss.Width(label) - lipgloss.Width(hint) - 3
+	if in.Width < 10 {
+		in.Width = 10
+	}
+	return label + " " + in.View() + hint
+}
+
+// renderPromptPanel shows the oldest pending operator prompt in place of the
+// main panel until it is answered.
+func (m Model) renderPromptPanel(w, h int) string {
+	p := m.prompts[0]
+	contentW := w - 2
+	title := titleStyle.Render("🙋 Your input is needed")
+	if n := len(m.prompts); n > 1 {
+		title += subtleStyle.Render(fmt.Sprintf("  (%d more waiting)", n-1))
+	}
+
+	var body []string
+	for _, l := range wrapText(p.Title, contentW) {
+		body = append(body, lipgloss.NewStyle().Foreground(colorHoney).Bold(true).Render(l))
+	}
+	if p.Details != "" {
+		body = append(body, "")
+		for _, line := range strings.Split(strings.TrimRight(p.Details, "\n"), "\n") {
+			for _, l := range wrapText(line, contentW) {
+				body = append(body, queenTextStyle.Render(l))
+			}
+		}
+	}
+	if len(p.Options) > 0 {
+		body = append(body, "")
+		for i, o := range p.Options {
+			body = append(body, toolCallStyle.Render(fmt.Sprintf("  %d. %s", i+1, o)))
+		}
+	}
+
+	var footer []string
+	switch {
+	case p.Approval && !m.promptReason:
+		footer = []string{subtleStyle.Render("y approve · n reject")}
+	case p.Approval:
+		footer = []string{m.promptInput.View(), subtleStyle.Render("Enter reject · Esc back")}
+	case len(p.Options) > 0:
+		footer = []string{m.promptInput.View(), subtleStyle.Render("Enter send · type an option number or your own answer")}
+	default:
+		footer = []string{m.promptInput.View(), subtleStyle.Render("Enter send")}
+	}
+
+	// Keep the footer visible by trimming the details
+	if room := h - 2 - len(footer); len(body) > room && room > 0 {
+		body = append(body[:room-1], subtleStyle.Render("…"))
+	}
+	lines := append([]string{title}, body...)
+	lines = append(lines, "")
+	lines = append(lines, footer...)
+	for len(lines) < h {
+		lines = append(lines, "")
+	}
+	return queenBorder.Width(w).Render(strings.Join(lines, "\n"))
+}
+
 func (m Model) renderWorkerOutputPanel(w, h int) string {
 	_ = h
 	// Title with worker info
@@ -160,6 +233,18 @@ func (m Model) renderTaskPanel(w, h int) string {
 	return taskBorder.Width(w).Render(content)
 }
 
+// renderCost formats the session's spend for the status bar, against the
+// budget when one is set.
+func (m Model) renderCost() string {
+	if m.cost.USD == 0 {
+		return ""
+	}
+	if m.cost.BudgetUSD > 0 {
+		return fmt.Sprintf("$%.2f/$%.2f", m.cost.USD, m.cost.BudgetUSD)
+	}
+	return fmt.Sprintf("$%.2f", m.cost.USD)
+}
+
 func (m Model) renderStatusBar(w int) string {
 	elapsed := time.Since(m.startTime).Round(time.Second)
 
@@ -204,6 +289,9 @@ func (m Model) renderStatusBar(w int) string {
 	// Right: workers + time
 	workerCount := len(m.workers)
 	right := fmt.Sprintf("%d workers · %s", workerCount, elapsed)
+	if cost := m.renderCost(); cost != "" {
+		right = cost + " · " + right
+	}
 	if helpStr != "" {
 		right += "  " + helpStr
 	}
@@ -272,6 +360,7 @@ func (m Model) buildTaskDAGASCII(width int) string {
 		id        string
 		title     string
 		status    string
+		sub       string
 		dependsOn []string
 	}
 
@@ -282,6 +371,7 @@ func (m Model) buildTaskDAGASCII(width int) string {
 			id:        t.ID,
 			title:     t.Title,
 			status:    t.Status,
+			sub:       t.SubSession,
 			dependsOn: t.DependsOn,
 		}
 	}
@@ -335,6 +425,12 @@ func (m Model) buildTaskDAGASCII(width int) string {
 		}
 	}
 
+	cp := m.criticalPath()
+	var slackByID map[string]time.Duration
+	if cp != nil {
+		slackByID = cp.Slack
+	}
+
 	var b strings.Builder
 	for d := 0; d <= maxDepth; d++ {
 		ids := grouped[d]
@@ -359,10 +455,26 @@ func (m Model) buildTaskDAGASCII(width int) string {
 			if len(title) > maxTitle {
 				title = title[:maxTitle-1] + "…"
 			}
-			b.WriteString(fmt.Sprintf("  [%s %s]\n", icon, title))
+			suffix := ""
+			if t.sub != "" {
+				suffix = " ↳ " + t.sub
+			}
+			if slack, ok := slackByID[id]; ok {
+				if slack == 0 {
+					suffix += " ★"
+				} else {
+					suffix += " (slack " + task.FormatEstimate(slack) + ")"
+				}
+			}
+			b.WriteString(fmt.Sprintf("  [%s %s]%s\n", icon, title, suffix))
 		}
 	}
 
+	if cp != nil && len(cp.Path) > 0 {
+		b.WriteString(fmt.Sprintf("\n★ Critical path (~%s): %s\n",
+			task.FormatEstimate(cp.Length), strings.Join(cp.Path, " → ")))
+	}
+
 	return strings.TrimRight(b.String(), "\n")
 }
 
@@ -374,6 +486,8 @@ func (m Model) renderQueenViewportContent(width int) string {
 			switch line.style {
 			case "think":
 				rendered = append(rendered, queenTextStyle.Render(wrappedLine))
+			case "reason":
+				rendered = append(rendered, queenReasoningStyle.Render(wrappedLine))
 			case "tool":
 				rendered = append(rendered, toolCallStyle.Render(wrappedLine))
 			case "result":
@@ -385,9 +499,42 @@ func (m Model) renderQueenViewportContent(width int) string {
 			}
 		}
 	}
+	if !m.stream.empty() {
+		rendered = append(rendered, m.renderStream(width)...)
+	}
 	return strings.Join(rendered, "\n")
 }
 
+// renderStream renders the partial Queen response, ending in a cursor.
+func (m Model) renderStream(width int) []string {
+	var rendered []string
+	if m.stream.thinking != "" {
+		for _, l := range wrapText("💭 "+m.stream.thinking, width) {
+			rendered = append(rendered, queenReasoningStyle.Render(l))
+		}
+	}
+	if m.stream.text != "" {
+		for _, l := range wrapText(m.stream.text, width) {
+			rendered = append(rendered, queenTextStyle.Render(l))
+		}
+	}
+	for _, t := range m.stream.tools {
+		line := "→ " + t.name
+		if t.input != "" {
+			input := t.input
+			if len(input) > 80 {
+				input = input[:80] + "..."
+			}
+			line += "(" + input
+		}
+		for _, l := range wrapText(line, width) {
+			rendered = append(rendered, toolCallStyle.Render(l))
+		}
+	}
+	rendered[len(rendered)-1] += subtleStyle.Render("▌")
+	return rendered
+}
+
 func (m Model) renderWorkerViewportContent(width int, workerID string) string {
 	lines := m.workerOutputs[workerID]
 	var rendered []string
@@ -409,6 +556,10 @@ func (m Model) renderDAGViewportContent(width int) string {
 	lines := strings.Split(ascii, "\n")
 	rendered := make([]string, 0, len(lines))
 	for _, line := range lines {
+		if strings.Contains(line, "★") {
+			rendered = append(rendered, criticalStyle.Render(line))
+			continue
+		}
 		rendered = append(rendered, queenTextStyle.Render(line))
 	}
 	return strings.Join(rendered, "\n")
@@ -42,10 +42,17 @@ type Bee interface {
 // Factory creates a Bee for a given adapter name
 type Factory func(id string, adapterName string) (Bee, error)
 
-// Pool manages a set of concurrent workers
+// Pool manages a set of concurrent workers.
+//
+// A pool can hand out sub-pools (see Sub) to nested orchestrators. All pools
+// in a tree share one lock and one MaxParallel limit, but each only sees the
+// workers it started itself.
 type Pool struct {
-	mu          sync.Mutex
+	mu          *sync.Mutex
 	workers     map[string]Bee
+	unmetered   map[string]bool // adopted workers that don't count against maxParallel
+	parent      *Pool
+	children    []*Pool
 	maxParallel int
 	factory     Factory
 	msgBus      *bus.MessageBus
@@ -53,13 +60,75 @@ type Pool struct {
 
 func NewPool(maxParallel int, factory Factory, b *bus.MessageBus) *Pool {
 	return &Pool{
+		mu:          &sync.Mutex{},
 		workers:     make(map[string]Bee),
+		unmetered:   make(map[string]bool),
 		maxParallel: maxParallel,
 		factory:     factory,
 		msgBus:      b,
 	}
 }
 
+// Sub returns a pool for a nested orchestrator. Workers spawned through it
+// count against this pool's MaxParallel limit and are killed by its KillAll,
+// while lifecycle events are published on b.
+func (p *Pool) Sub(b *bus.MessageBus) *Pool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sub := &Pool{
+		mu:          p.mu,
+		workers:     make(map[string]Bee),
+		unmetered:   make(map[string]bool),
+		parent:      p,
+		maxParallel: p.maxParallel,
+		factory:     p.factory,
+		msgBus:      b,
+	}
+	p.children = append(p.children, sub)
+	return sub
+}
+
+// Release detaches a sub-pool from its parent once the nested orchestrator
+// has finished. Its workers no longer count against the shared limit.
+func (p *Pool) Release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.parent == nil {
+		return
+	}
+	siblings := p.parent.children
+	for i, c := range siblings {
+		if c == p {
+			p.parent.children = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	p.parent = nil
+}
+
+// meteredLocked counts running workers across the whole pool tree that
+// count against maxParallel. Caller must hold p.mu.
+func (p *Pool) meteredLocked() int {
+	root := p
+	for root.parent != nil {
+		root = root.parent
+	}
+	var count func(*Pool) int
+	count = func(pp *Pool) int {
+		n := 0
+		for id, w := range pp.workers {
+			if !pp.unmetered[id] && w.Monitor() == StatusRunning {
+				n++
+			}
+		}
+		for _, c := range pp.children {
+			n += count(c)
+		}
+		return n
+	}
+	return count(root)
+}
+
 // Spawn creates and starts a new worker for a task.
 // If the task has a Timeout, the worker's context is wrapped with a deadline
 // so the process is killed automatically if it exceeds the timeout.
@@ -69,17 +138,10 @@ func (p *Pool) Spawn(ctx context.Context, t *task.Task, adapterName string) (Bee
 	// Hold lock across capacity check, factory call, and insert to prevent
 	// TOCTOU races. Factory calls are fast (struct allocation only).
 	p.mu.Lock()
-	active := 0
-	for _, w := range p.workers {
-		if w.Monitor() == StatusRunning {
-			active++
-		}
-	}
-	if active >= p.maxParallel {
+	if p.meteredLocked() >= p.maxParallel {
 		p.mu.Unlock()
 		return nil, fmt.Errorf("max parallel workers (%d) reached", p.maxParallel)
 	}
-
 	bee, err := p.factory(workerID, adapterName)
 	if err != nil {
 		p.mu.Unlock()
@@ -135,6 +197,31 @@ func (p *Pool) Spawn(ctx context.Context, t *task.Task, adapterName string) (Bee
 	return bee, nil
 }
 
+// Adopt registers an externally constructed worker, such as a nested
+// orchestrator, and starts it. Adopted workers are tracked like any other
+// (Get, Active, KillAll) but don't count against MaxParallel, so they never
+// starve the workers they coordinate. Task timeouts are not applied.
+func (p *Pool) Adopt(ctx context.Context, t *task.Task, bee Bee) error {
+	p.mu.Lock()
+	p.workers[bee.ID()] = bee
+	p.unmetered[bee.ID()] = true
+	p.mu.Unlock()
+
+	if p.msgBus != nil {
+		p.msgBus.Publish(bus.Message{
+			Type:     bus.MsgWorkerSpawned,
+			WorkerID: bee.ID(),
+			TaskID:   t.ID,
+			Time:     time.Now(),
+		})
+	}
+
+	if err := bee.Spawn(ctx, t); err != nil {
+		return fmt.Errorf("spawn worker: %w", err)
+	}
+	return nil
+}
+
 // Get returns a worker by ID
 func (p *Pool) Get(id string) (Bee, bool) {
 	p.mu.Lock()
@@ -143,7 +230,7 @@ func (p *Pool) Get(id string) (Bee, bool) {
 	return w, ok
 }
 
-// Active returns currently running workers
+// Active returns this pool's currently running workers
 func (p *Pool) Active() []Bee {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -156,17 +243,27 @@ func (p *Pool) Active() []Bee {
 	return active
 }
 
-// ActiveCount returns the number of running workers
+// ActiveCount returns the number of running workers that count against
+// MaxParallel, including those of related sub-pools.
 func (p *Pool) ActiveCount() int {
-	return len(p.Active())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.meteredLocked()
 }
 
-// KillAll terminates all running workers.
+// KillAll terminates all running workers, including those of sub-pools.
 // Returns any errors encountered during termination.
 func (p *Pool) KillAll() []error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	return p.killAllLocked()
+}
+
+func (p *Pool) killAllLocked() []error {
 	var errs []error
+	for _, c := range p.children {
+		errs = append(errs, c.killAllLocked()...)
+	}
 	for _, w := range p.workers {
 		if w.Monitor() == StatusRunning {
 			if err := w.Kill(); err != nil {
@@ -185,6 +282,7 @@ func (p *Pool) Cleanup() {
 		s := w.Monitor()
 		if s == StatusComplete || s == StatusFailed {
 			delete(p.workers, id)
+			delete(p.unmetered, id)
 		}
 	}
 }
@@ -856,3 +856,60 @@ func TestPoolSpawnNoTimeoutCompletes(t *testing.T) {
 		t.Errorf("expected StatusComplete, got %s", bee.Monitor())
 	}
 }
+
+func TestPoolSubSharesLimit(t *testing.T) {
+	factory := func(id, adapter string) (Bee, error) {
+		b := newMockBee(id, adapter)
+		b.spawnFunc = func(ctx context.Context, t *task.Task) error {
+			b.status.Store(StatusRunning)
+			return nil
+		}
+		return b, nil
+	}
+	pool := NewPool(2, factory, nil)
+	ctx := context.Background()
+
+	// A nested orchestrator is adopted without taking a slot.
+	coordinator := newMockBee("queen-1", "queen")
+	coordinator.spawnFunc = func(ctx context.Context, t *task.Task) error {
+		coordinator.status.Store(StatusRunning)
+		return nil
+	}
+	if err := pool.Adopt(ctx, &task.Task{ID: "sub"}, coordinator); err != nil {
+		t.Fatalf("Adopt: %v", err)
+	}
+	if pool.ActiveCount() != 0 || len(pool.Active()) != 1 {
+		t.Fatalf("adopted worker should be active but unmetered: count=%d active=%d", pool.ActiveCount(), len(pool.Active()))
+	}
+
+	sub := pool.Sub(nil)
+	subBee, err := sub.Spawn(ctx, &task.Task{ID: "c1", Type: task.TypeCode}, "a")
+	if err != nil {
+		t.Fatalf("sub spawn: %v", err)
+	}
+	if _, err := pool.Spawn(ctx, &task.Task{ID: "p1", Type: task.TypeCode}, "a"); err != nil {
+		t.Fatalf("parent spawn: %v", err)
+	}
+	if _, err := sub.Spawn(ctx, &task.Task{ID: "c2", Type: task.TypeCode}, "a"); err == nil {
+		t.Error("expected shared limit to reject a third worker")
+	}
+	if pool.ActiveCount() != 2 || sub.ActiveCount() != 2 {
+		t.Errorf("expected shared count 2, got parent=%d sub=%d", pool.ActiveCount(), sub.ActiveCount())
+	}
+	if len(sub.Active()) != 1 {
+		t.Errorf("sub-pool should only list its own workers, got %d", len(sub.Active()))
+	}
+	if _, ok := pool.Get(subBee.ID()); ok {
+		t.Error("parent should not look up sub-pool workers")
+	}
+
+	pool.KillAll()
+	if !subBee.(*mockBee).killCalled.Load() || !coordinator.killCalled.Load() {
+		t.Error("KillAll should reach sub-pool and adopted workers")
+	}
+
+	sub.Release()
+	if _, err := pool.Spawn(ctx, &task.Task{ID: "p2", Type: task.TypeCode}, "a"); err != nil {
+		t.Errorf("spawn after release: %v", err)
+	}
+}